@@ -3,6 +3,8 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/canonical/lxd/lxd/response"
@@ -24,6 +26,28 @@ var configsCmd = rest.Endpoint{
 	Delete: rest.EndpointAction{Handler: cmdConfigsDelete, ProxyTarget: true},
 }
 
+// /1.0/configs/history endpoint.
+var configsHistoryCmd = rest.Endpoint{
+	Path: "configs/history",
+
+	Get: rest.EndpointAction{Handler: cmdConfigsHistoryGet, ProxyTarget: true},
+}
+
+// /1.0/configs/drift endpoint.
+var configsDriftCmd = rest.Endpoint{
+	Path: "configs/drift",
+
+	Get: rest.EndpointAction{Handler: cmdConfigsDriftGet, ProxyTarget: true},
+	Put: rest.EndpointAction{Handler: cmdConfigsDriftPut, ProxyTarget: true},
+}
+
+// /1.0/configs/rollback endpoint.
+var configsRollbackCmd = rest.Endpoint{
+	Path: "configs/rollback",
+
+	Put: rest.EndpointAction{Handler: cmdConfigsRollbackPut, ProxyTarget: true},
+}
+
 func cmdConfigsGet(s state.State, r *http.Request) response.Response {
 	var err error
 	var req types.Config
@@ -39,7 +63,7 @@ func cmdConfigsGet(s state.State, r *http.Request) response.Response {
 		configs, err = ceph.GetConfigItem(req)
 	} else {
 		// Fetch all configs.
-		configs, err = ceph.ListConfigs()
+		configs, err = ceph.ListConfigs(r.Context(), s, req.All)
 	}
 	if err != nil {
 		return response.SmartError(err)
@@ -50,22 +74,32 @@ func cmdConfigsGet(s state.State, r *http.Request) response.Response {
 
 func cmdConfigsPut(s state.State, r *http.Request) response.Response {
 	var req types.Config
-	configTable := ceph.GetConstConfigTable()
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	// Members losing each other on a new cluster network can't replicate, check it first.
+	if req.Key == "cluster_network" && !req.SkipCheck {
+		report, err := ceph.CheckClusterNetwork(r.Context(), s, req.Value)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if report.Failed() != 0 {
+			return response.BadRequest(fmt.Errorf("%d cluster network probes failed, see 'microceph cluster network check --cluster-network %s', or pass --skip-check to set it anyway", report.Failed(), req.Value))
+		}
+	}
+
 	// Configure the key/value
-	err = ceph.SetConfigItem(req)
+	config, err := ceph.ChangeConfig(r.Context(), s, req, changedBy(r, req.User))
 	if err != nil {
 		return response.SmartError(err)
 	}
 
 	if !req.SkipRestart {
-		services := configTable[req.Key].Daemons
-		configChangeRefresh(r.Context(), s, services, req.Wait)
+		configChangeRefresh(r.Context(), s, config.Daemons, req.Wait)
 	}
 
 	return response.EmptySyncResponse
@@ -73,7 +107,6 @@ func cmdConfigsPut(s state.State, r *http.Request) response.Response {
 
 func cmdConfigsDelete(s state.State, r *http.Request) response.Response {
 	var req types.Config
-	configTable := ceph.GetConstConfigTable()
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -81,19 +114,101 @@ func cmdConfigsDelete(s state.State, r *http.Request) response.Response {
 	}
 
 	// Clean the key/value
-	err = ceph.RemoveConfigItem(req)
+	config, err := ceph.ResetConfig(r.Context(), s, req, changedBy(r, req.User))
 	if err != nil {
 		return response.SmartError(err)
 	}
 
 	if !req.SkipRestart {
-		services := configTable[req.Key].Daemons
-		configChangeRefresh(r.Context(), s, services, req.Wait)
+		configChangeRefresh(r.Context(), s, config.Daemons, req.Wait)
 	}
 
 	return response.EmptySyncResponse
 }
 
+func cmdConfigsHistoryGet(s state.State, r *http.Request) response.Response {
+	var req types.ConfigHistoryRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	changes, err := ceph.ListConfigHistory(r.Context(), s, req.Key)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, changes)
+}
+
+func cmdConfigsRollbackPut(s state.State, r *http.Request) response.Response {
+	var req types.ConfigRollback
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	config, err := ceph.RollbackConfig(r.Context(), s, req.ID, changedBy(r, req.User))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !req.SkipRestart {
+		configChangeRefresh(r.Context(), s, config.Daemons, req.Wait)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdConfigsDriftGet is the handler for GET /1.0/configs/drift.
+func cmdConfigsDriftGet(s state.State, r *http.Request) response.Response {
+	policy, err := ceph.GetConfigDriftPolicy(r.Context(), s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	drifts, err := ceph.CheckConfigDrift(r.Context(), s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, types.ConfigDriftReport{Reassert: policy.Reassert, Drifts: drifts})
+}
+
+// cmdConfigsDriftPut is the handler for PUT /1.0/configs/drift.
+func cmdConfigsDriftPut(s state.State, r *http.Request) response.Response {
+	var req types.ConfigDriftPolicy
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.SetConfigDriftPolicy(r.Context(), s, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// changedBy identifies who requested a config change for the config history, as the user
+// and the address the request came from, if not over the local unix socket.
+func changedBy(r *http.Request, user string) string {
+	if len(user) == 0 {
+		user = "unknown"
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || len(host) == 0 {
+		return user
+	}
+
+	return fmt.Sprintf("%s@%s", user, host)
+}
+
 // Perform ordered (one after other) restart of provided Ceph services across the ceph cluster.
 func configChangeRefresh(ctx context.Context, s state.State, services []string, wait bool) error {
 	if wait {
@@ -22,6 +22,7 @@ import (
 
 	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/database"
 )
 
 // /1.0/disks endpoint.
@@ -39,6 +40,74 @@ var disksDelCmd = rest.Endpoint{
 	Delete: rest.EndpointAction{Handler: cmdDisksDelete, ProxyTarget: true},
 }
 
+// /1.0/disks/adopt endpoint.
+var disksAdoptCmd = rest.Endpoint{
+	Path: "disks/adopt",
+
+	Post: rest.EndpointAction{Handler: cmdDisksAdoptPost, ProxyTarget: true},
+}
+
+// /1.0/disks/encryption endpoint.
+var disksEncryptionCmd = rest.Endpoint{
+	Path: "disks/encryption",
+
+	Get: rest.EndpointAction{Handler: cmdDisksEncryptionGet, ProxyTarget: false},
+	Put: rest.EndpointAction{Handler: cmdDisksEncryptionPut, ProxyTarget: false},
+}
+
+// /1.0/disks/{osdid}/key endpoint.
+var disksKeyCmd = rest.Endpoint{
+	Path: "disks/{osdid}/key",
+
+	Get:  rest.EndpointAction{Handler: cmdDisksKeyGet, ProxyTarget: true},
+	Post: rest.EndpointAction{Handler: cmdDisksKeyPost, ProxyTarget: true},
+}
+
+// /1.0/disks/{osdid}/encrypt endpoint.
+var disksEncryptCmd = rest.Endpoint{
+	Path: "disks/{osdid}/encrypt",
+
+	Post: rest.EndpointAction{Handler: cmdDisksEncryptPost, ProxyTarget: true},
+}
+
+// /1.0/disks/auto-add endpoint.
+var disksAutoAddCmd = rest.Endpoint{
+	Path: "disks/auto-add",
+
+	Get: rest.EndpointAction{Handler: cmdDisksAutoAddGet, ProxyTarget: true},
+	Put: rest.EndpointAction{Handler: cmdDisksAutoAddPut, ProxyTarget: true},
+}
+
+// /1.0/disks/health endpoint.
+var disksHealthCmd = rest.Endpoint{
+	Path: "disks/health",
+
+	Get: rest.EndpointAction{Handler: cmdDisksHealthGet, ProxyTarget: false},
+	Put: rest.EndpointAction{Handler: cmdDisksHealthPut, ProxyTarget: false},
+}
+
+// /1.0/disks/{osdid}/health endpoint.
+var disksOsdHealthCmd = rest.Endpoint{
+	Path: "disks/{osdid}/health",
+
+	Get: rest.EndpointAction{Handler: cmdDisksOsdHealthGet, ProxyTarget: false},
+}
+
+// /1.0/disks/{osdid}/device endpoint.
+var disksDeviceCmd = rest.Endpoint{
+	Path: "disks/{osdid}/device",
+
+	Post: rest.EndpointAction{Handler: cmdDisksDevicePost, ProxyTarget: true},
+}
+
+// /1.0/disks/{osdid}/drain endpoint.
+var disksDrainCmd = rest.Endpoint{
+	Path: "disks/{osdid}/drain",
+
+	Get:  rest.EndpointAction{Handler: cmdDisksDrainGet, ProxyTarget: false},
+	Post: rest.EndpointAction{Handler: cmdDisksDrainPost, ProxyTarget: false},
+}
+
 var mu sync.Mutex
 
 func cmdDisksGet(s state.State, r *http.Request) response.Response {
@@ -73,19 +142,20 @@ func cmdDisksPost(s state.State, r *http.Request) response.Response {
 	disks = make([]types.DiskParameter, len(req.Path))
 	for i, diskPath := range req.Path {
 		disks[i] = types.DiskParameter{
-			Path:     diskPath,
-			Encrypt:  req.Encrypt,
-			Wipe:     req.Wipe,
-			LoopSize: 0,
+			Path:          diskPath,
+			Encrypt:       req.Encrypt,
+			Wipe:          req.Wipe,
+			LoopSize:      0,
+			OSDsPerDevice: req.OSDsPerDevice,
 		}
 	}
 
 	if req.WALDev != nil {
-		wal = &types.DiskParameter{Path: *req.WALDev, Encrypt: req.WALEncrypt, Wipe: req.WALWipe, LoopSize: 0}
+		wal = &types.DiskParameter{Path: *req.WALDev, Encrypt: req.WALEncrypt, Wipe: req.WALWipe, LoopSize: 0, Size: req.WALSize}
 	}
 
 	if req.DBDev != nil {
-		db = &types.DiskParameter{Path: *req.DBDev, Encrypt: req.DBEncrypt, Wipe: req.DBWipe, LoopSize: 0}
+		db = &types.DiskParameter{Path: *req.DBDev, Encrypt: req.DBEncrypt, Wipe: req.DBWipe, LoopSize: 0, Size: req.DBSize}
 	}
 
 	resp := ceph.AddBulkDisks(r.Context(), s, disks, wal, db)
@@ -137,7 +207,195 @@ func cmdDisksDelete(s state.State, r *http.Request) response.Response {
 		}
 	}
 
-	err = ceph.RemoveOSD(r.Context(), cs, osdid, req.BypassSafety, req.Timeout)
+	err = ceph.RemoveOSD(r.Context(), cs, osdid, req.BypassSafety, req.Timeout, req.Drain)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksAdoptPost is the handler for POST /1.0/disks/adopt.
+func cmdDisksAdoptPost(s state.State, r *http.Request) response.Response {
+	var req types.DisksAdopt
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	resp, err := ceph.AdoptOSDs(r.Context(), s, req.DryRun)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, resp)
+}
+
+// cmdDisksEncryptionGet is the handler for GET /1.0/disks/encryption.
+func cmdDisksEncryptionGet(s state.State, r *http.Request) response.Response {
+	conf, err := ceph.GetKeyProviderConfig(r.Context(), s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Never hand out the key server credentials.
+	conf.Token = ""
+	return response.SyncResponse(true, conf)
+}
+
+// cmdDisksEncryptionPut is the handler for PUT /1.0/disks/encryption.
+func cmdDisksEncryptionPut(s state.State, r *http.Request) response.Response {
+	var req types.DiskEncryptionProvider
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.SetKeyProviderConfig(r.Context(), s, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksKeyGet is the handler for GET /1.0/disks/{osdid}/key.
+func cmdDisksKeyGet(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOsdId(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	key, err := ceph.GetOSDKey(s, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, types.DiskEncryptionKey{OSD: osdid, Key: key})
+}
+
+// cmdDisksKeyPost is the handler for POST /1.0/disks/{osdid}/key, it rotates the keys of the OSD.
+func cmdDisksKeyPost(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOsdId(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.RotateOSDKeys(s, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksEncryptPost is the handler for POST /1.0/disks/{osdid}/encrypt.
+func cmdDisksEncryptPost(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOsdId(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var req types.DisksEncrypt
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.EncryptOSD(r.Context(), s, osdid, req.Noout, req.Timeout)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksDevicePost is the handler for POST /1.0/disks/{osdid}/device, it attaches or replaces a WAL or DB device.
+func cmdDisksDevicePost(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOsdId(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var req types.DisksSetDevice
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Role != types.DiskRoleWAL && req.Role != types.DiskRoleDB {
+		return response.BadRequest(fmt.Errorf("unknown device role %q", req.Role))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.SetBluefsDevice(r.Context(), s, osdid, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksAutoAddGet is the handler for GET /1.0/disks/auto-add, it returns the policy of the targeted member.
+func cmdDisksAutoAddGet(s state.State, r *http.Request) response.Response {
+	policy, err := ceph.GetAutoAddPolicy(r.Context(), s, s.Name())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, policy)
+}
+
+// cmdDisksAutoAddPut is the handler for PUT /1.0/disks/auto-add, it sets the policy of the targeted member.
+func cmdDisksAutoAddPut(s state.State, r *http.Request) response.Response {
+	var req types.DiskAutoAddPolicy
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.SetAutoAddPolicy(r.Context(), s, s.Name(), req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksHealthGet is the handler for GET /1.0/disks/health.
+func cmdDisksHealthGet(s state.State, r *http.Request) response.Response {
+	policy, err := ceph.GetDiskHealthPolicy(r.Context(), s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, policy)
+}
+
+// cmdDisksHealthPut is the handler for PUT /1.0/disks/health.
+func cmdDisksHealthPut(s state.State, r *http.Request) response.Response {
+	var req types.DiskHealthPolicy
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.SetDiskHealthPolicy(r.Context(), s, req)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -145,6 +403,94 @@ func cmdDisksDelete(s state.State, r *http.Request) response.Response {
 	return response.EmptySyncResponse
 }
 
+// cmdDisksOsdHealthGet is the handler for GET /1.0/disks/{osdid}/health.
+func cmdDisksOsdHealthGet(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOsdId(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	present, err := database.OSDQuery.HaveOSD(r.Context(), s, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+	if !present {
+		return response.NotFound(fmt.Errorf("osd.%d not found", osdid))
+	}
+
+	health, err := ceph.GetDiskHealth(r.Context(), s, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, health)
+}
+
+// cmdDisksDrainGet is the handler for GET /1.0/disks/{osdid}/drain.
+func cmdDisksDrainGet(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOsdId(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	drain, err := ceph.GetDrain(r.Context(), s, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// An OSD which was never drained has an empty status.
+	return response.SyncResponse(true, drain)
+}
+
+// cmdDisksDrainPost is the handler for POST /1.0/disks/{osdid}/drain, it starts, pauses or resumes the drain of the OSD.
+func cmdDisksDrainPost(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOsdId(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var req types.DisksDrain
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	present, err := database.OSDQuery.HaveOSD(r.Context(), s, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+	if !present {
+		return response.NotFound(fmt.Errorf("osd.%d not found", osdid))
+	}
+
+	var drain types.DiskDrain
+	switch req.Action {
+	case types.DiskDrainStart:
+		drain, err = ceph.StartDrain(r.Context(), s, osdid, req.Step)
+	case types.DiskDrainPause:
+		drain, err = ceph.PauseDrain(r.Context(), s, osdid)
+	case types.DiskDrainResume:
+		drain, err = ceph.ResumeDrain(r.Context(), s, osdid)
+	default:
+		return response.BadRequest(fmt.Errorf("unknown drain action %q", req.Action))
+	}
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, drain)
+}
+
+// parseOsdId parses the osd id from the request path.
+func parseOsdId(r *http.Request) (int64, error) {
+	osd, err := url.PathUnescape(mux.Vars(r)["osdid"])
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(osd, 10, 64)
+}
+
 // parseAndPatchDiskPostParams parses/patches Disk add command parameters
 // to keep the API compatible with older clients.
 func parseAndPatchDiskPostParams(rb io.ReadCloser) (types.DisksPost, error) {
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/ceph"
+)
+
+// /1.0/doctor endpoint, served before the member is initialised so hosts can be checked ahead of bootstrap or join.
+var doctorCmd = rest.Endpoint{
+	Path: "doctor",
+
+	Get:               rest.EndpointAction{Handler: cmdDoctorGet, ProxyTarget: true},
+	AllowedBeforeInit: true,
+}
+
+// cmdDoctorGet runs the preflight checks of the member.
+func cmdDoctorGet(s state.State, r *http.Request) response.Response {
+	return response.SyncResponse(true, ceph.RunDoctor(r.Context(), s))
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+)
+
+// /1.0/cluster/messenger endpoint.
+var clusterMessengerCmd = rest.Endpoint{
+	Path: "cluster/messenger",
+
+	Put: rest.EndpointAction{Handler: cmdClusterMessengerPut, ProxyTarget: false},
+}
+
+// cmdClusterMessengerPut converts the cluster to msgr2 only and/or on-wire encryption.
+func cmdClusterMessengerPut(s state.State, r *http.Request) response.Response {
+	var req types.MessengerRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	steps, err := ceph.SetMessenger(r.Context(), s, req.V2Only, req.Secure, req.DryRun)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, types.MessengerResponse{Steps: steps})
+}
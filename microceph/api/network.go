@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+)
+
+// /1.0/cluster/network/check endpoint.
+var clusterNetworkCheckCmd = rest.Endpoint{
+	Path: "cluster/network/check",
+
+	Post: rest.EndpointAction{Handler: cmdClusterNetworkCheckPost, ProxyTarget: false},
+}
+
+// /1.0/cluster/network/addresses endpoint.
+var clusterNetworkAddressesCmd = rest.Endpoint{
+	Path: "cluster/network/addresses",
+
+	Get: rest.EndpointAction{Handler: cmdClusterNetworkAddressesGet, ProxyTarget: true},
+}
+
+// /1.0/cluster/network/probe endpoint.
+var clusterNetworkProbeCmd = rest.Endpoint{
+	Path: "cluster/network/probe",
+
+	Post: rest.EndpointAction{Handler: cmdClusterNetworkProbePost, ProxyTarget: true},
+}
+
+// /1.0/cluster/network/migrate endpoint.
+var clusterNetworkMigrateCmd = rest.Endpoint{
+	Path: "cluster/network/migrate",
+
+	Post: rest.EndpointAction{Handler: cmdClusterNetworkMigratePost, ProxyTarget: false},
+}
+
+// cmdClusterNetworkCheckPost has every member probe the network addresses of every other member.
+func cmdClusterNetworkCheckPost(s state.State, r *http.Request) response.Response {
+	var req types.NetworkCheckRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	report, err := ceph.CheckClusterNetwork(r.Context(), s, req.ClusterNetwork)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, report)
+}
+
+// cmdClusterNetworkAddressesGet returns the addresses of the member on the subnets given as "subnet" query parameters.
+func cmdClusterNetworkAddressesGet(s state.State, r *http.Request) response.Response {
+	subnets := r.URL.Query()["subnet"]
+	if len(subnets) == 0 {
+		return response.BadRequest(fmt.Errorf("no subnet requested"))
+	}
+
+	return response.SyncResponse(true, ceph.GetNetworkAddresses(s, subnets))
+}
+
+// cmdClusterNetworkProbePost probes the requested addresses from the member.
+func cmdClusterNetworkProbePost(s state.State, r *http.Request) response.Response {
+	var req types.NetworkProbeRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return response.SyncResponse(true, ceph.ProbeNetwork(r.Context(), s.Name(), req.Targets))
+}
+
+// cmdClusterNetworkMigratePost moves the cluster to a new public network.
+func cmdClusterNetworkMigratePost(s state.State, r *http.Request) response.Response {
+	var req types.NetworkMigrateRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	steps, err := ceph.MigratePublicNetwork(r.Context(), s, req.Public, req.DryRun)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, types.NetworkMigrateResponse{Steps: steps})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// /1.0/ops/backup endpoint.
+var opsBackupCmd = rest.Endpoint{
+	Path: "ops/backup",
+	Post: rest.EndpointAction{Handler: cmdOpsBackupPost, ProxyTarget: true},
+}
+
+// /1.0/ops/restore endpoint, served before the member is initialised.
+var opsRestoreCmd = rest.Endpoint{
+	Path:              "ops/restore",
+	Post:              rest.EndpointAction{Handler: cmdOpsRestorePost, ProxyTarget: false},
+	AllowedBeforeInit: true,
+}
+
+// cmdOpsBackupPost creates an encrypted backup of the member state.
+func cmdOpsBackupPost(s state.State, r *http.Request) response.Response {
+	var req types.BackupRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	archive, err := ceph.CreateBackup(r.Context(), s, req.Passphrase)
+	if err != nil {
+		logger.Errorf("failed to create backup: %v", err)
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, types.BackupResponse{Archive: archive})
+}
+
+// cmdOpsRestorePost restores the member state from an encrypted backup.
+func cmdOpsRestorePost(s state.State, r *http.Request) response.Response {
+	var req types.RestoreRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	resp, err := ceph.RestoreBackup(r.Context(), s, req.Archive, req.Passphrase)
+	if err != nil {
+		logger.Errorf("failed to restore backup: %v", err)
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, resp)
+}
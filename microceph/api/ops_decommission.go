@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/gorilla/mux"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// /ops/decommission/{node} endpoint.
+var opsDecommissionNodeCmd = rest.Endpoint{
+	Path: "ops/decommission/{node}",
+	Put:  rest.EndpointAction{Handler: cmdPutDecommission, ProxyTarget: false},
+}
+
+// cmdPutDecommission removes a node along with its OSDs, services and keys from the cluster.
+func cmdPutDecommission(s state.State, r *http.Request) response.Response {
+	var req types.DecommissionRequest
+
+	node, err := url.PathUnescape(mux.Vars(r)["node"])
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		logger.Errorf("failed decoding body: %v", err)
+		return response.InternalError(err)
+	}
+
+	decommission := ceph.Decommission{
+		Node: node,
+		ClusterOps: ceph.ClusterOps{
+			State:   s,
+			Context: r.Context(),
+		},
+	}
+
+	results, err := decommission.Run(req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	for _, result := range results {
+		if result.Error != "" && !req.Force {
+			return &operationsResponse{operation: "decommission", success: false, content: results}
+		}
+	}
+
+	return &operationsResponse{operation: "decommission", success: true, content: results}
+}
@@ -19,14 +19,15 @@ import (
 	"github.com/gorilla/mux"
 )
 
-// Maintenance response.
-type maintenanceResponse struct {
-	success bool
-	content []ceph.Result
+// Operations response.
+type operationsResponse struct {
+	operation string
+	success   bool
+	content   []ceph.Result
 }
 
-// Render renders a response for /ops/maintenance/{node} endpoint.
-func (r *maintenanceResponse) Render(w http.ResponseWriter, req *http.Request) (err error) {
+// Render renders a response for the /ops/maintenance/{node} and /ops/decommission/{node} endpoints.
+func (r *operationsResponse) Render(w http.ResponseWriter, req *http.Request) (err error) {
 	debugLogger := logger.NewLXDLoggerAdapter(logger.DaemonLogger)
 	w.Header().Set("Content-Type", "application/json")
 
@@ -43,7 +44,7 @@ func (r *maintenanceResponse) Render(w http.ResponseWriter, req *http.Request) (
 		resp = api.ResponseRaw{
 			Type:     api.ErrorResponse,
 			Code:     http.StatusBadRequest,
-			Error:    fmt.Sprintf("maintenance operations failed: [%v]", strings.Join(errMessages, " ")),
+			Error:    fmt.Sprintf("%s operations failed: [%v]", r.operation, strings.Join(errMessages, " ")),
 			Metadata: r.content,
 		}
 	} else {
@@ -59,7 +60,7 @@ func (r *maintenanceResponse) Render(w http.ResponseWriter, req *http.Request) (
 	return util.WriteJSON(w, resp, debugLogger)
 }
 
-func (r *maintenanceResponse) String() string {
+func (r *operationsResponse) String() string {
 	if !r.success {
 		return "success"
 	}
@@ -112,9 +113,9 @@ func cmdPutMaintenance(s state.State, r *http.Request) response.Response {
 
 	for _, result := range results {
 		if result.Error != "" && !maintenanceRequest.Force {
-			return &maintenanceResponse{success: false, content: results}
+			return &operationsResponse{operation: "maintenance", success: false, content: results}
 		}
 	}
 
-	return &maintenanceResponse{success: true, content: results}
+	return &operationsResponse{operation: "maintenance", success: true, content: results}
 }
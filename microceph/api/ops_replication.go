@@ -64,7 +64,7 @@ func postOpsReplicationResource(s state.State, r *http.Request) response.Respons
 	return cmdOpsReplication(s, r, types.EnableReplicationRequest)
 }
 
-// putOpsReplicationResource handles configuration of the requested resource
+// putOpsReplicationResource handles configuration (or promote/demote for rbd groups) of the requested resource
 func putOpsReplicationResource(s state.State, r *http.Request) response.Response {
 	return cmdOpsReplication(s, r, types.ConfigureReplicationRequest)
 }
@@ -102,8 +102,8 @@ func cmdOpsReplication(s state.State, r *http.Request, patchRequest types.Replic
 
 		// carry RbdReplicationRequest in interface object.
 		data.SetAPIObjectId(resource)
-		// Patch request type.
-		if len(patchRequest) != 0 {
+		// Patch request type, resource level promote/demote requests are kept as is.
+		if len(patchRequest) != 0 && !isResourceSiteOp(patchRequest, data.RequestType) {
 			data.RequestType = patchRequest
 		}
 
@@ -117,6 +117,15 @@ func cmdOpsReplication(s state.State, r *http.Request, patchRequest types.Replic
 	return handleReplicationRequest(s, r.Context(), req)
 }
 
+// isResourceSiteOp checks if a resource PUT request carries a promote/demote request instead of configure.
+func isResourceSiteOp(patchRequest types.ReplicationRequestType, requestType types.ReplicationRequestType) bool {
+	if patchRequest != types.ConfigureReplicationRequest {
+		return false
+	}
+
+	return requestType == types.PromoteReplicationRequest || requestType == types.DemoteReplicationRequest
+}
+
 // handleReplicationRequest parses the replication request and feeds it to the corresponding state machine.
 func handleReplicationRequest(s state.State, ctx context.Context, req types.ReplicationRequest) response.Response {
 	// Fetch replication handler
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+)
+
+// /1.0/cluster/profiles endpoint.
+var clusterProfilesCmd = rest.Endpoint{
+	Path: "cluster/profiles",
+
+	Get: rest.EndpointAction{Handler: cmdClusterProfilesGet, ProxyTarget: true},
+}
+
+// /1.0/cluster/profiles/apply endpoint.
+var clusterProfilesApplyCmd = rest.Endpoint{
+	Path: "cluster/profiles/apply",
+
+	Post: rest.EndpointAction{Handler: cmdClusterProfilesApplyPost, ProxyTarget: true},
+}
+
+// cmdClusterProfilesGet lists the built-in profiles and the ones supplied on the member.
+func cmdClusterProfilesGet(s state.State, r *http.Request) response.Response {
+	profiles, err := ceph.ListProfiles()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, profiles)
+}
+
+// cmdClusterProfilesApplyPost applies a profile, or lists the changes it makes on a dry run.
+func cmdClusterProfilesApplyPost(s state.State, r *http.Request) response.Response {
+	var req types.ProfileApplyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var profile types.Profile
+	if req.Profile != nil {
+		profile = *req.Profile
+	} else {
+		profile, err = ceph.GetProfile(req.Name)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
+	changes, daemons, err := ceph.ApplyProfile(r.Context(), s, profile, req.DryRun, changedBy(r, req.User))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if req.DryRun {
+		return response.SyncResponse(true, types.ProfileApplyResponse{Changes: changes})
+	}
+
+	if !req.SkipRestart && len(daemons) != 0 {
+		configChangeRefresh(r.Context(), s, daemons, req.Wait)
+	}
+
+	// Render the client configs into ceph.conf on every member.
+	if slices.ContainsFunc(changes, func(c types.ProfileChange) bool { return c.Kind == types.ProfileClientConfig }) {
+		clientConfigUpdate(r.Context(), s, req.Wait)
+	}
+
+	return response.SyncResponse(true, types.ProfileApplyResponse{Changes: changes})
+}
@@ -152,6 +152,8 @@ func renderConfAndKeyringFiles(remoteName string, localName string, configs map[
 			"pubNet":   configs["public_network"],
 			"ipv4":     strings.Contains(configs["public_network"], "."),
 			"ipv6":     strings.Contains(configs["public_network"], ":"),
+			// Clients connecting to the remote cluster use the msgr2 mode it was exported with.
+			"msMode": configs["ms_mode"],
 		},
 		0644,
 	)
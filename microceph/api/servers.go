@@ -15,10 +15,23 @@ var Servers = map[string]rest.Server{
 				PathPrefix: types.ExtendedPathPrefix,
 				Endpoints: []rest.Endpoint{
 					disksCmd,
+					disksAdoptCmd,
+					disksEncryptionCmd,
+					disksKeyCmd,
+					disksEncryptCmd,
+					disksDeviceCmd,
+					disksHealthCmd,
+					disksAutoAddCmd,
+					disksOsdHealthCmd,
+					disksDrainCmd,
 					disksDelCmd,
 					resourcesCmd,
+					doctorCmd,
 					servicesCmd,
 					configsCmd,
+					configsHistoryCmd,
+					configsRollbackCmd,
+					configsDriftCmd,
 					restartServiceCmd,
 					mdsServiceCmd,
 					mgrServiceCmd,
@@ -36,6 +49,13 @@ var Servers = map[string]rest.Server{
 					microcephConfigsCmd,
 					logLevelCmd,
 					clusterCmd,
+					clusterNetworkCheckCmd,
+					clusterNetworkAddressesCmd,
+					clusterNetworkProbeCmd,
+					clusterNetworkMigrateCmd,
+					clusterMessengerCmd,
+					clusterProfilesCmd,
+					clusterProfilesApplyCmd,
 					remoteCmd,
 					remoteNameCmd,
 					opsCmd,
@@ -45,6 +65,10 @@ var Servers = map[string]rest.Server{
 					opsReplicationResourceCmd,
 					// Maintenance APIs
 					opsMaintenanceNodeCmd,
+					opsDecommissionNodeCmd,
+					// Backup APIs
+					opsBackupCmd,
+					opsRestoreCmd,
 				},
 			},
 		},
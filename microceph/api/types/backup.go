@@ -0,0 +1,26 @@
+// Package types provides shared types and structs.
+package types
+
+// BackupRequest holds the passphrase used to encrypt a member backup.
+type BackupRequest struct {
+	Passphrase string `json:"passphrase" yaml:"passphrase"`
+}
+
+// BackupResponse holds the encrypted member backup archive.
+type BackupResponse struct {
+	Archive []byte `json:"archive" yaml:"archive"`
+}
+
+// RestoreRequest holds an encrypted member backup archive and its passphrase.
+type RestoreRequest struct {
+	Archive    []byte `json:"archive" yaml:"archive"`
+	Passphrase string `json:"passphrase" yaml:"passphrase"`
+}
+
+// RestoreResponse summarises the restored member.
+type RestoreResponse struct {
+	Member   string   `json:"member" yaml:"member"`
+	Created  string   `json:"created" yaml:"created"`
+	OSDs     []int64  `json:"osds" yaml:"osds"`
+	Services []string `json:"services" yaml:"services"`
+}
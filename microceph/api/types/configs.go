@@ -1,13 +1,71 @@
 // Package types provides shared types and structs.
 package types
 
+import "time"
+
 // Configs holds the key value pair
 type Config struct {
 	Key         string `json:"key" yaml:"key"`
 	Value       string `json:"value" yaml:"value"`
 	Wait        bool   `json:"wait" yaml:"wait"`
 	SkipRestart bool   `json:"skip_restart" yaml:"skip_restart"`
+	// SkipCheck sets a cluster_network without checking the members reach each other on it first.
+	SkipCheck bool `json:"skip_check,omitempty" yaml:"skip_check,omitempty"`
+	// All lists every config set cluster wide or for a service, not only those set through MicroCeph.
+	All bool `json:"all,omitempty" yaml:"all,omitempty"`
+	// User is the user requesting a change, recorded in the config history.
+	User string `json:"user,omitempty" yaml:"user,omitempty"`
 }
 
 // Configs is a slice of configs
 type Configs []Config
+
+// ConfigChange is a change of a config recorded in the config history, an empty value means unset.
+type ConfigChange struct {
+	ID        int64     `json:"id" yaml:"id"`
+	Key       string    `json:"key" yaml:"key"`
+	Section   string    `json:"section" yaml:"section"`
+	OldValue  string    `json:"old_value" yaml:"old_value"`
+	NewValue  string    `json:"new_value" yaml:"new_value"`
+	ChangedBy string    `json:"changed_by" yaml:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" yaml:"changed_at"`
+}
+
+// ConfigChanges is a slice of config changes
+type ConfigChanges []ConfigChange
+
+// ConfigHistoryRequest holds the key to list the changes of, all keys if empty.
+type ConfigHistoryRequest struct {
+	Key string `json:"key" yaml:"key"`
+}
+
+// ConfigRollback holds the parameters for reverting a config change.
+type ConfigRollback struct {
+	ID          int64  `json:"id" yaml:"id"`
+	Wait        bool   `json:"wait" yaml:"wait"`
+	SkipRestart bool   `json:"skip_restart" yaml:"skip_restart"`
+	User        string `json:"user,omitempty" yaml:"user,omitempty"`
+}
+
+// ConfigDrift is a config whose value in Ceph differs from the one set through MicroCeph,
+// an empty value means unset.
+type ConfigDrift struct {
+	Key      string `json:"key" yaml:"key"`
+	Section  string `json:"section" yaml:"section"`
+	Expected string `json:"expected" yaml:"expected"`
+	Actual   string `json:"actual" yaml:"actual"`
+}
+
+// ConfigDrifts is a slice of config drifts
+type ConfigDrifts []ConfigDrift
+
+// ConfigDriftPolicy holds whether the values set through MicroCeph are re-asserted on drift.
+type ConfigDriftPolicy struct {
+	Reassert bool `json:"reassert" yaml:"reassert"`
+}
+
+// ConfigDriftReport holds the current config drifts and the drift policy.
+type ConfigDriftReport struct {
+	Reassert bool         `json:"reassert" yaml:"reassert"`
+	Drifts   ConfigDrifts `json:"drifts" yaml:"drifts"`
+}
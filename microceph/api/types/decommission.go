@@ -0,0 +1,12 @@
+// Package types provides shared types and structs.
+package types
+
+// DecommissionRequest holds the options for decommissioning a cluster member.
+type DecommissionRequest struct {
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+	Force  bool `json:"force" yaml:"force"`
+	// Dead removes the traces of a member which is permanently gone instead of migrating off it.
+	Dead bool `json:"dead" yaml:"dead"`
+	// Timeout bounds the drain and removal of each OSD (seconds).
+	Timeout int64 `json:"timeout" yaml:"timeout"`
+}
@@ -1,6 +1,10 @@
 // Package types provides shared types and structs.
 package types
 
+import (
+	"time"
+)
+
 // DisksPost hold a path and a flag for enabling device wiping
 type DisksPost struct {
 	Path       []string `json:"path" yaml:"path"`
@@ -12,6 +16,10 @@ type DisksPost struct {
 	DBDev      *string  `json:"dbdev" yaml:"dbdev"`
 	DBWipe     bool     `json:"dbwipe" yaml:"dbwipe"`
 	DBEncrypt  bool     `json:"dbencrypt" yaml:"dbencrypt"`
+	WALSize    uint64   `json:"walsize" yaml:"walsize"`
+	DBSize     uint64   `json:"dbsize" yaml:"dbsize"`
+	// OSDsPerDevice splits each device into this many OSDs, 0 or 1 uses the whole device.
+	OSDsPerDevice int `json:"osds_per_device" yaml:"osds_per_device"`
 }
 
 // DiskAddReport holds report for single disk addition i.e. success/failure and optional error for failures.
@@ -34,16 +42,123 @@ type DisksDelete struct {
 	ConfirmDowngrade       bool  `json:"confirm_downgrade" yaml:"confirm_downgrade"`
 	ProhibitCrushScaledown bool  `json:"prohibit_crush_scaledown" yaml:"prohibit_crush_scaledown"`
 	Timeout                int64 `json:"timeout" yaml:"timeout"`
+	// Drain requires the gradual drain of the OSD to have completed instead of taking it out at once.
+	Drain bool `json:"drain" yaml:"drain"`
 }
 
 // Disks is a slice of disks
 type Disks []Disk
 
 // Disk holds data for a device: OSD number, it's path and a location
+// OSDs on a partition of a split device also hold the device they share.
 type Disk struct {
 	OSD      int64  `json:"osd" yaml:"osd"`
 	Path     string `json:"path" yaml:"path"`
 	Location string `json:"location" yaml:"location"`
+	Parent   string `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Health   string `json:"health,omitempty" yaml:"health,omitempty"`
+}
+
+// Device roles of an OSD, the data role is only recorded for partitions of a split device.
+const (
+	DiskRoleData = "data"
+	DiskRoleWAL  = "wal"
+	DiskRoleDB   = "db"
+)
+
+// DiskDevice holds a data partition, WAL or DB device attached to an OSD.
+// Slices of a shared device also record the shared device, their partition number and size.
+type DiskDevice struct {
+	OSD       int64  `json:"osd" yaml:"osd"`
+	Role      string `json:"role" yaml:"role"`
+	Path      string `json:"path" yaml:"path"`
+	Encrypted bool   `json:"encrypted" yaml:"encrypted"`
+	Parent    string `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Partition int    `json:"partition,omitempty" yaml:"partition,omitempty"`
+	Size      uint64 `json:"size,omitempty" yaml:"size,omitempty"`
+}
+
+// DisksSetDevice holds the parameters for attaching or replacing the WAL or DB device of an OSD.
+type DisksSetDevice struct {
+	Role    string `json:"role" yaml:"role"`
+	Path    string `json:"path" yaml:"path"`
+	Wipe    bool   `json:"wipe" yaml:"wipe"`
+	Encrypt bool   `json:"encrypt" yaml:"encrypt"`
+}
+
+// Health states of an OSD device.
+const (
+	DiskHealthOK      = "ok"
+	DiskHealthWarning = "warning"
+	DiskHealthFailing = "failing"
+	DiskHealthUnknown = "unknown"
+)
+
+// DiskHealth holds the latest SMART readings of the device backing an OSD.
+type DiskHealth struct {
+	OSD                int64     `json:"osd" yaml:"osd"`
+	Status             string    `json:"status" yaml:"status"`
+	Reasons            []string  `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+	Temperature        int64     `json:"temperature" yaml:"temperature"`
+	PowerOnHours       int64     `json:"power_on_hours" yaml:"power_on_hours"`
+	ReallocatedSectors int64     `json:"reallocated_sectors" yaml:"reallocated_sectors"`
+	PendingSectors     int64     `json:"pending_sectors" yaml:"pending_sectors"`
+	MediaErrors        int64     `json:"media_errors" yaml:"media_errors"`
+	PercentageUsed     int64     `json:"percentage_used" yaml:"percentage_used"`
+	MarkedOut          bool      `json:"marked_out" yaml:"marked_out"`
+	UpdatedAt          time.Time `json:"updated_at" yaml:"updated_at"`
+}
+
+// DiskHealthPolicy holds the cluster wide handling of OSD devices predicted to fail.
+type DiskHealthPolicy struct {
+	MarkOut bool `json:"mark_out" yaml:"mark_out"`
+}
+
+// Drain states of an OSD.
+const (
+	DiskDrainRunning = "running"
+	DiskDrainPaused  = "paused"
+	DiskDrainDone    = "done"
+	DiskDrainFailed  = "failed"
+)
+
+// Drain actions of an OSD.
+const (
+	DiskDrainStart  = "start"
+	DiskDrainPause  = "pause"
+	DiskDrainResume = "resume"
+)
+
+// DiskDrain holds the progress of the gradual drain of an OSD.
+type DiskDrain struct {
+	OSD    int64  `json:"osd" yaml:"osd"`
+	Status string `json:"status" yaml:"status"`
+	// InitialWeight and Weight hold the CRUSH weight before the drain and now.
+	InitialWeight float64   `json:"initial_weight" yaml:"initial_weight"`
+	Weight        float64   `json:"weight" yaml:"weight"`
+	Step          float64   `json:"step" yaml:"step"`
+	Error         string    `json:"error,omitempty" yaml:"error,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at" yaml:"updated_at"`
+}
+
+// DisksDrain holds the parameters for starting, pausing or resuming the drain of an OSD.
+type DisksDrain struct {
+	Action string `json:"action" yaml:"action"`
+	// Step holds the CRUSH weight removed per step, a tenth of the OSD weight if 0.
+	Step float64 `json:"step,omitempty" yaml:"step,omitempty"`
+}
+
+// DiskAutoAddPolicy holds the per node policy for adding new devices as OSDs as they appear.
+// Only whole, pristine devices matching all of the set filters are added.
+type DiskAutoAddPolicy struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Types holds the accepted bus types, e.g. nvme or sata, any type if empty.
+	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+	// Model holds a glob pattern matched against the device model.
+	Model   string `json:"model,omitempty" yaml:"model,omitempty"`
+	MinSize uint64 `json:"min_size,omitempty" yaml:"min_size,omitempty"`
+	MaxSize uint64 `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+	Encrypt bool   `json:"encrypt" yaml:"encrypt"`
 }
 
 type DiskParameter struct {
@@ -51,4 +166,56 @@ type DiskParameter struct {
 	Encrypt  bool
 	Wipe     bool
 	LoopSize uint64
+	// Size is the size of the slice carved off a shared WAL/DB device, 0 uses the whole device.
+	Size uint64
+	// OSDsPerDevice splits the data device into this many OSDs.
+	OSDsPerDevice int
+	// Parent and Partition are set for partitions split off a data device.
+	Parent    string
+	Partition int
+}
+
+// DisksAdopt holds the parameters for adopting existing OSD devices on a member.
+type DisksAdopt struct {
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+}
+
+// DiskAdoptReport holds the outcome of adopting a single OSD.
+type DiskAdoptReport struct {
+	OSD    int64  `json:"osd" yaml:"osd"`
+	Path   string `json:"path" yaml:"path"`
+	Report string `json:"report" yaml:"report"`
+	Error  string `json:"error" yaml:"error"`
+}
+
+// DiskAdoptResponse holds response data for OSD adoption.
+type DiskAdoptResponse struct {
+	Reports []DiskAdoptReport `json:"report" yaml:"report"`
+}
+
+// Key providers for encrypted OSD devices.
+const (
+	KeyProviderConfigKey = "config-key"
+	KeyProviderFile      = "file"
+	KeyProviderHTTP      = "http"
+)
+
+// DiskEncryptionProvider holds the cluster wide key provider settings for encrypted OSD devices.
+type DiskEncryptionProvider struct {
+	Provider string `json:"provider" yaml:"provider"`
+	URL      string `json:"url" yaml:"url"`
+	Token    string `json:"token,omitempty" yaml:"token,omitempty"`
+}
+
+// DiskEncryptionKey holds the LUKS key of an encrypted OSD data device.
+type DiskEncryptionKey struct {
+	OSD int64  `json:"osd" yaml:"osd"`
+	Key string `json:"key" yaml:"key"`
+}
+
+// DisksEncrypt holds the parameters for converting an OSD to encrypted in place.
+type DisksEncrypt struct {
+	OSD     int64 `json:"osdid" yaml:"osdid"`
+	Noout   bool  `json:"noout" yaml:"noout"`
+	Timeout int64 `json:"timeout" yaml:"timeout"`
 }
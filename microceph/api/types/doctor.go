@@ -0,0 +1,24 @@
+// Package types provides shared types and structs.
+package types
+
+// Outcomes of a doctor check.
+const (
+	DoctorPass = "pass"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+)
+
+// DoctorResult holds the outcome of a single preflight check of a member.
+type DoctorResult struct {
+	Check   string `json:"check" yaml:"check"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	// Hint suggests how to fix a warning or failure.
+	Hint string `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+// DoctorReport holds the outcome of the preflight checks of a member.
+type DoctorReport struct {
+	Member  string         `json:"member" yaml:"member"`
+	Results []DoctorResult `json:"results" yaml:"results"`
+}
@@ -0,0 +1,17 @@
+// Package types provides shared types and structs.
+package types
+
+// MessengerRequest holds the messenger settings to convert a cluster to.
+type MessengerRequest struct {
+	// V2Only drops the msgr1 addresses of the monitors.
+	V2Only bool `json:"v2_only" yaml:"v2_only"`
+	// Secure requires on-wire encryption of every msgr2 connection.
+	Secure bool `json:"secure" yaml:"secure"`
+	// DryRun only plans the conversion.
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+}
+
+// MessengerResponse lists the steps of a messenger conversion, run or planned.
+type MessengerResponse struct {
+	Steps []string `json:"steps" yaml:"steps"`
+}
@@ -0,0 +1,86 @@
+// Package types provides shared types and structs.
+package types
+
+// NetworkCheckRequest holds the options of a cluster network check.
+type NetworkCheckRequest struct {
+	// ClusterNetwork checks the given subnet instead of the configured cluster network.
+	ClusterNetwork string `json:"cluster_network" yaml:"cluster_network"`
+}
+
+// NetworkAddresses holds the addresses of a member on a set of subnets.
+type NetworkAddresses struct {
+	Member string `json:"member" yaml:"member"`
+	// Addresses maps each subnet to the address of the member on it, empty if it has none.
+	Addresses map[string]string `json:"addresses" yaml:"addresses"`
+}
+
+// NetworkProbeTarget is an address of a member to probe.
+type NetworkProbeTarget struct {
+	Member string `json:"member" yaml:"member"`
+	// Network is either public or cluster.
+	Network string `json:"network" yaml:"network"`
+	Subnet  string `json:"subnet" yaml:"subnet"`
+	Address string `json:"address" yaml:"address"`
+	// Ports lists the Ceph daemon ports listening on the address.
+	Ports []int `json:"ports" yaml:"ports"`
+}
+
+// NetworkProbeRequest holds the addresses a member probes.
+type NetworkProbeRequest struct {
+	Targets []NetworkProbeTarget `json:"targets" yaml:"targets"`
+}
+
+// NetworkPortResult holds the TCP reachability of a port.
+type NetworkPortResult struct {
+	Port  int    `json:"port" yaml:"port"`
+	Open  bool   `json:"open" yaml:"open"`
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// NetworkProbeResult holds the outcome of a member probing the address of another one.
+type NetworkProbeResult struct {
+	From    string `json:"from" yaml:"from"`
+	To      string `json:"to" yaml:"to"`
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+	// Status is pass, warn or fail, as for doctor checks.
+	Status    string              `json:"status" yaml:"status"`
+	Message   string              `json:"message" yaml:"message"`
+	Reachable bool                `json:"reachable" yaml:"reachable"`
+	LatencyMs float64             `json:"latency_ms" yaml:"latency_ms"`
+	Ports     []NetworkPortResult `json:"ports" yaml:"ports"`
+	// InterfaceMTU is the MTU of the local interface on the network, PathMTU the largest packet reaching the peer.
+	InterfaceMTU int `json:"interface_mtu" yaml:"interface_mtu"`
+	PathMTU      int `json:"path_mtu" yaml:"path_mtu"`
+}
+
+// NetworkCheckReport holds the probes of every member to every other one.
+type NetworkCheckReport struct {
+	PublicNetwork  string               `json:"public_network" yaml:"public_network"`
+	ClusterNetwork string               `json:"cluster_network" yaml:"cluster_network"`
+	Results        []NetworkProbeResult `json:"results" yaml:"results"`
+}
+
+// Failed counts the probes which failed.
+func (r NetworkCheckReport) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if result.Status == DoctorFail {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// NetworkMigrateRequest holds the options of a public network migration.
+type NetworkMigrateRequest struct {
+	Public string `json:"public" yaml:"public"`
+	// DryRun only plans the migration.
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+}
+
+// NetworkMigrateResponse lists the steps of a public network migration, run or planned.
+type NetworkMigrateResponse struct {
+	Steps []string `json:"steps" yaml:"steps"`
+}
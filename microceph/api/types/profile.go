@@ -0,0 +1,46 @@
+package types
+
+// Profile bundles the settings tuning a cluster for a common shape.
+type Profile struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	// Configs are Ceph cluster configs, set as with `microceph cluster config set`.
+	Configs map[string]string `json:"configs,omitempty" yaml:"configs,omitempty"`
+	// ClientConfigs are client configs, set for every host.
+	ClientConfigs map[string]string `json:"client_configs,omitempty" yaml:"client_configs,omitempty"`
+	// PoolDefaults are the defaults of new pools, keyed by the osd_pool_default_ option suffix, e.g. size.
+	PoolDefaults map[string]string `json:"pool_defaults,omitempty" yaml:"pool_defaults,omitempty"`
+}
+
+// Profiles is a slice of profiles
+type Profiles []Profile
+
+// Kinds of settings changed by a profile.
+const (
+	ProfileConfig       = "config"
+	ProfileClientConfig = "client"
+)
+
+// ProfileChange is a setting a profile changes, an empty value means unset.
+type ProfileChange struct {
+	Kind     string `json:"kind" yaml:"kind"`
+	Key      string `json:"key" yaml:"key"`
+	OldValue string `json:"old_value" yaml:"old_value"`
+	NewValue string `json:"new_value" yaml:"new_value"`
+}
+
+// ProfileApplyRequest holds the profile to apply, either a known profile by name or the profile itself.
+type ProfileApplyRequest struct {
+	Name    string   `json:"name" yaml:"name"`
+	Profile *Profile `json:"profile,omitempty" yaml:"profile,omitempty"`
+	// DryRun only lists the changes.
+	DryRun      bool   `json:"dry_run" yaml:"dry_run"`
+	Wait        bool   `json:"wait" yaml:"wait"`
+	SkipRestart bool   `json:"skip_restart" yaml:"skip_restart"`
+	User        string `json:"user,omitempty" yaml:"user,omitempty"`
+}
+
+// ProfileApplyResponse lists the changes of a profile, made or planned.
+type ProfileApplyResponse struct {
+	Changes []ProfileChange `json:"changes" yaml:"changes"`
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/canonical/microceph/microceph/logger"
@@ -19,6 +20,8 @@ type RbdPoolStatusRemoteBrief struct {
 	Name      string `json:"name" yaml:"name"`
 	UUID      string `json:"uuid" yaml:"uuid"`
 	Direction string `json:"direction" yaml:"direction"`
+	// count of mirroring images per replication state as seen by this peer.
+	ImageStates map[string]int `json:"image_states" yaml:"image_states"`
 }
 
 type RbdPoolStatus struct {
@@ -35,6 +38,7 @@ type RbdPoolStatus struct {
 // Types for RBD Image status table.
 type RbdImageStatusRemoteBrief struct {
 	Name             string `json:"name" yaml:"name"`
+	State            string `json:"state" yaml:"state"`
 	Status           string `json:"status" yaml:"status"`
 	LastRemoteUpdate string `json:"last_remote_update" yaml:"last_remote_update"`
 }
@@ -49,6 +53,17 @@ type RbdImageStatus struct {
 	Remotes         []RbdImageStatusRemoteBrief `json:"remotes" yaml:"remotes"`
 }
 
+// Types for RBD Group status table.
+type RbdGroupStatus struct {
+	Name            string                      `json:"name" yaml:"name"`
+	ID              string                      `json:"id" yaml:"id"`
+	IsPrimary       bool                        `json:"is_primary" yaml:"is_primary"`
+	Status          string                      `json:"status" yaml:"status"`
+	LastLocalUpdate string                      `json:"last_local_update" yaml:"last_local_update"`
+	Images          []string                    `json:"images" yaml:"images"`
+	Remotes         []RbdImageStatusRemoteBrief `json:"remotes" yaml:"remotes"`
+}
+
 // Types for Rbd List
 
 type RbdPoolListImageBrief struct {
@@ -81,8 +96,12 @@ const (
 	RbdResourceDisabled RbdResourceType = "disabled"
 	RbdResourcePool     RbdResourceType = "pool"
 	RbdResourceImage    RbdResourceType = "image"
+	RbdResourceGroup    RbdResourceType = "group"
 )
 
+// RbdGroupResourceTag separates pool and group names in $pool/group/$group resource names.
+const RbdGroupResourceTag = "group"
+
 // RbdReplicationType defines mode of rbd mirroring
 type RbdReplicationType string
 
@@ -96,7 +115,10 @@ const (
 type RbdReplicationRequest struct {
 	SourcePool  string `json:"source_pool" yaml:"source_pool"`
 	SourceImage string `json:"source_image" yaml:"source_image"`
-	RemoteName  string `json:"remote" yaml:"remote"`
+	SourceGroup string `json:"source_group" yaml:"source_group"`
+	// RemoteName is kept for requests targeting a single remote, use Remotes for more.
+	RemoteName string   `json:"remote" yaml:"remote"`
+	Remotes    []string `json:"remotes" yaml:"remotes"`
 	// snapshot in d,h,m format
 	Schedule        string                 `json:"schedule" yaml:"schedule"`
 	ReplicationType RbdReplicationType     `json:"replication_type" yaml:"replication_type"`
@@ -113,6 +135,13 @@ func (req RbdReplicationRequest) GetWorkloadType() CephWorkloadType {
 
 // GetAPIObjectId provides the API object id i.e. /replication/rbd/<object-id>
 func (req RbdReplicationRequest) GetAPIObjectId() string {
+	// If both Pool and Group values are present encode for query.
+	if len(req.SourceGroup) != 0 && len(req.SourcePool) != 0 {
+		resource := url.QueryEscape(fmt.Sprintf("%s/%s/%s", req.SourcePool, RbdGroupResourceTag, req.SourceGroup))
+		logger.Debugf("REPAPI: Resource: %s", resource)
+		return resource
+	}
+
 	// If both Pool and Image values are present encode for query.
 	if len(req.SourceImage) != 0 && len(req.SourcePool) != 0 {
 		resource := url.QueryEscape(fmt.Sprintf("%s/%s", req.SourcePool, req.SourceImage))
@@ -131,17 +160,30 @@ func (req *RbdReplicationRequest) SetAPIObjectId(id string) error {
 		return err
 	}
 
-	frags := strings.Split(string(object), "/")
-	if len(frags) > 1 {
-		req.SourcePool = frags[0]
-		req.SourceImage = frags[1]
-	} else {
-		req.SourcePool = object
+	pool, image, group, err := ParseRbdResource(object)
+	if err != nil {
+		return err
 	}
 
+	req.SourcePool = pool
+	req.SourceImage = image
+	req.SourceGroup = group
 	return nil
 }
 
+// GetRemoteNames provides the deduplicated set of remotes targeted by the request.
+func (req RbdReplicationRequest) GetRemoteNames() []string {
+	remotes := []string{}
+	for _, remote := range append([]string{req.RemoteName}, req.Remotes...) {
+		if len(remote) == 0 || slices.Contains(remotes, remote) {
+			continue
+		}
+		remotes = append(remotes, remote)
+	}
+
+	return remotes
+}
+
 // GetAPIRequestType provides the REST method for the request
 func (req RbdReplicationRequest) GetAPIRequestType() string {
 	frags := strings.Split(string(req.RequestType), "-")
@@ -166,14 +208,31 @@ func (req RbdReplicationRequest) GetWorkloadRequestType() string {
 
 // ################### Helpers ############################
 // GetRbdResourceType gets the resource type of the said request
-func GetRbdResourceType(poolName string, imageName string) RbdResourceType {
-	if len(poolName) != 0 && len(imageName) != 0 {
+func GetRbdResourceType(poolName string, imageName string, groupName string) RbdResourceType {
+	if len(poolName) != 0 && len(groupName) != 0 {
+		return RbdResourceGroup
+	} else if len(poolName) != 0 && len(imageName) != 0 {
 		return RbdResourceImage
 	} else {
 		return RbdResourcePool
 	}
 }
 
+// ParseRbdResource splits a $pool, $pool/$image or $pool/group/$group resource name.
+func ParseRbdResource(resource string) (string, string, string, error) {
+	resourceFrags := strings.Split(resource, "/")
+	if len(resourceFrags) == 3 {
+		if resourceFrags[1] != RbdGroupResourceTag || len(resourceFrags[0]) == 0 || len(resourceFrags[2]) == 0 {
+			return "", "", "", fmt.Errorf("check resource name %s, should be in $pool/group/$group format", resource)
+		}
+
+		return resourceFrags[0], "", resourceFrags[2], nil
+	}
+
+	pool, image, err := GetPoolAndImageFromResource(resource)
+	return pool, image, "", err
+}
+
 func GetPoolAndImageFromResource(resource string) (string, string, error) {
 	var pool string
 	var image string
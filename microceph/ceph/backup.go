@@ -0,0 +1,474 @@
+package ceph
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// backupMagic prefixes every encrypted backup archive and carries the format version.
+const backupMagic = "MCBACKUP1"
+
+const (
+	backupSaltSize     = 16
+	backupManifestName = "manifest.json"
+	// Archive prefixes for the backed up directories.
+	backupConfPrefix  = "conf"
+	backupStatePrefix = "state"
+	backupOSDPrefix   = "osd"
+	backupKeysPrefix  = "keys"
+)
+
+// BackupManifest describes the member records and secrets carried by a backup archive.
+type BackupManifest struct {
+	Member   string            `json:"member"`
+	Created  string            `json:"created"`
+	Disks    types.Disks       `json:"disks"`
+	Services []string          `json:"services"`
+	Keys     map[string]string `json:"keys"`
+}
+
+// backupDir maps a local directory to its prefix inside the backup archive.
+type backupDir struct {
+	Path   string
+	Prefix string
+}
+
+// CreateBackup produces an encrypted archive of the local member state, configuration, keyrings and OSD keys.
+func CreateBackup(ctx context.Context, s state.State, passphrase string) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("a passphrase is required to encrypt the backup")
+	}
+
+	manifest := BackupManifest{
+		Member:  s.Name(),
+		Created: time.Now().UTC().Format(time.RFC3339),
+		Disks:   types.Disks{},
+	}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		member := s.Name()
+		disks, err := database.GetDisks(ctx, tx, database.DiskFilter{Member: &member})
+		if err != nil {
+			return fmt.Errorf("failed to fetch disks: %w", err)
+		}
+
+		for _, disk := range disks {
+			manifest.Disks = append(manifest.Disks, types.Disk{OSD: int64(disk.ID), Location: disk.Member, Path: disk.Path})
+		}
+
+		services, err := database.GetServices(ctx, tx, database.ServiceFilter{Member: &member})
+		if err != nil {
+			return fmt.Errorf("failed to fetch services: %w", err)
+		}
+
+		for _, service := range services {
+			manifest.Services = append(manifest.Services, service.Service)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Keys = getBackupKeys(manifest.Disks)
+
+	dirs := []backupDir{
+		{Path: constants.GetPathConst().ConfPath, Prefix: backupConfPrefix},
+		{Path: s.FileSystem().StateDir, Prefix: backupStatePrefix},
+		{Path: filepath.Join(constants.GetPathConst().DataPath, "keys"), Prefix: backupKeysPrefix},
+	}
+	for _, disk := range manifest.Disks {
+		dirs = append(dirs, backupDir{
+			Path:   getOSDDataPath(disk.OSD),
+			Prefix: filepath.Join(backupOSDPrefix, fmt.Sprintf("ceph-%d", disk.OSD)),
+		})
+	}
+
+	archive, err := writeBackupArchive(manifest, dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Created backup for member %s with %d OSDs and %d services", manifest.Member, len(manifest.Disks), len(manifest.Services))
+	return encryptBackup(archive, passphrase)
+}
+
+// RestoreBackup rebuilds a freshly installed member from an encrypted backup archive and re-activates its OSDs.
+func RestoreBackup(ctx context.Context, s state.State, archive []byte, passphrase string) (types.RestoreResponse, error) {
+	if s.Database().IsOpen(ctx) == nil {
+		return types.RestoreResponse{}, fmt.Errorf("member is already initialised, restore requires a freshly installed node")
+	}
+
+	data, err := decryptBackup(archive, passphrase)
+	if err != nil {
+		return types.RestoreResponse{}, err
+	}
+
+	pathConsts := constants.GetPathConst()
+	targets := map[string]string{
+		backupConfPrefix:  pathConsts.ConfPath,
+		backupStatePrefix: s.FileSystem().StateDir,
+		backupOSDPrefix:   filepath.Join(pathConsts.DataPath, "osd"),
+		backupKeysPrefix:  filepath.Join(pathConsts.DataPath, "keys"),
+	}
+
+	manifest, err := readBackupArchive(data, targets)
+	if err != nil {
+		return types.RestoreResponse{}, err
+	}
+
+	logger.Infof("Restored backup for member %s created at %s", manifest.Member, manifest.Created)
+
+	// Keys are held by the monitors, only put back the ones that went missing.
+	restoreBackupKeys(manifest.Keys)
+
+	if len(manifest.Disks) > 0 {
+		err = restartService("osd", true)
+		if err != nil {
+			logger.Warnf("failed to re-activate OSDs: %v", err)
+		}
+	}
+
+	// Restart the daemon asynchronously so that it picks up the restored member state.
+	go func() {
+		time.Sleep(2 * time.Second)
+		err := restartService("daemon", false)
+		if err != nil {
+			logger.Errorf("failed to restart daemon after restore: %v", err)
+		}
+	}()
+
+	osds := make([]int64, len(manifest.Disks))
+	for i, disk := range manifest.Disks {
+		osds[i] = disk.OSD
+	}
+
+	return types.RestoreResponse{
+		Member:   manifest.Member,
+		Created:  manifest.Created,
+		OSDs:     osds,
+		Services: manifest.Services,
+	}, nil
+}
+
+// getBackupKeys fetches the LUKS keys of the provided OSDs (data, WAL and DB) from the config-key store.
+func getBackupKeys(disks types.Disks) map[string]string {
+	keys := map[string]string{}
+	for _, disk := range disks {
+		for _, suffix := range []string{"", ".wal", ".db"} {
+			name := fmt.Sprintf("microceph:osd%s.%d/key", suffix, disk.OSD)
+			key, err := common.ProcessExec.RunCommand("ceph", "config-key", "get", name)
+			if err != nil {
+				// Not an encrypted device.
+				continue
+			}
+			keys[name] = strings.TrimSpace(key)
+		}
+	}
+
+	return keys
+}
+
+// restoreBackupKeys stores the backed up LUKS keys which are missing from the config-key store.
+func restoreBackupKeys(keys map[string]string) {
+	for name, key := range keys {
+		_, err := common.ProcessExec.RunCommand("ceph", "config-key", "exists", name)
+		if err == nil {
+			continue
+		}
+
+		_, err = common.ProcessExec.RunCommand("ceph", "config-key", "set", name, key)
+		if err != nil {
+			logger.Warnf("failed to restore key %s: %v", name, err)
+		}
+	}
+}
+
+// writeBackupArchive writes the manifest and the provided directories into a gzipped tarball.
+func writeBackupArchive(manifest BackupManifest, dirs []backupDir) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+
+	err = tw.WriteHeader(&tar.Header{Name: backupManifestName, Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
+	_, err = tw.Write(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
+	for _, dir := range dirs {
+		err = addDirToArchive(tw, dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close backup archive: %w", err)
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close backup archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// addDirToArchive adds regular files, directories and symlinks under dir to the archive.
+func addDirToArchive(tw *tar.Writer, dir backupDir) error {
+	return filepath.WalkDir(dir.Path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				logger.Warnf("skipping missing backup path %s", path)
+				return nil
+			}
+			return err
+		}
+
+		// Loop file backed OSDs can not be backed up.
+		if entry.Name() == "osd-backing.img" {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		// Skip sockets, devices and other special files.
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir.Path, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(dir.Prefix, rel))
+
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to backup: %w", path, err)
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to backup: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+// readBackupArchive extracts the archive entries into the target directory of their prefix and returns the manifest.
+func readBackupArchive(data []byte, targets map[string]string) (BackupManifest, error) {
+	manifest := BackupManifest{}
+	foundManifest := false
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return manifest, fmt.Errorf("failed to read backup archive: %w", err)
+		}
+
+		if header.Name == backupManifestName {
+			err = json.NewDecoder(tr).Decode(&manifest)
+			if err != nil {
+				return manifest, fmt.Errorf("failed to read backup manifest: %w", err)
+			}
+			foundManifest = true
+			continue
+		}
+
+		target, err := getBackupEntryTarget(header.Name, targets)
+		if err != nil {
+			return manifest, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.FileMode(header.Mode))
+		case tar.TypeSymlink:
+			_ = os.Remove(target)
+			err = os.Symlink(header.Linkname, target)
+		case tar.TypeReg:
+			err = writeBackupEntry(tr, target, os.FileMode(header.Mode))
+		default:
+			logger.Debugf("skipping backup entry %s of type %v", header.Name, header.Typeflag)
+		}
+		if err != nil {
+			return manifest, fmt.Errorf("failed to restore %s: %w", target, err)
+		}
+	}
+
+	if !foundManifest {
+		return manifest, fmt.Errorf("backup archive has no manifest")
+	}
+
+	return manifest, nil
+}
+
+// getBackupEntryTarget resolves the local path for an archive entry, rejecting entries escaping their target.
+func getBackupEntryTarget(name string, targets map[string]string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	prefix, rel, _ := strings.Cut(clean, string(filepath.Separator))
+
+	dir, ok := targets[prefix]
+	if !ok || filepath.IsAbs(clean) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("unexpected entry %s in backup archive", name)
+	}
+
+	return filepath.Join(dir, rel), nil
+}
+
+// writeBackupEntry writes a single regular file from the archive.
+func writeBackupEntry(r io.Reader, target string, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	return err
+}
+
+// getBackupCipher derives the AES-GCM cipher for the passphrase and salt.
+func getBackupCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive backup key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptBackup encrypts the archive using a key derived from the passphrase.
+func encryptBackup(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, backupSaltSize)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := getBackupCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := append([]byte(backupMagic), salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, []byte(backupMagic)), nil
+}
+
+// decryptBackup decrypts an archive produced by encryptBackup.
+func decryptBackup(data []byte, passphrase string) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(backupMagic)) {
+		return nil, fmt.Errorf("not a MicroCeph backup archive")
+	}
+	data = data[len(backupMagic):]
+
+	if len(data) < backupSaltSize {
+		return nil, fmt.Errorf("backup archive is truncated")
+	}
+
+	gcm, err := getBackupCipher(passphrase, data[:backupSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	data = data[backupSaltSize:]
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("backup archive is truncated")
+	}
+
+	out, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(backupMagic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup archive, check the passphrase: %w", err)
+	}
+
+	return out, nil
+}
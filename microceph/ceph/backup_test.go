@@ -0,0 +1,139 @@
+package ceph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type BackupSuite struct {
+	tests.BaseSuite
+}
+
+func TestBackup(t *testing.T) {
+	suite.Run(t, new(BackupSuite))
+}
+
+func (s *BackupSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+func (s *BackupSuite) TestEncryptionRoundTrip() {
+	data := []byte("some member state")
+
+	encrypted, err := encryptBackup(data, "secret")
+	assert.NoError(s.T(), err)
+	assert.NotContains(s.T(), string(encrypted), string(data))
+
+	decrypted, err := decryptBackup(encrypted, "secret")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), data, decrypted)
+
+	// Wrong passphrase.
+	_, err = decryptBackup(encrypted, "wrong")
+	assert.ErrorContains(s.T(), err, "check the passphrase")
+
+	// Not a backup archive.
+	_, err = decryptBackup(data, "secret")
+	assert.ErrorContains(s.T(), err, "not a MicroCeph backup archive")
+}
+
+func (s *BackupSuite) TestArchiveRoundTrip() {
+	src := filepath.Join(s.Tmp, "src")
+	osdDir := filepath.Join(src, "ceph-1")
+	assert.NoError(s.T(), os.MkdirAll(osdDir, 0700))
+	assert.NoError(s.T(), os.WriteFile(filepath.Join(osdDir, "keyring"), []byte("osd keyring"), 0600))
+	assert.NoError(s.T(), os.WriteFile(filepath.Join(osdDir, "osd-backing.img"), []byte("loop data"), 0600))
+	assert.NoError(s.T(), os.Symlink("/dev/sdb", filepath.Join(osdDir, "block")))
+
+	manifest := BackupManifest{
+		Member:   "node-1",
+		Disks:    types.Disks{{OSD: 1, Location: "node-1", Path: "/dev/sdb"}},
+		Services: []string{"mon", "osd"},
+		Keys:     map[string]string{"microceph:osd.1/key": "key"},
+	}
+
+	archive, err := writeBackupArchive(manifest, []backupDir{
+		{Path: filepath.Join(s.Tmp, "SNAP_DATA", "conf"), Prefix: backupConfPrefix},
+		{Path: osdDir, Prefix: filepath.Join(backupOSDPrefix, "ceph-1")},
+		// missing directories are skipped.
+		{Path: filepath.Join(s.Tmp, "missing"), Prefix: backupStatePrefix},
+	})
+	assert.NoError(s.T(), err)
+
+	dst := filepath.Join(s.Tmp, "dst")
+	restored, err := readBackupArchive(archive, map[string]string{
+		backupConfPrefix:  filepath.Join(dst, "conf"),
+		backupStatePrefix: filepath.Join(dst, "state"),
+		backupOSDPrefix:   filepath.Join(dst, "osd"),
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), manifest, restored)
+
+	conf, err := os.ReadFile(filepath.Join(dst, "conf", "ceph.conf"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), s.ReadCephConfig("ceph.conf"), string(conf))
+
+	keyring, err := os.ReadFile(filepath.Join(dst, "osd", "ceph-1", "keyring"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "osd keyring", string(keyring))
+
+	link, err := os.Readlink(filepath.Join(dst, "osd", "ceph-1", "block"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "/dev/sdb", link)
+
+	assert.NoFileExists(s.T(), filepath.Join(dst, "osd", "ceph-1", "osd-backing.img"))
+}
+
+func (s *BackupSuite) TestArchiveEntryTarget() {
+	targets := map[string]string{backupConfPrefix: "/conf"}
+
+	target, err := getBackupEntryTarget("conf/ceph.conf", targets)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "/conf/ceph.conf", target)
+
+	for _, name := range []string{"conf/../../etc/passwd", "/conf/ceph.conf", "unknown/file"} {
+		_, err = getBackupEntryTarget(name, targets)
+		assert.Error(s.T(), err, name)
+	}
+}
+
+func (s *BackupSuite) TestBackupKeys() {
+	r := mocks.NewRunner(s.T())
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"ceph", "config-key", "get", "microceph:osd.1/key"}...).Return("datakey\n", nil).Once()
+	r.On("RunCommand", []interface{}{
+		"ceph", "config-key", "get", "microceph:osd.wal.1/key"}...).Return("", fmt.Errorf("not found")).Once()
+	r.On("RunCommand", []interface{}{
+		"ceph", "config-key", "get", "microceph:osd.db.1/key"}...).Return("dbkey", nil).Once()
+	common.ProcessExec = r
+
+	keys := getBackupKeys(types.Disks{{OSD: 1}})
+	assert.Equal(s.T(), map[string]string{"microceph:osd.1/key": "datakey", "microceph:osd.db.1/key": "dbkey"}, keys)
+}
+
+func (s *BackupSuite) TestRestoreMissingKeys() {
+	r := mocks.NewRunner(s.T())
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"ceph", "config-key", "exists", "microceph:osd.1/key"}...).Return("", nil).Once()
+	r.On("RunCommand", []interface{}{
+		"ceph", "config-key", "exists", "microceph:osd.2/key"}...).Return("", fmt.Errorf("not found")).Once()
+	r.On("RunCommand", []interface{}{
+		"ceph", "config-key", "set", "microceph:osd.2/key", "key2"}...).Return("ok", nil).Once()
+	common.ProcessExec = r
+
+	restoreBackupKeys(map[string]string{"microceph:osd.1/key": "key1", "microceph:osd.2/key": "key2"})
+}
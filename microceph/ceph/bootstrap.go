@@ -275,7 +275,7 @@ func initMon(s interfaces.StateInterface, dataPath string, path string) error {
 		return fmt.Errorf("Failed to bootstrap monitor: %w", err)
 	}
 
-	err = snapStart("mon", true)
+	err = startService("mon", true)
 	if err != nil {
 		return fmt.Errorf("Failed to start monitor: %w", err)
 	}
@@ -305,7 +305,7 @@ func initMgr(s interfaces.StateInterface, dataPath string) error {
 		return fmt.Errorf("Failed to bootstrap manager: %w", err)
 	}
 
-	err = snapStart("mgr", true)
+	err = startService("mgr", true)
 	if err != nil {
 		return fmt.Errorf("Failed to start manager: %w", err)
 	}
@@ -373,7 +373,7 @@ func enableMsgr2() error {
 
 func startOSDs(s interfaces.StateInterface, path string) error {
 	// Start OSD service.
-	err := snapStart("osd", true)
+	err := startService("osd", true)
 	if err != nil {
 		return fmt.Errorf("Failed to start OSD service: %w", err)
 	}
@@ -394,7 +394,7 @@ func initMds(s interfaces.StateInterface, dataPath string) error {
 		return fmt.Errorf("Failed to bootstrap metadata server: %w", err)
 	}
 
-	err = snapStart("mds", true)
+	err = startService("mds", true)
 	if err != nil {
 		return fmt.Errorf("Failed to start metadata server: %w", err)
 	}
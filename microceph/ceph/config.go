@@ -8,8 +8,11 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/canonical/microcluster/v2/state"
+
 	"github.com/canonical/microceph/microceph/constants"
 	"github.com/canonical/microceph/microceph/interfaces"
 
@@ -105,36 +108,36 @@ type ConfigDumpItem struct {
 }
 type ConfigDump []ConfigDumpItem
 
+// SetConfigItem sets a MicroCeph supported cluster config, or any Ceph config option after
+// validating the value against the schema Ceph reports for it.
 func SetConfigItem(c types.Config) error {
-	// safety checks
-	canSet, err := canSetConfig(c.Key)
-	if !canSet {
-		return fmt.Errorf("config set(%s) failed: %v", c.Key, err)
+	config, err := checkSetConfig(c)
+	if err != nil {
+		return err
 	}
 
-	return setConfigItem(c)
+	return setConfigItem(config.Who, c)
 }
 
 func SetConfigItemUnsafe(c types.Config) error {
-	return setConfigItem(c)
+	return setConfigItem(GetConstConfigTable()[c.Key].Who, c)
 }
 
 func GetConfigItem(c types.Config) (types.Configs, error) {
 	var err error
 	ret := make(types.Configs, 1)
-	configTable := GetConstConfigTable()
 	who := "mon"
 
 	// safety checks
-	canRead, err := canReadConfig(c.Key)
-	if !canRead {
+	config, err := canReadConfig(c.Key)
+	if err != nil {
 		return nil, err
 	}
 
 	// workaround to query global configs from mon entity
 	// otherwise use the provided entity.
-	if configTable[c.Key].Who != "global" {
-		who = configTable[c.Key].Who
+	if config.Who != "global" {
+		who = config.Who
 	}
 
 	args := []string{
@@ -155,62 +158,74 @@ func GetConfigItem(c types.Config) (types.Configs, error) {
 
 func RemoveConfigItem(c types.Config) error {
 	// safety checks
-	canSet, err := canSetConfig(c.Key)
-	if !canSet {
+	config, _, err := canSetConfig(c.Key)
+	if err != nil {
 		return err
 	}
 
-	args := []string{
-		"config",
-		"rm",
-		GetConstConfigTable()[c.Key].Who,
-		c.Key,
-	}
+	return removeConfigItem(config.Who, c.Key)
+}
 
-	_, err = common.ProcessExec.RunCommand("ceph", args...)
+// ListConfigs lists the configs set through MicroCeph: the supported cluster configs and those
+// recorded in the config history. With all, every config set cluster wide or for a whole service is
+// listed instead, settings of single daemons are left out.
+func ListConfigs(ctx context.Context, s state.State, all bool) (types.Configs, error) {
+	dump, err := dumpConfigs()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
-}
-
-func ListConfigs() (types.Configs, error) {
-	var dump ConfigDump
-	var configs types.Configs
-	configTable := GetConstConfigTable()
-	args := []string{
-		"config",
-		"dump",
-		"-f",
-		"json-pretty",
+	changes := types.ConfigChanges{}
+	if !all {
+		changes, err = database.ConfigHistoryQuery.List(ctx, s, "")
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	output, err := common.ProcessExec.RunCommand("ceph", args...)
-	if err != nil {
-		return configs, err
+	return filterConfigs(dump, changes, all), nil
+}
+
+// filterConfigs picks the configs of the dump to list.
+func filterConfigs(dump ConfigDump, changes types.ConfigChanges, all bool) types.Configs {
+	recorded := map[configEntry]bool{}
+	for _, change := range changes {
+		recorded[configEntry{Section: change.Section, Key: change.Key}] = true
 	}
 
-	json.Unmarshal([]byte(output), &dump)
-	// Only take configs permitted in config table.
+	ret := types.Configs{}
+	configTable := GetConstConfigTable()
+	serviceSet := GetConfigTableServiceSet()
 	for _, configItem := range dump {
-		if configTable.isKeyPresent(configItem.Name) {
-			configs = append(configs, types.Config{
+		_, isService := serviceSet[configItem.Section]
+		listed := configTable.isKeyPresent(configItem.Name) || recorded[configEntry{Section: configItem.Section, Key: configItem.Name}]
+		if all {
+			listed = listed || configItem.Section == "global" || isService
+		}
+
+		if listed {
+			ret = append(ret, types.Config{
 				Key:   configItem.Name,
 				Value: configItem.Value,
 			})
 		}
 	}
 
-	return configs, nil
+	return ret
+}
+
+// GetConfigDefinition returns how a config key is set and which daemons need a restart on changes.
+func GetConfigDefinition(key string) (ClusterConfigDefinition, error) {
+	config, _, err := getClusterConfigDefinition(key)
+	return config, err
 }
 
 // ****** Helper Functions ******//
-func setConfigItem(c types.Config) error {
+func setConfigItem(who string, c types.Config) error {
 	args := []string{
 		"config",
 		"set",
-		GetConstConfigTable()[c.Key].Who,
+		who,
 		c.Key,
 		c.Value,
 		"-f",
@@ -225,43 +240,127 @@ func setConfigItem(c types.Config) error {
 	return nil
 }
 
-// canSetConfig checks if the config option is configurable.
-func canSetConfig(key string) (bool, error) {
-	config, err := getClusterConfigDefinition(key)
+func removeConfigItem(who string, key string) error {
+	args := []string{
+		"config",
+		"rm",
+		who,
+		key,
+	}
+
+	_, err := common.ProcessExec.RunCommand("ceph", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// dumpConfigs returns the configs set in the monitor config database.
+func dumpConfigs() (ConfigDump, error) {
+	var dump ConfigDump
+	args := []string{
+		"config",
+		"dump",
+		"-f",
+		"json-pretty",
+	}
+
+	output, err := common.ProcessExec.RunCommand("ceph", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(output), &dump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config dump: %w", err)
+	}
+
+	return dump, nil
+}
+
+// checkSetConfig checks the config is configurable and the value valid for it.
+func checkSetConfig(c types.Config) (ClusterConfigDefinition, error) {
+	config, schema, err := canSetConfig(c.Key)
+	if err != nil {
+		return config, fmt.Errorf("config set(%s) failed: %v", c.Key, err)
+	}
+
+	if schema != nil {
+		err = schema.validate(c.Value)
+		if err != nil {
+			return config, fmt.Errorf("config set(%s) failed: %v", c.Key, err)
+		}
+	}
+
+	return config, nil
+}
+
+// canSetConfig checks if the config option is configurable, the schema of options
+// outside the config table is returned for validating values.
+func canSetConfig(key string) (ClusterConfigDefinition, *configSchema, error) {
+	err := checkManagedConfig(key)
 	if err != nil {
 		logger.Warnf(err.Error())
-		return false, err
+		return ClusterConfigDefinition{}, nil, err
+	}
+
+	config, schema, err := getClusterConfigDefinition(key)
+	if err != nil {
+		return config, nil, err
 	}
 
 	if config.Permission != ClusterConfigRW {
 		err := fmt.Errorf("requested key %s does not support write operation", key)
 		logger.Warnf(err.Error())
-		return false, err
+		return config, nil, err
+	}
+
+	return config, schema, nil
+}
+
+// checkManagedConfig refuses Ceph options whose state MicroCeph keeps itself, pointing to the
+// command managing them.
+func checkManagedConfig(key string) error {
+	switch {
+	case slices.Contains(msModeOptions, key):
+		return fmt.Errorf("requested key %s is managed by MicroCeph, use 'microceph cluster set-messenger --secure' instead", key)
+	case key == "ms_bind_msgr1":
+		return fmt.Errorf("requested key %s is managed by MicroCeph, use 'microceph cluster set-messenger --v2-only' instead", key)
+	case strings.HasPrefix(key, "auth_"):
+		// The keyrings MicroCeph renders rely on cephx.
+		return fmt.Errorf("requested key %s is managed by MicroCeph, cephx authentication can't be changed", key)
 	}
 
-	return true, nil
+	return nil
 }
 
-func canReadConfig(key string) (bool, error) {
-	_, err := getClusterConfigDefinition(key)
+func canReadConfig(key string) (ClusterConfigDefinition, error) {
+	config, _, err := getClusterConfigDefinition(key)
 	if err != nil {
-		logger.Warnf(err.Error())
-		return false, err
+		return config, err
 	}
 
-	return true, nil
+	return config, nil
 }
 
-func getClusterConfigDefinition(key string) (ClusterConfigDefinition, error) {
+// getClusterConfigDefinition looks the key up in the config table, falling back to the
+// schema of the Ceph config option for keys outside of it.
+func getClusterConfigDefinition(key string) (ClusterConfigDefinition, *configSchema, error) {
 	configTable := GetConstConfigTable()
 	config, ok := configTable[key]
-	if !ok {
-		err := fmt.Errorf("requested key %s is not a MicroCeph supported cluster config", key)
+	if ok {
+		return config, nil, nil
+	}
+
+	schema, err := getConfigSchema(key)
+	if err != nil {
+		err := fmt.Errorf("requested key %s is not a MicroCeph supported cluster config or known Ceph option: %v", key, err)
 		logger.Warnf(err.Error())
-		return config, err
+		return config, nil, err
 	}
 
-	return config, nil
+	return schema.definition(), schema, nil
 }
 
 // backwardCompatPubnet ensures that the public_network is set in the database
@@ -327,10 +426,15 @@ func backwardCompatMonitors(ctx context.Context, s interfaces.StateInterface) ([
 	return monitorAddresses, nil
 }
 
-// UpdateConfig updates the ceph.conf file with the current configuration.
+// UpdateConfig updates the ceph.conf file, and the conf files of the remote clusters, with the current configuration.
 func UpdateConfig(ctx context.Context, s interfaces.StateInterface) error {
-	confPath := filepath.Join(os.Getenv("SNAP_DATA"), "conf")
-	runPath := filepath.Join(filepath.Dir(os.Getenv("SNAP_DATA")), "current", "run")
+	pathConsts := constants.GetPathConst()
+	confPath := pathConsts.ConfPath
+	runPath := pathConsts.RunPath
+	if constants.IsSnapLayout() {
+		// Refer to the run path independent of the snap revision.
+		runPath = filepath.Join(filepath.Dir(os.Getenv("SNAP_DATA")), "current", "run")
+	}
 
 	err := backwardCompatPubnet(ctx, s)
 	if err != nil {
@@ -387,6 +491,7 @@ func UpdateConfig(ctx context.Context, s interfaces.StateInterface) error {
 			"isCacheWritethrough": clientConfig.IsCacheWritethrough,
 			"cacheMaxDirty":       clientConfig.CacheMaxDirty,
 			"cacheTargetDirty":    clientConfig.CacheTargetDirty,
+			"msMode":              config["ms_mode"],
 		},
 		0644,
 	)
@@ -0,0 +1,206 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// configDriftReassertConfig is the cluster config key recording whether drifted configs are re-asserted.
+const configDriftReassertConfig = "config.drift.reassert"
+
+// configDriftInterval is the interval between comparisons of the configs set through MicroCeph with Ceph.
+var configDriftInterval = 5 * time.Minute
+
+// configEntry identifies a config in the monitor config database.
+type configEntry struct {
+	Section string
+	Key     string
+}
+
+// desiredConfigs returns the values set through MicroCeph, by section and key.
+func desiredConfigs(ctx context.Context, s state.State) (map[configEntry]string, error) {
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster config: %w", err)
+	}
+
+	changes, err := database.ConfigHistoryQuery.List(ctx, s, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeDesiredConfigs(config, changes), nil
+}
+
+// mergeDesiredConfigs takes the supported cluster configs recorded in the cluster config and
+// overrides them with the latest recorded change of every config. Changes hold the values as
+// Ceph stored them, so they compare with the config dump whatever form they were passed in.
+func mergeDesiredConfigs(config map[string]string, changes types.ConfigChanges) map[configEntry]string {
+	desired := map[configEntry]string{}
+	for key, definition := range GetConstConfigTable() {
+		value, ok := config[key]
+		if ok {
+			desired[configEntry{Section: definition.Who, Key: key}] = value
+		}
+	}
+
+	for _, change := range changes {
+		desired[configEntry{Section: change.Section, Key: change.Key}] = change.NewValue
+	}
+
+	return desired
+}
+
+// findConfigDrift compares the desired configs with the ones set in Ceph.
+func findConfigDrift(desired map[configEntry]string, dump ConfigDump) types.ConfigDrifts {
+	actual := map[configEntry]string{}
+	for _, item := range dump {
+		actual[configEntry{Section: item.Section, Key: item.Name}] = item.Value
+	}
+
+	drifts := types.ConfigDrifts{}
+	for entry, value := range desired {
+		if actual[entry] != value {
+			drifts = append(drifts, types.ConfigDrift{Key: entry.Key, Section: entry.Section, Expected: value, Actual: actual[entry]})
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Key != drifts[j].Key {
+			return drifts[i].Key < drifts[j].Key
+		}
+		return drifts[i].Section < drifts[j].Section
+	})
+
+	return drifts
+}
+
+// CheckConfigDrift returns the configs set through MicroCeph which since changed in Ceph.
+func CheckConfigDrift(ctx context.Context, s state.State) (types.ConfigDrifts, error) {
+	desired, err := desiredConfigs(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	dump, err := dumpConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	return findConfigDrift(desired, dump), nil
+}
+
+// reassertConfig sets a drifted config back to the value set through MicroCeph.
+func reassertConfig(drift types.ConfigDrift) error {
+	if len(drift.Expected) == 0 {
+		return removeConfigItem(drift.Section, drift.Key)
+	}
+
+	return setConfigItem(drift.Section, types.Config{Key: drift.Key, Value: drift.Expected})
+}
+
+// reconcileConfig reports the drifted configs, and re-asserts them if requested.
+func reconcileConfig(ctx context.Context, s state.State) error {
+	policy, err := GetConfigDriftPolicy(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	drifts, err := CheckConfigDrift(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	for _, drift := range drifts {
+		logger.Warnf("config %s/%s drifted from %q to %q", drift.Section, drift.Key, drift.Expected, drift.Actual)
+		if !policy.Reassert {
+			continue
+		}
+
+		err := reassertConfig(drift)
+		if err != nil {
+			logger.Errorf("failed to re-assert config %s/%s: %v", drift.Section, drift.Key, err)
+			continue
+		}
+
+		logger.Infof("Re-asserted config %s/%s to %q", drift.Section, drift.Key, drift.Expected)
+	}
+
+	return nil
+}
+
+// isConfigReconciler checks whether this member is the one reconciling the cluster wide configs,
+// the first member by name.
+func isConfigReconciler(s state.State) bool {
+	names := []string{}
+	for name := range s.Remotes().RemotesByName() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return len(names) == 0 || names[0] == s.Name()
+}
+
+// monitorConfigDrift periodically compares the configs set through MicroCeph with Ceph.
+func monitorConfigDrift(ctx context.Context, s state.State) {
+	for {
+		err := s.Database().IsOpen(ctx)
+		if err != nil {
+			logger.Debug("config drift: database not ready, waiting...")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+
+		if isConfigReconciler(s) {
+			err = reconcileConfig(ctx, s)
+			if err != nil {
+				logger.Warnf("config drift: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(configDriftInterval):
+		}
+	}
+}
+
+// GetConfigDriftPolicy fetches whether drifted configs are re-asserted from the cluster config.
+func GetConfigDriftPolicy(ctx context.Context, s state.State) (types.ConfigDriftPolicy, error) {
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return types.ConfigDriftPolicy{}, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	reassert, _ := strconv.ParseBool(config[configDriftReassertConfig])
+	return types.ConfigDriftPolicy{Reassert: reassert}, nil
+}
+
+// SetConfigDriftPolicy records whether drifted configs are re-asserted in the cluster config.
+func SetConfigDriftPolicy(ctx context.Context, s state.State, policy types.ConfigDriftPolicy) error {
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.SetConfigItem(ctx, tx, configDriftReassertConfig, strconv.FormatBool(policy.Reassert))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record config drift policy: %w", err)
+	}
+
+	logger.Infof("Config drift re-assert set to %t", policy.Reassert)
+	return nil
+}
@@ -0,0 +1,96 @@
+package ceph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type configDriftSuite struct {
+	tests.BaseSuite
+}
+
+func TestConfigDrift(t *testing.T) {
+	suite.Run(t, new(configDriftSuite))
+}
+
+func (s *configDriftSuite) TestMergeDesiredConfigs() {
+	config := map[string]string{"public_network": "10.0.0.0/24", "fsid": "abcd"}
+	changes := types.ConfigChanges{
+		{ID: 1, Key: "osd_max_backfills", Section: "osd", NewValue: "2"},
+		{ID: 2, Key: "osd_max_backfills", Section: "osd", OldValue: "2", NewValue: "4"},
+		{ID: 3, Key: "mon_max_pg_per_osd", Section: "global", NewValue: "300"},
+		{ID: 4, Key: "mon_max_pg_per_osd", Section: "global", OldValue: "300"},
+	}
+
+	// Internal keys aren't Ceph configs, later changes override earlier ones.
+	desired := mergeDesiredConfigs(config, changes)
+	assert.Equal(s.T(), map[configEntry]string{
+		{Section: "global", Key: "public_network"}:     "10.0.0.0/24",
+		{Section: "osd", Key: "osd_max_backfills"}:     "4",
+		{Section: "global", Key: "mon_max_pg_per_osd"}: "",
+	}, desired)
+}
+
+func (s *configDriftSuite) TestFindConfigDrift() {
+	desired := map[configEntry]string{
+		{Section: "global", Key: "public_network"}:     "10.0.0.0/24",
+		{Section: "osd", Key: "osd_max_backfills"}:     "4",
+		{Section: "global", Key: "mon_max_pg_per_osd"}: "",
+		{Section: "global", Key: "cluster_network"}:    "10.1.0.0/24",
+	}
+	dump := ConfigDump{
+		{Section: "global", Name: "public_network", Value: "10.0.0.0/24"},
+		{Section: "osd", Name: "osd_max_backfills", Value: "8"},
+		{Section: "global", Name: "mon_max_pg_per_osd", Value: "500"},
+		{Section: "osd.1", Name: "osd_max_backfills", Value: "1"},
+	}
+
+	drifts := findConfigDrift(desired, dump)
+	assert.Equal(s.T(), types.ConfigDrifts{
+		{Key: "cluster_network", Section: "global", Expected: "10.1.0.0/24"},
+		{Key: "mon_max_pg_per_osd", Section: "global", Actual: "500"},
+		{Key: "osd_max_backfills", Section: "osd", Expected: "4", Actual: "8"},
+	}, drifts)
+}
+
+func (s *configDriftSuite) TestNormalizedConfigNoDrift() {
+	stored := ConfigDump{{Section: "global", Name: "rgw_keystone_verify_ssl", Value: "true"}}
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[]`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "rgw_keystone_verify_ssl", "yes", "-f", "json-pretty").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[{"section":"global","name":"rgw_keystone_verify_ssl","value":"true"}]`, nil).Once()
+	common.ProcessExec = r
+
+	changes := types.ConfigChanges{}
+	q := mocks.NewConfigHistoryQueryInterface(s.T())
+	q.On("Add", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		changes = append(changes, args.Get(2).(types.ConfigChange))
+	}).Return(int64(1), nil).Once()
+	database.ConfigHistoryQuery = q
+
+	// A value Ceph normalizes on set doesn't drift.
+	_, err := ChangeConfig(context.Background(), &mocks.MockState{ClusterName: "node0"}, types.Config{Key: "rgw_keystone_verify_ssl", Value: "yes"}, "admin")
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), findConfigDrift(mergeDesiredConfigs(map[string]string{}, changes), stored))
+}
+
+func (s *configDriftSuite) TestReassertConfig() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "set", "osd", "osd_max_backfills", "4", "-f", "json-pretty").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "rm", "global", "mon_max_pg_per_osd").Return("", nil).Once()
+	common.ProcessExec = r
+
+	assert.NoError(s.T(), reassertConfig(types.ConfigDrift{Key: "osd_max_backfills", Section: "osd", Expected: "4", Actual: "8"}))
+	assert.NoError(s.T(), reassertConfig(types.ConfigDrift{Key: "mon_max_pg_per_osd", Section: "global", Actual: "500"}))
+}
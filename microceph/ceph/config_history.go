@@ -0,0 +1,118 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+)
+
+// ChangeConfig sets a config and records the change in the config history. It returns the
+// definition of the config, holding the daemons to restart.
+func ChangeConfig(ctx context.Context, s state.State, c types.Config, by string) (ClusterConfigDefinition, error) {
+	config, err := checkSetConfig(c)
+	if err != nil {
+		return config, err
+	}
+
+	old, err := getSetConfigValue(config.Who, c.Key)
+	if err != nil {
+		return config, err
+	}
+
+	err = setConfigItem(config.Who, c)
+	if err != nil {
+		return config, err
+	}
+
+	// Ceph normalizes the value it stores, e.g. 4G becomes 4294967296, record what it kept.
+	new, err := getSetConfigValue(config.Who, c.Key)
+	if err != nil {
+		return config, err
+	}
+
+	return config, recordConfigChange(ctx, s, config.Who, c.Key, old, new, by)
+}
+
+// ResetConfig removes a config and records the change in the config history. It returns the
+// definition of the config, holding the daemons to restart.
+func ResetConfig(ctx context.Context, s state.State, c types.Config, by string) (ClusterConfigDefinition, error) {
+	config, _, err := canSetConfig(c.Key)
+	if err != nil {
+		return config, err
+	}
+
+	old, err := getSetConfigValue(config.Who, c.Key)
+	if err != nil {
+		return config, err
+	}
+
+	err = removeConfigItem(config.Who, c.Key)
+	if err != nil {
+		return config, err
+	}
+
+	return config, recordConfigChange(ctx, s, config.Who, c.Key, old, "", by)
+}
+
+// RollbackConfig restores the value a config had before the given change, itself recorded as
+// a new change. It returns the definition of the config, holding the daemons to restart.
+func RollbackConfig(ctx context.Context, s state.State, id int64, by string) (ClusterConfigDefinition, error) {
+	change, err := database.ConfigHistoryQuery.Get(ctx, s, id)
+	if err != nil {
+		return ClusterConfigDefinition{}, err
+	}
+
+	c := types.Config{Key: change.Key, Value: change.OldValue}
+	if len(change.OldValue) == 0 {
+		return ResetConfig(ctx, s, c, by)
+	}
+
+	return ChangeConfig(ctx, s, c, by)
+}
+
+// ListConfigHistory returns the recorded changes of a config, or of every config if key is empty.
+func ListConfigHistory(ctx context.Context, s state.State, key string) (types.ConfigChanges, error) {
+	return database.ConfigHistoryQuery.List(ctx, s, key)
+}
+
+// getSetConfigValue returns the value set for a config in the given section, empty if unset.
+func getSetConfigValue(who string, key string) (string, error) {
+	dump, err := dumpConfigs()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the current value of %s: %w", key, err)
+	}
+
+	for _, item := range dump {
+		if item.Section == who && item.Name == key {
+			return item.Value, nil
+		}
+	}
+
+	return "", nil
+}
+
+// recordConfigChange adds a change to the config history, unless the value is unchanged.
+func recordConfigChange(ctx context.Context, s state.State, who string, key string, old string, new string, by string) error {
+	if old == new {
+		return nil
+	}
+
+	_, err := database.ConfigHistoryQuery.Add(ctx, s, types.ConfigChange{
+		Key:       key,
+		Section:   who,
+		OldValue:  old,
+		NewValue:  new,
+		ChangedBy: by,
+		ChangedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("%s changed but failed to record it in the config history: %w", key, err)
+	}
+
+	return nil
+}
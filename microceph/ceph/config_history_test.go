@@ -0,0 +1,108 @@
+package ceph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type configHistorySuite struct {
+	tests.BaseSuite
+}
+
+func TestConfigHistory(t *testing.T) {
+	suite.Run(t, new(configHistorySuite))
+}
+
+const clusterNetworkDump = `[{"section":"global","name":"cluster_network","value":"10.0.0.0/24"}]`
+
+func (s *configHistorySuite) TestChangeConfig() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(clusterNetworkDump, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "cluster_network", "10.1.0.0/24", "-f", "json-pretty").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[{"section":"global","name":"cluster_network","value":"10.1.0.0/24"}]`, nil).Once()
+	common.ProcessExec = r
+
+	q := mocks.NewConfigHistoryQueryInterface(s.T())
+	q.On("Add", mock.Anything, mock.Anything, mock.MatchedBy(func(change types.ConfigChange) bool {
+		return change.Key == "cluster_network" && change.Section == "global" && change.OldValue == "10.0.0.0/24" && change.NewValue == "10.1.0.0/24" && change.ChangedBy == "admin"
+	})).Return(int64(1), nil).Once()
+	database.ConfigHistoryQuery = q
+
+	config, err := ChangeConfig(context.Background(), &mocks.MockState{ClusterName: "node0"}, types.Config{Key: "cluster_network", Value: "10.1.0.0/24"}, "admin")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"osd"}, config.Daemons)
+}
+
+func (s *configHistorySuite) TestChangeConfigNormalized() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[]`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "rgw_keystone_verify_ssl", "yes", "-f", "json-pretty").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[{"section":"global","name":"rgw_keystone_verify_ssl","value":"true"}]`, nil).Once()
+	common.ProcessExec = r
+
+	// The history holds the value as stored by Ceph, not as passed.
+	q := mocks.NewConfigHistoryQueryInterface(s.T())
+	q.On("Add", mock.Anything, mock.Anything, mock.MatchedBy(func(change types.ConfigChange) bool {
+		return change.Key == "rgw_keystone_verify_ssl" && change.OldValue == "" && change.NewValue == "true"
+	})).Return(int64(1), nil).Once()
+	database.ConfigHistoryQuery = q
+
+	_, err := ChangeConfig(context.Background(), &mocks.MockState{ClusterName: "node0"}, types.Config{Key: "rgw_keystone_verify_ssl", Value: "yes"}, "admin")
+	assert.NoError(s.T(), err)
+}
+
+func (s *configHistorySuite) TestChangeConfigUnchanged() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(clusterNetworkDump, nil).Twice()
+	r.On("RunCommand", "ceph", "config", "set", "global", "cluster_network", "10.0.0.0/24", "-f", "json-pretty").Return("", nil).Once()
+	common.ProcessExec = r
+
+	// Setting the current value again leaves no trace in the history.
+	database.ConfigHistoryQuery = mocks.NewConfigHistoryQueryInterface(s.T())
+
+	_, err := ChangeConfig(context.Background(), &mocks.MockState{ClusterName: "node0"}, types.Config{Key: "cluster_network", Value: "10.0.0.0/24"}, "admin")
+	assert.NoError(s.T(), err)
+}
+
+func (s *configHistorySuite) TestRollbackToUnset() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(clusterNetworkDump, nil).Once()
+	r.On("RunCommand", "ceph", "config", "rm", "global", "cluster_network").Return("", nil).Once()
+	common.ProcessExec = r
+
+	q := mocks.NewConfigHistoryQueryInterface(s.T())
+	q.On("Get", mock.Anything, mock.Anything, int64(3)).Return(types.ConfigChange{ID: 3, Key: "cluster_network", Section: "global", NewValue: "10.0.0.0/24"}, nil).Once()
+	q.On("Add", mock.Anything, mock.Anything, mock.MatchedBy(func(change types.ConfigChange) bool {
+		return change.OldValue == "10.0.0.0/24" && change.NewValue == ""
+	})).Return(int64(4), nil).Once()
+	database.ConfigHistoryQuery = q
+
+	_, err := RollbackConfig(context.Background(), &mocks.MockState{ClusterName: "node0"}, 3, "admin")
+	assert.NoError(s.T(), err)
+}
+
+func (s *configHistorySuite) TestRollbackToValue() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(clusterNetworkDump, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "cluster_network", "10.2.0.0/24", "-f", "json-pretty").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[{"section":"global","name":"cluster_network","value":"10.2.0.0/24"}]`, nil).Once()
+	common.ProcessExec = r
+
+	q := mocks.NewConfigHistoryQueryInterface(s.T())
+	q.On("Get", mock.Anything, mock.Anything, int64(2)).Return(types.ConfigChange{ID: 2, Key: "cluster_network", Section: "global", OldValue: "10.2.0.0/24", NewValue: "10.0.0.0/24"}, nil).Once()
+	q.On("Add", mock.Anything, mock.Anything, mock.Anything).Return(int64(3), nil).Once()
+	database.ConfigHistoryQuery = q
+
+	_, err := RollbackConfig(context.Background(), &mocks.MockState{ClusterName: "node0"}, 2, "admin")
+	assert.NoError(s.T(), err)
+}
@@ -0,0 +1,178 @@
+package ceph
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// configSchema is the schema of a Ceph config option, as reported by `ceph config help`.
+type configSchema struct {
+	Name               string
+	Type               string
+	Services           []string
+	EnumValues         []string
+	Min                string
+	Max                string
+	CanUpdateAtRuntime bool
+	Flags              []string
+}
+
+// configWhoServices lists the services an option can be set for on its own, options used by
+// any other or several services are set globally.
+var configWhoServices = []string{"mon", "mgr", "osd", "mds"}
+
+var (
+	configSizeRe     = regexp.MustCompile(`^(\d+)\s*([kKmMgGtTpPeE]?)(i?[bB]?)$`)
+	configTimespanRe = regexp.MustCompile(`^(\d+\s*[a-z]+\s*)+$`)
+	configUUIDRe     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// getConfigSchema queries Ceph for the schema of a config option.
+func getConfigSchema(key string) (*configSchema, error) {
+	output, err := cephRun("config", "help", key, "-f", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	result := gjson.Parse(output)
+	if result.Get("name").String() != key {
+		return nil, fmt.Errorf("unexpected schema for %s: %s", key, output)
+	}
+
+	return &configSchema{
+		Name:               key,
+		Type:               result.Get("type").String(),
+		Services:           gjsonStrings(result.Get("services")),
+		EnumValues:         gjsonStrings(result.Get("enum_values")),
+		Min:                result.Get("min").String(),
+		Max:                result.Get("max").String(),
+		CanUpdateAtRuntime: result.Get("can_update_at_runtime").Bool(),
+		Flags:              gjsonStrings(result.Get("flags")),
+	}, nil
+}
+
+// gjsonStrings returns the strings of a JSON array.
+func gjsonStrings(result gjson.Result) []string {
+	values := []string{}
+	for _, value := range result.Array() {
+		values = append(values, value.String())
+	}
+
+	return values
+}
+
+// definition derives how to set the option and which daemons to restart on changes from its schema.
+func (s *configSchema) definition() ClusterConfigDefinition {
+	config := ClusterConfigDefinition{Who: "global", Permission: ClusterConfigRW, Daemons: []string{}}
+
+	if len(s.Services) == 1 && slices.Contains(configWhoServices, s.Services[0]) {
+		config.Who = s.Services[0]
+	}
+
+	// Options only read from the local conf files can't be set in the monitor config database.
+	if slices.Contains(s.Flags, "no_mon_update") {
+		config.Permission = ClusterConfigRO
+	}
+
+	if !s.CanUpdateAtRuntime || slices.Contains(s.Flags, "startup") {
+		serviceSet := GetConfigTableServiceSet()
+		for _, service := range s.Services {
+			if _, ok := serviceSet[service]; ok {
+				config.Daemons = append(config.Daemons, service)
+			}
+		}
+	}
+
+	return config
+}
+
+// validate checks a value against the type, allowed values and range of the option.
+func (s *configSchema) validate(value string) error {
+	if len(s.EnumValues) != 0 && !slices.Contains(s.EnumValues, value) {
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(s.EnumValues, ", "))
+	}
+
+	var number float64
+	var err error
+	switch s.Type {
+	case "bool":
+		if !slices.Contains([]string{"true", "false", "yes", "no", "on", "off", "1", "0"}, strings.ToLower(value)) {
+			return fmt.Errorf("%q is not a valid bool", value)
+		}
+
+		return nil
+	case "uuid":
+		if !configUUIDRe.MatchString(value) {
+			return fmt.Errorf("%q is not a valid uuid", value)
+		}
+
+		return nil
+	case "int":
+		var n int64
+		n, err = strconv.ParseInt(value, 10, 64)
+		number = float64(n)
+	case "uint":
+		var n uint64
+		n, err = strconv.ParseUint(value, 10, 64)
+		number = float64(n)
+	case "float":
+		number, err = strconv.ParseFloat(value, 64)
+	case "size":
+		number, err = parseConfigSize(value)
+	case "secs", "millisecs":
+		number, err = strconv.ParseFloat(value, 64)
+		if err != nil && configTimespanRe.MatchString(value) {
+			// Timespans like "1h 30m" are checked by Ceph itself.
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, s.Type)
+	}
+
+	return s.checkRange(value, number)
+}
+
+// checkRange checks a numeric value lies within the bounds of the option, if any.
+func (s *configSchema) checkRange(value string, number float64) error {
+	min, err := strconv.ParseFloat(s.Min, 64)
+	if err == nil && number < min {
+		return fmt.Errorf("%s is below the minimum of %s", value, s.Min)
+	}
+
+	max, err := strconv.ParseFloat(s.Max, 64)
+	if err == nil && number > max {
+		return fmt.Errorf("%s is above the maximum of %s", value, s.Max)
+	}
+
+	return nil
+}
+
+// parseConfigSize parses a size in bytes, with an optional binary unit prefix as in 4G or 4GiB.
+func parseConfigSize(value string) (float64, error) {
+	match := configSizeRe.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %s", value)
+	}
+
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	exponent := strings.Index("KMGTPE", strings.ToUpper(match[2])) + 1
+	if len(match[2]) == 0 {
+		exponent = 0
+	}
+
+	return number * math.Pow(1024, float64(exponent)), nil
+}
@@ -0,0 +1,85 @@
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type configSchemaSuite struct {
+	tests.BaseSuite
+}
+
+func TestConfigSchema(t *testing.T) {
+	suite.Run(t, new(configSchemaSuite))
+}
+
+func (s *configSchemaSuite) TestGetConfigSchema() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "help", "osd_op_queue", "-f", "json").Return(`{"name":"osd_op_queue","type":"str","level":"advanced","default":"mclock_scheduler","services":["osd"],"enum_values":["wpq","mclock_scheduler","debug_random"],"min":"","max":"","can_update_at_runtime":false,"flags":["startup"]}`, nil).Once()
+	common.ProcessExec = r
+
+	schema, err := getConfigSchema("osd_op_queue")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"wpq", "mclock_scheduler", "debug_random"}, schema.EnumValues)
+
+	// The option only applies to OSDs, which must restart to pick it up.
+	config := schema.definition()
+	assert.Equal(s.T(), "osd", config.Who)
+	assert.Equal(s.T(), ClusterConfigRW, config.Permission)
+	assert.Equal(s.T(), []string{"osd"}, config.Daemons)
+
+	assert.NoError(s.T(), schema.validate("wpq"))
+	assert.ErrorContains(s.T(), schema.validate("fifo"), "is not one of wpq, mclock_scheduler, debug_random")
+}
+
+func (s *configSchemaSuite) TestDefinition() {
+	schema := configSchema{Services: []string{"mon", "osd"}, CanUpdateAtRuntime: true}
+	config := schema.definition()
+	assert.Equal(s.T(), "global", config.Who)
+	assert.Empty(s.T(), config.Daemons)
+
+	schema = configSchema{Services: []string{"rgw"}, Flags: []string{"no_mon_update"}}
+	config = schema.definition()
+	assert.Equal(s.T(), "global", config.Who)
+	assert.Equal(s.T(), ClusterConfigRO, config.Permission)
+	assert.Equal(s.T(), []string{"rgw"}, config.Daemons)
+}
+
+func (s *configSchemaSuite) TestValidate() {
+	tests := []struct {
+		schema configSchema
+		value  string
+		err    string
+	}{
+		{configSchema{Type: "bool"}, "true", ""},
+		{configSchema{Type: "bool"}, "maybe", `"maybe" is not a valid bool`},
+		{configSchema{Type: "int", Min: "-1", Max: "10"}, "-1", ""},
+		{configSchema{Type: "int", Min: "-1", Max: "10"}, "11", "11 is above the maximum of 10"},
+		{configSchema{Type: "uint", Min: "1"}, "0", "0 is below the minimum of 1"},
+		{configSchema{Type: "float", Max: "1"}, "0.5", ""},
+		{configSchema{Type: "float"}, "half", `"half" is not a valid float`},
+		{configSchema{Type: "size", Min: "1073741824"}, "4G", ""},
+		{configSchema{Type: "size", Min: "1073741824"}, "512MiB", "512MiB is below the minimum of 1073741824"},
+		{configSchema{Type: "size"}, "4 bytes", `"4 bytes" is not a valid size`},
+		{configSchema{Type: "secs", Max: "600"}, "1h 30m", ""},
+		{configSchema{Type: "secs", Max: "600"}, "900", "900 is above the maximum of 600"},
+		{configSchema{Type: "uuid"}, "b0e9d6e4-4f0c-4b8f-9e6a-3d2d8c1f6a7b", ""},
+		{configSchema{Type: "uuid"}, "b0e9d6e4", `"b0e9d6e4" is not a valid uuid`},
+		{configSchema{Type: "str"}, "anything", ""},
+	}
+
+	for _, test := range tests {
+		err := test.schema.validate(test.value)
+		if len(test.err) == 0 {
+			assert.NoError(s.T(), err, test.value)
+		} else {
+			assert.EqualError(s.T(), err, test.err)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package ceph
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/canonical/microceph/microceph/common"
 	"testing"
 
@@ -79,6 +81,10 @@ func (s *configSuite) TestSetROConfigBypassChecks() {
 func (s *configSuite) TestSetUnknowConfig() {
 	t := types.Config{Key: "unknown_config", Value: "0.0.0.0/16"}
 
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "help", t.Key, "-f", "json").Return("", errors.New("Error ENOENT: Unrecognized config option 'unknown_config'")).Once()
+	common.ProcessExec = r
+
 	err := SetConfigItem(t)
 	assert.ErrorContains(s.T(), err, "is not a MicroCeph supported cluster config")
 }
@@ -97,6 +103,10 @@ func (s *configSuite) TestGetConfig() {
 func (s *configSuite) TestGetUnknownConfig() {
 	t := types.Config{Key: "unknown_config", Value: "0.0.0.0/16"}
 
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "help", t.Key, "-f", "json").Return("", errors.New("Error ENOENT: Unrecognized config option 'unknown_config'")).Once()
+	common.ProcessExec = r
+
 	_, err := GetConfigItem(t)
 	assert.ErrorContains(s.T(), err, "is not a MicroCeph supported cluster config")
 }
@@ -119,8 +129,55 @@ func (s *configSuite) TestListConfig() {
 	addListConfigExpectations(r, t.Key, t.Value)
 	common.ProcessExec = r
 
-	configs, err := ListConfigs()
+	configs, err := ListConfigs(context.Background(), &mocks.MockState{}, true)
 	assert.NoError(s.T(), err)
 	assert.Equal(s.T(), configs[0].Key, t.Key)
 	assert.Equal(s.T(), configs[0].Value, t.Value)
 }
+
+func (s *configSuite) TestFilterConfigs() {
+	dump := ConfigDump{
+		{Section: "global", Name: "cluster_network", Value: "10.1.0.0/24"},
+		{Section: "osd", Name: "osd_max_backfills", Value: "2"},
+		{Section: "global", Name: "mon_max_pg_per_osd", Value: "500"},
+		{Section: "osd.1", Name: "osd_memory_target", Value: "4294967296"},
+	}
+	changes := types.ConfigChanges{{Key: "osd_max_backfills", Section: "osd", NewValue: "2"}}
+
+	// By default, only the supported configs and those set with microceph are listed.
+	configs := filterConfigs(dump, changes, false)
+	assert.Equal(s.T(), types.Configs{{Key: "cluster_network", Value: "10.1.0.0/24"}, {Key: "osd_max_backfills", Value: "2"}}, configs)
+
+	configs = filterConfigs(dump, types.ConfigChanges{}, true)
+	assert.Len(s.T(), configs, 3)
+}
+
+func (s *configSuite) TestSetManagedConfig() {
+	for _, key := range []string{"ms_client_mode", "ms_bind_msgr1", "auth_cluster_required"} {
+		err := SetConfigItem(types.Config{Key: key, Value: "none"})
+		assert.ErrorContains(s.T(), err, "is managed by MicroCeph")
+	}
+}
+
+func (s *configSuite) TestSetCephConfig() {
+	t := types.Config{Key: "osd_max_backfills", Value: "2"}
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "help", t.Key, "-f", "json").Return(`{"name":"osd_max_backfills","type":"uint","level":"advanced","services":["osd"],"min":"","max":"","can_update_at_runtime":true,"flags":["runtime"]}`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "osd", t.Key, t.Value, "-f", "json-pretty").Return("", nil).Once()
+	common.ProcessExec = r
+
+	err := SetConfigItem(t)
+	assert.NoError(s.T(), err)
+}
+
+func (s *configSuite) TestSetCephConfigInvalid() {
+	t := types.Config{Key: "osd_max_backfills", Value: "-1"}
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "help", t.Key, "-f", "json").Return(`{"name":"osd_max_backfills","type":"uint","services":["osd"],"can_update_at_runtime":true,"flags":[]}`, nil).Once()
+	common.ProcessExec = r
+
+	err := SetConfigItem(t)
+	assert.ErrorContains(s.T(), err, `"-1" is not a valid uint`)
+}
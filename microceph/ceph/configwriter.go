@@ -55,7 +55,13 @@ ms bind ipv4 = {{.ipv4}}
 ms bind ipv6 = {{.ipv6}}
 # https://tracker.ceph.com/issues/70390
 bluestore_elastic_shared_blobs = false
-
+{{if .msMode}}ms_cluster_mode = {{.msMode}}
+ms_service_mode = {{.msMode}}
+ms_client_mode = {{.msMode}}
+ms_mon_cluster_mode = {{.msMode}}
+ms_mon_service_mode = {{.msMode}}
+ms_mon_client_mode = {{.msMode}}
+{{end}}
 [client]
 {{if .isCache}}rbd_cache = {{.isCache}}{{end}}
 {{if .cacheSize}}rbd_cache_size = {{.cacheSize}}{{end}}
@@ -0,0 +1,474 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// movableServices are the services moved to other members when decommissioning a node, in order.
+// The remaining services of the node are removed along with the member.
+var movableServices = []string{"mon", "mgr", "mds", "rgw", "nfs"}
+
+// Decommission plans and runs the removal of a cluster member along with its OSDs, services and keys.
+type Decommission struct {
+	Node       string
+	ClusterOps ClusterOps
+}
+
+// Run runs the decommission operations, or returns the plan on a dry run.
+// The plan is derived from the current state of the cluster, so a failed decommission resumes where it stopped when run again.
+func (d *Decommission) Run(req types.DecommissionRequest) ([]Result, error) {
+	plan := d.plan
+	if req.Dead {
+		plan = d.planDead
+	}
+
+	operations, err := plan(req)
+	if err != nil {
+		return []Result{}, err
+	}
+
+	return RunOperations(d.Node, operations, req.DryRun, req.Force), nil
+}
+
+// plan returns the operations decommissioning the node.
+func (d *Decommission) plan(req types.DecommissionRequest) ([]Operation, error) {
+	ctx := d.ClusterOps.Context
+	s := d.ClusterOps.State
+
+	if d.Node == s.Name() {
+		return nil, fmt.Errorf("cannot decommission the local node '%s', run the decommission from another member", d.Node)
+	}
+
+	members, err := common.GetClusterMemberNames(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster members: %w", err)
+	}
+	if !slices.Contains(members, d.Node) {
+		return nil, fmt.Errorf("node '%s' not found", d.Node)
+	}
+
+	disks, err := ListOSD(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	services, err := ListAllServices(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	operations := []Operation{}
+	for _, disk := range disks {
+		if disk.Location != d.Node {
+			continue
+		}
+
+		// An earlier run may have left the OSD partially drained, its drain is resumed.
+		drain, err := database.OSDQuery.Drain(ctx, s, disk.OSD)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get drain of osd.%d: %w", disk.OSD, err)
+		}
+
+		operations = append(operations, &RemoveOsdOps{ClusterOps: d.ClusterOps, OSD: disk.OSD, Timeout: req.Timeout, Drain: drain})
+	}
+
+	for _, name := range movableServices {
+		for _, service := range services {
+			if service.Service != name || service.Location != d.Node {
+				continue
+			}
+
+			target := pickServiceTarget(service, d.Node, members, services)
+			if len(target) == 0 && !haveServiceElsewhere(service, d.Node, services) && !req.Force {
+				return nil, fmt.Errorf("no member left to take over %s from node '%s'", service.Service, d.Node)
+			}
+
+			payload := ""
+			if service.Service == "nfs" && len(target) != 0 {
+				payload, err = nfsMovePayload(ctx, d.ClusterOps, service)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			operations = append(operations, &MoveServiceOps{
+				ClusterOps: d.ClusterOps,
+				Service:    service.Service,
+				GroupID:    service.GroupID,
+				Target:     target,
+				Payload:    payload,
+			})
+		}
+	}
+
+	operations = append(operations,
+		&RemoveCrushHostOps{ClusterOps: d.ClusterOps},
+		&RemoveAuthKeysOps{ClusterOps: d.ClusterOps},
+		&RemoveMemberOps{ClusterOps: d.ClusterOps, Force: req.Force},
+	)
+
+	return operations, nil
+}
+
+// hasService checks if a member runs the given service, of the same group for grouped services.
+func hasService(services types.Services, member string, service types.Service) bool {
+	for _, svc := range services {
+		if svc.Location == member && svc.Service == service.Service && svc.GroupID == service.GroupID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// haveServiceElsewhere checks if members other than node run the given service.
+func haveServiceElsewhere(service types.Service, node string, services types.Services) bool {
+	for _, svc := range services {
+		if svc.Location != node && svc.Service == service.Service && svc.GroupID == service.GroupID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pickServiceTarget picks the member taking over a service from node, the one running the fewest services
+// among those not running it yet. Returns an empty string if all other members run the service already.
+func pickServiceTarget(service types.Service, node string, members []string, services types.Services) string {
+	load := map[string]int{}
+	for _, svc := range services {
+		load[svc.Location]++
+	}
+
+	candidates := []string{}
+	for _, member := range members {
+		if member != node && !hasService(services, member, service) {
+			candidates = append(candidates, member)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if load[candidates[i]] != load[candidates[j]] {
+			return load[candidates[i]] < load[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	return candidates[0]
+}
+
+// nfsMovePayload returns the placement payload recreating an NFS service of the node on another member.
+func nfsMovePayload(ctx context.Context, ops ClusterOps, service types.Service) (string, error) {
+	placement := NFSServicePlacement{ClusterID: service.GroupID}
+
+	var info database.NFSServiceInfo
+	err := json.Unmarshal([]byte(service.Info), &info)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse NFS service info: %w", err)
+	}
+	placement.BindAddress = info.BindAddress
+	placement.BindPort = info.BindPort
+
+	err = ops.State.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		group, err := database.GetServiceGroup(ctx, tx, "nfs", service.GroupID)
+		if err != nil {
+			return err
+		}
+
+		var config database.NFSServiceGroupConfig
+		err = json.Unmarshal([]byte(group.Config), &config)
+		if err != nil {
+			return err
+		}
+
+		placement.V4MinVersion = config.V4MinVersion
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get NFS cluster %s: %w", service.GroupID, err)
+	}
+
+	payload, err := json.Marshal(placement)
+	if err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
+
+// RemoveOsdOps is an operation to drain and remove an OSD of a node.
+type RemoveOsdOps struct {
+	ClusterOps
+	OSD     int64
+	Timeout int64
+	// Drain holds the drain progress of the OSD when planned.
+	Drain types.DiskDrain
+}
+
+// partiallyDrained checks if the OSD was left partially drained.
+func (o *RemoveOsdOps) partiallyDrained() bool {
+	return len(o.Drain.Status) != 0 && o.Drain.Status != types.DiskDrainDone && o.Drain.Weight < o.Drain.InitialWeight
+}
+
+// Run drains and removes the OSD on the node hosting it.
+func (o *RemoveOsdOps) Run(name string) error {
+	present, err := database.OSDQuery.HaveOSD(o.Context, o.State, o.OSD)
+	if err != nil {
+		return err
+	}
+	if !present {
+		logger.Infof("osd.%d already removed", o.OSD)
+		return nil
+	}
+
+	cli, err := o.State.Leader()
+	if err != nil {
+		return err
+	}
+
+	// The drain runs in the background on the node, a decommission re-run resumes it.
+	err = client.WaitForDiskDrain(o.Context, cli, o.OSD, 0, o.Timeout, func(drain types.DiskDrain) {
+		logger.Infof("draining osd.%d of node '%s': %s, CRUSH weight %f of %f", o.OSD, name, drain.Status, drain.Weight, drain.InitialWeight)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drain osd.%d, re-run the decommission to resume: %w", o.OSD, err)
+	}
+
+	req := &types.DisksDelete{
+		OSD:              o.OSD,
+		ConfirmDowngrade: true,
+		Timeout:          o.Timeout,
+		Drain:            true,
+	}
+
+	err = client.RemoveDisk(o.Context, cli, req)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("removed osd.%d of node '%s'", o.OSD, name)
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *RemoveOsdOps) DryRun(name string) string {
+	if o.partiallyDrained() {
+		return fmt.Sprintf("Drain and remove osd.%d from node '%s', resuming its %s drain at CRUSH weight %.4f of %.4f.",
+			o.OSD, name, o.Drain.Status, o.Drain.Weight, o.Drain.InitialWeight)
+	}
+
+	return fmt.Sprintf("Drain and remove osd.%d from node '%s'.", o.OSD, name)
+}
+
+// GetName returns the name of the action
+func (o *RemoveOsdOps) GetName() string {
+	return "remove-osd-ops"
+}
+
+// MoveServiceOps is an operation to move a service of a node to another member.
+type MoveServiceOps struct {
+	ClusterOps
+	Service string
+	GroupID string
+	// Target is the member taking over the service, the service is only removed if empty.
+	Target  string
+	Payload string
+}
+
+// Run enables the service on the target member and removes it from the node.
+func (o *MoveServiceOps) Run(name string) error {
+	cli, err := o.State.Leader()
+	if err != nil {
+		return err
+	}
+
+	services, err := ListAllServices(o.Context, o.State)
+	if err != nil {
+		return fmt.Errorf("error listing services: %v", err)
+	}
+
+	service := types.Service{Service: o.Service, GroupID: o.GroupID}
+	if len(o.Target) != 0 && !hasService(services, o.Target, service) {
+		req := &types.EnableService{Name: o.Service, Wait: true, Payload: o.Payload}
+		err = client.SendServicePlacementReq(o.Context, cli, req, o.Target)
+		if err != nil {
+			return err
+		}
+		logger.Infof("enabled %s on node '%s'", o.Service, o.Target)
+	}
+
+	if !hasService(services, name, service) {
+		return nil
+	}
+
+	if o.Service == "nfs" {
+		err = client.DeleteNFSService(o.Context, cli, name, &types.NFSService{ClusterID: o.GroupID})
+	} else {
+		err = client.DeleteService(o.Context, cli, name, o.Service)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("removed %s from node '%s'", o.Service, name)
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *MoveServiceOps) DryRun(name string) string {
+	service := o.Service
+	if len(o.GroupID) != 0 {
+		service = fmt.Sprintf("%s (%s)", o.Service, o.GroupID)
+	}
+
+	if len(o.Target) == 0 {
+		return fmt.Sprintf("Remove %s from node '%s'.", service, name)
+	}
+
+	return fmt.Sprintf("Move %s from node '%s' to node '%s'.", service, name, o.Target)
+}
+
+// GetName returns the name of the action
+func (o *MoveServiceOps) GetName() string {
+	return "move-service-ops"
+}
+
+// RemoveCrushHostOps is an operation to remove the CRUSH host bucket of a node.
+type RemoveCrushHostOps struct {
+	ClusterOps
+}
+
+// Run removes the CRUSH host bucket of the node, which must not hold OSDs anymore.
+func (o *RemoveCrushHostOps) Run(name string) error {
+	_, err := cephRun("osd", "crush", "remove", name)
+	if err != nil {
+		return fmt.Errorf("failed to remove CRUSH host bucket '%s': %w", name, err)
+	}
+
+	logger.Infof("removed CRUSH host bucket '%s'", name)
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *RemoveCrushHostOps) DryRun(name string) string {
+	return fmt.Sprintf("Remove the CRUSH host bucket of node '%s'.", name)
+}
+
+// GetName returns the name of the action
+func (o *RemoveCrushHostOps) GetName() string {
+	return "remove-crush-host-ops"
+}
+
+// RemoveAuthKeysOps is an operation to remove the cephx keys of the daemons of a node.
+type RemoveAuthKeysOps struct {
+	ClusterOps
+	// NFSClusters are the NFS clusters the node still runs a Ganesha daemon of.
+	NFSClusters []string
+}
+
+// nodeAuthEntities returns the cephx entities of the per node daemons of a node.
+func nodeAuthEntities(name string, nfsClusters []string) []string {
+	entities := []string{
+		fmt.Sprintf("mgr.%s", name),
+		fmt.Sprintf("mds.%s", name),
+		fmt.Sprintf("client.rbd-mirror.%s", name),
+		fmt.Sprintf("client.cephfs-mirror.%s", name),
+	}
+
+	for _, clusterID := range nfsClusters {
+		entities = append(entities, fmt.Sprintf("client.nfs.%s.%s", clusterID, name))
+	}
+
+	return entities
+}
+
+// Run removes the cephx keys of the node which are still present.
+func (o *RemoveAuthKeysOps) Run(name string) error {
+	output, err := cephRun("auth", "ls", "--format", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list cephx keys: %w", err)
+	}
+
+	var auth struct {
+		AuthDump []struct {
+			Entity string `json:"entity"`
+		} `json:"auth_dump"`
+	}
+	err = json.Unmarshal([]byte(output), &auth)
+	if err != nil {
+		return fmt.Errorf("failed to parse cephx keys: %w", err)
+	}
+
+	entities := nodeAuthEntities(name, o.NFSClusters)
+	for _, key := range auth.AuthDump {
+		if !slices.Contains(entities, key.Entity) {
+			continue
+		}
+
+		_, err = cephRun("auth", "del", key.Entity)
+		if err != nil {
+			return fmt.Errorf("failed to remove cephx key %s: %w", key.Entity, err)
+		}
+		logger.Infof("removed cephx key %s", key.Entity)
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *RemoveAuthKeysOps) DryRun(name string) string {
+	return fmt.Sprintf("Remove the cephx keys of node '%s'.", name)
+}
+
+// GetName returns the name of the action
+func (o *RemoveAuthKeysOps) GetName() string {
+	return "remove-auth-keys-ops"
+}
+
+// RemoveMemberOps is an operation to remove a node from the cluster.
+type RemoveMemberOps struct {
+	ClusterOps
+	Force bool
+}
+
+// Run removes the node from the cluster.
+func (o *RemoveMemberOps) Run(name string) error {
+	cli, err := o.State.Leader()
+	if err != nil {
+		return err
+	}
+
+	err = client.MClient.DeleteClusterMember(cli, name, o.Force)
+	if err != nil {
+		return fmt.Errorf("failed to remove node '%s': %w", name, err)
+	}
+
+	logger.Infof("removed node '%s' from the cluster", name)
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *RemoveMemberOps) DryRun(name string) string {
+	return fmt.Sprintf("Remove node '%s' from the cluster.", name)
+}
+
+// GetName returns the name of the action
+func (o *RemoveMemberOps) GetName() string {
+	return "remove-member-ops"
+}
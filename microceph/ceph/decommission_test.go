@@ -0,0 +1,164 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+func TestDecommission(t *testing.T) {
+	suite.Run(t, new(decommissionSuite))
+}
+
+// decommissionSuite is the test suite for decommissioning nodes.
+type decommissionSuite struct {
+	tests.BaseSuite
+}
+
+func (s *decommissionSuite) TestPickServiceTarget() {
+	members := []string{"node0", "node1", "node2", "node3"}
+	services := types.Services{
+		{Service: "mon", Location: "node0"},
+		{Service: "mon", Location: "node1"},
+		{Service: "mgr", Location: "node1"},
+		{Service: "mon", Location: "node2"},
+		{Service: "nfs", Location: "node0", GroupID: "foo"},
+		{Service: "nfs", Location: "node3", GroupID: "bar"},
+	}
+
+	// The least loaded member without the service takes over.
+	assert.Equal(s.T(), "node3", pickServiceTarget(types.Service{Service: "mon"}, "node0", members, services))
+	assert.Equal(s.T(), "node2", pickServiceTarget(types.Service{Service: "mgr"}, "node1", members, services))
+
+	// Only members of the same group count for grouped services.
+	assert.Equal(s.T(), "node2", pickServiceTarget(types.Service{Service: "nfs", GroupID: "foo"}, "node0", members, services))
+
+	// No member left to take over.
+	assert.Empty(s.T(), pickServiceTarget(types.Service{Service: "mon"}, "node0", members[:3], services))
+	assert.True(s.T(), haveServiceElsewhere(types.Service{Service: "mon"}, "node0", services))
+	assert.False(s.T(), haveServiceElsewhere(types.Service{Service: "mgr"}, "node1", services))
+}
+
+func (s *decommissionSuite) TestRemoveOsdOpsDone() {
+	// An OSD removed by an earlier run is skipped.
+	m := mocks.NewOSDQueryInterface(s.T())
+	m.On("HaveOSD", mock.Anything, mock.Anything, int64(3)).Return(false, nil).Once()
+	database.OSDQuery = m
+
+	ops := RemoveOsdOps{ClusterOps: ClusterOps{nil, context.Background()}, OSD: 3}
+	assert.NoError(s.T(), ops.Run("node0"))
+	assert.Equal(s.T(), "Drain and remove osd.3 from node 'node0'.", ops.DryRun("node0"))
+}
+
+func (s *decommissionSuite) TestRemoveOsdOpsNeverDrained() {
+	// An OSD without a drain record gets drained and removed.
+	m := mocks.NewOSDQueryInterface(s.T())
+	m.On("HaveOSD", mock.Anything, mock.Anything, int64(3)).Return(true, nil).Once()
+	database.OSDQuery = m
+
+	requests := []string{}
+	cli := s.LocalClient(func(r *http.Request) (any, error) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /1.0/disks/3/drain":
+			return types.DiskDrain{OSD: 3}, nil
+		case "POST /1.0/disks/3/drain":
+			return types.DiskDrain{OSD: 3, Status: types.DiskDrainDone, InitialWeight: 1.0}, nil
+		case "GET /1.0/disks":
+			return types.Disks{{OSD: 3, Path: "/dev/sdb", Location: "node0"}}, nil
+		case "DELETE /1.0/disks/3":
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	ops := RemoveOsdOps{ClusterOps: ClusterOps{&mocks.MockState{LeaderCli: cli}, context.Background()}, OSD: 3, Timeout: 60}
+	assert.NoError(s.T(), ops.Run("node0"))
+	assert.Equal(s.T(), []string{"GET /1.0/disks/3/drain", "POST /1.0/disks/3/drain", "GET /1.0/disks", "DELETE /1.0/disks/3"}, requests)
+}
+
+func (s *decommissionSuite) TestRemoveOsdOpsPartiallyDrained() {
+	// A drain left behind by an earlier run shows in the plan.
+	ops := RemoveOsdOps{OSD: 3, Drain: types.DiskDrain{OSD: 3, Status: types.DiskDrainFailed, InitialWeight: 1.0, Weight: 0.4}}
+	assert.Equal(s.T(), "Drain and remove osd.3 from node 'node0', resuming its failed drain at CRUSH weight 0.4000 of 1.0000.", ops.DryRun("node0"))
+
+	ops.Drain.Status = types.DiskDrainDone
+	ops.Drain.Weight = 0
+	assert.Equal(s.T(), "Drain and remove osd.3 from node 'node0'.", ops.DryRun("node0"))
+}
+
+func (s *decommissionSuite) TestRemoveAuthKeysOps() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "auth", "ls", "--format", "json").Return(
+		`{"auth_dump":[{"entity":"osd.0"},{"entity":"mgr.node0"},{"entity":"mgr.node1"},{"entity":"client.rbd-mirror.node0"},{"entity":"client.radosgw.gateway"},{"entity":"client.nfs.foo.node0"},{"entity":"client.nfs.foo.node1"}]}`, nil).Once()
+	r.On("RunCommand", "ceph", "auth", "del", "mgr.node0").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "auth", "del", "client.rbd-mirror.node0").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "auth", "del", "client.nfs.foo.node0").Return("", nil).Once()
+	common.ProcessExec = r
+
+	ops := RemoveAuthKeysOps{ClusterOps: ClusterOps{nil, context.Background()}, NFSClusters: []string{"foo"}}
+	assert.NoError(s.T(), ops.Run("node0"))
+}
+
+func (s *decommissionSuite) TestPurgeOsdOps() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "tree", "-f", "json").Return(`{"nodes":[{"id":3,"type":"osd"}]}`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "down", "osd.3").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "purge", "osd.3", "--yes-i-really-mean-it").Return("", nil).Once()
+	common.ProcessExec = r
+
+	ops := PurgeOsdOps{ClusterOps: ClusterOps{nil, context.Background()}, OSD: 3}
+	assert.NoError(s.T(), ops.Run("node0"))
+}
+
+func (s *decommissionSuite) TestPurgeOsdOpsDone() {
+	// An OSD purged by an earlier run is skipped.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "tree", "-f", "json").Return(`{"nodes":[{"id":4,"type":"osd"}]}`, nil).Once()
+	common.ProcessExec = r
+
+	ops := PurgeOsdOps{ClusterOps: ClusterOps{nil, context.Background()}, OSD: 3}
+	assert.NoError(s.T(), ops.Run("node0"))
+}
+
+func (s *decommissionSuite) TestRemoveMonOps() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "mon", "dump", "-f", "json").Return(`{"mons":[{"name":"node0"},{"name":"node1"}]}`, nil).Twice()
+	r.On("RunCommand", "ceph", "mon", "rm", "node0").Return("", nil).Once()
+	common.ProcessExec = r
+
+	ops := RemoveMonOps{ClusterOps: ClusterOps{nil, context.Background()}}
+	assert.NoError(s.T(), ops.Run("node0"))
+
+	// A monitor removed by an earlier run is skipped.
+	assert.NoError(s.T(), ops.Run("node2"))
+}
+
+func (s *decommissionSuite) TestRemoveCrushHostOps() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "crush", "remove", "node0").Return("", nil).Once()
+	common.ProcessExec = r
+
+	ops := RemoveCrushHostOps{ClusterOps: ClusterOps{nil, context.Background()}}
+	assert.NoError(s.T(), ops.Run("node0"))
+}
+
+func (s *decommissionSuite) TestMoveServiceOpsDryRun() {
+	ops := MoveServiceOps{Service: "mon", Target: "node3"}
+	assert.Equal(s.T(), "Move mon from node 'node0' to node 'node3'.", ops.DryRun("node0"))
+
+	ops = MoveServiceOps{Service: "nfs", GroupID: "foo"}
+	assert.Equal(s.T(), "Remove nfs (foo) from node 'node0'.", ops.DryRun("node0"))
+}
@@ -0,0 +1,331 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/spf13/afero"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// diskHealthInterval is the interval between SMART readings of the OSD devices of a member.
+var diskHealthInterval = time.Hour
+
+// Config table key holding the disk health policy.
+const diskHealthMarkOutConfig = "disk_health_mark_out"
+
+// Predictive failure thresholds of OSD devices.
+const (
+	reallocatedSectorsWarning = 1
+	reallocatedSectorsFailing = 100
+	pendingSectorsWarning     = 1
+	pendingSectorsFailing     = 10
+	mediaErrorsWarning        = 1
+	percentageUsedWarning     = 90
+	percentageUsedFailing     = 100
+	temperatureWarning        = 70
+)
+
+// ATA SMART attributes counting bad sectors.
+const (
+	ataReallocatedSectors   = 5
+	ataPendingSectors       = 197
+	ataUncorrectableSectors = 198
+)
+
+// smartData holds the relevant fields of the smartctl json output.
+type smartData struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours int64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeHealth *struct {
+		CriticalWarning         int64 `json:"critical_warning"`
+		AvailableSpare          int64 `json:"available_spare"`
+		AvailableSpareThreshold int64 `json:"available_spare_threshold"`
+		PercentageUsed          int64 `json:"percentage_used"`
+		MediaErrors             int64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// evaluateDiskHealth turns SMART readings into the health of an OSD device.
+func evaluateDiskHealth(osd int64, data smartData) types.DiskHealth {
+	health := types.DiskHealth{
+		OSD:          osd,
+		Status:       types.DiskHealthOK,
+		Temperature:  data.Temperature.Current,
+		PowerOnHours: data.PowerOnTime.Hours,
+		UpdatedAt:    time.Now().UTC(),
+	}
+
+	for _, attr := range data.ATASmartAttributes.Table {
+		switch attr.ID {
+		case ataReallocatedSectors:
+			health.ReallocatedSectors = attr.Raw.Value
+		case ataPendingSectors, ataUncorrectableSectors:
+			health.PendingSectors += attr.Raw.Value
+		}
+	}
+
+	if data.NVMeHealth != nil {
+		health.MediaErrors = data.NVMeHealth.MediaErrors
+		health.PercentageUsed = data.NVMeHealth.PercentageUsed
+	}
+
+	// Devices without SMART support, e.g. virtual disks, can't be judged.
+	if data.SmartStatus == nil {
+		health.Status = types.DiskHealthUnknown
+		return health
+	}
+
+	warn := func(reason string, args ...any) {
+		if health.Status == types.DiskHealthOK {
+			health.Status = types.DiskHealthWarning
+		}
+		health.Reasons = append(health.Reasons, fmt.Sprintf(reason, args...))
+	}
+	fail := func(reason string, args ...any) {
+		health.Status = types.DiskHealthFailing
+		health.Reasons = append(health.Reasons, fmt.Sprintf(reason, args...))
+	}
+
+	if !data.SmartStatus.Passed {
+		fail("SMART overall health self-assessment failed")
+	}
+
+	switch {
+	case health.ReallocatedSectors >= reallocatedSectorsFailing:
+		fail("%d reallocated sectors", health.ReallocatedSectors)
+	case health.ReallocatedSectors >= reallocatedSectorsWarning:
+		warn("%d reallocated sectors", health.ReallocatedSectors)
+	}
+
+	switch {
+	case health.PendingSectors >= pendingSectorsFailing:
+		fail("%d pending or uncorrectable sectors", health.PendingSectors)
+	case health.PendingSectors >= pendingSectorsWarning:
+		warn("%d pending or uncorrectable sectors", health.PendingSectors)
+	}
+
+	if data.NVMeHealth != nil {
+		if data.NVMeHealth.CriticalWarning != 0 {
+			fail("NVMe critical warning 0x%x", data.NVMeHealth.CriticalWarning)
+		}
+
+		if data.NVMeHealth.AvailableSpare < data.NVMeHealth.AvailableSpareThreshold {
+			fail("available spare %d%% below threshold of %d%%", data.NVMeHealth.AvailableSpare, data.NVMeHealth.AvailableSpareThreshold)
+		}
+
+		if health.MediaErrors >= mediaErrorsWarning {
+			warn("%d media errors", health.MediaErrors)
+		}
+	}
+
+	switch {
+	case health.PercentageUsed >= percentageUsedFailing:
+		fail("%d%% of rated endurance used", health.PercentageUsed)
+	case health.PercentageUsed >= percentageUsedWarning:
+		warn("%d%% of rated endurance used", health.PercentageUsed)
+	}
+
+	if health.Temperature >= temperatureWarning {
+		warn("temperature of %d°C", health.Temperature)
+	}
+
+	return health
+}
+
+// readDiskHealth collects the SMART readings of an OSD device.
+func (m *OSDManager) readDiskHealth(osd int64, path string) (types.DiskHealth, error) {
+	// smartctl flags disk problems in its exit status, the json output is still complete.
+	output, err := m.runner.RunCommand("smartctl", "--json", "--all", path)
+	if len(output) == 0 && err != nil {
+		return types.DiskHealth{}, fmt.Errorf("failed to read SMART data of %s: %w", path, err)
+	}
+
+	var data smartData
+	jerr := json.Unmarshal([]byte(output), &data)
+	if jerr != nil {
+		return types.DiskHealth{}, fmt.Errorf("failed to parse SMART data of %s: %w", path, jerr)
+	}
+
+	// The two lowest exit status bits report that the device could not be queried.
+	if data.Smartctl.ExitStatus&0x3 != 0 {
+		msg := fmt.Sprintf("exit status %d", data.Smartctl.ExitStatus)
+		if len(data.Smartctl.Messages) != 0 {
+			msg = data.Smartctl.Messages[0].String
+		}
+		return types.DiskHealth{}, fmt.Errorf("failed to read SMART data of %s: %s", path, msg)
+	}
+
+	return evaluateDiskHealth(osd, data), nil
+}
+
+// isVirtualDevice checks whether a device is a device mapper or loop device, neither of which hold SMART data.
+func (m *OSDManager) isVirtualDevice(path string) bool {
+	_, _, major, minor, _, _, err := m.fileStater.GetFileStat(path)
+	if err != nil {
+		return false
+	}
+
+	for _, kind := range []string{"dm", "loop"} {
+		if exists, _ := afero.DirExists(m.fs, fmt.Sprintf("/sys/dev/block/%d:%d/%s", major, minor, kind)); exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkDiskHealth records the health of the OSD devices of this member, marking out OSDs predicted to fail if requested.
+func (m *OSDManager) checkDiskHealth(ctx context.Context, policy types.DiskHealthPolicy) error {
+	disks, err := database.OSDQuery.List(ctx, m.state)
+	if err != nil {
+		return fmt.Errorf("failed to fetch disks: %w", err)
+	}
+
+	for _, disk := range disks {
+		if disk.Location != m.state.Name() || !m.validator.IsBlockdevPath(disk.Path) {
+			continue
+		}
+
+		// SMART data belongs to the whole device of a split device.
+		path := disk.Path
+		if len(disk.Parent) != 0 {
+			path = disk.Parent
+		}
+
+		// Logical volumes and loop devices have no SMART data of their own.
+		if m.isVirtualDevice(path) {
+			continue
+		}
+
+		health, err := m.readDiskHealth(disk.OSD, path)
+		if err != nil {
+			logger.Warnf("disk health: %v", err)
+			continue
+		}
+
+		previous, err := database.OSDQuery.Health(ctx, m.state, disk.OSD)
+		if err != nil {
+			logger.Warnf("failed to fetch previous health of osd.%d: %v", disk.OSD, err)
+		}
+		health.MarkedOut = previous.MarkedOut
+
+		if health.Status == types.DiskHealthFailing && previous.Status != types.DiskHealthFailing {
+			msg := fmt.Sprintf("osd.%d device %s is predicted to fail: %v", disk.OSD, path, health.Reasons)
+			logger.Warn(msg)
+			_, err = m.runner.RunCommand("ceph", "log", msg)
+			if err != nil {
+				logger.Warnf("failed to raise warning in the cluster log: %v", err)
+			}
+		}
+
+		if health.Status == types.DiskHealthFailing && policy.MarkOut && !health.MarkedOut {
+			_, err = m.runner.RunCommand("ceph", "osd", "out", fmt.Sprintf("osd.%d", disk.OSD))
+			if err != nil {
+				logger.Errorf("failed to mark out failing osd.%d: %v", disk.OSD, err)
+			} else {
+				logger.Warnf("Marked out osd.%d, its device is predicted to fail", disk.OSD)
+				health.MarkedOut = true
+			}
+		}
+
+		err = database.OSDQuery.SetHealth(ctx, m.state, health)
+		if err != nil {
+			logger.Errorf("failed to record health of osd.%d: %v", disk.OSD, err)
+		}
+	}
+
+	return nil
+}
+
+// monitorDiskHealth periodically records the health of the OSD devices of this member.
+func monitorDiskHealth(ctx context.Context, s state.State) {
+	m := NewOSDManager(s)
+	for {
+		err := s.Database().IsOpen(ctx)
+		if err != nil {
+			logger.Debug("disk health: database not ready, waiting...")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+
+		policy, err := GetDiskHealthPolicy(ctx, s)
+		if err != nil {
+			logger.Warnf("disk health: failed to fetch policy: %v", err)
+		}
+
+		err = m.checkDiskHealth(ctx, policy)
+		if err != nil {
+			logger.Warnf("disk health: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(diskHealthInterval):
+		}
+	}
+}
+
+// GetDiskHealth returns the latest health readings of an OSD device.
+func GetDiskHealth(ctx context.Context, s state.State, osd int64) (types.DiskHealth, error) {
+	return database.OSDQuery.Health(ctx, s, osd)
+}
+
+// GetDiskHealthPolicy fetches the disk health policy from the cluster config.
+func GetDiskHealthPolicy(ctx context.Context, s state.State) (types.DiskHealthPolicy, error) {
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return types.DiskHealthPolicy{}, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	markOut, _ := strconv.ParseBool(config[diskHealthMarkOutConfig])
+	return types.DiskHealthPolicy{MarkOut: markOut}, nil
+}
+
+// SetDiskHealthPolicy records the disk health policy in the cluster config.
+func SetDiskHealthPolicy(ctx context.Context, s state.State, policy types.DiskHealthPolicy) error {
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.SetConfigItem(ctx, tx, diskHealthMarkOutConfig, strconv.FormatBool(policy.MarkOut))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record disk health policy: %w", err)
+	}
+
+	logger.Infof("Disk health mark out set to %t", policy.MarkOut)
+	return nil
+}
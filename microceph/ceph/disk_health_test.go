@@ -0,0 +1,141 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type DiskHealthSuite struct {
+	tests.BaseSuite
+}
+
+func TestDiskHealth(t *testing.T) {
+	suite.Run(t, new(DiskHealthSuite))
+}
+
+func (s *DiskHealthSuite) TestReadDiskHealthATA() {
+	output, _ := os.ReadFile("./test_assets/smartctl_ata_failing.json")
+
+	// smartctl flags the failing attributes in its exit status.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "smartctl", "--json", "--all", "/dev/sdb").Return(string(output), fmt.Errorf("exit status 8")).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	health, err := osdmgr.readDiskHealth(1, "/dev/sdb")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), types.DiskHealthFailing, health.Status)
+	assert.Equal(s.T(), int64(152), health.ReallocatedSectors)
+	assert.Equal(s.T(), int64(16), health.PendingSectors)
+	assert.Equal(s.T(), int64(34512), health.PowerOnHours)
+	assert.Equal(s.T(), []string{"152 reallocated sectors", "16 pending or uncorrectable sectors"}, health.Reasons)
+}
+
+func (s *DiskHealthSuite) TestReadDiskHealthNVMe() {
+	output, _ := os.ReadFile("./test_assets/smartctl_nvme.json")
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "smartctl", "--json", "--all", "/dev/nvme0n1").Return(string(output), nil).Once()
+	r.On("RunCommand", "smartctl", "--json", "--all", "/dev/vda").Return(`{"smartctl": {"exit_status": 2, "messages": [{"string": "/dev/vda: Unable to detect device type"}]}}`, fmt.Errorf("exit status 2")).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	health, err := osdmgr.readDiskHealth(2, "/dev/nvme0n1")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), types.DiskHealthWarning, health.Status)
+	assert.Equal(s.T(), int64(93), health.PercentageUsed)
+	assert.Equal(s.T(), int64(41), health.Temperature)
+	assert.Equal(s.T(), []string{"93% of rated endurance used"}, health.Reasons)
+
+	// Devices which can't be queried report an error.
+	_, err = osdmgr.readDiskHealth(3, "/dev/vda")
+	assert.ErrorContains(s.T(), err, "Unable to detect device type")
+}
+
+func (s *DiskHealthSuite) TestEvaluateDiskHealthUnknown() {
+	health := evaluateDiskHealth(1, smartData{})
+	assert.Equal(s.T(), types.DiskHealthUnknown, health.Status)
+	assert.Empty(s.T(), health.Reasons)
+}
+
+func (s *DiskHealthSuite) TestCheckDiskHealth() {
+	output, _ := os.ReadFile("./test_assets/smartctl_ata_failing.json")
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 1, Location: "node0", Path: "/dev/sdb"},
+		{OSD: 2, Location: "node1", Path: "/dev/sdc"},
+		{OSD: 3, Location: "node0", Path: "/var/snap/microceph/common/data/osd/ceph-3/osd-backing.img"},
+		{OSD: 4, Location: "node0", Path: "/dev/mapper/vg0-lv0"},
+	}, nil).Once()
+	q.On("Health", mock.Anything, mock.Anything, int64(1)).Return(types.DiskHealth{OSD: 1, Status: types.DiskHealthWarning}, nil).Once()
+	q.On("SetHealth", mock.Anything, mock.Anything, mock.MatchedBy(func(health types.DiskHealth) bool {
+		return health.OSD == 1 && health.Status == types.DiskHealthFailing && health.MarkedOut
+	})).Return(nil).Once()
+	database.OSDQuery = q
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "smartctl", "--json", "--all", "/dev/sdb").Return(string(output), nil).Once()
+	r.On("RunCommand", "ceph", "log", mock.Anything).Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "out", "osd.1").Return("", nil).Once()
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", "/dev/sdb").Return(true)
+	mockValidator.On("IsBlockdevPath", "/dev/mapper/vg0-lv0").Return(true)
+	mockValidator.On("IsBlockdevPath", mock.Anything).Return(false)
+
+	// The logical volume is skipped.
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/sdb").Return(0, 0, uint32(8), uint32(16), uint64(0), 0, nil)
+	mockFileStater.On("GetFileStat", "/dev/mapper/vg0-lv0").Return(0, 0, uint32(253), uint32(0), uint64(0), 0, nil)
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+	osdmgr.fs = afero.NewMemMapFs()
+	assert.NoError(s.T(), osdmgr.fs.MkdirAll("/sys/dev/block/253:0/dm", 0755))
+
+	err := osdmgr.checkDiskHealth(context.Background(), types.DiskHealthPolicy{MarkOut: true})
+	assert.NoError(s.T(), err)
+}
+
+func (s *DiskHealthSuite) TestCheckDiskHealthMarkedOut() {
+	output, _ := os.ReadFile("./test_assets/smartctl_ata_failing.json")
+
+	// An OSD already marked out is neither marked out nor reported again.
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{{OSD: 1, Location: "node0", Path: "/dev/sdb"}}, nil).Once()
+	q.On("Health", mock.Anything, mock.Anything, int64(1)).Return(types.DiskHealth{OSD: 1, Status: types.DiskHealthFailing, MarkedOut: true}, nil).Once()
+	q.On("SetHealth", mock.Anything, mock.Anything, mock.MatchedBy(func(health types.DiskHealth) bool {
+		return health.Status == types.DiskHealthFailing && health.MarkedOut
+	})).Return(nil).Once()
+	database.OSDQuery = q
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "smartctl", "--json", "--all", "/dev/sdb").Return(string(output), nil).Once()
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", mock.Anything).Return(true)
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+	osdmgr.validator = mockValidator
+
+	err := osdmgr.checkDiskHealth(context.Background(), types.DiskHealthPolicy{MarkOut: true})
+	assert.NoError(s.T(), err)
+}
@@ -0,0 +1,285 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/spf13/afero"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// DoctorCheck is a preflight check of the host a member runs on.
+type DoctorCheck interface {
+	// Name returns the name of the check.
+	Name() string
+	// Run runs the check, a check may report several results.
+	Run(ctx context.Context, s state.State) []types.DoctorResult
+}
+
+// DoctorChecks lists the checks run by RunDoctor, in order.
+// Patch-able for testing purposes.
+var DoctorChecks = []DoctorCheck{
+	interfacesCheck{},
+	encryptionCheck{},
+	freeSpaceCheck{},
+	timeSyncCheck{},
+	networksCheck{},
+	kernelModulesCheck{fs: afero.NewOsFs()},
+}
+
+// RunDoctor runs the preflight checks on the local member.
+func RunDoctor(ctx context.Context, s state.State) types.DoctorReport {
+	report := types.DoctorReport{Member: s.Name(), Results: []types.DoctorResult{}}
+	for _, check := range DoctorChecks {
+		report.Results = append(report.Results, check.Run(ctx, s)...)
+	}
+
+	return report
+}
+
+// requiredPlugs lists the snap interfaces MicroCeph can't work without which are not connected automatically.
+var requiredPlugs = []string{"block-devices", "hardware-observe", "mount-observe", "microceph-support", "process-control"}
+
+// interfacesCheck checks the snap interfaces MicroCeph needs are connected.
+type interfacesCheck struct{}
+
+func (c interfacesCheck) Name() string {
+	return "interfaces"
+}
+
+func (c interfacesCheck) Run(ctx context.Context, s state.State) []types.DoctorResult {
+	if !constants.IsSnapLayout() {
+		return []types.DoctorResult{{Check: c.Name(), Status: types.DoctorPass, Message: "not running from the snap, no interfaces to connect"}}
+	}
+
+	results := []types.DoctorResult{}
+	for _, plug := range requiredPlugs {
+		check := fmt.Sprintf("interface %s", plug)
+		if isIntfConnected(plug) {
+			results = append(results, types.DoctorResult{Check: check, Status: types.DoctorPass, Message: "connected"})
+			continue
+		}
+
+		results = append(results, types.DoctorResult{
+			Check:   check,
+			Status:  types.DoctorFail,
+			Message: "not connected",
+			Hint:    fmt.Sprintf("sudo snap connect microceph:%s && sudo snap restart microceph.daemon", plug),
+		})
+	}
+
+	return results
+}
+
+// encryptionCheck checks the host supports encrypting disks.
+type encryptionCheck struct{}
+
+func (c encryptionCheck) Name() string {
+	return "encryption"
+}
+
+func (c encryptionCheck) Run(ctx context.Context, s state.State) []types.DoctorResult {
+	err := NewOSDManager(s).checkEncryptSupport()
+	if err != nil {
+		return []types.DoctorResult{{
+			Check:   c.Name(),
+			Status:  types.DoctorWarn,
+			Message: fmt.Sprintf("disks can't be encrypted: %v", err),
+			Hint:    "only needed for 'microceph disk add --encrypt': connect the dm-crypt interface and load the dm_crypt module",
+		}}
+	}
+
+	return []types.DoctorResult{{Check: c.Name(), Status: types.DoctorPass, Message: "disks can be encrypted"}}
+}
+
+// Free space ratios below which the monitors warn about or refuse to run on their data directory,
+// after the Ceph defaults of mon_data_avail_warn and mon_data_avail_crit.
+const (
+	freeSpaceWarnPercent = 30
+	freeSpaceFailPercent = 5
+)
+
+// freeSpaceCheck checks the file system holding the Ceph data has room left.
+type freeSpaceCheck struct{}
+
+func (c freeSpaceCheck) Name() string {
+	return "free-space"
+}
+
+func (c freeSpaceCheck) Run(ctx context.Context, s state.State) []types.DoctorResult {
+	// The data directory is only created on bootstrap or join, look at the file system it will be on.
+	path := nearestExistingPath(constants.GetPathConst().DataPath)
+
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return []types.DoctorResult{{Check: c.Name(), Status: types.DoctorFail, Message: fmt.Sprintf("failed to stat %s: %v", path, err)}}
+	}
+
+	return []types.DoctorResult{freeSpaceResult(path, stat.Bavail*uint64(stat.Bsize), stat.Blocks*uint64(stat.Bsize))}
+}
+
+// nearestExistingPath returns path, or its closest parent directory which exists.
+func nearestExistingPath(path string) string {
+	for {
+		_, err := os.Stat(path)
+		if err == nil || !os.IsNotExist(err) {
+			return path
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+
+		path = parent
+	}
+}
+
+// freeSpaceResult rates the available space of a file system.
+func freeSpaceResult(path string, avail uint64, total uint64) types.DoctorResult {
+	percent := uint64(100)
+	if total != 0 {
+		percent = avail * 100 / total
+	}
+
+	result := types.DoctorResult{
+		Check:   "free-space",
+		Status:  types.DoctorPass,
+		Message: fmt.Sprintf("%d MiB (%d%%) free under %s", avail/1024/1024, percent, path),
+	}
+
+	if percent < freeSpaceFailPercent {
+		result.Status = types.DoctorFail
+		result.Hint = "free up space, monitors shut down below 5% free space"
+	} else if percent < freeSpaceWarnPercent {
+		result.Status = types.DoctorWarn
+		result.Hint = "free up space, monitors report a health warning below 30% free space"
+	}
+
+	return result
+}
+
+// clockSynchronised reports whether the kernel clock is synchronised by NTP.
+// Patch-able for testing purposes.
+var clockSynchronised = func() (bool, error) {
+	var timex syscall.Timex
+	state, err := syscall.Adjtimex(&timex)
+	if err != nil {
+		return false, err
+	}
+
+	// TIME_ERROR, or the STA_UNSYNC status flag, is reported while no time source disciplines the clock.
+	return state != 5 && timex.Status&0x0040 == 0, nil
+}
+
+// timeSyncCheck checks the clock is synchronised, monitors lose quorum over clock skew.
+type timeSyncCheck struct{}
+
+func (c timeSyncCheck) Name() string {
+	return "time-sync"
+}
+
+func (c timeSyncCheck) Run(ctx context.Context, s state.State) []types.DoctorResult {
+	synced, err := clockSynchronised()
+	if err != nil {
+		return []types.DoctorResult{{Check: c.Name(), Status: types.DoctorWarn, Message: fmt.Sprintf("failed to read clock status: %v", err)}}
+	}
+
+	if !synced {
+		return []types.DoctorResult{{
+			Check:   c.Name(),
+			Status:  types.DoctorWarn,
+			Message: "clock is not synchronised, monitors report clock skew and may lose quorum",
+			Hint:    "enable NTP, e.g. 'sudo timedatectl set-ntp true'",
+		}}
+	}
+
+	return []types.DoctorResult{{Check: c.Name(), Status: types.DoctorPass, Message: "clock is synchronised"}}
+}
+
+// networksCheck checks the member has an address on the public and cluster networks.
+type networksCheck struct{}
+
+func (c networksCheck) Name() string {
+	return "networks"
+}
+
+func (c networksCheck) Run(ctx context.Context, s state.State) []types.DoctorResult {
+	if s.Database().IsOpen(ctx) != nil {
+		return []types.DoctorResult{{Check: c.Name(), Status: types.DoctorPass, Message: "skipped, the member is not part of a cluster yet"}}
+	}
+
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return []types.DoctorResult{{Check: c.Name(), Status: types.DoctorFail, Message: fmt.Sprintf("failed to read cluster config: %v", err)}}
+	}
+
+	return checkNetworks(config)
+}
+
+// checkNetworks checks the host has an address on each configured network.
+func checkNetworks(config map[string]string) []types.DoctorResult {
+	results := []types.DoctorResult{}
+	for _, key := range []string{"public_network", "cluster_network"} {
+		subnet := config[key]
+		if len(subnet) == 0 {
+			continue
+		}
+
+		check := fmt.Sprintf("%s %s", key, subnet)
+		ip, err := common.Network.FindIpOnSubnet(subnet)
+		if err != nil {
+			results = append(results, types.DoctorResult{
+				Check:   check,
+				Status:  types.DoctorFail,
+				Message: fmt.Sprintf("no address on the network: %v", err),
+				Hint:    fmt.Sprintf("configure an address within %s on this host", subnet),
+			})
+			continue
+		}
+
+		results = append(results, types.DoctorResult{Check: check, Status: types.DoctorPass, Message: fmt.Sprintf("reachable via %s", ip)})
+	}
+
+	return results
+}
+
+// kernelModulesCheck checks the kernel modules needed by Ceph clients on the host are loaded.
+type kernelModulesCheck struct {
+	fs afero.Fs
+}
+
+func (c kernelModulesCheck) Name() string {
+	return "kernel-modules"
+}
+
+func (c kernelModulesCheck) Run(ctx context.Context, s state.State) []types.DoctorResult {
+	check := "kernel module rbd"
+	_, err := c.fs.Stat("/sys/module/rbd")
+	if os.IsNotExist(err) {
+		hint := "sudo modprobe rbd"
+		if constants.IsSnapLayout() {
+			hint = "sudo snap connect microceph:load-rbd, or sudo modprobe rbd"
+		}
+
+		return []types.DoctorResult{{
+			Check:   check,
+			Status:  types.DoctorWarn,
+			Message: "not loaded, rbd images can't be mapped on this host",
+			Hint:    hint,
+		}}
+	} else if err != nil {
+		return []types.DoctorResult{{Check: check, Status: types.DoctorWarn, Message: fmt.Sprintf("failed to check: %v", err)}}
+	}
+
+	return []types.DoctorResult{{Check: check, Status: types.DoctorPass, Message: "loaded"}}
+}
@@ -0,0 +1,132 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type doctorSuite struct {
+	tests.BaseSuite
+}
+
+func TestDoctor(t *testing.T) {
+	suite.Run(t, new(doctorSuite))
+}
+
+// fakeCheck is a doctor check reporting a fixed result.
+type fakeCheck struct {
+	result types.DoctorResult
+}
+
+func (c fakeCheck) Name() string {
+	return c.result.Check
+}
+
+func (c fakeCheck) Run(ctx context.Context, s state.State) []types.DoctorResult {
+	return []types.DoctorResult{c.result}
+}
+
+func (s *doctorSuite) TestRunDoctor() {
+	orig := DoctorChecks
+	defer func() { DoctorChecks = orig }()
+
+	DoctorChecks = []DoctorCheck{
+		fakeCheck{result: types.DoctorResult{Check: "one", Status: types.DoctorPass}},
+		fakeCheck{result: types.DoctorResult{Check: "two", Status: types.DoctorFail, Hint: "fix it"}},
+	}
+
+	report := RunDoctor(context.Background(), &mocks.MockState{ClusterName: "node0"})
+	assert.Equal(s.T(), "node0", report.Member)
+	assert.Equal(s.T(), DoctorChecks[0].(fakeCheck).result, report.Results[0])
+	assert.Equal(s.T(), DoctorChecks[1].(fakeCheck).result, report.Results[1])
+}
+
+func (s *doctorSuite) TestInterfacesCheck() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "snapctl", "is-connected", "block-devices").Return("", errors.New("exit status 1")).Once()
+	r.On("RunCommand", "snapctl", "is-connected", mock.Anything).Return("", nil)
+	common.ProcessExec = r
+
+	results := interfacesCheck{}.Run(context.Background(), &mocks.MockState{ClusterName: "node0"})
+	assert.Len(s.T(), results, len(requiredPlugs))
+	assert.Equal(s.T(), "interface block-devices", results[0].Check)
+	assert.Equal(s.T(), types.DoctorFail, results[0].Status)
+	assert.Contains(s.T(), results[0].Hint, "snap connect microceph:block-devices")
+	for _, result := range results[1:] {
+		assert.Equal(s.T(), types.DoctorPass, result.Status)
+	}
+}
+
+func (s *doctorSuite) TestFreeSpaceResult() {
+	gib := uint64(1024 * 1024 * 1024)
+
+	result := freeSpaceResult("/data", 50*gib, 100*gib)
+	assert.Equal(s.T(), types.DoctorPass, result.Status)
+	assert.Equal(s.T(), "51200 MiB (50%) free under /data", result.Message)
+
+	assert.Equal(s.T(), types.DoctorWarn, freeSpaceResult("/data", 20*gib, 100*gib).Status)
+	assert.Equal(s.T(), types.DoctorFail, freeSpaceResult("/data", 4*gib, 100*gib).Status)
+}
+
+func (s *doctorSuite) TestNearestExistingPath() {
+	// Before bootstrap the data directory doesn't exist yet.
+	assert.Equal(s.T(), s.Tmp, nearestExistingPath(filepath.Join(s.Tmp, "SNAP_COMMON", "data")))
+	assert.Equal(s.T(), filepath.Join(s.Tmp, "proc"), nearestExistingPath(filepath.Join(s.Tmp, "proc")))
+}
+
+func (s *doctorSuite) TestTimeSyncCheck() {
+	orig := clockSynchronised
+	defer func() { clockSynchronised = orig }()
+
+	clockSynchronised = func() (bool, error) { return false, nil }
+	results := timeSyncCheck{}.Run(context.Background(), &mocks.MockState{})
+	assert.Equal(s.T(), types.DoctorWarn, results[0].Status)
+	assert.Contains(s.T(), results[0].Hint, "timedatectl")
+
+	clockSynchronised = func() (bool, error) { return true, nil }
+	results = timeSyncCheck{}.Run(context.Background(), &mocks.MockState{})
+	assert.Equal(s.T(), types.DoctorPass, results[0].Status)
+}
+
+func (s *doctorSuite) TestCheckNetworks() {
+	nw := mocks.NewNetworkIntf(s.T())
+	nw.On("FindIpOnSubnet", "10.0.0.0/24").Return("10.0.0.5", nil).Once()
+	nw.On("FindIpOnSubnet", "10.1.0.0/24").Return("", errors.New("no IP belongs to provided subnet 10.1.0.0/24")).Once()
+	common.Network = nw
+
+	results := checkNetworks(map[string]string{"public_network": "10.0.0.0/24", "cluster_network": "10.1.0.0/24"})
+	assert.Len(s.T(), results, 2)
+	assert.Equal(s.T(), types.DoctorPass, results[0].Status)
+	assert.Equal(s.T(), "reachable via 10.0.0.5", results[0].Message)
+	assert.Equal(s.T(), "cluster_network 10.1.0.0/24", results[1].Check)
+	assert.Equal(s.T(), types.DoctorFail, results[1].Status)
+
+	// An unset cluster network isn't checked.
+	nw.On("FindIpOnSubnet", "10.0.0.0/24").Return("10.0.0.5", nil).Once()
+	assert.Len(s.T(), checkNetworks(map[string]string{"public_network": "10.0.0.0/24"}), 1)
+}
+
+func (s *doctorSuite) TestKernelModulesCheck() {
+	fs := afero.NewMemMapFs()
+	check := kernelModulesCheck{fs: fs}
+
+	results := check.Run(context.Background(), &mocks.MockState{})
+	assert.Equal(s.T(), types.DoctorWarn, results[0].Status)
+
+	assert.NoError(s.T(), fs.MkdirAll("/sys/module/rbd", 0755))
+	results = check.Run(context.Background(), &mocks.MockState{})
+	assert.Equal(s.T(), types.DoctorPass, results[0].Status)
+}
@@ -37,7 +37,7 @@ func msgrv2OnlyFile(path string) (bool, error) {
 }
 
 func msgrv2OnlyCluster() (bool, error) {
-	confPath := filepath.Join(os.Getenv("SNAP_DATA"), "conf", constants.CephConfFileName)
+	confPath := filepath.Join(constants.GetPathConst().ConfPath, constants.CephConfFileName)
 	return msgrv2OnlyFile(confPath)
 }
 
@@ -92,7 +92,7 @@ func Join(ctx context.Context, s interfaces.StateInterface) error {
 	}
 
 	// Start OSD service.
-	err = snapStart("osd", true)
+	err = startService("osd", true)
 	if err != nil {
 		return fmt.Errorf("failed to start OSD service: %w", err)
 	}
@@ -161,15 +161,20 @@ func updateDbForMon(s interfaces.StateInterface, ctx context.Context, tx *sql.Tx
 		return fmt.Errorf("failed to locate ip on subnet %s: %w", configItem.Value, err)
 	}
 
-	if v2Only {
-		monHost = "v2:" + monHost + ":3300"
-	}
-
 	key := fmt.Sprintf("mon.host.%s", s.ClusterState().Name())
-	_, err = database.CreateConfigItem(ctx, tx, database.ConfigItem{Key: key, Value: monHost})
+	_, err = database.CreateConfigItem(ctx, tx, database.ConfigItem{Key: key, Value: monHostValue(monHost, v2Only)})
 	if err != nil {
 		return fmt.Errorf("failed to record mon host: %w", err)
 	}
 
 	return nil
 }
+
+// monHostValue returns the mon.host config entry of a monitor listening on the given address.
+func monHostValue(ip string, v2Only bool) string {
+	if v2Only {
+		return "v2:" + ip + ":3300"
+	}
+
+	return ip
+}
@@ -0,0 +1,265 @@
+package ceph
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/spf13/afero"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// Config table keys holding the key provider settings.
+const (
+	keyProviderConfig    = "osd_key_provider"
+	keyServerURLConfig   = "osd_key_server_url"
+	keyServerTokenConfig = "osd_key_server_token"
+)
+
+// KeyProvider stores and fetches the LUKS keys of encrypted OSD devices.
+type KeyProvider interface {
+	StoreKey(name string, key []byte) error
+	GetKey(name string) ([]byte, error)
+	DeleteKey(name string) error
+}
+
+// osdKeyName returns the provider independent name of an OSD device key.
+func osdKeyName(osdID int64, suffix string) string {
+	return fmt.Sprintf("osd%s.%d", suffix, osdID)
+}
+
+// ConfigKeyProvider keeps keys in the Ceph mon config-key store.
+type ConfigKeyProvider struct {
+	runner common.Runner
+}
+
+func (p ConfigKeyProvider) keyName(name string) string {
+	return fmt.Sprintf("microceph:%s/key", name)
+}
+
+// StoreKey stores the key under the given name.
+func (p ConfigKeyProvider) StoreKey(name string, key []byte) error {
+	_, err := p.runner.RunCommand("ceph", "config-key", "set", p.keyName(name), string(key))
+	if err != nil {
+		return fmt.Errorf("failed to store key: %w", err)
+	}
+	return nil
+}
+
+// GetKey fetches the key stored under the given name.
+func (p ConfigKeyProvider) GetKey(name string) ([]byte, error) {
+	key, err := p.runner.RunCommand("ceph", "config-key", "get", p.keyName(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key: %w", err)
+	}
+	return []byte(strings.TrimSpace(key)), nil
+}
+
+// DeleteKey removes the key stored under the given name.
+func (p ConfigKeyProvider) DeleteKey(name string) error {
+	_, err := p.runner.RunCommand("ceph", "config-key", "rm", p.keyName(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+	return nil
+}
+
+// FileKeyProvider keeps keys in files on the local node.
+type FileKeyProvider struct {
+	fs  afero.Fs
+	dir string
+}
+
+func (p FileKeyProvider) keyPath(name string) string {
+	return filepath.Join(p.dir, fmt.Sprintf("%s.key", name))
+}
+
+// StoreKey stores the key under the given name.
+func (p FileKeyProvider) StoreKey(name string, key []byte) error {
+	err := p.fs.MkdirAll(p.dir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	err = afero.WriteFile(p.fs, p.keyPath(name), key, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to store key: %w", err)
+	}
+	return nil
+}
+
+// GetKey fetches the key stored under the given name.
+func (p FileKeyProvider) GetKey(name string) ([]byte, error) {
+	key, err := afero.ReadFile(p.fs, p.keyPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key: %w", err)
+	}
+	return key, nil
+}
+
+// DeleteKey removes the key stored under the given name.
+func (p FileKeyProvider) DeleteKey(name string) error {
+	err := p.fs.Remove(p.keyPath(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+	return nil
+}
+
+// HTTPKeyProvider keeps keys on a remote key server, e.g. a KMIP gateway, at <url>/keys/<name>.
+type HTTPKeyProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (p HTTPKeyProvider) do(method string, name string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/keys/%s", p.url, url.PathEscape(name)), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.token) != 0 {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.token))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("key server returned %s", resp.Status)
+	}
+
+	return data, nil
+}
+
+// StoreKey stores the key under the given name.
+func (p HTTPKeyProvider) StoreKey(name string, key []byte) error {
+	_, err := p.do(http.MethodPut, name, key)
+	if err != nil {
+		return fmt.Errorf("failed to store key: %w", err)
+	}
+	return nil
+}
+
+// GetKey fetches the key stored under the given name.
+func (p HTTPKeyProvider) GetKey(name string) ([]byte, error) {
+	key, err := p.do(http.MethodGet, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key: %w", err)
+	}
+	return bytes.TrimSpace(key), nil
+}
+
+// DeleteKey removes the key stored under the given name.
+func (p HTTPKeyProvider) DeleteKey(name string) error {
+	_, err := p.do(http.MethodDelete, name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+	return nil
+}
+
+// NewKeyProvider returns the key provider for the given settings.
+func NewKeyProvider(conf types.DiskEncryptionProvider) (KeyProvider, error) {
+	switch conf.Provider {
+	case "", types.KeyProviderConfigKey:
+		return ConfigKeyProvider{runner: common.ProcessExec}, nil
+	case types.KeyProviderFile:
+		return FileKeyProvider{fs: afero.NewOsFs(), dir: filepath.Join(constants.GetPathConst().DataPath, "keys")}, nil
+	case types.KeyProviderHTTP:
+		u, err := url.Parse(conf.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return nil, fmt.Errorf("invalid key server url %q", conf.URL)
+		}
+		return HTTPKeyProvider{
+			url:    strings.TrimSuffix(conf.URL, "/"),
+			token:  conf.Token,
+			client: &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown key provider %q, expected one of %s, %s or %s", conf.Provider, types.KeyProviderConfigKey, types.KeyProviderFile, types.KeyProviderHTTP)
+	}
+}
+
+// GetKeyProviderConfig fetches the key provider settings from the cluster config.
+func GetKeyProviderConfig(ctx context.Context, s state.State) (types.DiskEncryptionProvider, error) {
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return types.DiskEncryptionProvider{}, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	conf := types.DiskEncryptionProvider{
+		Provider: config[keyProviderConfig],
+		URL:      config[keyServerURLConfig],
+		Token:    config[keyServerTokenConfig],
+	}
+
+	if len(conf.Provider) == 0 {
+		conf.Provider = types.KeyProviderConfigKey
+	}
+
+	return conf, nil
+}
+
+// SetKeyProviderConfig validates and records the key provider settings in the cluster config.
+func SetKeyProviderConfig(ctx context.Context, s state.State, conf types.DiskEncryptionProvider) error {
+	_, err := NewKeyProvider(conf)
+	if err != nil {
+		return err
+	}
+
+	items := map[string]string{
+		keyProviderConfig:    conf.Provider,
+		keyServerURLConfig:   conf.URL,
+		keyServerTokenConfig: conf.Token,
+	}
+
+	err = s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for key, value := range items {
+			err := database.SetConfigItem(ctx, tx, key, value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record key provider: %w", err)
+	}
+
+	logger.Infof("OSD key provider set to %s", conf.Provider)
+	return nil
+}
+
+// GetKeyProvider returns the key provider configured for the cluster.
+func GetKeyProvider(ctx context.Context, s state.State) (KeyProvider, error) {
+	conf, err := GetKeyProviderConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeyProvider(conf)
+}
@@ -0,0 +1,200 @@
+package ceph
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type KeyProviderSuite struct {
+	tests.BaseSuite
+}
+
+func TestKeyProvider(t *testing.T) {
+	suite.Run(t, new(KeyProviderSuite))
+}
+
+func (s *KeyProviderSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+// keyServer is a local stand-in for a key server.
+type keyServer struct {
+	sync.Mutex
+	token string
+	keys  map[string]string
+}
+
+func (k *keyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	k.Lock()
+	defer k.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+k.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/keys/")
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		k.keys[name] = string(data)
+	case http.MethodGet:
+		key, ok := k.keys[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(key))
+	case http.MethodDelete:
+		delete(k.keys, name)
+	}
+}
+
+func (s *KeyProviderSuite) TestConfigKeyProvider() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config-key", "set", "microceph:osd.wal.1/key", "secret").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.wal.1/key").Return("secret\n", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "rm", "microceph:osd.wal.1/key").Return("", nil).Once()
+
+	provider := ConfigKeyProvider{runner: r}
+	name := osdKeyName(1, ".wal")
+
+	assert.NoError(s.T(), provider.StoreKey(name, []byte("secret")))
+	key, err := provider.GetKey(name)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("secret"), key)
+	assert.NoError(s.T(), provider.DeleteKey(name))
+}
+
+func (s *KeyProviderSuite) TestFileKeyProvider() {
+	fs := afero.NewMemMapFs()
+	provider := FileKeyProvider{fs: fs, dir: "/keys"}
+
+	assert.NoError(s.T(), provider.StoreKey("osd.1", []byte("secret")))
+
+	info, err := fs.Stat("/keys/osd.1.key")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "-rw-------", info.Mode().String())
+
+	key, err := provider.GetKey("osd.1")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("secret"), key)
+
+	assert.NoError(s.T(), provider.DeleteKey("osd.1"))
+	_, err = provider.GetKey("osd.1")
+	assert.ErrorContains(s.T(), err, "failed to fetch key")
+}
+
+func (s *KeyProviderSuite) TestHTTPKeyProvider() {
+	server := httptest.NewServer(&keyServer{token: "token", keys: map[string]string{}})
+	defer server.Close()
+
+	kp, err := NewKeyProvider(types.DiskEncryptionProvider{Provider: types.KeyProviderHTTP, URL: server.URL + "/", Token: "token"})
+	assert.NoError(s.T(), err)
+
+	assert.NoError(s.T(), kp.StoreKey("osd.1", []byte("secret")))
+	key, err := kp.GetKey("osd.1")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("secret"), key)
+
+	assert.NoError(s.T(), kp.DeleteKey("osd.1"))
+	_, err = kp.GetKey("osd.1")
+	assert.ErrorContains(s.T(), err, "404")
+
+	// Wrong credentials.
+	kp, err = NewKeyProvider(types.DiskEncryptionProvider{Provider: types.KeyProviderHTTP, URL: server.URL, Token: "wrong"})
+	assert.NoError(s.T(), err)
+	assert.ErrorContains(s.T(), kp.StoreKey("osd.1", []byte("secret")), "401")
+}
+
+func (s *KeyProviderSuite) TestNewKeyProvider() {
+	kp, err := NewKeyProvider(types.DiskEncryptionProvider{})
+	assert.NoError(s.T(), err)
+	assert.IsType(s.T(), ConfigKeyProvider{}, kp)
+
+	kp, err = NewKeyProvider(types.DiskEncryptionProvider{Provider: types.KeyProviderFile})
+	assert.NoError(s.T(), err)
+	assert.IsType(s.T(), FileKeyProvider{}, kp)
+
+	_, err = NewKeyProvider(types.DiskEncryptionProvider{Provider: types.KeyProviderHTTP, URL: "keys.example.com"})
+	assert.ErrorContains(s.T(), err, "invalid key server url")
+
+	_, err = NewKeyProvider(types.DiskEncryptionProvider{Provider: "vault"})
+	assert.ErrorContains(s.T(), err, "unknown key provider")
+}
+
+func (s *KeyProviderSuite) TestRotateOSDKeys() {
+	fs := afero.NewOsFs()
+	osdDataPath := getOSDDataPath(1)
+	assert.NoError(s.T(), fs.MkdirAll(osdDataPath, 0700))
+	device := filepath.Join(osdDataPath, "unencrypted")
+	assert.NoError(s.T(), afero.WriteFile(fs, device, []byte(""), 0600))
+
+	// The key still lives in the config-key store from before the provider change.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.1/key").Return("oldkey", nil).Once()
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "--key-file", mock.Anything, "luksAddKey", device, mock.Anything).Return("", nil).Once()
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "luksRemoveKey", device, mock.Anything).Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "rm", "microceph:osd.1/key").Return("", nil).Once()
+	common.ProcessExec = r
+
+	provider := FileKeyProvider{fs: afero.NewMemMapFs(), dir: "/keys"}
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+	osdmgr.fs = fs
+	osdmgr.keyProvider = provider
+
+	err := osdmgr.rotateOSDKeys(1)
+	assert.NoError(s.T(), err)
+
+	key, err := provider.GetKey("osd.1")
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), key, 128)
+	assert.NotEqual(s.T(), []byte("oldkey"), key)
+
+	// No encrypted devices.
+	err = osdmgr.rotateOSDKeys(2)
+	assert.ErrorContains(s.T(), err, "no encrypted devices")
+}
+
+func (s *KeyProviderSuite) TestRotateOSDKeysStoreFailure() {
+	fs := afero.NewOsFs()
+	osdDataPath := getOSDDataPath(1)
+	assert.NoError(s.T(), fs.MkdirAll(osdDataPath, 0700))
+	device := filepath.Join(osdDataPath, "unencrypted")
+	assert.NoError(s.T(), afero.WriteFile(fs, device, []byte(""), 0600))
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.1/key").Return("oldkey", nil).Once()
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "--key-file", mock.Anything, "luksAddKey", device, mock.Anything).Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "set", "microceph:osd.1/key", mock.Anything).Return("", fmt.Errorf("mon down")).Once()
+	// The new keyslot is dropped again, the old one stays.
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "luksRemoveKey", device, mock.MatchedBy(func(path string) bool {
+		return filepath.Base(path) == "new"
+	})).Return("", nil).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+	osdmgr.fs = fs
+	osdmgr.keyProvider = ConfigKeyProvider{runner: r}
+
+	err := osdmgr.rotateOSDKeys(1)
+	assert.ErrorContains(s.T(), err, "key store error")
+}
@@ -0,0 +1,167 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// msModeSecure is the msgr2 connection mode encrypting the traffic on the wire.
+const msModeSecure = "secure"
+
+// msModeOptions lists the options selecting the msgr2 connection modes of daemons and clients.
+var msModeOptions = []string{
+	"ms_cluster_mode",
+	"ms_service_mode",
+	"ms_client_mode",
+	"ms_mon_cluster_mode",
+	"ms_mon_service_mode",
+	"ms_mon_client_mode",
+}
+
+// messengerChange converts a running cluster to msgr2 only and/or on-wire encryption.
+type messengerChange struct {
+	*rollout
+	toV2Only bool
+	toSecure bool
+	// monAddresses maps each monitor to its address.
+	monAddresses map[string]string
+}
+
+// SetMessenger converts the cluster to msgr2 only and/or requires on-wire encryption of msgr2 connections,
+// restarting the daemons in a rolling fashion. It returns the steps run, or only plans them on a dry run.
+func SetMessenger(ctx context.Context, s state.State, v2Only bool, secure bool, dryRun bool) ([]string, error) {
+	m, err := prepareMessengerChange(ctx, s, v2Only, secure)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.run(ctx, "Messenger change", m.steps(), dryRun)
+}
+
+// prepareMessengerChange checks what the requested messenger settings change.
+func prepareMessengerChange(ctx context.Context, s state.State, v2Only bool, secure bool) (*messengerChange, error) {
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster config: %w", err)
+	}
+
+	isV2Only, err := msgrv2OnlyCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the monitor addresses: %w", err)
+	}
+
+	// Connections over msgr1 can't be encrypted.
+	if secure && !v2Only && !isV2Only {
+		return nil, fmt.Errorf("on-wire encryption needs a msgr2 only cluster, add --v2-only")
+	}
+
+	m := &messengerChange{
+		toV2Only:     v2Only && !isV2Only,
+		toSecure:     secure && config["ms_mode"] != msModeSecure,
+		monAddresses: map[string]string{},
+	}
+
+	if !m.toV2Only && !m.toSecure {
+		return nil, fmt.Errorf("the cluster already uses the requested messenger settings")
+	}
+
+	m.rollout, err = newRollout(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mon := range m.mons() {
+		value, ok := config[fmt.Sprintf("mon.host.%s", mon)]
+		if !ok {
+			return nil, fmt.Errorf("no address recorded for monitor %s", mon)
+		}
+
+		m.monAddresses[mon] = monHostAddress(value)
+	}
+
+	return m, nil
+}
+
+// steps returns the steps of the change, in order.
+func (m *messengerChange) steps() []rolloutStep {
+	steps := []rolloutStep{}
+	if m.toSecure {
+		steps = append(steps, rolloutStep{
+			description: fmt.Sprintf("Set %s to %s", strings.Join(msModeOptions, ", "), msModeSecure),
+			run:         m.setSecure,
+		})
+
+		if !m.toV2Only {
+			steps = append(steps, m.updateConfigsStep())
+		}
+	}
+
+	// Restart the monitors one at a time, dropping their msgr1 address first if requested.
+	for _, mon := range m.mons() {
+		if m.toV2Only {
+			address := m.monAddresses[mon]
+			steps = append(steps,
+				rolloutStep{
+					description: fmt.Sprintf("Remove the msgr1 address of monitor %s", mon),
+					run:         func(ctx context.Context) error { return m.dropMsgr1(ctx, mon, address) },
+				},
+				m.updateConfigsStep(),
+			)
+		}
+
+		steps = append(steps, m.restartMonStep(mon))
+	}
+
+	return append(steps, m.restartSteps()...)
+}
+
+// dropMsgr1 leaves only the msgr2 address of a monitor in the monmap and in the mon hosts of the cluster config.
+func (m *messengerChange) dropMsgr1(ctx context.Context, mon string, address string) error {
+	err := setMonAddrs(mon, address, true)
+	if err != nil {
+		return err
+	}
+
+	return m.s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.SetConfigItem(ctx, tx, fmt.Sprintf("mon.host.%s", mon), monHostValue(address, true))
+	})
+}
+
+// setSecure requires the secure mode for every msgr2 connection, in the cluster config and in Ceph.
+func (m *messengerChange) setSecure(ctx context.Context) error {
+	err := m.s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.SetConfigItem(ctx, tx, "ms_mode", msModeSecure)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record ms_mode: %w", err)
+	}
+
+	for _, option := range msModeOptions {
+		_, err := cephRun("config", "set", "global", option, msModeSecure)
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %w", option, err)
+		}
+	}
+
+	return nil
+}
+
+// monHostAddress returns the address of a monitor from its mon.host config entry.
+func monHostAddress(value string) string {
+	if strings.HasPrefix(value, "v2:") {
+		host, _, err := net.SplitHostPort(strings.TrimPrefix(value, "v2:"))
+		if err == nil {
+			return host
+		}
+	}
+
+	return strings.Trim(value, "[]")
+}
@@ -0,0 +1,76 @@
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type messengerSuite struct {
+	tests.BaseSuite
+}
+
+func TestMessenger(t *testing.T) {
+	suite.Run(t, new(messengerSuite))
+}
+
+func (s *messengerSuite) newChange(toV2Only bool, toSecure bool) *messengerChange {
+	return &messengerChange{
+		toV2Only:     toV2Only,
+		toSecure:     toSecure,
+		monAddresses: map[string]string{"node0": "10.0.0.1", "node1": "10.0.0.2"},
+		rollout: &rollout{s: &mocks.MockState{ClusterName: "node0"}, services: types.Services{
+			{Service: "mon", Location: "node0"},
+			{Service: "osd", Location: "node0"},
+			{Service: "mon", Location: "node1"},
+		}},
+	}
+}
+
+func (s *messengerSuite) stepDescriptions(m *messengerChange) []string {
+	descriptions := []string{}
+	for _, step := range m.steps() {
+		descriptions = append(descriptions, step.description)
+	}
+
+	return descriptions
+}
+
+func (s *messengerSuite) TestStepsV2OnlySecure() {
+	assert.Equal(s.T(), []string{
+		"Set ms_cluster_mode, ms_service_mode, ms_client_mode, ms_mon_cluster_mode, ms_mon_service_mode, ms_mon_client_mode to secure",
+		"Remove the msgr1 address of monitor node0",
+		"Regenerate ceph.conf on every member",
+		"Restart monitor node0 and wait for it to rejoin quorum",
+		"Remove the msgr1 address of monitor node1",
+		"Regenerate ceph.conf on every member",
+		"Restart monitor node1 and wait for it to rejoin quorum",
+		"Run `ceph osd set noout`",
+		"Restart osd on node0",
+		"Run `ceph osd unset noout`",
+	}, s.stepDescriptions(s.newChange(true, true)))
+}
+
+func (s *messengerSuite) TestStepsSecure() {
+	assert.Equal(s.T(), []string{
+		"Set ms_cluster_mode, ms_service_mode, ms_client_mode, ms_mon_cluster_mode, ms_mon_service_mode, ms_mon_client_mode to secure",
+		"Regenerate ceph.conf on every member",
+		"Restart monitor node0 and wait for it to rejoin quorum",
+		"Restart monitor node1 and wait for it to rejoin quorum",
+		"Run `ceph osd set noout`",
+		"Restart osd on node0",
+		"Run `ceph osd unset noout`",
+	}, s.stepDescriptions(s.newChange(false, true)))
+}
+
+func (s *messengerSuite) TestMonHostAddress() {
+	assert.Equal(s.T(), "10.0.0.1", monHostAddress("10.0.0.1"))
+	assert.Equal(s.T(), "10.0.0.1", monHostAddress("v2:10.0.0.1:3300"))
+	assert.Equal(s.T(), "fd00::1", monHostAddress("fd00::1"))
+	assert.Equal(s.T(), "fd00::1", monHostAddress("v2:[fd00::1]:3300"))
+}
@@ -0,0 +1,406 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/tidwall/gjson"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// Limits of a network probe.
+const (
+	networkProbeTimeout  = 2 * time.Second
+	networkLatencyWarnMs = 5.0
+	defaultMTU           = 1500
+)
+
+// dialTCP opens a TCP connection to an address.
+// Patch-able for testing purposes.
+var dialTCP = func(address string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", address, timeout)
+}
+
+// interfaceMTU returns the MTU of the interface holding the given address.
+// Patch-able for testing purposes.
+var interfaceMTU = func(address string) (int, error) {
+	ip := net.ParseIP(address)
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if ok && ipNet.IP.Equal(ip) {
+				return iface.MTU, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("no interface holds address %s", address)
+}
+
+// GetNetworkAddresses returns the addresses of the local member on the given subnets.
+func GetNetworkAddresses(s state.State, subnets []string) types.NetworkAddresses {
+	addresses := types.NetworkAddresses{Member: s.Name(), Addresses: map[string]string{}}
+	for _, subnet := range subnets {
+		ip, err := common.Network.FindIpOnSubnet(subnet)
+		if err != nil {
+			logger.Warnf("no address on subnet %s: %v", subnet, err)
+		}
+
+		addresses.Addresses[subnet] = ip
+	}
+
+	return addresses
+}
+
+// ProbeNetwork probes the given addresses from the local member.
+func ProbeNetwork(ctx context.Context, from string, targets []types.NetworkProbeTarget) []types.NetworkProbeResult {
+	results := []types.NetworkProbeResult{}
+	for _, target := range targets {
+		results = append(results, probeTarget(ctx, from, target))
+	}
+
+	return results
+}
+
+// probeTarget checks an address can be reached, measures the latency to it and the largest packet reaching it.
+func probeTarget(ctx context.Context, from string, target types.NetworkProbeTarget) types.NetworkProbeResult {
+	result := types.NetworkProbeResult{
+		From:    from,
+		To:      target.Member,
+		Network: target.Network,
+		Address: target.Address,
+		Ports:   []types.NetworkPortResult{},
+	}
+
+	closed := []string{}
+	for _, port := range target.Ports {
+		start := time.Now()
+		conn, err := dialTCP(net.JoinHostPort(target.Address, strconv.Itoa(port)), networkProbeTimeout)
+		if err != nil {
+			result.Ports = append(result.Ports, types.NetworkPortResult{Port: port, Error: err.Error()})
+			closed = append(closed, strconv.Itoa(port))
+			continue
+		}
+
+		elapsed := float64(time.Since(start).Microseconds()) / 1000
+		_ = conn.Close()
+
+		result.Reachable = true
+		if result.LatencyMs == 0 || elapsed < result.LatencyMs {
+			result.LatencyMs = elapsed
+		}
+		result.Ports = append(result.Ports, types.NetworkPortResult{Port: port, Open: true})
+	}
+
+	// ICMP measures the latency more precisely than TCP connects and works with no daemon listening yet.
+	latency, err := pingLatency(ctx, target.Address)
+	if err == nil {
+		result.Reachable = true
+		result.LatencyMs = latency
+	}
+
+	if !result.Reachable {
+		result.Status = types.DoctorFail
+		result.Message = "unreachable"
+		return result
+	}
+
+	// Jumbo frames only work if every hop carries them, compare the local MTU with the largest packet making it.
+	result.InterfaceMTU = defaultMTU
+	local, err := common.Network.FindIpOnSubnet(target.Subnet)
+	if err == nil {
+		mtu, err := interfaceMTU(local)
+		if err == nil {
+			result.InterfaceMTU = mtu
+		}
+	}
+	result.PathMTU = probePathMTU(ctx, target.Address, result.InterfaceMTU)
+
+	rateProbe(&result, closed)
+	return result
+}
+
+// rateProbe sets the status of a probe of a reachable address.
+func rateProbe(result *types.NetworkProbeResult, closed []string) {
+	notes := []string{fmt.Sprintf("%.2f ms", result.LatencyMs)}
+	if result.PathMTU != 0 {
+		mtu := fmt.Sprintf("MTU %d", result.PathMTU)
+		if result.PathMTU > defaultMTU {
+			mtu += " (jumbo frames)"
+		}
+		notes = append(notes, mtu)
+	}
+
+	result.Status = types.DoctorPass
+	result.Message = fmt.Sprintf("reachable, %s", strings.Join(notes, ", "))
+
+	switch {
+	case len(closed) != 0:
+		result.Status = types.DoctorFail
+		result.Message = fmt.Sprintf("port(s) %s not reachable, check the firewall", strings.Join(closed, ", "))
+	case result.PathMTU == 0:
+		result.Status = types.DoctorWarn
+		result.Message = fmt.Sprintf("%s, path MTU unknown, ICMP may be filtered", result.Message)
+	case result.PathMTU < result.InterfaceMTU:
+		result.Status = types.DoctorFail
+		result.Message = fmt.Sprintf("interface MTU is %d but only %d byte packets reach the peer, check the MTU of the peer and switches", result.InterfaceMTU, result.PathMTU)
+	case result.LatencyMs > networkLatencyWarnMs:
+		result.Status = types.DoctorWarn
+		result.Message = fmt.Sprintf("%s, latency above %.0f ms slows down Ceph", result.Message, networkLatencyWarnMs)
+	}
+}
+
+// pingRtt matches the average round trip time in the summary of ping.
+var pingRtt = regexp.MustCompile(`= [\d.]+/([\d.]+)/`)
+
+// pingLatency returns the average round trip time to an address in milliseconds.
+func pingLatency(ctx context.Context, address string) (float64, error) {
+	output, err := common.ProcessExec.RunCommandContext(ctx, "ping", "-c", "3", "-i", "0.2", "-q", "-W", "1", address)
+	if err != nil {
+		return 0, err
+	}
+
+	match := pingRtt.FindStringSubmatch(output)
+	if match == nil {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(match[1], 64)
+}
+
+// probePathMTU returns the largest of the interface MTU and the default MTU reaching an address
+// unfragmented, 0 if neither does.
+func probePathMTU(ctx context.Context, address string, mtu int) int {
+	// IP and ICMP headers take up part of each packet.
+	overhead := 28
+	if strings.Contains(address, ":") {
+		overhead = 48
+	}
+
+	sizes := []int{mtu}
+	if mtu > defaultMTU {
+		sizes = append(sizes, defaultMTU)
+	}
+
+	for _, size := range sizes {
+		_, err := common.ProcessExec.RunCommandContext(ctx, "ping", "-c", "1", "-W", "1", "-M", "do", "-s", strconv.Itoa(size-overhead), address)
+		if err == nil {
+			return size
+		}
+	}
+
+	return 0
+}
+
+// getDaemonPorts returns the ports the monitors and OSDs listen on, by address. Monitors out of quorum and
+// down OSDs, e.g. of a member in maintenance, keep their addresses in the maps but don't listen, so they're left out.
+func getDaemonPorts() map[string][]int {
+	ports := map[string][]int{}
+	add := func(addrvec gjson.Result) {
+		for _, addr := range addrvec.Get("addrvec.#.addr").Array() {
+			host, port, err := net.SplitHostPort(addr.String())
+			if err != nil {
+				continue
+			}
+
+			p, err := strconv.Atoi(port)
+			if err == nil && p != 0 {
+				ports[host] = append(ports[host], p)
+			}
+		}
+	}
+
+	output, err := cephRun("mon", "dump", "-f", "json")
+	if err != nil {
+		logger.Warnf("failed to list monitor addresses: %v", err)
+	} else {
+		quorum := map[int64]bool{}
+		for _, rank := range gjson.Get(output, "quorum").Array() {
+			quorum[rank.Int()] = true
+		}
+
+		for _, mon := range gjson.Get(output, "mons").Array() {
+			if !quorum[mon.Get("rank").Int()] {
+				continue
+			}
+			add(mon.Get("public_addrs"))
+		}
+	}
+
+	output, err = cephRun("osd", "dump", "-f", "json")
+	if err != nil {
+		logger.Warnf("failed to list OSD addresses: %v", err)
+	} else {
+		for _, osd := range gjson.Get(output, "osds").Array() {
+			if osd.Get("up").Int() == 0 {
+				continue
+			}
+			add(osd.Get("public_addrs"))
+			add(osd.Get("cluster_addrs"))
+		}
+	}
+
+	for host := range ports {
+		sort.Ints(ports[host])
+		ports[host] = slices.Compact(ports[host])
+	}
+
+	return ports
+}
+
+// planNetworkProbes returns the addresses each member probes: those of every other member on each network.
+// Members lacking an address on a network are reported as failures instead.
+func planNetworkProbes(members []types.NetworkAddresses, networks map[string]string, ports map[string][]int) (map[string][]types.NetworkProbeTarget, []types.NetworkProbeResult) {
+	names := []string{}
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plan := map[string][]types.NetworkProbeTarget{}
+	missing := []types.NetworkProbeResult{}
+	for _, network := range names {
+		subnet := networks[network]
+		for _, member := range members {
+			address := member.Addresses[subnet]
+			if len(address) == 0 {
+				missing = append(missing, types.NetworkProbeResult{
+					From:    member.Member,
+					Network: network,
+					Status:  types.DoctorFail,
+					Message: fmt.Sprintf("no address on the %s network %s", network, subnet),
+				})
+				continue
+			}
+
+			for _, peer := range members {
+				if peer.Member == member.Member || len(peer.Addresses[subnet]) == 0 {
+					continue
+				}
+
+				plan[peer.Member] = append(plan[peer.Member], types.NetworkProbeTarget{
+					Member:  member.Member,
+					Network: network,
+					Subnet:  subnet,
+					Address: address,
+					Ports:   ports[address],
+				})
+			}
+		}
+	}
+
+	return plan, missing
+}
+
+// CheckClusterNetwork has every member probe the public and cluster network addresses of every other member.
+// If clusterNet is set, it is checked instead of the configured cluster network.
+func CheckClusterNetwork(ctx context.Context, s state.State, clusterNet string) (types.NetworkCheckReport, error) {
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return types.NetworkCheckReport{}, fmt.Errorf("failed to read cluster config: %w", err)
+	}
+
+	report := types.NetworkCheckReport{
+		PublicNetwork:  config["public_network"],
+		ClusterNetwork: config["cluster_network"],
+		Results:        []types.NetworkProbeResult{},
+	}
+	if len(clusterNet) != 0 {
+		report.ClusterNetwork = clusterNet
+	}
+
+	networks := map[string]string{"public": report.PublicNetwork}
+	subnets := []string{report.PublicNetwork}
+	if len(report.ClusterNetwork) != 0 && report.ClusterNetwork != report.PublicNetwork {
+		networks["cluster"] = report.ClusterNetwork
+		subnets = append(subnets, report.ClusterNetwork)
+	}
+
+	members, remotes, err := gatherNetworkAddresses(ctx, s, subnets)
+	if err != nil {
+		return report, err
+	}
+
+	report.Results = probeNetworks(ctx, s, members, remotes, networks)
+
+	logger.Infof("Checked networks %v of %d members: %d of %d probes failed", subnets, len(members), report.Failed(), len(report.Results))
+	return report, nil
+}
+
+// gatherNetworkAddresses returns the addresses of every member on the given subnets, the local member first,
+// along with a client to each other member by name.
+func gatherNetworkAddresses(ctx context.Context, s state.State, subnets []string) ([]types.NetworkAddresses, map[string]*microCli.Client, error) {
+	cluster, err := s.Cluster(false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get a client for every cluster member: %w", err)
+	}
+
+	members := []types.NetworkAddresses{GetNetworkAddresses(s, subnets)}
+	remotes := map[string]*microCli.Client{}
+	for i := range cluster {
+		remote := &cluster[i]
+		addresses, err := client.GetNetworkAddresses(ctx, remote, subnets)
+		if err != nil {
+			url := remote.URL()
+			return nil, nil, fmt.Errorf("failed to get network addresses of %s: %w", url.String(), err)
+		}
+
+		members = append(members, addresses)
+		remotes[addresses.Member] = remote
+	}
+
+	return members, remotes, nil
+}
+
+// probeNetworks has every member probe the addresses of every other member on the given networks.
+func probeNetworks(ctx context.Context, s state.State, members []types.NetworkAddresses, remotes map[string]*microCli.Client, networks map[string]string) []types.NetworkProbeResult {
+	plan, missing := planNetworkProbes(members, networks, getDaemonPorts())
+
+	results := append([]types.NetworkProbeResult{}, missing...)
+	results = append(results, ProbeNetwork(ctx, s.Name(), plan[s.Name()])...)
+
+	for _, member := range members[1:] {
+		if len(plan[member.Member]) == 0 {
+			continue
+		}
+
+		probes, err := client.ProbeNetwork(ctx, remotes[member.Member], types.NetworkProbeRequest{Targets: plan[member.Member]})
+		if err != nil {
+			results = append(results, types.NetworkProbeResult{
+				From:    member.Member,
+				Status:  types.DoctorFail,
+				Message: fmt.Sprintf("failed to run probes: %v", err),
+			})
+			continue
+		}
+
+		results = append(results, probes...)
+	}
+
+	return results
+}
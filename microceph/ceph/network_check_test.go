@@ -0,0 +1,140 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type networkCheckSuite struct {
+	tests.BaseSuite
+}
+
+func TestNetworkCheck(t *testing.T) {
+	suite.Run(t, new(networkCheckSuite))
+}
+
+const pingOutput = `PING 10.1.0.2 (10.1.0.2) 56(84) bytes of data.
+
+--- 10.1.0.2 ping statistics ---
+3 packets transmitted, 3 received, 0% packet loss, time 404ms
+rtt min/avg/max/mdev = 0.211/0.254/0.301/0.037 ms
+`
+
+func (s *networkCheckSuite) TestPlanNetworkProbes() {
+	members := []types.NetworkAddresses{
+		{Member: "node0", Addresses: map[string]string{"10.0.0.0/24": "10.0.0.1", "10.1.0.0/24": "10.1.0.1"}},
+		{Member: "node1", Addresses: map[string]string{"10.0.0.0/24": "10.0.0.2", "10.1.0.0/24": "10.1.0.2"}},
+		{Member: "node2", Addresses: map[string]string{"10.0.0.0/24": "10.0.0.3", "10.1.0.0/24": ""}},
+	}
+	networks := map[string]string{"public": "10.0.0.0/24", "cluster": "10.1.0.0/24"}
+	ports := map[string][]int{"10.0.0.2": {3300, 6789}}
+
+	plan, missing := planNetworkProbes(members, networks, ports)
+
+	// node2 has no cluster network address, nobody probes it there and it probes nobody.
+	assert.Len(s.T(), missing, 1)
+	assert.Equal(s.T(), "node2", missing[0].From)
+	assert.Equal(s.T(), "cluster", missing[0].Network)
+	assert.Equal(s.T(), types.DoctorFail, missing[0].Status)
+
+	assert.Len(s.T(), plan["node0"], 3)
+	assert.Len(s.T(), plan["node1"], 3)
+	assert.Len(s.T(), plan["node2"], 2)
+	assert.Contains(s.T(), plan["node0"], types.NetworkProbeTarget{Member: "node1", Network: "public", Subnet: "10.0.0.0/24", Address: "10.0.0.2", Ports: []int{3300, 6789}})
+	assert.Contains(s.T(), plan["node0"], types.NetworkProbeTarget{Member: "node1", Network: "cluster", Subnet: "10.1.0.0/24", Address: "10.1.0.2"})
+}
+
+func (s *networkCheckSuite) TestGetDaemonPorts() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "mon", "dump", "-f", "json").Return(`{"quorum":[0],"mons":[{"name":"node0","rank":0,"public_addrs":{"addrvec":[{"type":"v2","addr":"10.0.0.1:3300","nonce":0},{"type":"v1","addr":"10.0.0.1:6789","nonce":0}]}},{"name":"node2","rank":1,"public_addrs":{"addrvec":[{"type":"v2","addr":"10.0.0.3:3300","nonce":0}]}}]}`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "dump", "-f", "json").Return(`{"osds":[{"osd":1,"up":1,"public_addrs":{"addrvec":[{"type":"v2","addr":"10.0.0.1:6802","nonce":1}]},"cluster_addrs":{"addrvec":[{"type":"v2","addr":"10.1.0.1:6803","nonce":1}]}},{"osd":2,"up":1,"public_addrs":{"addrvec":[{"type":"v2","addr":"10.0.0.1:6802","nonce":1}]},"cluster_addrs":{"addrvec":[]}},{"osd":3,"up":0,"public_addrs":{"addrvec":[{"type":"v2","addr":"10.0.0.3:6800","nonce":1}]},"cluster_addrs":{"addrvec":[{"type":"v2","addr":"10.1.0.3:6801","nonce":1}]}}]}`, nil).Once()
+	common.ProcessExec = r
+
+	ports := getDaemonPorts()
+	assert.Equal(s.T(), []int{3300, 6789, 6802}, ports["10.0.0.1"])
+	assert.Equal(s.T(), []int{6803}, ports["10.1.0.1"])
+	// The monitor out of quorum and the down OSD of node2 don't listen.
+	assert.NotContains(s.T(), ports, "10.0.0.3")
+	assert.NotContains(s.T(), ports, "10.1.0.3")
+}
+
+func (s *networkCheckSuite) TestProbeTargetJumboFrames() {
+	origDial, origMTU := dialTCP, interfaceMTU
+	defer func() { dialTCP, interfaceMTU = origDial, origMTU }()
+
+	dialTCP = func(address string, timeout time.Duration) (net.Conn, error) {
+		if address == "10.1.0.2:6803" {
+			client, server := net.Pipe()
+			_ = server.Close()
+			return client, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+	interfaceMTU = func(address string) (int, error) { return 9000, nil }
+
+	nw := mocks.NewNetworkIntf(s.T())
+	nw.On("FindIpOnSubnet", "10.1.0.0/24").Return("10.1.0.1", nil)
+	common.Network = nw
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommandContext", mock.Anything, "ping", "-c", "3", "-i", "0.2", "-q", "-W", "1", "10.1.0.2").Return(pingOutput, nil)
+	r.On("RunCommandContext", mock.Anything, "ping", "-c", "1", "-W", "1", "-M", "do", "-s", "8972", "10.1.0.2").Return("", errors.New("message too long")).Once()
+	r.On("RunCommandContext", mock.Anything, "ping", "-c", "1", "-W", "1", "-M", "do", "-s", "1472", "10.1.0.2").Return("", nil).Once()
+	common.ProcessExec = r
+
+	target := types.NetworkProbeTarget{Member: "node1", Network: "cluster", Subnet: "10.1.0.0/24", Address: "10.1.0.2", Ports: []int{6803}}
+	result := probeTarget(context.Background(), "node0", target)
+	assert.True(s.T(), result.Reachable)
+	assert.Equal(s.T(), 0.254, result.LatencyMs)
+	assert.Equal(s.T(), []types.NetworkPortResult{{Port: 6803, Open: true}}, result.Ports)
+	assert.Equal(s.T(), 9000, result.InterfaceMTU)
+	assert.Equal(s.T(), 1500, result.PathMTU)
+	assert.Equal(s.T(), types.DoctorFail, result.Status)
+	assert.Contains(s.T(), result.Message, "interface MTU is 9000 but only 1500")
+
+	// A closed port fails the probe.
+	target.Ports = []int{6804}
+	r.On("RunCommandContext", mock.Anything, "ping", "-c", "1", "-W", "1", "-M", "do", "-s", "8972", "10.1.0.2").Return("", nil).Once()
+	result = probeTarget(context.Background(), "node0", target)
+	assert.Equal(s.T(), types.DoctorFail, result.Status)
+	assert.Contains(s.T(), result.Message, "port(s) 6804 not reachable")
+}
+
+func (s *networkCheckSuite) TestProbeTargetUnreachable() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommandContext", mock.Anything, "ping", "-c", "3", "-i", "0.2", "-q", "-W", "1", "10.1.0.9").Return("", errors.New("exit status 1")).Once()
+	common.ProcessExec = r
+
+	result := probeTarget(context.Background(), "node0", types.NetworkProbeTarget{Member: "node9", Network: "cluster", Subnet: "10.1.0.0/24", Address: "10.1.0.9"})
+	assert.False(s.T(), result.Reachable)
+	assert.Equal(s.T(), types.DoctorFail, result.Status)
+	assert.Equal(s.T(), "unreachable", result.Message)
+}
+
+func (s *networkCheckSuite) TestRateProbe() {
+	result := types.NetworkProbeResult{LatencyMs: 0.3, InterfaceMTU: 9000, PathMTU: 9000}
+	rateProbe(&result, nil)
+	assert.Equal(s.T(), types.DoctorPass, result.Status)
+	assert.Equal(s.T(), "reachable, 0.30 ms, MTU 9000 (jumbo frames)", result.Message)
+
+	result = types.NetworkProbeResult{LatencyMs: 12, InterfaceMTU: 1500, PathMTU: 1500}
+	rateProbe(&result, nil)
+	assert.Equal(s.T(), types.DoctorWarn, result.Status)
+
+	result = types.NetworkProbeResult{LatencyMs: 0.3, InterfaceMTU: 1500}
+	rateProbe(&result, nil)
+	assert.Equal(s.T(), types.DoctorWarn, result.Status)
+	assert.Contains(s.T(), result.Message, "path MTU unknown")
+}
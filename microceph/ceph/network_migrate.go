@@ -0,0 +1,160 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// publicNetworkMigration moves the daemons of a cluster to a new public network.
+type publicNetworkMigration struct {
+	*rollout
+	subnet string
+	v2Only bool
+	// addresses maps each member to its address on the new public network.
+	addresses map[string]string
+}
+
+// MigratePublicNetwork moves the monitors, one at a time, and then every other daemon to a new public network.
+// It returns the steps run, or only plans them on a dry run.
+func MigratePublicNetwork(ctx context.Context, s state.State, subnet string, dryRun bool) ([]string, error) {
+	m, err := preparePublicNetworkMigration(ctx, s, subnet)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.run(ctx, "Public network migration", m.steps(), dryRun)
+}
+
+// preparePublicNetworkMigration checks the cluster can move to the given public network.
+func preparePublicNetworkMigration(ctx context.Context, s state.State, subnet string) (*publicNetworkMigration, error) {
+	_, _, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public network %s: %w", subnet, err)
+	}
+
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster config: %w", err)
+	}
+
+	if config["public_network"] == subnet {
+		return nil, fmt.Errorf("the public network already is %s", subnet)
+	}
+
+	v2Only, err := msgrv2OnlyCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the monitor addresses: %w", err)
+	}
+
+	r, err := newRollout(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	members, remotes, err := gatherNetworkAddresses(ctx, s, []string{subnet})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &publicNetworkMigration{rollout: r, subnet: subnet, v2Only: v2Only, addresses: map[string]string{}}
+
+	missing := []string{}
+	for _, member := range members {
+		address := member.Addresses[subnet]
+		if len(address) == 0 {
+			missing = append(missing, member.Member)
+			continue
+		}
+
+		m.addresses[member.Member] = address
+	}
+
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("members %s have no address on %s", strings.Join(missing, ", "), subnet)
+	}
+
+	for _, result := range probeNetworks(ctx, s, members, remotes, map[string]string{"public": subnet}) {
+		if result.Status == types.DoctorFail {
+			return nil, fmt.Errorf("%s can't reach %s on %s: %s", result.From, result.To, subnet, result.Message)
+		}
+	}
+
+	return m, nil
+}
+
+// steps returns the steps of the migration, in order.
+func (m *publicNetworkMigration) steps() []rolloutStep {
+	steps := []rolloutStep{}
+
+	// Move the monitors one at a time, keeping the quorum and ceph.conf of every member up to date.
+	for _, mon := range m.mons() {
+		address := m.addresses[mon]
+		steps = append(steps,
+			rolloutStep{
+				description: fmt.Sprintf("Move monitor %s to %s", mon, address),
+				run:         func(ctx context.Context) error { return m.moveMon(ctx, mon, address) },
+			},
+			m.updateConfigsStep(),
+			m.restartMonStep(mon),
+		)
+	}
+
+	steps = append(steps,
+		rolloutStep{
+			description: fmt.Sprintf("Set public_network to %s", m.subnet),
+			run:         m.setPublicNetwork,
+		},
+		m.updateConfigsStep(),
+	)
+
+	// Restart the other daemons one member at a time for them to bind to their new address.
+	return append(steps, m.restartSteps()...)
+}
+
+// moveMon changes the address of a monitor in the monmap and in the mon hosts of the cluster config.
+func (m *publicNetworkMigration) moveMon(ctx context.Context, mon string, address string) error {
+	err := setMonAddrs(mon, address, m.v2Only)
+	if err != nil {
+		return err
+	}
+
+	return m.s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.SetConfigItem(ctx, tx, fmt.Sprintf("mon.host.%s", mon), monHostValue(address, m.v2Only))
+	})
+}
+
+// setPublicNetwork records the new public network in the cluster config and in Ceph.
+func (m *publicNetworkMigration) setPublicNetwork(ctx context.Context) error {
+	err := m.s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.SetConfigItem(ctx, tx, "public_network", m.subnet)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record public_network: %w", err)
+	}
+
+	return SetConfigItemUnsafe(types.Config{Key: "public_network", Value: m.subnet})
+}
+
+// setMonAddrs sets the address of a monitor in the monmap.
+func setMonAddrs(mon string, address string, v2Only bool) error {
+	addrs := fmt.Sprintf("v2:%s", net.JoinHostPort(address, "3300"))
+	if !v2Only {
+		addrs = fmt.Sprintf("%s,v1:%s", addrs, net.JoinHostPort(address, "6789"))
+	}
+
+	_, err := cephRun("mon", "set-addrs", mon, fmt.Sprintf("[%s]", addrs))
+	if err != nil {
+		return fmt.Errorf("failed to set the address of monitor %s: %w", mon, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type networkMigrateSuite struct {
+	tests.BaseSuite
+}
+
+func TestNetworkMigrate(t *testing.T) {
+	suite.Run(t, new(networkMigrateSuite))
+}
+
+func (s *networkMigrateSuite) TestSteps() {
+	m := &publicNetworkMigration{
+		subnet:    "10.2.0.0/24",
+		addresses: map[string]string{"node0": "10.2.0.1", "node1": "10.2.0.2", "node2": "10.2.0.3"},
+		rollout: &rollout{s: &mocks.MockState{ClusterName: "node0"}, services: types.Services{
+			{Service: "mon", Location: "node0"},
+			{Service: "mgr", Location: "node0"},
+			{Service: "osd", Location: "node0"},
+			{Service: "mon", Location: "node1"},
+			{Service: "osd", Location: "node1"},
+			{Service: "rgw", Location: "node1"},
+		}},
+	}
+
+	descriptions := []string{}
+	for _, step := range m.steps() {
+		descriptions = append(descriptions, step.description)
+	}
+
+	assert.Equal(s.T(), []string{
+		"Move monitor node0 to 10.2.0.1",
+		"Regenerate ceph.conf on every member",
+		"Restart monitor node0 and wait for it to rejoin quorum",
+		"Move monitor node1 to 10.2.0.2",
+		"Regenerate ceph.conf on every member",
+		"Restart monitor node1 and wait for it to rejoin quorum",
+		"Set public_network to 10.2.0.0/24",
+		"Regenerate ceph.conf on every member",
+		"Run `ceph osd set noout`",
+		"Restart mgr, osd on node0",
+		"Restart osd, rgw on node1",
+		"Run `ceph osd unset noout`",
+	}, descriptions)
+}
+
+func (s *networkMigrateSuite) TestSetMonAddrs() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "mon", "set-addrs", "node0", "[v2:10.2.0.1:3300,v1:10.2.0.1:6789]").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "set-addrs", "node1", "[v2:[fd00::2]:3300]").Return("", nil).Once()
+	common.ProcessExec = r
+
+	assert.NoError(s.T(), setMonAddrs("node0", "10.2.0.1", false))
+	assert.NoError(s.T(), setMonAddrs("node1", "fd00::2", true))
+}
+
+func (s *networkMigrateSuite) TestMonHostValue() {
+	assert.Equal(s.T(), "10.2.0.1", monHostValue("10.2.0.1", false))
+	assert.Equal(s.T(), "v2:10.2.0.1:3300", monHostValue("10.2.0.1", true))
+}
@@ -201,7 +201,7 @@ func DisableNFS(ctx context.Context, s interfaces.StateInterface, clusterID stri
 
 // startNFS starts the NFS service.
 func startNFS() error {
-	err := snapStart("nfs", true)
+	err := startService("nfs", true)
 	if err != nil {
 		return fmt.Errorf("failed to start NFS Ganesha service: %w", err)
 	}
@@ -211,7 +211,7 @@ func startNFS() error {
 
 // stopNFS stops the NFS service.
 func stopNFS() error {
-	err := snapStop("nfs", true)
+	err := stopService("nfs", true)
 	if err != nil {
 		return fmt.Errorf("failed to stop NFS Ganesha service: %w", err)
 	}
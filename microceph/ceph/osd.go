@@ -98,6 +98,7 @@ type OSDManager struct {
 	mountChecker    MountChecker
 	fileStater      FileStater
 	pristineChecker PristineChecker
+	keyProvider     KeyProvider
 }
 
 // NewOSDManager returns a new OSD manager instance.
@@ -241,13 +242,146 @@ func encryptDevice(path string, key []byte) error {
 	return nil
 }
 
-// Store the key in the ceph key value store, under a name that derives from the osd id.
+// getKeyProvider returns the key provider configured for the cluster, resolving it on first use.
+func (m *OSDManager) getKeyProvider() (KeyProvider, error) {
+	if m.keyProvider != nil {
+		return m.keyProvider, nil
+	}
+
+	provider, err := GetKeyProvider(context.Background(), m.state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key provider: %w", err)
+	}
+
+	m.keyProvider = provider
+	return provider, nil
+}
+
+// Store the key with the configured key provider, under a name that derives from the osd id.
 func (m *OSDManager) storeKey(key []byte, osdID int64, suffix string) error {
-	// Run the ceph config-key set command
-	_, err := m.runner.RunCommand("ceph", "config-key", "set", fmt.Sprintf("microceph:osd%s.%d/key", suffix, osdID), string(key))
+	provider, err := m.getKeyProvider()
+	if err != nil {
+		return err
+	}
+
+	return provider.StoreKey(osdKeyName(osdID, suffix), key)
+}
+
+// getOSDKey fetches the key of an OSD device from the configured key provider.
+// Keys stored before a provider change are looked up in the config-key store,
+// the returned flag reports whether the key was found there instead.
+func (m *OSDManager) getOSDKey(osdID int64, suffix string) ([]byte, bool, error) {
+	provider, err := m.getKeyProvider()
+	if err != nil {
+		return nil, false, err
+	}
+
+	name := osdKeyName(osdID, suffix)
+	key, err := provider.GetKey(name)
+	if err == nil {
+		return key, false, nil
+	}
+
+	if _, ok := provider.(ConfigKeyProvider); ok {
+		return nil, false, err
+	}
+
+	key, legacyErr := ConfigKeyProvider{runner: m.runner}.GetKey(name)
+	if legacyErr != nil {
+		return nil, false, err
+	}
+
+	return key, true, nil
+}
+
+// rotateOSDKeys replaces the LUKS keys of all encrypted devices (data, WAL and DB) of an OSD.
+func (m *OSDManager) rotateOSDKeys(osdID int64) error {
+	osdDataPath := getOSDDataPath(osdID)
+
+	rotated := 0
+	for _, suffix := range []string{"", ".wal", ".db"} {
+		device := filepath.Join(osdDataPath, "unencrypted"+suffix)
+		if exists, _ := afero.Exists(m.fs, device); !exists {
+			continue
+		}
+
+		err := m.rotateDeviceKey(device, osdID, suffix)
+		if err != nil {
+			return fmt.Errorf("failed to rotate key of osd.%d%s: %w", osdID, suffix, err)
+		}
+		rotated++
+	}
+
+	if rotated == 0 {
+		return fmt.Errorf("osd.%d has no encrypted devices on this node", osdID)
+	}
+
+	logger.Infof("Rotated %d key(s) of osd.%d", rotated, osdID)
+	return nil
+}
+
+// rotateDeviceKey adds a new LUKS keyslot to the device, stores its key and retires the old keyslot.
+func (m *OSDManager) rotateDeviceKey(device string, osdID int64, suffix string) error {
+	provider, err := m.getKeyProvider()
+	if err != nil {
+		return err
+	}
+
+	oldKey, legacy, err := m.getOSDKey(osdID, suffix)
+	if err != nil {
+		return err
+	}
+
+	newKey, err := createKey()
+	if err != nil {
+		return fmt.Errorf("key creation error: %w", err)
+	}
+
+	// cryptsetup reads the keys from private scratch files.
+	dir, err := afero.TempDir(m.fs, constants.GetPathConst().RunPath, "keyrotate")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer func() { _ = m.fs.RemoveAll(dir) }()
+
+	oldKeyFile := filepath.Join(dir, "old")
+	newKeyFile := filepath.Join(dir, "new")
+	for path, key := range map[string][]byte{oldKeyFile: oldKey, newKeyFile: newKey} {
+		err = afero.WriteFile(m.fs, path, key, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write scratch key: %w", err)
+		}
+	}
+
+	_, err = m.runner.RunCommand("cryptsetup", "--batch-mode", "--key-file", oldKeyFile, "luksAddKey", device, newKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to add keyslot: %w", err)
+	}
+
+	name := osdKeyName(osdID, suffix)
+	err = provider.StoreKey(name, newKey)
+	if err != nil {
+		// Keep the stored key usable by dropping the new keyslot.
+		_, revertErr := m.runner.RunCommand("cryptsetup", "--batch-mode", "luksRemoveKey", device, newKeyFile)
+		if revertErr != nil {
+			logger.Errorf("failed to remove new keyslot of %s: %v", device, revertErr)
+		}
+		return fmt.Errorf("key store error: %w", err)
+	}
+
+	_, err = m.runner.RunCommand("cryptsetup", "--batch-mode", "luksRemoveKey", device, oldKeyFile)
 	if err != nil {
-		return fmt.Errorf("failed to store key: %w", err)
+		return fmt.Errorf("new key stored but failed to retire old keyslot: %w", err)
+	}
+
+	// The key moved to the configured provider, drop the stale copy.
+	if legacy {
+		err = ConfigKeyProvider{runner: m.runner}.DeleteKey(name)
+		if err != nil {
+			logger.Warnf("failed to delete stale key %s from config-key store: %v", name, err)
+		}
 	}
+
 	return nil
 }
 
@@ -374,6 +508,13 @@ func (m *OSDManager) setStablePath(storage *api.ResourcesStorage, param *types.D
 		return fmt.Errorf("invalid disk path: %w", err)
 	}
 
+	// Device mapper volumes such as LVM logical volumes are known by their mapper name.
+	if path := m.mapperPath(major, minor); len(path) != 0 {
+		param.Path = path
+		logger.Infof("Set stable path for to %s", param.Path)
+		return nil
+	}
+
 	dev := fmt.Sprintf("%d:%d", major, minor)
 
 	for _, disk := range storage.Disks {
@@ -520,8 +661,8 @@ func (m *OSDManager) addLoopBackOSDs(ctx context.Context, spec string) error {
 	if err != nil {
 		return err
 	}
-	// check available capacity for backing files under $SNAP_COMMON
-	freeSpace, err := getFreeSpace(os.Getenv("SNAP_COMMON"))
+	// check available capacity for backing files under the data path
+	freeSpace, err := getFreeSpace(constants.GetPathConst().DataPath)
 	if err != nil {
 		return err
 	}
@@ -566,58 +707,88 @@ func (m *OSDManager) checkPristineDevice(disk *types.DiskParameter, deviceType s
 	return nil
 }
 
-// bootstrapOSD bootstraps an OSD.
-func (m *OSDManager) bootstrapOSD(osdDataPath string, nr int64, wal, db *types.DiskParameter, storage *api.ResourcesStorage) error {
-	logger.Infof("Bootstrapping OSD %s to %d", osdDataPath, nr)
-	var err error
+// setupBluefsDevice validates and prepares a WAL or DB device for a new OSD, carving a slice off it if it is shared.
+// Returns the device record to store once the OSD is bootstrapped.
+func (m *OSDManager) setupBluefsDevice(ctx context.Context, reverter *revert.Reverter, disk *types.DiskParameter, role string, osdDataPath string, nr int64, storage *api.ResourcesStorage) (types.DiskDevice, error) {
+	deviceType := strings.ToUpper(role)
+	device := types.DiskDevice{OSD: nr, Role: role, Encrypted: disk.Encrypt}
 
-	args := []string{"--mkfs", "--no-mon-config", "-i", fmt.Sprintf("%d", nr)}
-	if wal != nil {
-		err = m.setStablePath(storage, wal)
+	// Partitions and logical volumes are used whole rather than sliced.
+	volume := false
+	if disk.Size != 0 {
+		var err error
+		volume, err = m.isVolume(storage, disk.Path)
 		if err != nil {
-			return fmt.Errorf("failed to set stable path for WAL: %w", err)
+			return device, fmt.Errorf("failed to check %s device: %w", deviceType, err)
 		}
+	}
 
-		// Check for partitions on WAL device unless wipe is enabled
-		err = m.checkPartitionsOnDevice(wal, storage, "WAL")
+	if disk.Size != 0 && !volume {
+		slice, err := m.carveSlice(ctx, disk, role, nr, storage)
 		if err != nil {
-			return err
+			return device, fmt.Errorf("failed to carve %s slice: %w", deviceType, err)
 		}
-
-		// Check if WAL device is pristine unless wipe is enabled
-		err = m.checkPristineDevice(wal, "WAL")
+		reverter.Add(func() { _ = m.removeSlice(slice.Parent, slice.Partition) })
+		device = slice
+	} else {
+		err := m.setStablePath(storage, disk)
 		if err != nil {
-			return err
+			return device, fmt.Errorf("failed to set stable path for %s: %w", deviceType, err)
 		}
 
-		err = m.prepareDisk(wal, ".wal", osdDataPath, nr)
-		if err != nil {
-			return fmt.Errorf("failed to set up WAL device: %w", err)
-		}
-		args = append(args, []string{"--bluestore-block-wal-path", wal.Path}...)
-	}
-	if db != nil {
-		err = m.setStablePath(storage, db)
+		// Check for partitions on the device unless wipe is enabled
+		err = m.checkPartitionsOnDevice(disk, storage, deviceType)
 		if err != nil {
-			return fmt.Errorf("failed to set stable path for DB: %w", err)
+			return device, err
 		}
 
-		// Check for partitions on DB device unless wipe is enabled
-		err = m.checkPartitionsOnDevice(db, storage, "DB")
+		// Check if the device is pristine unless wipe is enabled
+		err = m.checkPristineDevice(disk, deviceType)
 		if err != nil {
-			return err
+			return device, err
 		}
+		device.Path = disk.Path
+	}
+
+	err := m.prepareDisk(disk, bluefsDeviceSuffix[role], osdDataPath, nr)
+	if err != nil {
+		return device, fmt.Errorf("failed to set up %s device: %w", deviceType, err)
+	}
+
+	// The slice can only be removed once its mapper is closed.
+	if disk.Encrypt && len(device.Parent) != 0 {
+		reverter.Add(func() {
+			_, _ = m.runner.RunCommand("cryptsetup", "close", fmt.Sprintf("luksosd%s-%d", bluefsDeviceSuffix[role], nr))
+		})
+	}
+
+	return device, nil
+}
+
+// bootstrapOSD bootstraps an OSD.
+func (m *OSDManager) bootstrapOSD(ctx context.Context, osdDataPath string, nr int64, wal, db *types.DiskParameter, storage *api.ResourcesStorage) error {
+	logger.Infof("Bootstrapping OSD %s to %d", osdDataPath, nr)
+	var err error
 
-		// Check if DB device is pristine unless wipe is enabled
-		err = m.checkPristineDevice(db, "DB")
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	devices := []types.DiskDevice{}
+	args := []string{"--mkfs", "--no-mon-config", "-i", fmt.Sprintf("%d", nr)}
+	if wal != nil {
+		device, err := m.setupBluefsDevice(ctx, reverter, wal, types.DiskRoleWAL, osdDataPath, nr, storage)
 		if err != nil {
 			return err
 		}
-
-		err = m.prepareDisk(db, ".db", osdDataPath, nr)
+		devices = append(devices, device)
+		args = append(args, []string{"--bluestore-block-wal-path", wal.Path}...)
+	}
+	if db != nil {
+		device, err := m.setupBluefsDevice(ctx, reverter, db, types.DiskRoleDB, osdDataPath, nr, storage)
 		if err != nil {
-			return fmt.Errorf("failed to set up DB device: %w", err)
+			return err
 		}
+		devices = append(devices, device)
 		args = append(args, []string{"--bluestore-block-db-path", db.Path}...)
 	}
 
@@ -631,19 +802,29 @@ func (m *OSDManager) bootstrapOSD(osdDataPath string, nr int64, wal, db *types.D
 	if err != nil {
 		return fmt.Errorf("failed to write stamp file: %w", err)
 	}
+
+	// Record the WAL and DB devices, slices count against their shared device from here on.
+	for _, device := range devices {
+		err = database.OSDQuery.SetDevice(ctx, m.state, device)
+		if err != nil {
+			return err
+		}
+	}
+
+	reverter.Success()
 	logger.Infof("OSD %s bootstrapped successfully", osdDataPath)
 	return nil
 }
 
 func validateBulkDiskAdditionArgs(disks []types.DiskParameter, wal *types.DiskParameter, db *types.DiskParameter) error {
-	// No validation for non-batch requests.
-	if len(disks) == 1 {
+	// No validation for non-batch requests, a split device is a batch of its partitions.
+	if len(disks) == 1 && disks[0].OSDsPerDevice <= 1 {
 		return nil
 	}
 
-	// check if wal/db devices are provided for batch request.
-	if wal != nil || db != nil {
-		err := fmt.Errorf("wal/db devices are not supported in batch disk addition")
+	// check if whole wal/db devices are provided for batch request, shared devices are sliced per disk.
+	if (wal != nil && wal.Size == 0) || (db != nil && db.Size == 0) {
+		err := fmt.Errorf("wal/db devices are not supported in batch disk addition, unless sliced with a WAL/DB size")
 		logger.Error(err.Error())
 		return err
 	}
@@ -660,6 +841,16 @@ func validateBulkDiskAdditionArgs(disks []types.DiskParameter, wal *types.DiskPa
 	return nil
 }
 
+// copyDiskParameter copies an optional disk parameter, preparing a device rewrites its path.
+func copyDiskParameter(disk *types.DiskParameter) *types.DiskParameter {
+	if disk == nil {
+		return nil
+	}
+
+	param := *disk
+	return &param
+}
+
 // prepareValidationFailureResp generates the failure response for argument validation errors.
 func prepareValidationFailureResp(disks []types.DiskParameter, err error) types.DiskAddResponse {
 	ret := types.DiskAddResponse{ValidationError: err.Error()}
@@ -676,7 +867,7 @@ func prepareValidationFailureResp(disks []types.DiskParameter, err error) types.
 func (m *OSDManager) addBulkDisks(ctx context.Context, disks []types.DiskParameter, wal *types.DiskParameter, db *types.DiskParameter) types.DiskAddResponse {
 	ret := types.DiskAddResponse{}
 
-	if len(disks) == 1 {
+	if len(disks) == 1 && disks[0].OSDsPerDevice <= 1 {
 		// Add single disk with requested WAL/DB devices.
 		resp := m.addSingleDisk(ctx, disks[0], wal, db)
 		ret.Reports = append(ret.Reports, resp)
@@ -693,9 +884,28 @@ func (m *OSDManager) addBulkDisks(ctx context.Context, disks []types.DiskParamet
 		ret.ValidationError = ""
 	}
 
-	// Add all requested disks.
-	for _, disk := range disks {
-		resp := m.addSingleDisk(ctx, disk, nil, nil)
+	// Refuse to over-commit shared WAL/DB devices.
+	for _, shared := range []*types.DiskParameter{wal, db} {
+		err = m.checkSharedCapacity(ctx, shared, countOSDs(disks))
+		if err != nil {
+			logger.Error(err.Error())
+			return prepareValidationFailureResp(disks, err)
+		}
+	}
+
+	// Split devices hosting several OSDs into their data partitions.
+	osds, err := m.splitDevices(ctx, disks)
+	if err != nil {
+		logger.Error(err.Error())
+		return prepareValidationFailureResp(disks, err)
+	}
+
+	// Add all requested disks, each gets its own slice of shared WAL/DB devices.
+	for _, disk := range osds {
+		resp := m.addSingleDisk(ctx, disk, copyDiskParameter(wal), copyDiskParameter(db))
+		if resp.Report != "Success" {
+			m.removeDataPartitions([]types.DiskParameter{disk})
+		}
 		ret.Reports = append(ret.Reports, resp)
 	}
 
@@ -829,14 +1039,17 @@ func (m *OSDManager) prepareOSDData(ctx context.Context, data *types.DiskParamet
 	return nil
 }
 
-func (m *OSDManager) generateOSDFiles(osdDataPath string, nr int64) error {
+// generateOSDFiles writes the OSD keyring and fsid, an empty fsid generates a new one.
+func (m *OSDManager) generateOSDFiles(osdDataPath string, nr int64, fsid string) error {
 	err := genAuth(filepath.Join(osdDataPath, "keyring"), fmt.Sprintf("osd.%d", nr), []string{"mgr", "allow profile osd"}, []string{"mon", "allow profile osd"}, []string{"osd", "allow *"})
 	if err != nil {
 		logger.Errorf("failed to generate OSD files, osd path %s, err %v", osdDataPath, err)
 		return fmt.Errorf("failed to generate OSD keyring: %w", err)
 	}
 
-	fsid := uuid.NewRandom().String()
+	if len(fsid) == 0 {
+		fsid = uuid.NewRandom().String()
+	}
 	err = afero.WriteFile(m.fs, filepath.Join(osdDataPath, "fsid"), []byte(fsid), 0600)
 	if err != nil {
 		logger.Errorf("failed to write fsid, osd path %s, err %v", osdDataPath, err)
@@ -848,7 +1061,7 @@ func (m *OSDManager) generateOSDFiles(osdDataPath string, nr int64) error {
 
 func (m *OSDManager) spawnOSD(nr int64) error {
 	logger.Infof("Spawning OSD %d", nr)
-	err := snapRestart("osd", true)
+	err := restartService("osd", true)
 	if err != nil {
 		return fmt.Errorf("failed to start osd.%d: %w", nr, err)
 	}
@@ -868,6 +1081,7 @@ func (m *OSDManager) doAddOSD(ctx context.Context, data types.DiskParameter, wal
 		logger.Errorf("failed to create disk record for %s: %v", data.Path, err)
 		return err
 	}
+	devicePath := data.Path
 
 	osdDataPath := getOSDDataPath(nr)
 	logger.Infof("osd data path: %s", osdDataPath)
@@ -902,18 +1116,34 @@ func (m *OSDManager) doAddOSD(ctx context.Context, data types.DiskParameter, wal
 		return fmt.Errorf("failed to prepare data device: %w", err)
 	}
 
-	err = m.generateOSDFiles(osdDataPath, nr)
+	err = m.generateOSDFiles(osdDataPath, nr, "")
 	if err != nil {
 		logger.Errorf("failed to generate OSD files for %s: %v", data.Path, err)
 		return err
 	}
 
-	err = m.bootstrapOSD(osdDataPath, nr, wal, db, storage)
+	err = m.bootstrapOSD(ctx, osdDataPath, nr, wal, db, storage)
 	if err != nil {
 		logger.Errorf("failed to bootstrap OSD %d: %v", nr, err)
 		return err
 	}
 
+	// Record the partition of a split device, it is removed along with the OSD.
+	if len(data.Parent) != 0 {
+		err = database.OSDQuery.SetDevice(ctx, m.state, types.DiskDevice{
+			OSD:       nr,
+			Role:      types.DiskRoleData,
+			Path:      devicePath,
+			Encrypted: data.Encrypt,
+			Parent:    data.Parent,
+			Partition: data.Partition,
+			Size:      data.Size,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	err = m.spawnOSD(nr)
 	if err != nil {
 		logger.Errorf("failed to spawn OSD %d: %v", nr, err)
@@ -956,9 +1186,24 @@ func ListOSD(ctx context.Context, s state.State) (types.Disks, error) {
 	return database.OSDQuery.List(ctx, s)
 }
 
+// RotateOSDKeys rotates the LUKS keys of an encrypted OSD using a one-off manager.
+func RotateOSDKeys(s state.State, osd int64) error {
+	return NewOSDManager(s).rotateOSDKeys(osd)
+}
+
+// GetOSDKey fetches the LUKS key of an encrypted OSD data device using a one-off manager.
+func GetOSDKey(s state.State, osd int64) (string, error) {
+	key, _, err := NewOSDManager(s).getOSDKey(osd, "")
+	if err != nil {
+		return "", err
+	}
+
+	return string(key), nil
+}
+
 // RemoveOSD removes an OSD disk
-func RemoveOSD(ctx context.Context, s interfaces.StateInterface, osd int64, bypassSafety bool, timeout int64) error {
-	err := doRemoveOSD(ctx, s, osd, bypassSafety)
+func RemoveOSD(ctx context.Context, s interfaces.StateInterface, osd int64, bypassSafety bool, timeout int64, drain bool) error {
+	err := doRemoveOSD(ctx, s, osd, bypassSafety, timeout, drain)
 	if err != nil {
 		// Checking if the error is a context deadline exceeded error
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -1034,14 +1279,20 @@ func scaleDownFailureDomain(ctx context.Context, s interfaces.StateInterface, os
 	return nil
 }
 
-// reweightOSD reweights the given OSD to the given weight
-func (m *OSDManager) reweightOSD(ctx context.Context, osd int64, weight float64) {
+// crushReweight sets the CRUSH weight of the given OSD
+func (m *OSDManager) crushReweight(osd int64, weight float64) error {
 	logger.Debugf("Reweighting osd.%d to %f", osd, weight)
 	_, err := m.runner.RunCommand(
 		"ceph", "osd", "crush", "reweight",
 		fmt.Sprintf("osd.%d", osd),
 		fmt.Sprintf("%f", weight),
 	)
+	return err
+}
+
+// reweightOSD reweights the given OSD to the given weight
+func (m *OSDManager) reweightOSD(ctx context.Context, osd int64, weight float64) {
+	err := m.crushReweight(osd, weight)
 	if err != nil {
 		// only log a warn, don't treat fail to reweight as a fatal error
 		logger.Warnf("Failed to reweight osd.%d: %v", osd, err)
@@ -1136,7 +1387,7 @@ func (m *OSDManager) timeoutWipe(path string) error {
 	return err
 }
 
-func doRemoveOSD(ctx context.Context, s interfaces.StateInterface, osd int64, bypassSafety bool) error {
+func doRemoveOSD(ctx context.Context, s interfaces.StateInterface, osd int64, bypassSafety bool, timeout int64, drain bool) error {
 	var err error
 	m := NewOSDManager(s.ClusterState())
 
@@ -1164,8 +1415,13 @@ func doRemoveOSD(ctx context.Context, s interfaces.StateInterface, osd int64, by
 	if err != nil {
 		return fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd, err)
 	}
-	// reweight/drain data
-	if isPresent {
+	// reweight/drain data, a gradual drain must have completed beforehand
+	if isPresent && drain {
+		err = checkDrained(ctx, s.ClusterState(), osd)
+		if err != nil {
+			return err
+		}
+	} else if isPresent {
 		m.reweightOSD(ctx, osd, 0)
 	}
 	// perform safety check for stopping
@@ -1207,6 +1463,12 @@ func doRemoveOSD(ctx context.Context, s interfaces.StateInterface, osd int64, by
 		logger.Errorf("Failed to clear storage for osd.%d: %v", osd, err)
 	}
 
+	err = m.reclaimSlices(ctx, osd)
+	if err != nil {
+		// log error but don't fail, the slice can be removed by hand
+		logger.Errorf("Failed to reclaim WAL/DB slices of osd.%d: %v", osd, err)
+	}
+
 	// Remove osd config
 	err = m.removeOSDConfig(osd)
 	if err != nil {
@@ -1372,7 +1634,7 @@ func (m *OSDManager) testSafeStop(osds []int64) bool {
 }
 
 func (m *OSDManager) removeOSDConfig(osd int64) error {
-	dataPath := filepath.Join(os.Getenv("SNAP_COMMON"), "data")
+	dataPath := constants.GetPathConst().DataPath
 	osdDataPath := filepath.Join(dataPath, "osd", fmt.Sprintf("ceph-%d", osd))
 	err := m.fs.RemoveAll(osdDataPath)
 	if err != nil {
@@ -1383,8 +1645,9 @@ func (m *OSDManager) removeOSDConfig(osd int64) error {
 }
 
 type Node struct {
-	ID   int64  `json:"id"`
-	Type string `json:"type"`
+	ID          int64   `json:"id"`
+	Type        string  `json:"type"`
+	CrushWeight float64 `json:"crush_weight"`
 }
 
 type JSONData struct {
@@ -1559,9 +1822,9 @@ func SetOsdState(up bool) error {
 
 	switch up {
 	case true:
-		err = snapStart("osd", true)
+		err = startService("osd", true)
 	case false:
-		err = snapStop("osd", true)
+		err = stopService("osd", true)
 	}
 
 	if err != nil {
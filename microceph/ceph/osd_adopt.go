@@ -0,0 +1,410 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/revert"
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/spf13/afero"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// BlueStore label descriptions of the main, DB and WAL devices.
+const (
+	bluestoreMainDevice = "main"
+	bluestoreDBDevice   = "bluefs db"
+	bluestoreWALDevice  = "bluefs wal"
+)
+
+// bluestoreLabel holds the fields of a BlueStore device label used for adoption.
+type bluestoreLabel struct {
+	OSDUUID     string `json:"osd_uuid"`
+	CephFsid    string `json:"ceph_fsid"`
+	Whoami      string `json:"whoami"`
+	Description string `json:"description"`
+}
+
+// adoptableOSD is a BlueStore data device found on this member which belongs to a recorded OSD.
+type adoptableOSD struct {
+	// Path holds the labelled device, i.e. the LUKS mapper for encrypted OSDs.
+	Path string
+	// Unencrypted holds the raw LUKS device, empty for unencrypted OSDs.
+	Unencrypted string
+	Label       bluestoreLabel
+}
+
+// readBluestoreLabel reads the BlueStore label of the given device.
+func (m *OSDManager) readBluestoreLabel(path string) (bluestoreLabel, error) {
+	output, err := m.runner.RunCommand("ceph-bluestore-tool", "show-label", "--dev", path)
+	if err != nil {
+		return bluestoreLabel{}, fmt.Errorf("failed to read bluestore label of %s: %w", path, err)
+	}
+
+	// show-label returns the label keyed by the queried device.
+	labels := map[string]bluestoreLabel{}
+	err = json.Unmarshal([]byte(output), &labels)
+	if err != nil {
+		return bluestoreLabel{}, fmt.Errorf("failed to parse bluestore label of %s: %w", path, err)
+	}
+
+	if len(labels) != 1 {
+		return bluestoreLabel{}, fmt.Errorf("unexpected bluestore label count (%d) for %s", len(labels), path)
+	}
+
+	for _, label := range labels {
+		return label, nil
+	}
+
+	return bluestoreLabel{}, nil
+}
+
+// isLuksDevice checks if the given device carries a LUKS header.
+func (m *OSDManager) isLuksDevice(path string) bool {
+	_, err := m.runner.RunCommand("cryptsetup", "isLuks", path)
+	return err == nil
+}
+
+// getClusterFsid fetches the fsid of the ceph cluster.
+func (m *OSDManager) getClusterFsid() (string, error) {
+	output, err := m.runner.RunCommand("ceph", "fsid")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch cluster fsid: %w", err)
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// unlockOSDDevice opens a LUKS device of the given OSD using the key stored in the cluster, the suffix
+// selects the key and mapper of the WAL (".wal") or DB (".db") device.
+func (m *OSDManager) unlockOSDDevice(path string, osdID int64, suffix string) (string, error) {
+	mapper := fmt.Sprintf("/dev/mapper/luksosd%s-%d", suffix, osdID)
+	if exists, _ := afero.Exists(m.fs, mapper); exists {
+		logger.Debugf("%s already open as %s", path, mapper)
+		return mapper, nil
+	}
+
+	key, _, err := m.getOSDKey(osdID, suffix)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch key%s for osd.%d: %w", suffix, osdID, err)
+	}
+
+	return openEncryptedDevice(path, osdID, key, suffix)
+}
+
+// luksDevice identifies the OSD and key suffix of a recorded encrypted device.
+type luksDevice struct {
+	osd    int64
+	suffix string
+}
+
+// bluestoreAuxDescriptions maps the WAL and DB device roles to their BlueStore label descriptions.
+var bluestoreAuxDescriptions = map[string]string{
+	types.DiskRoleWAL: bluestoreWALDevice,
+	types.DiskRoleDB:  bluestoreDBDevice,
+}
+
+// listAdoptionCandidates returns the stable paths of all unpartitioned disks and partitions on this machine.
+func (m *OSDManager) listAdoptionCandidates(storage *api.ResourcesStorage) []string {
+	candidates := []string{}
+	for _, disk := range storage.Disks {
+		paths := []string{}
+		if len(disk.Partitions) == 0 {
+			paths = append(paths, filepath.Join("/dev", disk.ID))
+		}
+
+		for _, part := range disk.Partitions {
+			paths = append(paths, filepath.Join("/dev", part.ID))
+		}
+
+		for _, path := range paths {
+			param := types.DiskParameter{Path: path}
+			err := m.setStablePath(storage, &param)
+			if err != nil {
+				logger.Debugf("skipping adoption candidate %s: %v", path, err)
+				continue
+			}
+			candidates = append(candidates, param.Path)
+		}
+	}
+
+	return candidates
+}
+
+// scanAdoptableOSDs labels the candidate devices and matches them against the given OSD records and their
+// recorded WAL/DB devices. Returns the data devices keyed by OSD id and the DB/WAL devices keyed by OSD uuid
+// and label description.
+func (m *OSDManager) scanAdoptableOSDs(candidates []string, records types.Disks, devices map[int64][]types.DiskDevice, fsid string) (map[int64]adoptableOSD, map[string]map[string]adoptableOSD) {
+	osds := map[int64]adoptableOSD{}
+	aux := map[string]map[string]adoptableOSD{}
+
+	pending := map[int64]bool{}
+	paths := map[string]luksDevice{}
+	for _, record := range records {
+		pending[record.OSD] = true
+		paths[record.Path] = luksDevice{osd: record.OSD}
+
+		for _, device := range devices[record.OSD] {
+			if _, ok := bluestoreAuxDescriptions[device.Role]; ok && device.Encrypted {
+				paths[device.Path] = luksDevice{osd: record.OSD, suffix: "." + device.Role}
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		device := adoptableOSD{Path: candidate}
+
+		// Encrypted devices can only be unlocked with the key of their recorded OSD.
+		if m.isLuksDevice(candidate) {
+			luks, ok := paths[candidate]
+			if !ok {
+				logger.Infof("skipping LUKS device %s, no matching disk record", candidate)
+				continue
+			}
+
+			mapper, err := m.unlockOSDDevice(candidate, luks.osd, luks.suffix)
+			if err != nil {
+				logger.Warnf("skipping LUKS device %s: %v", candidate, err)
+				continue
+			}
+
+			device.Path = mapper
+			device.Unencrypted = candidate
+		}
+
+		label, err := m.readBluestoreLabel(device.Path)
+		if err != nil {
+			logger.Debugf("skipping %s: %v", device.Path, err)
+			continue
+		}
+		device.Label = label
+
+		if label.Description != bluestoreMainDevice {
+			if _, ok := aux[label.OSDUUID]; !ok {
+				aux[label.OSDUUID] = map[string]adoptableOSD{}
+			}
+			aux[label.OSDUUID][label.Description] = device
+			continue
+		}
+
+		if label.CephFsid != fsid {
+			logger.Infof("skipping %s, belongs to foreign cluster %s", device.Path, label.CephFsid)
+			continue
+		}
+
+		osdID, err := strconv.ParseInt(label.Whoami, 10, 64)
+		if err != nil || !pending[osdID] {
+			logger.Infof("skipping %s, osd.%s has no matching disk record", device.Path, label.Whoami)
+			continue
+		}
+
+		osds[osdID] = device
+	}
+
+	return osds, aux
+}
+
+// adoptOSD recreates the data directory of an existing OSD from its devices.
+func (m *OSDManager) adoptOSD(osdID int64, device adoptableOSD, aux map[string]adoptableOSD) error {
+	osdDataPath := getOSDDataPath(osdID)
+
+	lfs, ok := m.fs.(afero.Linker)
+	if !ok {
+		return fmt.Errorf("%T doesn't support symlinks", m.fs)
+	}
+
+	err := m.fs.MkdirAll(osdDataPath, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create OSD directory: %w", err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(func() { _ = m.fs.RemoveAll(osdDataPath) })
+
+	links := map[string]string{"block": device.Path}
+	if len(device.Unencrypted) != 0 {
+		links["unencrypted"] = device.Unencrypted
+	}
+	for suffix, description := range map[string]string{".db": bluestoreDBDevice, ".wal": bluestoreWALDevice} {
+		auxDevice, ok := aux[description]
+		if !ok {
+			continue
+		}
+
+		links["block"+suffix] = auxDevice.Path
+		if len(auxDevice.Unencrypted) != 0 {
+			links["unencrypted"+suffix] = auxDevice.Unencrypted
+		}
+	}
+
+	for name, target := range links {
+		err = lfs.SymlinkIfPossible(target, filepath.Join(osdDataPath, name))
+		if err != nil {
+			return fmt.Errorf("failed to symlink %s: %w", name, err)
+		}
+	}
+
+	err = m.generateOSDFiles(osdDataPath, osdID, device.Label.OSDUUID)
+	if err != nil {
+		return err
+	}
+
+	// Populate the remaining metadata (type, whoami, ceph_fsid, ...) from the label.
+	_, err = m.runner.RunCommand("ceph-bluestore-tool", "prime-osd-dir", "--dev", device.Path, "--path", osdDataPath, "--no-mon-config")
+	if err != nil {
+		return fmt.Errorf("failed to prime OSD directory: %w", err)
+	}
+
+	err = afero.WriteFile(m.fs, filepath.Join(osdDataPath, "ready"), []byte(""), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write stamp file: %w", err)
+	}
+
+	revert.Success()
+	logger.Infof("Adopted osd.%d from %s", osdID, device.Path)
+	return nil
+}
+
+// adoptOSDs scans the local devices for OSDs recorded against this member and brings them back.
+func (m *OSDManager) adoptOSDs(ctx context.Context, dryRun bool) (types.DiskAdoptResponse, error) {
+	ret := types.DiskAdoptResponse{Reports: []types.DiskAdoptReport{}}
+
+	disks, err := database.OSDQuery.List(ctx, m.state)
+	if err != nil {
+		return ret, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	// Only OSDs of this member which are not set up locally need adoption.
+	records := types.Disks{}
+	for _, disk := range disks {
+		if disk.Location != m.state.Name() {
+			continue
+		}
+
+		ready, _ := afero.Exists(m.fs, filepath.Join(getOSDDataPath(disk.OSD), "ready"))
+		if ready {
+			logger.Debugf("osd.%d is already set up, skipping adoption", disk.OSD)
+			continue
+		}
+
+		records = append(records, disk)
+	}
+
+	if len(records) == 0 {
+		logger.Infof("no OSDs to adopt on %s", m.state.Name())
+		return ret, nil
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].OSD < records[j].OSD })
+
+	// Encrypted WAL/DB devices are unlocked with their own keys, and an OSD can't start without them.
+	devices := map[int64][]types.DiskDevice{}
+	for _, record := range records {
+		devices[record.OSD], err = database.OSDQuery.Devices(ctx, m.state, record.OSD)
+		if err != nil {
+			return ret, fmt.Errorf("failed to list the devices of osd.%d: %w", record.OSD, err)
+		}
+	}
+
+	fsid, err := m.getClusterFsid()
+	if err != nil {
+		return ret, err
+	}
+
+	storage, err := m.storage.GetStorage()
+	if err != nil {
+		return ret, fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	osds, aux := m.scanAdoptableOSDs(m.listAdoptionCandidates(storage), records, devices, fsid)
+
+	adopted := 0
+	for _, record := range records {
+		report := types.DiskAdoptReport{OSD: record.OSD, Path: record.Path}
+
+		device, ok := osds[record.OSD]
+		if !ok {
+			report.Report = "Failure"
+			report.Error = fmt.Sprintf("no device found for osd.%d", record.OSD)
+			ret.Reports = append(ret.Reports, report)
+			continue
+		}
+
+		missing := missingAuxDevices(devices[record.OSD], aux[device.Label.OSDUUID])
+		if len(missing) != 0 {
+			report.Report = "Failure"
+			report.Error = fmt.Sprintf("no %s device found for osd.%d", strings.Join(missing, " and "), record.OSD)
+			ret.Reports = append(ret.Reports, report)
+			continue
+		}
+
+		present, err := m.haveOSDInCeph(record.OSD)
+		if err != nil || !present {
+			report.Report = "Failure"
+			report.Error = fmt.Sprintf("osd.%d is not present in the cluster", record.OSD)
+			ret.Reports = append(ret.Reports, report)
+			continue
+		}
+
+		if dryRun {
+			report.Report = "Adoptable"
+			ret.Reports = append(ret.Reports, report)
+			continue
+		}
+
+		err = m.adoptOSD(record.OSD, device, aux[device.Label.OSDUUID])
+		if err != nil {
+			logger.Errorf("failed to adopt osd.%d: %v", record.OSD, err)
+			report.Report = "Failure"
+			report.Error = err.Error()
+			ret.Reports = append(ret.Reports, report)
+			continue
+		}
+
+		report.Report = "Success"
+		ret.Reports = append(ret.Reports, report)
+		adopted++
+	}
+
+	// Reload the OSD service to start the adopted OSDs.
+	if adopted != 0 {
+		err = restartService("osd", true)
+		if err != nil {
+			return ret, fmt.Errorf("failed to start adopted OSDs: %w", err)
+		}
+	}
+
+	return ret, nil
+}
+
+// missingAuxDevices returns the roles of the recorded WAL/DB devices of an OSD which weren't found.
+func missingAuxDevices(devices []types.DiskDevice, found map[string]adoptableOSD) []string {
+	missing := []string{}
+	for _, device := range devices {
+		description, ok := bluestoreAuxDescriptions[device.Role]
+		if !ok {
+			continue
+		}
+
+		if _, ok := found[description]; !ok {
+			missing = append(missing, device.Role)
+		}
+	}
+
+	return missing
+}
+
+// AdoptOSDs adopts existing OSD devices on this member using a one-off manager.
+func AdoptOSDs(ctx context.Context, s state.State, dryRun bool) (types.DiskAdoptResponse, error) {
+	return NewOSDManager(s).adoptOSDs(ctx, dryRun)
+}
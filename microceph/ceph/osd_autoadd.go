@@ -0,0 +1,366 @@
+package ceph
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// autoAddSettleTime is the time to wait for udev to settle after a new device appeared.
+var autoAddSettleTime = 5 * time.Second
+
+// ueventKernelGroup is the netlink multicast group of kernel uevents.
+const ueventKernelGroup = 1
+
+// autoAddConfigKey returns the config table key holding the auto-add policy of a member.
+func autoAddConfigKey(member string) string {
+	return fmt.Sprintf("disk_auto_add.%s", member)
+}
+
+// validateAutoAddPolicy checks the device filter of an auto-add policy.
+func validateAutoAddPolicy(policy types.DiskAutoAddPolicy) error {
+	_, err := path.Match(policy.Model, "")
+	if err != nil {
+		return fmt.Errorf("invalid model pattern %q: %w", policy.Model, err)
+	}
+
+	if policy.MaxSize != 0 && policy.MinSize > policy.MaxSize {
+		return fmt.Errorf("minimum size %d exceeds maximum size %d", policy.MinSize, policy.MaxSize)
+	}
+
+	return nil
+}
+
+// GetAutoAddPolicy fetches the auto-add policy of a member from the cluster config, auto-add is disabled by default.
+func GetAutoAddPolicy(ctx context.Context, s state.State, member string) (types.DiskAutoAddPolicy, error) {
+	policy := types.DiskAutoAddPolicy{}
+
+	config, err := GetConfigDb(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return policy, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	value, ok := config[autoAddConfigKey(member)]
+	if !ok {
+		return policy, nil
+	}
+
+	err = json.Unmarshal([]byte(value), &policy)
+	if err != nil {
+		return policy, fmt.Errorf("failed to parse auto-add policy of %s: %w", member, err)
+	}
+
+	return policy, nil
+}
+
+// SetAutoAddPolicy validates and records the auto-add policy of a member in the cluster config.
+func SetAutoAddPolicy(ctx context.Context, s state.State, member string, policy types.DiskAutoAddPolicy) error {
+	err := validateAutoAddPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal auto-add policy: %w", err)
+	}
+
+	err = s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.SetConfigItem(ctx, tx, autoAddConfigKey(member), string(value))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record auto-add policy: %w", err)
+	}
+
+	logger.Infof("Disk auto-add policy of %s set to %s", member, value)
+	return nil
+}
+
+// matchAutoAddFilter checks a device against the filter of an auto-add policy.
+// Returns the reason the device does not match, empty if it does.
+func matchAutoAddFilter(policy types.DiskAutoAddPolicy, disk api.ResourcesStorageDisk) string {
+	if len(policy.Types) != 0 && !slices.Contains(policy.Types, disk.Type) {
+		return fmt.Sprintf("type %q not in %v", disk.Type, policy.Types)
+	}
+
+	if len(policy.Model) != 0 {
+		match, _ := path.Match(policy.Model, disk.Model)
+		if !match {
+			return fmt.Sprintf("model %q does not match %q", disk.Model, policy.Model)
+		}
+	}
+
+	if policy.MinSize != 0 && disk.Size < policy.MinSize {
+		return fmt.Sprintf("size %d below %d", disk.Size, policy.MinSize)
+	}
+
+	if policy.MaxSize != 0 && disk.Size > policy.MaxSize {
+		return fmt.Sprintf("size %d above %d", disk.Size, policy.MaxSize)
+	}
+
+	return ""
+}
+
+// autoAddDevicePath returns the path a device is added by, preferring stable paths.
+func autoAddDevicePath(disk api.ResourcesStorageDisk) string {
+	if len(disk.DeviceID) > 0 {
+		return fmt.Sprintf("%s%s", constants.DevicePathPrefix, disk.DeviceID)
+	} else if len(disk.DevicePath) > 0 {
+		return fmt.Sprintf("/dev/disk/by-path/%s", disk.DevicePath)
+	}
+
+	return fmt.Sprintf("/dev/%s", disk.ID)
+}
+
+// autoAddCandidates evaluates the devices of this member against the auto-add policy, logging every decision.
+func (m *OSDManager) autoAddCandidates(policy types.DiskAutoAddPolicy, storage *api.ResourcesStorage, disks types.Disks) []types.DiskParameter {
+	configured := map[string]bool{}
+	for _, disk := range disks {
+		if disk.Location == m.state.Name() {
+			configured[disk.Path] = true
+		}
+	}
+
+	candidates := []types.DiskParameter{}
+	for _, disk := range storage.Disks {
+		devicePath := autoAddDevicePath(disk)
+
+		reason := ""
+		switch {
+		case configured[devicePath]:
+			// Already an OSD, not worth logging on every scan.
+			continue
+		case disk.ReadOnly:
+			reason = "device is read-only"
+		case disk.Removable:
+			reason = "device is removable"
+		case len(disk.Partitions) > 0:
+			reason = "device has partitions"
+		case disk.Size < constants.MinOSDSize:
+			reason = "size less than 2GB"
+		default:
+			reason = matchAutoAddFilter(policy, disk)
+		}
+
+		if len(reason) == 0 {
+			mounted, err := m.mountChecker.IsMounted(devicePath)
+			if err != nil || mounted {
+				reason = "device is mounted"
+			}
+		}
+
+		if len(reason) == 0 {
+			pristine, err := m.pristineChecker.IsPristineDisk(devicePath)
+			if err != nil || !pristine {
+				reason = "device is not pristine"
+			}
+		}
+
+		if len(reason) != 0 {
+			logger.Infof("auto-add: ignoring %s: %s", devicePath, reason)
+			continue
+		}
+
+		logger.Infof("auto-add: adding %s (%s, %s)", devicePath, disk.Type, disk.Model)
+		candidates = append(candidates, types.DiskParameter{Path: devicePath, Encrypt: policy.Encrypt})
+	}
+
+	return candidates
+}
+
+// autoAddDisks adds the devices of this member matching its auto-add policy as OSDs.
+func (m *OSDManager) autoAddDisks(ctx context.Context) error {
+	policy, err := GetAutoAddPolicy(ctx, m.state, m.state.Name())
+	if err != nil {
+		return err
+	}
+
+	if !policy.Enabled {
+		logger.Debug("auto-add: disabled, ignoring new devices")
+		return nil
+	}
+
+	storage, err := m.storage.GetStorage()
+	if err != nil {
+		return fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	disks, err := database.OSDQuery.List(ctx, m.state)
+	if err != nil {
+		return fmt.Errorf("failed to fetch disks: %w", err)
+	}
+
+	candidates := m.autoAddCandidates(policy, storage, disks)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	resp := m.addBulkDisks(ctx, candidates, nil, nil)
+	if len(resp.ValidationError) != 0 {
+		return fmt.Errorf("failed to add devices: %s", resp.ValidationError)
+	}
+
+	for _, report := range resp.Reports {
+		if len(report.Error) != 0 {
+			logger.Errorf("auto-add: failed to add %s: %s", report.Path, report.Error)
+			continue
+		}
+		logger.Infof("auto-add: added %s", report.Path)
+	}
+
+	return nil
+}
+
+// parseUevent parses a kernel uevent of the form ACTION@DEVPATH\0KEY=VALUE\0...
+func parseUevent(msg []byte) map[string]string {
+	env := map[string]string{}
+	for _, field := range bytes.Split(msg, []byte{0}) {
+		key, value, found := bytes.Cut(field, []byte("="))
+		if found {
+			env[string(key)] = string(value)
+		}
+	}
+
+	return env
+}
+
+// isNewDiskUevent checks if a uevent reports a new whole block device. Device mapper and loop devices are
+// ignored, adding OSDs creates them.
+func isNewDiskUevent(env map[string]string) bool {
+	if strings.HasPrefix(env["DEVNAME"], "dm-") || strings.HasPrefix(env["DEVNAME"], "loop") {
+		return false
+	}
+
+	return env["ACTION"] == "add" && env["SUBSYSTEM"] == "block" && env["DEVTYPE"] == "disk"
+}
+
+// requestScan requests a scan for new devices without blocking, a pending request covers any further ones.
+func requestScan(scan chan<- struct{}) {
+	select {
+	case scan <- struct{}{}:
+	default:
+	}
+}
+
+// readUevents reads kernel uevents until reading fails for good, requesting a scan for new whole block devices.
+func readUevents(ctx context.Context, read func([]byte) (int, error), scan chan<- struct{}) {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := read(buf)
+		switch {
+		case errors.Is(err, syscall.ENOBUFS):
+			// Uevents were dropped while the socket was full, any of them may have been a new device.
+			logger.Warn("auto-add: uevents dropped, rescanning devices")
+			requestScan(scan)
+			continue
+		case errors.Is(err, syscall.EINTR), errors.Is(err, syscall.EAGAIN):
+			continue
+		case err != nil:
+			if ctx.Err() == nil {
+				logger.Errorf("auto-add: failed to read uevent: %v", err)
+			}
+			return
+		}
+
+		env := parseUevent(buf[:n])
+		if isNewDiskUevent(env) {
+			logger.Infof("auto-add: new device %s", env["DEVNAME"])
+			requestScan(scan)
+		}
+	}
+}
+
+// listenUevents requests a scan on the returned channel whenever kernel uevents report new whole block devices.
+func listenUevents(ctx context.Context) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uevent socket: %w", err)
+	}
+
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: ueventKernelGroup})
+	if err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind uevent socket: %w", err)
+	}
+
+	// Unblock the reader on shutdown.
+	go func() {
+		<-ctx.Done()
+		_ = syscall.Close(fd)
+	}()
+
+	scan := make(chan struct{}, 1)
+	go func() {
+		defer close(scan)
+		readUevents(ctx, func(buf []byte) (int, error) { return syscall.Read(fd, buf) }, scan)
+	}()
+
+	return scan, nil
+}
+
+// watchDiskHotplug adds new devices of this member as OSDs as they appear, if its auto-add policy is enabled.
+func watchDiskHotplug(ctx context.Context, s state.State) {
+	for {
+		err := s.Database().IsOpen(ctx)
+		if err == nil {
+			break
+		}
+		logger.Debug("auto-add: database not ready, waiting...")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+
+	m := NewOSDManager(s)
+
+	// Pick up devices which appeared while the daemon was down.
+	err := m.autoAddDisks(ctx)
+	if err != nil {
+		logger.Errorf("auto-add: %v", err)
+	}
+
+	scan, err := listenUevents(ctx)
+	if err != nil {
+		logger.Errorf("auto-add: not watching for new devices: %v", err)
+		return
+	}
+
+	// Devices tend to appear in bursts, scan once udev has settled.
+	settle := time.NewTimer(autoAddSettleTime)
+	settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-scan:
+			if !ok {
+				return
+			}
+			settle.Reset(autoAddSettleTime)
+		case <-settle.C:
+			err = m.autoAddDisks(ctx)
+			if err != nil {
+				logger.Errorf("auto-add: %v", err)
+			}
+		}
+	}
+}
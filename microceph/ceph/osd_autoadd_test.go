@@ -0,0 +1,132 @@
+package ceph
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type AutoAddSuite struct {
+	tests.BaseSuite
+}
+
+func TestAutoAdd(t *testing.T) {
+	suite.Run(t, new(AutoAddSuite))
+}
+
+func (s *AutoAddSuite) TestParseUevent() {
+	env := parseUevent([]byte("add@/devices/pci0000:00/0000:00:1f.2/ata2/host1/target1:0:0/1:0:0:0/block/sdb\x00ACTION=add\x00SUBSYSTEM=block\x00DEVTYPE=disk\x00DEVNAME=sdb\x00"))
+	assert.Equal(s.T(), "sdb", env["DEVNAME"])
+	assert.True(s.T(), isNewDiskUevent(env))
+
+	// Partitions and removals don't trigger a scan.
+	env = parseUevent([]byte("add@/block/sdb/sdb1\x00ACTION=add\x00SUBSYSTEM=block\x00DEVTYPE=partition\x00DEVNAME=sdb1\x00"))
+	assert.False(s.T(), isNewDiskUevent(env))
+
+	env = parseUevent([]byte("remove@/block/sdb\x00ACTION=remove\x00SUBSYSTEM=block\x00DEVTYPE=disk\x00DEVNAME=sdb\x00"))
+	assert.False(s.T(), isNewDiskUevent(env))
+
+	// Nor do the mapper and loop devices OSDs are created on.
+	env = parseUevent([]byte("add@/devices/virtual/block/dm-0\x00ACTION=add\x00SUBSYSTEM=block\x00DEVTYPE=disk\x00DEVNAME=dm-0\x00"))
+	assert.False(s.T(), isNewDiskUevent(env))
+
+	env = parseUevent([]byte("add@/devices/virtual/block/loop3\x00ACTION=add\x00SUBSYSTEM=block\x00DEVTYPE=disk\x00DEVNAME=loop3\x00"))
+	assert.False(s.T(), isNewDiskUevent(env))
+}
+
+// fakeUevents returns a read function replaying the given uevents, or errors, then failing for good.
+func fakeUevents(events ...any) func([]byte) (int, error) {
+	return func(buf []byte) (int, error) {
+		if len(events) == 0 {
+			return 0, syscall.EBADF
+		}
+
+		event := events[0]
+		events = events[1:]
+		if err, ok := event.(error); ok {
+			return 0, err
+		}
+
+		return copy(buf, event.(string)), nil
+	}
+}
+
+func (s *AutoAddSuite) TestReadUevents() {
+	sdb := "add@/block/sdb\x00ACTION=add\x00SUBSYSTEM=block\x00DEVTYPE=disk\x00DEVNAME=sdb\x00"
+	sdc := "add@/block/sdc\x00ACTION=add\x00SUBSYSTEM=block\x00DEVTYPE=disk\x00DEVNAME=sdc\x00"
+	dm := "add@/devices/virtual/block/dm-0\x00ACTION=add\x00SUBSYSTEM=block\x00DEVTYPE=disk\x00DEVNAME=dm-0\x00"
+
+	// Requests are coalesced rather than blocking the reader.
+	scan := make(chan struct{}, 1)
+	readUevents(context.Background(), fakeUevents(sdb, sdc), scan)
+	assert.Len(s.T(), scan, 1)
+
+	// Dropped uevents trigger a rescan and reading goes on.
+	scan = make(chan struct{}, 2)
+	readUevents(context.Background(), fakeUevents(syscall.ENOBUFS, sdb), scan)
+	assert.Len(s.T(), scan, 2)
+
+	scan = make(chan struct{}, 1)
+	readUevents(context.Background(), fakeUevents(dm), scan)
+	assert.Len(s.T(), scan, 0)
+}
+
+func (s *AutoAddSuite) TestValidateAutoAddPolicy() {
+	assert.NoError(s.T(), validateAutoAddPolicy(types.DiskAutoAddPolicy{Model: "Samsung*", MinSize: 1, MaxSize: 2}))
+	assert.Error(s.T(), validateAutoAddPolicy(types.DiskAutoAddPolicy{Model: "Samsung["}))
+	assert.Error(s.T(), validateAutoAddPolicy(types.DiskAutoAddPolicy{MinSize: 2, MaxSize: 1}))
+}
+
+func (s *AutoAddSuite) TestMatchAutoAddFilter() {
+	disk := api.ResourcesStorageDisk{Type: "nvme", Model: "Samsung SSD 980", Size: 1000 * 1024 * 1024 * 1024}
+
+	assert.Empty(s.T(), matchAutoAddFilter(types.DiskAutoAddPolicy{}, disk))
+	assert.Empty(s.T(), matchAutoAddFilter(types.DiskAutoAddPolicy{Types: []string{"sata", "nvme"}, Model: "Samsung*"}, disk))
+	assert.Contains(s.T(), matchAutoAddFilter(types.DiskAutoAddPolicy{Types: []string{"sata"}}, disk), "type")
+	assert.Contains(s.T(), matchAutoAddFilter(types.DiskAutoAddPolicy{Model: "Intel*"}, disk), "model")
+	assert.Contains(s.T(), matchAutoAddFilter(types.DiskAutoAddPolicy{MinSize: 2000 * 1024 * 1024 * 1024}, disk), "below")
+	assert.Contains(s.T(), matchAutoAddFilter(types.DiskAutoAddPolicy{MaxSize: 500 * 1024 * 1024 * 1024}, disk), "above")
+}
+
+func (s *AutoAddSuite) TestAutoAddCandidates() {
+	size := uint64(100 * 1024 * 1024 * 1024)
+	storage := &api.ResourcesStorage{
+		Disks: []api.ResourcesStorageDisk{
+			{ID: "sda", DeviceID: "ata-configured", Type: "sata", Size: size},
+			{ID: "sdb", DeviceID: "ata-partitioned", Type: "sata", Size: size, Partitions: []api.ResourcesStorageDiskPartition{{ID: "sdb1"}}},
+			{ID: "sdc", DeviceID: "ata-small", Type: "sata", Size: 1024 * 1024},
+			{ID: "sdd", DeviceID: "usb-filtered", Type: "usb", Size: size},
+			{ID: "sde", DeviceID: "ata-used", Type: "sata", Size: size},
+			{ID: "sdf", DeviceID: "ata-new", Type: "sata", Size: size},
+		},
+	}
+	disks := types.Disks{
+		{OSD: 0, Location: "node0", Path: "/dev/disk/by-id/ata-configured"},
+	}
+
+	mountChecker := &MockMountChecker{}
+	mountChecker.On("IsMounted", "/dev/disk/by-id/ata-used").Return(false, nil)
+	mountChecker.On("IsMounted", "/dev/disk/by-id/ata-new").Return(false, nil)
+	pristineChecker := &MockPristineChecker{}
+	pristineChecker.On("IsPristineDisk", "/dev/disk/by-id/ata-used").Return(false, nil)
+	pristineChecker.On("IsPristineDisk", "/dev/disk/by-id/ata-new").Return(true, nil)
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.mountChecker = mountChecker
+	osdmgr.pristineChecker = pristineChecker
+
+	policy := types.DiskAutoAddPolicy{Enabled: true, Types: []string{"sata"}, Encrypt: true}
+	candidates := osdmgr.autoAddCandidates(policy, storage, disks)
+	assert.Equal(s.T(), []types.DiskParameter{{Path: "/dev/disk/by-id/ata-new", Encrypt: true}}, candidates)
+
+	mountChecker.AssertExpectations(s.T())
+	pristineChecker.AssertExpectations(s.T())
+}
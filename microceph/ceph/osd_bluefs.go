@@ -0,0 +1,253 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/revert"
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/spf13/afero"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// osdStopPollInterval is the interval between checks for a stopped OSD process.
+var osdStopPollInterval = time.Second
+
+// osdStopRetries is the number of checks for a stopped OSD process.
+const osdStopRetries = 30
+
+// bluefsDeviceSuffix maps the BlueFS device roles to the suffix of their OSD data directory entries.
+var bluefsDeviceSuffix = map[string]string{
+	types.DiskRoleWAL: ".wal",
+	types.DiskRoleDB:  ".db",
+}
+
+// waitForOSDStop waits for the process of a killed OSD to exit, BlueStore tools need exclusive access.
+func (m *OSDManager) waitForOSDStop(osd int64) error {
+	cmdline := fmt.Sprintf("ceph-osd .* --id %d$", osd)
+	for i := 0; i < osdStopRetries; i++ {
+		// pgrep fails once no process matches.
+		_, err := m.runner.RunCommand("pgrep", "-f", cmdline)
+		if err != nil {
+			return nil
+		}
+		time.Sleep(osdStopPollInterval)
+	}
+
+	return fmt.Errorf("osd.%d did not stop", osd)
+}
+
+// linkBluefsDevice points the OSD data directory entry of a BlueFS device at the given path.
+func (m *OSDManager) linkBluefsDevice(link string, target string) error {
+	lfs, ok := m.fs.(afero.Linker)
+	if !ok {
+		return fmt.Errorf("%T doesn't support symlinks", m.fs)
+	}
+
+	err := m.fs.Remove(link)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", link, err)
+	}
+
+	err = lfs.SymlinkIfPossible(target, link)
+	if err != nil {
+		return fmt.Errorf("failed to symlink %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// checkBluefsDevice validates the new BlueFS device and sets its stable path.
+func (m *OSDManager) checkBluefsDevice(disk *types.DiskParameter, role string) error {
+	storage, err := m.storage.GetStorage()
+	if err != nil {
+		return fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	deviceType := strings.ToUpper(role)
+	err = m.setStablePath(storage, disk)
+	if err != nil {
+		return fmt.Errorf("failed to set stable path for %s: %w", deviceType, err)
+	}
+
+	err = m.checkPartitionsOnDevice(disk, storage, deviceType)
+	if err != nil {
+		return err
+	}
+
+	return m.checkPristineDevice(disk, deviceType)
+}
+
+// migrateBluefsDevice attaches the prepared device to the stopped OSD, moving BlueFS data off the device it replaces.
+func (m *OSDManager) migrateBluefsDevice(osdDataPath string, role string, suffix string, target string, replace bool) error {
+	link := filepath.Join(osdDataPath, "block"+suffix)
+
+	if replace {
+		_, err := m.runner.RunCommand("ceph-bluestore-tool", "bluefs-bdev-migrate", "--path", osdDataPath, "--devs-source", link, "--dev-target", target)
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s to %s: %w", role, target, err)
+		}
+	} else {
+		_, err := m.runner.RunCommand("ceph-bluestore-tool", fmt.Sprintf("bluefs-bdev-new-%s", role), "--path", osdDataPath, "--dev-target", target)
+		if err != nil {
+			return fmt.Errorf("failed to attach %s device %s: %w", role, target, err)
+		}
+
+		// The DB lives on the main device until now, the WAL needs no migration.
+		if role == types.DiskRoleDB {
+			_, err = m.runner.RunCommand("ceph-bluestore-tool", "bluefs-bdev-migrate", "--path", osdDataPath, "--devs-source", filepath.Join(osdDataPath, "block"), "--dev-target", link)
+			if err != nil {
+				return fmt.Errorf("failed to migrate DB to %s: %w", target, err)
+			}
+		}
+	}
+
+	return m.linkBluefsDevice(link, target)
+}
+
+// releaseEncryptedDevice closes the LUKS mapper of a replaced device and drops its key.
+func (m *OSDManager) releaseEncryptedDevice(osdDataPath string, osd int64, suffix string) error {
+	mapper := fmt.Sprintf("luksosd%s-%d", suffix, osd)
+	_, err := m.runner.RunCommand("cryptsetup", "close", mapper)
+	if err != nil {
+		return fmt.Errorf("failed to close %s: %w", mapper, err)
+	}
+
+	err = m.fs.Remove(filepath.Join(osdDataPath, "unencrypted"+suffix))
+	if err != nil {
+		return fmt.Errorf("failed to remove unencrypted%s symlink: %w", suffix, err)
+	}
+
+	kp, err := m.getKeyProvider()
+	if err == nil {
+		err = kp.DeleteKey(osdKeyName(osd, suffix))
+	}
+	if err != nil {
+		logger.Warnf("failed to delete key of replaced device luksosd%s-%d: %v", suffix, osd, err)
+	}
+
+	return nil
+}
+
+// setBluefsDevice attaches a WAL or DB device to an existing OSD, or moves its WAL or DB onto a new device.
+// The OSD is stopped with noout set for the duration of the migration.
+func (m *OSDManager) setBluefsDevice(ctx context.Context, osd int64, role string, disk types.DiskParameter) error {
+	suffix, ok := bluefsDeviceSuffix[role]
+	if !ok {
+		return fmt.Errorf("unknown device role %q, expected %s or %s", role, types.DiskRoleWAL, types.DiskRoleDB)
+	}
+
+	path, err := database.OSDQuery.Path(ctx, m.state, osd)
+	if err != nil {
+		return fmt.Errorf("failed to find osd.%d: %w", osd, err)
+	}
+
+	osdDataPath := getOSDDataPath(osd)
+	if ready, _ := afero.Exists(m.fs, filepath.Join(osdDataPath, "ready")); !ready {
+		return fmt.Errorf("osd.%d is not hosted on this node", osd)
+	}
+
+	if !m.validator.IsBlockdevPath(path) {
+		return fmt.Errorf("osd.%d is backed by a loop file, %s devices are not supported", osd, role)
+	}
+
+	replace, _ := afero.Exists(m.fs, filepath.Join(osdDataPath, "block"+suffix))
+	oldEncrypted, _ := afero.Exists(m.fs, filepath.Join(osdDataPath, "unencrypted"+suffix))
+	if oldEncrypted && disk.Encrypt {
+		return fmt.Errorf("osd.%d already has an encrypted %s device, replacing it with another encrypted device is not supported", osd, role)
+	}
+
+	err = m.checkBluefsDevice(&disk, role)
+	if err != nil {
+		return err
+	}
+	stablePath := disk.Path
+
+	err = m.safetyCheckStop([]int64{osd})
+	if err != nil {
+		return err
+	}
+
+	isSet, err := isOsdNooutSet()
+	if err != nil {
+		return err
+	}
+
+	if !isSet {
+		err = setOsdNooutFlag(true)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = setOsdNooutFlag(false) }()
+	}
+
+	logger.Infof("Stopping osd.%d to set its %s device to %s", osd, role, stablePath)
+	err = m.killOSD(osd)
+	if err != nil {
+		return err
+	}
+
+	// Bring the OSD back up whatever the outcome.
+	defer func() {
+		err := m.spawnOSD(osd)
+		if err != nil {
+			logger.Errorf("failed to restart osd.%d: %v", osd, err)
+		}
+	}()
+
+	err = m.waitForOSDStop(osd)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	if disk.Encrypt {
+		revert.Add(func() { _ = m.fs.Remove(filepath.Join(osdDataPath, "unencrypted"+suffix)) })
+	}
+
+	err = m.prepareDisk(&disk, suffix, osdDataPath, osd)
+	if err != nil {
+		return fmt.Errorf("failed to set up %s device: %w", role, err)
+	}
+
+	if disk.Encrypt {
+		revert.Add(func() { _, _ = m.runner.RunCommand("cryptsetup", "close", fmt.Sprintf("luksosd%s-%d", suffix, osd)) })
+	}
+
+	err = m.migrateBluefsDevice(osdDataPath, role, suffix, disk.Path, replace)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+
+	if oldEncrypted {
+		err = m.releaseEncryptedDevice(osdDataPath, osd, suffix)
+		if err != nil {
+			logger.Warnf("failed to release replaced %s device of osd.%d: %v", role, osd, err)
+		}
+	}
+
+	err = database.OSDQuery.SetDevice(ctx, m.state, types.DiskDevice{OSD: osd, Role: role, Path: stablePath, Encrypted: disk.Encrypt})
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Set %s device of osd.%d to %s", role, osd, stablePath)
+	return nil
+}
+
+// SetBluefsDevice attaches or replaces the WAL or DB device of an OSD using a one-off manager.
+func SetBluefsDevice(ctx context.Context, s state.State, osd int64, data types.DisksSetDevice) error {
+	disk := types.DiskParameter{Path: data.Path, Wipe: data.Wipe, Encrypt: data.Encrypt}
+	return NewOSDManager(s).setBluefsDevice(ctx, osd, data.Role, disk)
+}
@@ -0,0 +1,280 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// drainInterval is the interval between progress checks of the OSD drains of a member.
+var drainInterval = 10 * time.Second
+
+// drainSteps is the number of steps an OSD is drained in unless a step is given.
+const drainSteps = 10
+
+// drainEpsilon is the CRUSH weight below which an OSD counts as drained.
+const drainEpsilon = 0.0001
+
+// drainSettleTime is how long after a step placement groups reported active+clean are not trusted, unless
+// they were seen recovering since. PG states are reported with a delay and may predate the step.
+var drainSettleTime = time.Minute
+
+// getCrushWeight returns the CRUSH weight of the given OSD.
+func (m *OSDManager) getCrushWeight(osd int64) (float64, error) {
+	out, err := m.runner.RunCommand("ceph", "osd", "tree", "-f", "json")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ceph osd tree: %w", err)
+	}
+
+	var tree JSONData
+	err = json.Unmarshal([]byte(out), &tree)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ceph osd tree: %w", err)
+	}
+
+	for _, node := range tree.Nodes {
+		if node.Type == "osd" && node.ID == osd {
+			return node.CrushWeight, nil
+		}
+	}
+
+	return 0, fmt.Errorf("osd.%d not found in the CRUSH map", osd)
+}
+
+// nextDrainWeight returns the CRUSH weight after the next drain step.
+func nextDrainWeight(weight float64, step float64) float64 {
+	next := weight - step
+	if next < drainEpsilon {
+		return 0
+	}
+
+	return next
+}
+
+// advanceDrain lowers the CRUSH weight of a draining OSD by one step once all placement groups are active+clean.
+// recovering tracks the drains whose placement groups were seen recovering since their last step.
+func (m *OSDManager) advanceDrain(drain *types.DiskDrain, recovering map[int64]bool) error {
+	clean, err := m.arePGsClean()
+	if err != nil {
+		// Retried on the next round.
+		logger.Warnf("failed to check pg states while draining osd.%d: %v", drain.OSD, err)
+		return nil
+	}
+	if !clean {
+		recovering[drain.OSD] = true
+		return nil
+	}
+
+	if !recovering[drain.OSD] && time.Since(drain.UpdatedAt) < drainSettleTime {
+		return nil
+	}
+
+	if drain.Weight < drainEpsilon {
+		logger.Infof("osd.%d drained", drain.OSD)
+		drain.Status = types.DiskDrainDone
+		return nil
+	}
+
+	weight := nextDrainWeight(drain.Weight, drain.Step)
+	err = m.crushReweight(drain.OSD, weight)
+	if err != nil {
+		return fmt.Errorf("failed to reweight osd.%d: %w", drain.OSD, err)
+	}
+
+	logger.Infof("Draining osd.%d: CRUSH weight %f of %f", drain.OSD, weight, drain.InitialWeight)
+	drain.Weight = weight
+	delete(recovering, drain.OSD)
+	return nil
+}
+
+// runDrains advances the running drains of the OSDs hosted on this member.
+func (m *OSDManager) runDrains(ctx context.Context, recovering map[int64]bool) error {
+	drains, err := database.OSDQuery.Drains(ctx, m.state)
+	if err != nil {
+		return fmt.Errorf("failed to fetch drains: %w", err)
+	}
+
+	disks, err := database.OSDQuery.List(ctx, m.state)
+	if err != nil {
+		return fmt.Errorf("failed to fetch disks: %w", err)
+	}
+
+	local := map[int64]bool{}
+	for _, disk := range disks {
+		local[disk.OSD] = disk.Location == m.state.Name()
+	}
+
+	for _, drain := range drains {
+		if drain.Status != types.DiskDrainRunning || !local[drain.OSD] {
+			continue
+		}
+
+		weight := drain.Weight
+		err = m.advanceDrain(&drain, recovering)
+		if err != nil {
+			logger.Errorf("drain: %v", err)
+			drain.Status = types.DiskDrainFailed
+			drain.Error = err.Error()
+		} else if drain.Status == types.DiskDrainRunning && drain.Weight == weight {
+			// Still waiting for recovery.
+			continue
+		}
+
+		drain.UpdatedAt = time.Now().UTC()
+		err = database.OSDQuery.SetDrain(ctx, m.state, drain)
+		if err != nil {
+			logger.Errorf("failed to record drain of osd.%d: %v", drain.OSD, err)
+		}
+	}
+
+	return nil
+}
+
+// monitorDrains advances the drains of the OSDs hosted on this member, drains survive restarts of the daemon.
+func monitorDrains(ctx context.Context, s state.State) {
+	m := NewOSDManager(s)
+	recovering := map[int64]bool{}
+	for {
+		err := s.Database().IsOpen(ctx)
+		if err != nil {
+			logger.Debug("drain: database not ready, waiting...")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+
+		err = m.runDrains(ctx, recovering)
+		if err != nil {
+			logger.Warnf("drain: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(drainInterval):
+		}
+	}
+}
+
+// checkDrained checks that the drain of an OSD has completed.
+func checkDrained(ctx context.Context, s state.State, osd int64) error {
+	drain, err := database.OSDQuery.Drain(ctx, s, osd)
+	if err != nil {
+		return err
+	}
+
+	if drain.Status != types.DiskDrainDone {
+		return fmt.Errorf("osd.%d is not drained, drain it first with \"microceph disk drain %d --wait\"", osd, osd)
+	}
+
+	return nil
+}
+
+// StartDrain starts the gradual drain of an OSD, step is the CRUSH weight removed per step.
+func StartDrain(ctx context.Context, s state.State, osd int64, step float64) (types.DiskDrain, error) {
+	if step < 0 {
+		return types.DiskDrain{}, fmt.Errorf("drain step must not be negative")
+	}
+
+	drain, err := database.OSDQuery.Drain(ctx, s, osd)
+	if err != nil {
+		return drain, err
+	}
+	if drain.Status == types.DiskDrainRunning || drain.Status == types.DiskDrainPaused {
+		return drain, fmt.Errorf("osd.%d is already being drained", osd)
+	}
+
+	weight, err := NewOSDManager(s).getCrushWeight(osd)
+	if err != nil {
+		return drain, err
+	}
+	if weight < drainEpsilon {
+		return drain, fmt.Errorf("osd.%d has no CRUSH weight left to drain", osd)
+	}
+
+	if step == 0 {
+		step = weight / drainSteps
+	}
+
+	drain = types.DiskDrain{
+		OSD:           osd,
+		Status:        types.DiskDrainRunning,
+		InitialWeight: weight,
+		Weight:        weight,
+		Step:          step,
+		UpdatedAt:     time.Now().UTC(),
+	}
+
+	err = database.OSDQuery.SetDrain(ctx, s, drain)
+	if err != nil {
+		return drain, err
+	}
+
+	logger.Infof("Draining osd.%d from CRUSH weight %f in steps of %f", osd, weight, step)
+	return drain, nil
+}
+
+// PauseDrain pauses the drain of an OSD after its current step.
+func PauseDrain(ctx context.Context, s state.State, osd int64) (types.DiskDrain, error) {
+	drain, err := database.OSDQuery.Drain(ctx, s, osd)
+	if err != nil {
+		return drain, err
+	}
+	if drain.Status != types.DiskDrainRunning {
+		return drain, fmt.Errorf("osd.%d is not being drained", osd)
+	}
+
+	drain.Status = types.DiskDrainPaused
+	drain.UpdatedAt = time.Now().UTC()
+	err = database.OSDQuery.SetDrain(ctx, s, drain)
+	if err != nil {
+		return drain, err
+	}
+
+	logger.Infof("Paused drain of osd.%d", osd)
+	return drain, nil
+}
+
+// ResumeDrain resumes a paused or failed drain of an OSD from its current CRUSH weight.
+func ResumeDrain(ctx context.Context, s state.State, osd int64) (types.DiskDrain, error) {
+	drain, err := database.OSDQuery.Drain(ctx, s, osd)
+	if err != nil {
+		return drain, err
+	}
+	if !slices.Contains([]string{types.DiskDrainPaused, types.DiskDrainFailed}, drain.Status) {
+		return drain, fmt.Errorf("osd.%d has no paused or failed drain", osd)
+	}
+
+	// The weight may have been changed by hand in the meantime.
+	drain.Weight, err = NewOSDManager(s).getCrushWeight(osd)
+	if err != nil {
+		return drain, err
+	}
+
+	drain.Status = types.DiskDrainRunning
+	drain.Error = ""
+	drain.UpdatedAt = time.Now().UTC()
+	err = database.OSDQuery.SetDrain(ctx, s, drain)
+	if err != nil {
+		return drain, err
+	}
+
+	logger.Infof("Resumed drain of osd.%d at CRUSH weight %f", osd, drain.Weight)
+	return drain, nil
+}
+
+// GetDrain returns the drain progress of an OSD, the status is empty if the OSD was never drained.
+func GetDrain(ctx context.Context, s state.State, osd int64) (types.DiskDrain, error) {
+	return database.OSDQuery.Drain(ctx, s, osd)
+}
@@ -0,0 +1,157 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+const (
+	drainTree   = `{"nodes":[{"id":-1,"name":"default","type":"root"},{"crush_weight":1.0,"id":3,"name":"osd.3","type":"osd"}],"stray":[]}`
+	cleanPGs    = `{"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":2}],"num_pgs":2}}`
+	backfillPGs = `{"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":1},{"name":"active+remapped+backfilling","num":1}],"num_pgs":2}}`
+)
+
+type DrainSuite struct {
+	tests.BaseSuite
+}
+
+func TestDrain(t *testing.T) {
+	suite.Run(t, new(DrainSuite))
+}
+
+func (s *DrainSuite) TestGetCrushWeight() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "tree", "-f", "json").Return(drainTree, nil).Twice()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	weight, err := osdmgr.getCrushWeight(3)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1.0, weight)
+
+	_, err = osdmgr.getCrushWeight(4)
+	assert.ErrorContains(s.T(), err, "osd.4 not found")
+}
+
+func (s *DrainSuite) TestNextDrainWeight() {
+	assert.InDelta(s.T(), 0.7, nextDrainWeight(1.0, 0.3), drainEpsilon)
+	assert.Equal(s.T(), 0.0, nextDrainWeight(0.1, 0.3))
+	// Rounding leftovers don't cost an extra step.
+	assert.Equal(s.T(), 0.0, nextDrainWeight(0.1, 0.09999999))
+}
+
+func (s *DrainSuite) TestAdvanceDrain() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(backfillPGs, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(cleanPGs, nil).Times(3)
+	r.On("RunCommand", "ceph", "osd", "crush", "reweight", "osd.3", "0.500000").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "reweight", "osd.3", "0.000000").Return("", fmt.Errorf("timed out")).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	drain := types.DiskDrain{OSD: 3, Status: types.DiskDrainRunning, InitialWeight: 1.0, Weight: 1.0, Step: 0.5}
+	recovering := map[int64]bool{}
+
+	// Waits for recovery.
+	err := osdmgr.advanceDrain(&drain, recovering)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1.0, drain.Weight)
+
+	err = osdmgr.advanceDrain(&drain, recovering)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 0.5, drain.Weight)
+
+	err = osdmgr.advanceDrain(&drain, recovering)
+	assert.ErrorContains(s.T(), err, "failed to reweight osd.3")
+	assert.Equal(s.T(), 0.5, drain.Weight)
+
+	// Done once the placement groups are clean at weight 0.
+	drain.Weight = 0
+	err = osdmgr.advanceDrain(&drain, recovering)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), types.DiskDrainDone, drain.Status)
+}
+
+func (s *DrainSuite) TestAdvanceDrainSettle() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(cleanPGs, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(backfillPGs, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(cleanPGs, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "reweight", "osd.3", "0.000000").Return("", nil).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	drain := types.DiskDrain{OSD: 3, Status: types.DiskDrainRunning, InitialWeight: 1.0, Weight: 0.5, Step: 0.5, UpdatedAt: time.Now()}
+	recovering := map[int64]bool{}
+
+	// Clean placement groups right after a step may predate it.
+	err := osdmgr.advanceDrain(&drain, recovering)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 0.5, drain.Weight)
+
+	// Recovery was seen, clean placement groups can be trusted.
+	err = osdmgr.advanceDrain(&drain, recovering)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), recovering[3])
+
+	err = osdmgr.advanceDrain(&drain, recovering)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 0.0, drain.Weight)
+	assert.False(s.T(), recovering[3])
+}
+
+func (s *DrainSuite) TestRunDrains() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(cleanPGs, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "reweight", "osd.1", "0.750000").Return("", nil).Once()
+
+	// Only running drains of local OSDs are advanced.
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("Drains", mock.Anything, mock.Anything).Return([]types.DiskDrain{
+		{OSD: 1, Status: types.DiskDrainRunning, InitialWeight: 1.0, Weight: 1.0, Step: 0.25},
+		{OSD: 2, Status: types.DiskDrainPaused, InitialWeight: 1.0, Weight: 0.5, Step: 0.25},
+		{OSD: 3, Status: types.DiskDrainRunning, InitialWeight: 1.0, Weight: 0.5, Step: 0.25},
+	}, nil).Once()
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 1, Location: "node0"},
+		{OSD: 2, Location: "node0"},
+		{OSD: 3, Location: "node1"},
+	}, nil).Once()
+	q.On("SetDrain", mock.Anything, mock.Anything, mock.MatchedBy(func(drain types.DiskDrain) bool {
+		return drain.OSD == 1 && drain.Weight == 0.75 && drain.Status == types.DiskDrainRunning
+	})).Return(nil).Once()
+	database.OSDQuery = q
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+
+	err := osdmgr.runDrains(context.Background(), map[int64]bool{})
+	assert.NoError(s.T(), err)
+}
+
+func (s *DrainSuite) TestCheckDrained() {
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("Drain", mock.Anything, mock.Anything, int64(3)).Return(types.DiskDrain{OSD: 3, Status: types.DiskDrainRunning}, nil).Once()
+	q.On("Drain", mock.Anything, mock.Anything, int64(3)).Return(types.DiskDrain{OSD: 3, Status: types.DiskDrainDone}, nil).Once()
+	database.OSDQuery = q
+
+	err := checkDrained(context.Background(), nil, 3)
+	assert.ErrorContains(s.T(), err, "osd.3 is not drained")
+
+	err = checkDrained(context.Background(), nil, 3)
+	assert.NoError(s.T(), err)
+}
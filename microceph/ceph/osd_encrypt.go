@@ -0,0 +1,298 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/pborman/uuid"
+	"github.com/spf13/afero"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// pgPollInterval is the interval between placement group state checks.
+var pgPollInterval = 5 * time.Second
+
+// pgStat holds the relevant fields of the ceph pg stat output.
+type pgStat struct {
+	PGSummary struct {
+		NumPGByState []struct {
+			Name string `json:"name"`
+			Num  int    `json:"num"`
+		} `json:"num_pg_by_state"`
+		NumPGs int `json:"num_pgs"`
+	} `json:"pg_summary"`
+}
+
+// arePGsClean checks if all placement groups are active+clean.
+func (m *OSDManager) arePGsClean() (bool, error) {
+	output, err := m.runner.RunCommand("ceph", "pg", "stat", "--format", "json")
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch pg stat: %w", err)
+	}
+
+	var stat pgStat
+	err = json.Unmarshal([]byte(output), &stat)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse pg stat: %w", err)
+	}
+
+	clean := 0
+	for _, state := range stat.PGSummary.NumPGByState {
+		if state.Name == "active+clean" {
+			clean += state.Num
+		}
+	}
+
+	return clean == stat.PGSummary.NumPGs, nil
+}
+
+// waitForCleanPGs waits until all placement groups are active+clean or the timeout (seconds) expires.
+func (m *OSDManager) waitForCleanPGs(ctx context.Context, timeout int64) error {
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for {
+		clean, err := m.arePGsClean()
+		if err != nil {
+			logger.Warnf("failed to check pg states: %v", err)
+		} else if clean {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("placement groups not active+clean after %ds", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pgPollInterval):
+		}
+	}
+}
+
+// waitForSafeDestroy waits until the OSD is safe to destroy or the timeout (seconds) expires.
+func (m *OSDManager) waitForSafeDestroy(ctx context.Context, osd int64, timeout int64) error {
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for !m.testSafeDestroy(osd) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("osd.%d not safe to destroy after %ds", osd, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pgPollInterval):
+		}
+	}
+
+	logger.Infof("osd.%d safe to destroy", osd)
+	return nil
+}
+
+// checkEncryptable checks that an OSD hosted on this member can be converted to encrypted.
+func (m *OSDManager) checkEncryptable(osdDataPath string, path string, osd int64) error {
+	if exists, _ := afero.Exists(m.fs, osdDataPath); !exists {
+		return fmt.Errorf("osd.%d is not hosted on this node", osd)
+	}
+
+	encrypted, _ := afero.Exists(m.fs, filepath.Join(osdDataPath, "unencrypted"))
+	ready, _ := afero.Exists(m.fs, filepath.Join(osdDataPath, "ready"))
+	if encrypted && ready {
+		return fmt.Errorf("osd.%d is already encrypted", osd)
+	}
+
+	for _, name := range []string{"block.wal", "block.db"} {
+		if exists, _ := afero.Exists(m.fs, filepath.Join(osdDataPath, name)); exists {
+			return fmt.Errorf("osd.%d has a separate %s device, in place encryption is not supported", osd, name)
+		}
+	}
+
+	if !m.validator.IsBlockdevPath(path) {
+		return fmt.Errorf("osd.%d is not backed by a block device (%s)", osd, path)
+	}
+
+	return nil
+}
+
+// recreateEncryptedOSD wipes the OSD device and bootstraps it again on top of LUKS, keeping the OSD id.
+func (m *OSDManager) recreateEncryptedOSD(ctx context.Context, osdDataPath string, path string, osd int64) error {
+	// Close a mapper left behind by an earlier, interrupted conversion.
+	mapper := fmt.Sprintf("luksosd-%d", osd)
+	if exists, _ := afero.Exists(m.fs, filepath.Join("/dev/mapper", mapper)); exists {
+		_, err := m.runner.RunCommand("cryptsetup", "close", mapper)
+		if err != nil {
+			return fmt.Errorf("failed to close %s: %w", mapper, err)
+		}
+	}
+
+	err := m.fs.RemoveAll(osdDataPath)
+	if err != nil {
+		return fmt.Errorf("failed to clear OSD directory: %w", err)
+	}
+
+	err = m.fs.MkdirAll(osdDataPath, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create OSD directory: %w", err)
+	}
+
+	disk := types.DiskParameter{Path: path, Encrypt: true, Wipe: true}
+	err = m.prepareDisk(&disk, "", osdDataPath, osd)
+	if err != nil {
+		return fmt.Errorf("failed to prepare data device: %w", err)
+	}
+
+	osdUUID := uuid.NewRandom().String()
+	err = m.generateOSDFiles(osdDataPath, osd, osdUUID)
+	if err != nil {
+		return err
+	}
+
+	// Bring the destroyed id back with the new OSD uuid.
+	_, err = m.runner.RunCommand("ceph", "osd", "new", osdUUID, fmt.Sprintf("%d", osd))
+	if err != nil {
+		return fmt.Errorf("failed to recreate osd.%d: %w", osd, err)
+	}
+
+	return m.bootstrapOSD(ctx, osdDataPath, osd, nil, nil, nil)
+}
+
+// encryptOSD converts an unencrypted OSD to an encrypted one in place, keeping its id and disk record.
+// The OSD is drained first unless noout is requested, in which case it is only stopped for the conversion.
+// Draining and recovery together are bounded by twice the timeout (seconds), as the client waits for.
+func (m *OSDManager) encryptOSD(ctx context.Context, osd int64, noout bool, timeout int64) error {
+	deadline := time.Now().Add(time.Duration(2*timeout) * time.Second)
+
+	path, err := database.OSDQuery.Path(ctx, m.state, osd)
+	if err != nil {
+		return fmt.Errorf("failed to find osd.%d: %w", osd, err)
+	}
+
+	osdDataPath := getOSDDataPath(osd)
+	err = m.checkEncryptable(osdDataPath, path, osd)
+	if err != nil {
+		return err
+	}
+
+	err = m.checkEncryptSupport()
+	if err != nil {
+		return fmt.Errorf("encryption unsupported on this machine: %w", err)
+	}
+
+	isPresent, err := m.haveOSDInCeph(osd)
+	if err != nil {
+		return fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd, err)
+	}
+	if !isPresent {
+		return fmt.Errorf("osd.%d is not present in Ceph", osd)
+	}
+
+	// Only clear a noout flag set by this conversion.
+	unsetNoout := false
+	defer func() {
+		if unsetNoout {
+			_ = setOsdNooutFlag(false)
+		}
+	}()
+
+	// Take a drained OSD back in if the conversion fails before it is destroyed.
+	markIn := false
+	defer func() {
+		if markIn {
+			_, err := m.runner.RunCommand("ceph", "osd", "in", fmt.Sprintf("osd.%d", osd))
+			if err != nil {
+				logger.Errorf("failed to take osd.%d back in: %v", osd, err)
+			}
+		}
+	}()
+
+	if noout {
+		err = m.safetyCheckStop([]int64{osd})
+		if err != nil {
+			return err
+		}
+
+		isSet, err := isOsdNooutSet()
+		if err != nil {
+			return err
+		}
+
+		if !isSet {
+			err = setOsdNooutFlag(true)
+			if err != nil {
+				return err
+			}
+			unsetNoout = true
+		}
+	} else {
+		logger.Infof("Draining osd.%d for encryption", osd)
+		_, err = m.runner.RunCommand("ceph", "osd", "out", fmt.Sprintf("osd.%d", osd))
+		if err != nil {
+			return fmt.Errorf("failed to take osd.%d out: %w", osd, err)
+		}
+		markIn = true
+
+		err = m.waitForSafeDestroy(ctx, osd, timeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The OSD may already be stopped when re-running an interrupted conversion.
+	_ = m.killOSD(osd)
+	_, err = m.runner.RunCommand("ceph", "osd", "down", fmt.Sprintf("osd.%d", osd))
+	if err != nil {
+		return fmt.Errorf("failed to take osd.%d down: %w", osd, err)
+	}
+
+	_, err = m.runner.RunCommand("ceph", "osd", "destroy", fmt.Sprintf("osd.%d", osd), "--yes-i-really-mean-it")
+	if err != nil {
+		return fmt.Errorf("failed to destroy osd.%d: %w", osd, err)
+	}
+
+	// Past this point the OSD comes back in once recreated, a failed conversion is retried by re-running it.
+	markIn = false
+
+	err = m.recreateEncryptedOSD(ctx, osdDataPath, path, osd)
+	if err != nil {
+		return fmt.Errorf("failed to recreate osd.%d encrypted, re-run to retry: %w", osd, err)
+	}
+
+	err = m.spawnOSD(osd)
+	if err != nil {
+		return err
+	}
+
+	if unsetNoout {
+		unsetNoout = false
+		err = setOsdNooutFlag(false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !noout {
+		_, err = m.runner.RunCommand("ceph", "osd", "in", fmt.Sprintf("osd.%d", osd))
+		if err != nil {
+			return fmt.Errorf("failed to take osd.%d in: %w", osd, err)
+		}
+	}
+
+	remaining := int64(time.Until(deadline).Seconds())
+	if remaining <= 0 {
+		return fmt.Errorf("osd.%d encrypted, timeout reached before recovery completed", osd)
+	}
+
+	logger.Infof("osd.%d encrypted, waiting for recovery", osd)
+	return m.waitForCleanPGs(ctx, remaining)
+}
+
+// EncryptOSD converts an unencrypted OSD to an encrypted one in place using a one-off manager.
+func EncryptOSD(ctx context.Context, s state.State, osd int64, noout bool, timeout int64) error {
+	return NewOSDManager(s).encryptOSD(ctx, osd, noout, timeout)
+}
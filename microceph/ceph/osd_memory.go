@@ -0,0 +1,248 @@
+package ceph
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+const (
+	// osdMemoryTargetMin is the lowest osd_memory_target Ceph accepts.
+	osdMemoryTargetMin = uint64(896 * 1024 * 1024)
+	// osdMemorySystemReserve is the memory left to the OS and MicroCeph itself on every member.
+	osdMemorySystemReserve = uint64(2 * 1024 * 1024 * 1024)
+)
+
+// serviceMemoryReserve is the memory set aside for each of the other daemons on a member.
+var serviceMemoryReserve = map[string]uint64{
+	"mon": 2 * 1024 * 1024 * 1024,
+	"mgr": 1 * 1024 * 1024 * 1024,
+	"mds": 4 * 1024 * 1024 * 1024,
+	"rgw": 1 * 1024 * 1024 * 1024,
+}
+
+// osdMemoryInterval is the interval between checks of the OSD memory targets of a member.
+var osdMemoryInterval = 30 * time.Second
+
+// readMemTotal returns the total memory of the host in bytes.
+// Patch-able for testing purposes.
+var readMemTotal = func() (uint64, error) {
+	file, err := os.Open(filepath.Join(constants.GetPathConst().ProcPath, "meminfo"))
+	if err != nil {
+		return 0, err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "MemTotal:" && fields[2] == "kB" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid MemTotal %s: %w", fields[1], err)
+			}
+
+			return kb * 1024, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no MemTotal in meminfo")
+}
+
+// osdMemoryLayout holds what the OSD memory targets of a member are computed from.
+type osdMemoryLayout struct {
+	Services []string
+	OSDs     []int64
+	// AllOSDs are the OSDs of the whole cluster.
+	AllOSDs []int64
+}
+
+// computeOSDMemoryTarget splits the memory left by the other daemons of a member between its OSDs. It
+// returns the target, and whether the memory falls short of the lowest target Ceph accepts.
+func computeOSDMemoryTarget(total uint64, services []string, osds int) (uint64, bool) {
+	reserved := osdMemorySystemReserve
+	for _, service := range services {
+		reserved += serviceMemoryReserve[service]
+	}
+
+	if osds == 0 || total <= reserved {
+		return osdMemoryTargetMin, true
+	}
+
+	target := (total - reserved) / uint64(osds)
+	if target < osdMemoryTargetMin {
+		return osdMemoryTargetMin, true
+	}
+
+	return target, false
+}
+
+// getOSDMemoryLayout returns the services and OSDs of the member.
+func getOSDMemoryLayout(ctx context.Context, s state.State) (osdMemoryLayout, error) {
+	layout := osdMemoryLayout{Services: []string{}, OSDs: []int64{}, AllOSDs: []int64{}}
+
+	member := s.Name()
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		services, err := database.GetServices(ctx, tx, database.ServiceFilter{Member: &member})
+		if err != nil {
+			return err
+		}
+
+		for _, service := range services {
+			layout.Services = append(layout.Services, service.Service)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return layout, fmt.Errorf("failed to list the services of %s: %w", member, err)
+	}
+
+	disks, err := database.OSDQuery.List(ctx, s)
+	if err != nil {
+		return layout, fmt.Errorf("failed to list OSDs: %w", err)
+	}
+
+	for _, disk := range disks {
+		layout.AllOSDs = append(layout.AllOSDs, disk.OSD)
+		if disk.Location == member {
+			layout.OSDs = append(layout.OSDs, disk.OSD)
+		}
+	}
+
+	slices.Sort(layout.Services)
+	slices.Sort(layout.OSDs)
+	slices.Sort(layout.AllOSDs)
+	return layout, nil
+}
+
+// updateOSDMemoryTargets sets the osd_memory_target of each OSD of the member, unless the target is set
+// for all OSDs through the cluster config, and clears it for OSDs no longer in the cluster.
+func updateOSDMemoryTargets(layout osdMemoryLayout) error {
+	dump, err := dumpConfigs()
+	if err != nil {
+		return err
+	}
+
+	current := map[string]string{}
+	manual := false
+	for _, item := range dump {
+		if item.Name != "osd_memory_target" {
+			continue
+		}
+
+		current[item.Section] = item.Value
+		if item.Section == "global" || item.Section == "osd" {
+			manual = true
+		}
+
+		// OSDs removed from the cluster keep their settings in the monitor config database.
+		id, err := strconv.ParseInt(strings.TrimPrefix(item.Section, "osd."), 10, 64)
+		if err == nil && strings.HasPrefix(item.Section, "osd.") && !slices.Contains(layout.AllOSDs, id) {
+			err = removeConfigItem(item.Section, item.Name)
+			if err != nil {
+				return fmt.Errorf("failed to clear osd_memory_target of %s: %w", item.Section, err)
+			}
+		}
+	}
+
+	if len(layout.OSDs) == 0 {
+		return nil
+	}
+
+	target := ""
+	short := false
+	if !manual {
+		total, err := readMemTotal()
+		if err != nil {
+			return fmt.Errorf("failed to read the memory of the host: %w", err)
+		}
+
+		var value uint64
+		value, short = computeOSDMemoryTarget(total, layout.Services, len(layout.OSDs))
+		target = strconv.FormatUint(value, 10)
+	}
+
+	for _, osd := range layout.OSDs {
+		section := fmt.Sprintf("osd.%d", osd)
+		value, ok := current[section]
+
+		// A target set for all OSDs takes over, drop the one computed per OSD.
+		if manual {
+			if !ok {
+				continue
+			}
+
+			err = removeConfigItem(section, "osd_memory_target")
+			if err != nil {
+				return fmt.Errorf("failed to clear osd_memory_target of %s: %w", section, err)
+			}
+
+			continue
+		}
+
+		if value == target {
+			continue
+		}
+
+		if short {
+			logger.Warnf("Memory is short for %d OSDs next to %v, the memory target of %s is set to the minimum", len(layout.OSDs), layout.Services, section)
+		}
+
+		err = setConfigItem(section, types.Config{Key: "osd_memory_target", Value: target})
+		if err != nil {
+			return fmt.Errorf("failed to set osd_memory_target of %s: %w", section, err)
+		}
+
+		logger.Infof("Set osd_memory_target of %s to %s", section, target)
+	}
+
+	return nil
+}
+
+// monitorOSDMemory keeps the memory target of the OSDs of this member sized for its services and OSDs. The
+// targets are checked on every run, as targets set or removed for all OSDs change them too.
+func monitorOSDMemory(ctx context.Context, s state.State) {
+	for {
+		err := s.Database().IsOpen(ctx)
+		if err != nil {
+			logger.Debug("osd memory: database not ready, waiting...")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+
+		layout, err := getOSDMemoryLayout(ctx, s)
+		if err == nil {
+			err = updateOSDMemoryTargets(layout)
+		}
+
+		if err != nil {
+			logger.Warnf("osd memory: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(osdMemoryInterval):
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type osdMemorySuite struct {
+	tests.BaseSuite
+
+	readMemTotal func() (uint64, error)
+}
+
+func TestOSDMemory(t *testing.T) {
+	suite.Run(t, new(osdMemorySuite))
+}
+
+func (s *osdMemorySuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.readMemTotal = readMemTotal
+}
+
+func (s *osdMemorySuite) TearDownTest() {
+	readMemTotal = s.readMemTotal
+	s.BaseSuite.TearDownTest()
+}
+
+// patchMemTotal makes the host appear to have 16GiB of memory.
+func patchMemTotal() {
+	readMemTotal = func() (uint64, error) {
+		return 16 * 1024 * 1024 * 1024, nil
+	}
+}
+
+func (s *osdMemorySuite) TestComputeOSDMemoryTarget() {
+	gib := uint64(1024 * 1024 * 1024)
+
+	// 16GiB less 2GiB for the system, 2GiB for the mon and 1GiB for the mgr, split between 2 OSDs.
+	target, short := computeOSDMemoryTarget(16*gib, []string{"mgr", "mon"}, 2)
+	assert.Equal(s.T(), 11*gib/2, target)
+	assert.False(s.T(), short)
+
+	// Too many OSDs for the memory left.
+	target, short = computeOSDMemoryTarget(8*gib, []string{"mds", "mon"}, 4)
+	assert.Equal(s.T(), osdMemoryTargetMin, target)
+	assert.True(s.T(), short)
+
+	// Services alone take up all the memory.
+	target, short = computeOSDMemoryTarget(4*gib, []string{"mon", "rgw"}, 1)
+	assert.Equal(s.T(), osdMemoryTargetMin, target)
+	assert.True(s.T(), short)
+}
+
+func (s *osdMemorySuite) TestUpdateOSDMemoryTargets() {
+	dump := `[
+		{"section":"osd.0","name":"osd_memory_target","value":"4294967296"},
+		{"section":"osd.1","name":"osd_memory_target","value":"5905580032"},
+		{"section":"osd.7","name":"osd_memory_target","value":"4294967296"}
+	]`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(dump, nil).Once()
+	// osd.7 was removed from the cluster, osd.1 is already up to date.
+	r.On("RunCommand", "ceph", "config", "rm", "osd.7", "osd_memory_target").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "osd.0", "osd_memory_target", "5905580032", "-f", "json-pretty").Return("", nil).Once()
+	common.ProcessExec = r
+	patchMemTotal()
+
+	layout := osdMemoryLayout{Services: []string{"mgr", "mon"}, OSDs: []int64{0, 1}, AllOSDs: []int64{0, 1, 2}}
+	assert.NoError(s.T(), updateOSDMemoryTargets(layout))
+}
+
+func (s *osdMemorySuite) TestUpdateOSDMemoryTargetsManual() {
+	dump := `[
+		{"section":"osd","name":"osd_memory_target","value":"8589934592"},
+		{"section":"osd.0","name":"osd_memory_target","value":"5905580032"}
+	]`
+
+	// A target set for all OSDs takes over the computed ones.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(dump, nil).Once()
+	r.On("RunCommand", "ceph", "config", "rm", "osd.0", "osd_memory_target").Return("", nil).Once()
+	common.ProcessExec = r
+
+	layout := osdMemoryLayout{Services: []string{"mgr", "mon"}, OSDs: []int64{0, 1}, AllOSDs: []int64{0, 1}}
+	assert.NoError(s.T(), updateOSDMemoryTargets(layout))
+}
+
+func (s *osdMemorySuite) TestUpdateOSDMemoryTargetsManualRemoved() {
+	// Once the target for all OSDs is removed, the computed ones are set again with the layout unchanged.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[]`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "osd.0", "osd_memory_target", "5905580032", "-f", "json-pretty").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "osd.1", "osd_memory_target", "5905580032", "-f", "json-pretty").Return("", nil).Once()
+	common.ProcessExec = r
+	patchMemTotal()
+
+	layout := osdMemoryLayout{Services: []string{"mgr", "mon"}, OSDs: []int64{0, 1}, AllOSDs: []int64{0, 1}}
+	assert.NoError(s.T(), updateOSDMemoryTargets(layout))
+}
@@ -0,0 +1,457 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/units"
+	"github.com/spf13/afero"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// sharedDevice is a device sliced into per-OSD partitions, WAL/DB slices or data partitions of a split device.
+type sharedDevice struct {
+	// Path holds the stable path of the device.
+	Path string
+	Disk api.ResourcesStorageDisk
+	// Used holds the number of bytes already sliced off for OSDs of this member.
+	Used uint64
+}
+
+// fits checks that count further slices of the given size fit on the shared device.
+func (d sharedDevice) fits(size uint64, count int) error {
+	want := size * uint64(count)
+	if d.Used+want > d.Disk.Size {
+		free := uint64(0)
+		if d.Disk.Size > d.Used {
+			free = d.Disk.Size - d.Used
+		}
+		return fmt.Errorf("shared device %s has %s left, cannot carve %d slice(s) of %s",
+			d.Path, units.GetByteSizeStringIEC(int64(free), 2), count, units.GetByteSizeStringIEC(int64(size), 2))
+	}
+	return nil
+}
+
+// mapperName returns the device mapper name of the given device, such as vg0-lv0 for an LVM logical volume,
+// empty if it is not a device mapper device.
+func (m *OSDManager) mapperName(major uint32, minor uint32) string {
+	name, err := afero.ReadFile(m.fs, fmt.Sprintf("/sys/dev/block/%d:%d/dm/name", major, minor))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(name))
+}
+
+// mapperPath returns the stable path of a device mapper device, empty if it is not one.
+func (m *OSDManager) mapperPath(major uint32, minor uint32) string {
+	name := m.mapperName(major, minor)
+	if len(name) == 0 {
+		return ""
+	}
+
+	return filepath.Join("/dev/mapper", name)
+}
+
+// isVolume checks whether a device is a partition or a device mapper volume such as an LVM logical volume.
+// Volumes are used whole, they are neither sliced nor split.
+func (m *OSDManager) isVolume(storage *api.ResourcesStorage, path string) (bool, error) {
+	_, _, major, minor, _, _, err := m.fileStater.GetFileStat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to get device info for %s: %w", path, err)
+	}
+
+	if len(m.mapperName(major, minor)) != 0 {
+		return true, nil
+	}
+
+	if storage == nil {
+		return false, nil
+	}
+
+	dev := fmt.Sprintf("%d:%d", major, minor)
+	for _, disk := range storage.Disks {
+		for _, part := range disk.Partitions {
+			if part.Device == dev {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// getSharedDevice looks up the shared device and the space already sliced off it.
+func (m *OSDManager) getSharedDevice(ctx context.Context, storage *api.ResourcesStorage, disk types.DiskParameter) (sharedDevice, error) {
+	if storage == nil {
+		return sharedDevice{}, fmt.Errorf("no storage information for shared device %s", disk.Path)
+	}
+
+	err := m.setStablePath(storage, &disk)
+	if err != nil {
+		return sharedDevice{}, err
+	}
+
+	_, _, major, minor, _, _, err := m.fileStater.GetFileStat(disk.Path)
+	if err != nil {
+		return sharedDevice{}, fmt.Errorf("failed to get device info for %s: %w", disk.Path, err)
+	}
+
+	dev := fmt.Sprintf("%d:%d", major, minor)
+	for _, candidate := range storage.Disks {
+		if candidate.Device == dev {
+			used, err := database.OSDQuery.DeviceUsage(ctx, m.state, disk.Path)
+			if err != nil {
+				return sharedDevice{}, fmt.Errorf("failed to fetch usage of %s: %w", disk.Path, err)
+			}
+
+			return sharedDevice{Path: disk.Path, Disk: candidate, Used: used}, nil
+		}
+
+		for _, part := range candidate.Partitions {
+			if part.Device == dev {
+				return sharedDevice{}, fmt.Errorf("shared device %s must be a whole disk, not a partition", disk.Path)
+			}
+		}
+	}
+
+	return sharedDevice{}, fmt.Errorf("shared device %s not found", disk.Path)
+}
+
+// checkSharedCapacity refuses a request which would over-commit a shared WAL/DB device.
+func (m *OSDManager) checkSharedCapacity(ctx context.Context, disk *types.DiskParameter, count int) error {
+	if disk == nil || disk.Size == 0 {
+		return nil
+	}
+
+	storage, err := m.storage.GetStorage()
+	if err != nil {
+		return fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	volume, err := m.isVolume(storage, disk.Path)
+	if err != nil {
+		return err
+	}
+
+	// A volume is used whole by a single OSD.
+	if volume {
+		if count > 1 {
+			return fmt.Errorf("%s is a partition or logical volume, it can only back a single OSD", disk.Path)
+		}
+		return nil
+	}
+
+	shared, err := m.getSharedDevice(ctx, storage, *disk)
+	if err != nil {
+		return err
+	}
+
+	return shared.fits(disk.Size, count)
+}
+
+// carveSlice partitions a slice off the shared device for the given OSD and points the disk parameter at it.
+// Returns the device record of the slice.
+func (m *OSDManager) carveSlice(ctx context.Context, disk *types.DiskParameter, role string, nr int64, storage *api.ResourcesStorage) (types.DiskDevice, error) {
+	device := types.DiskDevice{OSD: nr, Role: role, Encrypted: disk.Encrypt, Size: disk.Size}
+
+	shared, err := m.getSharedDevice(ctx, storage, *disk)
+	if err != nil {
+		return device, err
+	}
+
+	err = shared.fits(disk.Size, 1)
+	if err != nil {
+		return device, err
+	}
+	device.Parent = shared.Path
+
+	partitions, err := m.claimSharedDevice(shared, disk.Wipe, storage, strings.ToUpper(role))
+	if err != nil {
+		return device, err
+	}
+
+	for _, part := range partitions {
+		if int(part.Partition) > device.Partition {
+			device.Partition = int(part.Partition)
+		}
+	}
+	device.Partition++
+
+	err = m.createPartition(shared.Path, device.Partition, fmt.Sprintf("+%dK", disk.Size/1024), fmt.Sprintf("microceph-osd.%d-%s", nr, role))
+	if err != nil {
+		return device, err
+	}
+
+	path, err := m.slicePath(shared, device.Partition)
+	if err != nil {
+		_ = m.removeSlice(shared.Path, device.Partition)
+		return device, err
+	}
+
+	logger.Infof("Carved %s slice %s for osd.%d off %s", role, path, nr, shared.Path)
+	device.Path = path
+	disk.Path = path
+	return device, nil
+}
+
+// claimSharedDevice checks that a shared device may be sliced, wiping it first if requested.
+// Only the first slice may take over the device, later ones share it with existing slices.
+// Returns the partitions left on the device.
+func (m *OSDManager) claimSharedDevice(shared sharedDevice, wipe bool, storage *api.ResourcesStorage, deviceType string) ([]api.ResourcesStorageDiskPartition, error) {
+	if shared.Used != 0 {
+		return shared.Disk.Partitions, nil
+	}
+
+	param := types.DiskParameter{Path: shared.Path, Wipe: wipe}
+	if wipe {
+		_, err := m.runner.RunCommand("sgdisk", "--zap-all", shared.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wipe %s: %w", shared.Path, err)
+		}
+
+		// Drop stale partitions from the kernel, there may be none.
+		_, _ = m.runner.RunCommand("partx", "--delete", shared.Path)
+		return nil, nil
+	}
+
+	err := m.checkPartitionsOnDevice(&param, storage, deviceType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.checkPristineDevice(&param, deviceType)
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.Disk.Partitions, nil
+}
+
+// createPartition creates the given partition on a shared device and announces it to the kernel.
+// The end is relative to the start (e.g. +1024K), 0 uses the rest of the free space.
+func (m *OSDManager) createPartition(parent string, partition int, end string, name string) error {
+	_, err := m.runner.RunCommand("sgdisk",
+		fmt.Sprintf("--new=%d:0:%s", partition, end),
+		fmt.Sprintf("--change-name=%d:%s", partition, name),
+		parent)
+	if err != nil {
+		return fmt.Errorf("failed to create partition on %s: %w", parent, err)
+	}
+
+	// Other partitions may be in use, only announce the new one to the kernel.
+	_, err = m.runner.RunCommand("partx", "--add", "--nr", strconv.Itoa(partition), parent)
+	if err != nil {
+		_ = m.removeSlice(parent, partition)
+		return fmt.Errorf("failed to add partition %d of %s: %w", partition, parent, err)
+	}
+
+	return nil
+}
+
+// splitAlignment is the alignment of data partitions, sgdisk keeps the first MiB for the partition table.
+const splitAlignment = uint64(1024 * 1024)
+
+// splitDevice partitions a device into OSDsPerDevice equally sized data partitions.
+// Returns a disk parameter per partition, each becomes an OSD of its own.
+func (m *OSDManager) splitDevice(ctx context.Context, disk types.DiskParameter) ([]types.DiskParameter, error) {
+	storage, err := m.storage.GetStorage()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	volume, err := m.isVolume(storage, disk.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if volume {
+		return nil, fmt.Errorf("%s is a partition or logical volume, only whole disks can be split", disk.Path)
+	}
+
+	shared, err := m.getSharedDevice(ctx, storage, disk)
+	if err != nil {
+		return nil, err
+	}
+
+	if shared.Used != 0 {
+		return nil, fmt.Errorf("device %s already holds OSD partitions", shared.Path)
+	}
+
+	// Keep room for the primary and backup partition tables.
+	size := uint64(0)
+	if shared.Disk.Size > 2*splitAlignment {
+		size = (shared.Disk.Size - 2*splitAlignment) / uint64(disk.OSDsPerDevice)
+		size -= size % splitAlignment
+	}
+
+	if size < constants.MinOSDSize {
+		return nil, fmt.Errorf("device %s is too small for %d OSDs of at least %s", shared.Path, disk.OSDsPerDevice,
+			units.GetByteSizeStringIEC(int64(constants.MinOSDSize), 2))
+	}
+
+	_, err = m.claimSharedDevice(shared, disk.Wipe, storage, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	disks := make([]types.DiskParameter, 0, disk.OSDsPerDevice)
+	for partition := 1; partition <= disk.OSDsPerDevice; partition++ {
+		// The last partition takes the rest of the device.
+		end := fmt.Sprintf("+%dK", size/1024)
+		if partition == disk.OSDsPerDevice {
+			end = "0"
+		}
+
+		err = m.createPartition(shared.Path, partition, end, fmt.Sprintf("microceph-data-%d", partition))
+		if err != nil {
+			m.removeDataPartitions(disks)
+			return nil, err
+		}
+
+		path, err := m.slicePath(shared, partition)
+		if err != nil {
+			_ = m.removeSlice(shared.Path, partition)
+			m.removeDataPartitions(disks)
+			return nil, err
+		}
+
+		disks = append(disks, types.DiskParameter{
+			Path:      path,
+			Encrypt:   disk.Encrypt,
+			Wipe:      disk.Wipe,
+			Size:      size,
+			Parent:    shared.Path,
+			Partition: partition,
+		})
+	}
+
+	logger.Infof("Split %s into %d data partitions of %s", shared.Path, disk.OSDsPerDevice, units.GetByteSizeStringIEC(int64(size), 2))
+	return disks, nil
+}
+
+// splitDevices expands the disks to split into one disk parameter per data partition.
+func (m *OSDManager) splitDevices(ctx context.Context, disks []types.DiskParameter) ([]types.DiskParameter, error) {
+	expanded := make([]types.DiskParameter, 0, len(disks))
+	for _, disk := range disks {
+		if disk.OSDsPerDevice <= 1 {
+			expanded = append(expanded, disk)
+			continue
+		}
+
+		parts, err := m.splitDevice(ctx, disk)
+		if err != nil {
+			m.removeDataPartitions(expanded)
+			return nil, fmt.Errorf("failed to split %s: %w", disk.Path, err)
+		}
+		expanded = append(expanded, parts...)
+	}
+
+	return expanded, nil
+}
+
+// removeDataPartitions removes the partitions split off a device for OSDs that were not added.
+func (m *OSDManager) removeDataPartitions(disks []types.DiskParameter) {
+	for _, disk := range disks {
+		if len(disk.Parent) == 0 {
+			continue
+		}
+
+		err := m.removeSlice(disk.Parent, disk.Partition)
+		if err != nil {
+			logger.Warnf("failed to remove data partition %s: %v", disk.Path, err)
+		}
+	}
+}
+
+// countOSDs returns the number of OSDs the disks make up once split.
+func countOSDs(disks []types.DiskParameter) int {
+	count := 0
+	for _, disk := range disks {
+		count += max(disk.OSDsPerDevice, 1)
+	}
+
+	return count
+}
+
+// slicePath returns the stable path of the given partition of the shared device.
+func (m *OSDManager) slicePath(shared sharedDevice, partition int) (string, error) {
+	storage, err := m.storage.GetStorage()
+	if err != nil {
+		return "", fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	for _, disk := range storage.Disks {
+		if disk.ID != shared.Disk.ID {
+			continue
+		}
+
+		for _, part := range disk.Partitions {
+			if int(part.Partition) != partition {
+				continue
+			}
+
+			param := types.DiskParameter{Path: fmt.Sprintf("/dev/%s", part.ID)}
+			err = m.setStablePath(storage, &param)
+			if err != nil {
+				return "", err
+			}
+			return param.Path, nil
+		}
+	}
+
+	return "", fmt.Errorf("partition %d of %s not found", partition, shared.Path)
+}
+
+// removeSlice deletes the given partition of a shared device.
+func (m *OSDManager) removeSlice(parent string, partition int) error {
+	_, err := m.runner.RunCommand("partx", "--delete", "--nr", strconv.Itoa(partition), parent)
+	if err != nil {
+		logger.Warnf("failed to remove partition %d of %s from the kernel: %v", partition, parent, err)
+	}
+
+	_, err = m.runner.RunCommand("sgdisk", fmt.Sprintf("--delete=%d", partition), parent)
+	if err != nil {
+		return fmt.Errorf("failed to delete partition %d of %s: %w", partition, parent, err)
+	}
+
+	logger.Infof("Removed slice %d of %s", partition, parent)
+	return nil
+}
+
+// reclaimSlices returns the shared device slices and the data partition of a removed OSD.
+func (m *OSDManager) reclaimSlices(ctx context.Context, osd int64) error {
+	devices, err := database.OSDQuery.Devices(ctx, m.state, osd)
+	if err != nil {
+		return fmt.Errorf("failed to fetch devices of osd.%d: %w", osd, err)
+	}
+
+	for _, device := range devices {
+		if len(device.Parent) == 0 {
+			continue
+		}
+
+		if device.Encrypted {
+			mapper := fmt.Sprintf("luksosd%s-%d", bluefsDeviceSuffix[device.Role], osd)
+			_, err = m.runner.RunCommand("cryptsetup", "close", mapper)
+			if err != nil {
+				logger.Warnf("failed to close %s: %v", mapper, err)
+			}
+		}
+
+		err = m.removeSlice(device.Parent, device.Partition)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"github.com/canonical/microceph/microceph/common"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/revert"
 	"github.com/canonical/microceph/microceph/tests"
 	"github.com/spf13/afero"
 
@@ -987,3 +990,585 @@ func (s *osdSuite) TestIsPristineDisk() {
 	assert.Contains(s.T(), err.Error(), "permission denied")
 }
 
+// bluestoreLabelJSON renders a show-label output for the given device.
+func bluestoreLabelJSON(dev string, osdUUID string, fsid string, whoami string, description string) string {
+	return fmt.Sprintf(`{"%s": {"osd_uuid": "%s", "ceph_fsid": "%s", "whoami": "%s", "description": "%s"}}`, dev, osdUUID, fsid, whoami, description)
+}
+
+// TestReadBluestoreLabel tests parsing of BlueStore labels
+func (s *osdSuite) TestReadBluestoreLabel() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph-bluestore-tool", "show-label", "--dev", "/dev/sdb").Return(bluestoreLabelJSON("/dev/sdb", "uuid-1", "fsid-1", "1", "main"), nil).Once()
+	r.On("RunCommand", "ceph-bluestore-tool", "show-label", "--dev", "/dev/sdc").Return("", fmt.Errorf("no label")).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	label, err := osdmgr.readBluestoreLabel("/dev/sdb")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), bluestoreLabel{OSDUUID: "uuid-1", CephFsid: "fsid-1", Whoami: "1", Description: "main"}, label)
+
+	_, err = osdmgr.readBluestoreLabel("/dev/sdc")
+	assert.ErrorContains(s.T(), err, "failed to read bluestore label")
+}
+
+// TestScanAdoptableOSDs tests matching of labelled devices against disk records
+func (s *osdSuite) TestScanAdoptableOSDs() {
+	r := mocks.NewRunner(s.T())
+	devices := map[string]string{
+		"/dev/disk/by-id/data":  bluestoreLabelJSON("/dev/disk/by-id/data", "uuid-1", "fsid-1", "1", bluestoreMainDevice),
+		"/dev/disk/by-id/db":    bluestoreLabelJSON("/dev/disk/by-id/db", "uuid-1", "", "", bluestoreDBDevice),
+		"/dev/disk/by-id/alien": bluestoreLabelJSON("/dev/disk/by-id/alien", "uuid-2", "fsid-2", "2", bluestoreMainDevice),
+		"/dev/disk/by-id/other": bluestoreLabelJSON("/dev/disk/by-id/other", "uuid-3", "fsid-1", "3", bluestoreMainDevice),
+	}
+	candidates := []string{}
+	for dev, label := range devices {
+		candidates = append(candidates, dev)
+		r.On("RunCommand", "cryptsetup", "isLuks", dev).Return("", fmt.Errorf("not luks")).Once()
+		r.On("RunCommand", "ceph-bluestore-tool", "show-label", "--dev", dev).Return(label, nil).Once()
+	}
+
+	// The encrypted WAL device is unlocked with the WAL key of its recorded OSD, it is already open here.
+	candidates = append(candidates, "/dev/disk/by-id/wal")
+	r.On("RunCommand", "cryptsetup", "isLuks", "/dev/disk/by-id/wal").Return("", nil).Once()
+	r.On("RunCommand", "ceph-bluestore-tool", "show-label", "--dev", "/dev/mapper/luksosd.wal-1").Return(
+		bluestoreLabelJSON("/dev/mapper/luksosd.wal-1", "uuid-1", "", "", bluestoreWALDevice), nil).Once()
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(s.T(), afero.WriteFile(fs, "/dev/mapper/luksosd.wal-1", nil, 0600))
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+	osdmgr.fs = fs
+
+	records := types.Disks{
+		{OSD: 1, Path: "/dev/disk/by-id/data", Location: "node0"},
+		{OSD: 2, Path: "/dev/disk/by-id/alien", Location: "node0"},
+	}
+	recorded := map[int64][]types.DiskDevice{
+		1: {{OSD: 1, Role: types.DiskRoleWAL, Path: "/dev/disk/by-id/wal", Encrypted: true}},
+	}
+
+	osds, aux := osdmgr.scanAdoptableOSDs(candidates, records, recorded, "fsid-1")
+
+	// Only osd.1 belongs to this cluster and has a record, osd.2 is foreign and osd.3 is unknown.
+	assert.Len(s.T(), osds, 1)
+	assert.Equal(s.T(), "/dev/disk/by-id/data", osds[1].Path)
+	assert.Empty(s.T(), osds[1].Unencrypted)
+	assert.Equal(s.T(), "/dev/disk/by-id/db", aux["uuid-1"][bluestoreDBDevice].Path)
+	assert.Equal(s.T(), adoptableOSD{
+		Path:        "/dev/mapper/luksosd.wal-1",
+		Unencrypted: "/dev/disk/by-id/wal",
+		Label:       bluestoreLabel{OSDUUID: "uuid-1", Description: bluestoreWALDevice},
+	}, aux["uuid-1"][bluestoreWALDevice])
+}
+
+// TestMissingAuxDevices tests detecting recorded WAL/DB devices which weren't found
+func (s *osdSuite) TestMissingAuxDevices() {
+	devices := []types.DiskDevice{
+		{OSD: 1, Role: types.DiskRoleData, Path: "/dev/sdb1"},
+		{OSD: 1, Role: types.DiskRoleWAL, Path: "/dev/sdc", Encrypted: true},
+		{OSD: 1, Role: types.DiskRoleDB, Path: "/dev/sdd"},
+	}
+	found := map[string]adoptableOSD{bluestoreDBDevice: {Path: "/dev/sdd"}}
+
+	assert.Equal(s.T(), []string{types.DiskRoleWAL}, missingAuxDevices(devices, found))
+	assert.Empty(s.T(), missingAuxDevices(devices[2:], found))
+}
+
+// TestAdoptOSDs tests recreating the data directory of an existing OSD
+func (s *osdSuite) TestAdoptOSDs() {
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 0, Path: "/dev/sdb", Location: "node0"},
+		{OSD: 1, Path: "/dev/disk/by-id/wwn-0x2", Location: "node1"},
+	}, nil).Once()
+	q.On("Devices", mock.Anything, mock.Anything, int64(0)).Return([]types.DiskDevice{}, nil).Once()
+	database.OSDQuery = q
+
+	st := mocks.NewStorageInterface(s.T())
+	st.On("GetStorage").Return(&api.ResourcesStorage{
+		Disks: []api.ResourcesStorageDisk{{ID: "sdb", Device: "8:16", DeviceID: "wwn-0x1"}},
+	}, nil).Once()
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", "/dev/sdb").Return(true).Once()
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/sdb").Return(0, 0, uint32(8), uint32(16), uint64(0), 0, nil).Once()
+
+	fs := afero.NewOsFs()
+	osdDataPath := getOSDDataPath(0)
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "fsid").Return("fsid-1\n", nil).Once()
+	r.On("RunCommand", "cryptsetup", "isLuks", "/dev/sdb").Return("", fmt.Errorf("not luks")).Once()
+	r.On("RunCommand", "ceph-bluestore-tool", "show-label", "--dev", "/dev/sdb").Return(
+		bluestoreLabelJSON("/dev/sdb", "uuid-0", "fsid-1", "0", bluestoreMainDevice), nil).Once()
+	addOsdTreeExpectations(r)
+	r.On("RunCommand", "ceph", "auth", "get-or-create", "osd.0", "mgr", "allow profile osd", "mon", "allow profile osd", "osd", "allow *", "-o", filepath.Join(osdDataPath, "keyring")).Return("", nil).Once()
+	r.On("RunCommand", "ceph-bluestore-tool", "prime-osd-dir", "--dev", "/dev/sdb", "--path", osdDataPath, "--no-mon-config").Return("", nil).Once()
+	r.On("RunCommand", "snapctl", "restart", "--reload", "microceph.osd").Return("", nil).Once()
+	common.ProcessExec = r
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+	osdmgr.fs = fs
+	osdmgr.storage = st
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+
+	resp, err := osdmgr.adoptOSDs(context.Background(), false)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []types.DiskAdoptReport{{OSD: 0, Path: "/dev/sdb", Report: "Success"}}, resp.Reports)
+
+	target, err := os.Readlink(filepath.Join(osdDataPath, "block"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "/dev/sdb", target)
+
+	fsid, err := afero.ReadFile(fs, filepath.Join(osdDataPath, "fsid"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "uuid-0", string(fsid))
+
+	exists, err := afero.Exists(fs, filepath.Join(osdDataPath, "ready"))
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), exists)
+}
+
+// TestArePGsClean tests the placement group state check
+func (s *osdSuite) TestArePGsClean() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(
+		`{"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":30},{"name":"active+undersized+degraded","num":2}],"num_pgs":32}}`, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(
+		`{"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":32}],"num_pgs":32}}`, nil).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	clean, err := osdmgr.arePGsClean()
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), clean)
+
+	clean, err = osdmgr.arePGsClean()
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), clean)
+}
+
+// TestWaitForCleanPGs tests waiting for recovery
+func (s *osdSuite) TestWaitForCleanPGs() {
+	defer func(interval time.Duration) { pgPollInterval = interval }(pgPollInterval)
+	pgPollInterval = time.Millisecond
+	dirty := `{"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":1},{"name":"active+recovering","num":1}],"num_pgs":2}}`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(dirty, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(
+		`{"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":2}],"num_pgs":2}}`, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "--format", "json").Return(dirty, nil).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.runner = r
+
+	err := osdmgr.waitForCleanPGs(context.Background(), 60)
+	assert.NoError(s.T(), err)
+
+	err = osdmgr.waitForCleanPGs(context.Background(), 0)
+	assert.ErrorContains(s.T(), err, "not active+clean")
+}
+
+// TestCheckEncryptable tests the preconditions of in place encryption
+func (s *osdSuite) TestCheckEncryptable() {
+	mockValidator := &MockPathValidator{}
+	osdmgr := NewOSDManager(nil)
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.validator = mockValidator
+
+	// Not hosted here.
+	err := osdmgr.checkEncryptable("/osd/ceph-1", "/dev/sdb", 1)
+	assert.ErrorContains(s.T(), err, "not hosted on this node")
+
+	assert.NoError(s.T(), osdmgr.fs.MkdirAll("/osd/ceph-1", 0700))
+	mockValidator.On("IsBlockdevPath", "/dev/sdb").Return(true).Once()
+	err = osdmgr.checkEncryptable("/osd/ceph-1", "/dev/sdb", 1)
+	assert.NoError(s.T(), err)
+
+	// Loop file backed.
+	mockValidator.On("IsBlockdevPath", "/osd/ceph-1/osd-backing.img").Return(false).Once()
+	err = osdmgr.checkEncryptable("/osd/ceph-1", "/osd/ceph-1/osd-backing.img", 1)
+	assert.ErrorContains(s.T(), err, "not backed by a block device")
+
+	// Separate DB device.
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, "/osd/ceph-1/block.db", []byte(""), 0600))
+	err = osdmgr.checkEncryptable("/osd/ceph-1", "/dev/sdb", 1)
+	assert.ErrorContains(s.T(), err, "separate block.db device")
+
+	// Already encrypted.
+	assert.NoError(s.T(), osdmgr.fs.MkdirAll("/osd/ceph-2", 0700))
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, "/osd/ceph-2/unencrypted", []byte(""), 0600))
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, "/osd/ceph-2/ready", []byte(""), 0600))
+	err = osdmgr.checkEncryptable("/osd/ceph-2", "/dev/sdc", 2)
+	assert.ErrorContains(s.T(), err, "already encrypted")
+}
+
+// TestEncryptOSDDrainFailure tests a drained OSD is taken back in when it can't be destroyed in time
+func (s *osdSuite) TestEncryptOSDDrainFailure() {
+	defer func(interval time.Duration) { pgPollInterval = interval }(pgPollInterval)
+	pgPollInterval = time.Millisecond
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("Path", mock.Anything, mock.Anything, int64(1)).Return("/dev/sdb", nil).Once()
+	database.OSDQuery = q
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", "/dev/sdb").Return(true).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.validator = mockValidator
+	for _, dir := range []string{getOSDDataPath(1), "/dev/mapper/control", "/sys/module/dm_crypt", "/run"} {
+		assert.NoError(s.T(), osdmgr.fs.MkdirAll(dir, 0755))
+	}
+
+	p := mocks.NewRunner(s.T())
+	p.On("RunCommand", "snapctl", "is-connected", "dm-crypt").Return("", nil).Once()
+	originalProcessExec := common.ProcessExec
+	common.ProcessExec = p
+	defer func() { common.ProcessExec = originalProcessExec }()
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "tree", "-f", "json").Return(`{"nodes":[{"id":1,"type":"osd"}]}`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "out", "osd.1").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "safe-to-destroy", "osd.1").Return("", fmt.Errorf("not safe"))
+	r.On("RunCommand", "ceph", "osd", "in", "osd.1").Return("", nil).Once()
+	osdmgr.runner = r
+
+	err := osdmgr.encryptOSD(context.Background(), 1, false, 0)
+	assert.ErrorContains(s.T(), err, "not safe to destroy")
+}
+
+// TestSetBluefsDevice tests attaching a DB device to an existing OSD
+func (s *osdSuite) TestSetBluefsDevice() {
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("Path", mock.Anything, mock.Anything, int64(0)).Return("/dev/sdb", nil).Once()
+	q.On("SetDevice", mock.Anything, mock.Anything, types.DiskDevice{OSD: 0, Role: types.DiskRoleDB, Path: "/dev/nvme0n1"}).Return(nil).Once()
+	database.OSDQuery = q
+
+	st := mocks.NewStorageInterface(s.T())
+	st.On("GetStorage").Return(&api.ResourcesStorage{
+		Disks: []api.ResourcesStorageDisk{{ID: "nvme0n1", Device: "259:0", DeviceID: "nvme-1"}},
+	}, nil).Once()
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", "/dev/sdb").Return(true)
+	mockValidator.On("IsBlockdevPath", "/dev/nvme0n1").Return(true)
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1").Return(0, 0, uint32(259), uint32(0), uint64(0), 0, nil)
+	mockMountChecker := &MockMountChecker{}
+	mockMountChecker.On("IsMounted", "/dev/nvme0n1").Return(false, nil).Once()
+	mockPristineChecker := &MockPristineChecker{}
+	mockPristineChecker.On("IsPristineDisk", "/dev/nvme0n1").Return(true, nil).Once()
+
+	fs := afero.NewOsFs()
+	osdDataPath := getOSDDataPath(0)
+	assert.NoError(s.T(), fs.MkdirAll(osdDataPath, 0700))
+	assert.NoError(s.T(), afero.WriteFile(fs, filepath.Join(osdDataPath, "ready"), []byte(""), 0600))
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "ok-to-stop", "osd.0").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "dump").Return("flags sortbitwise", nil).Once()
+	addOsdtNooutFlagTrueExpectations(r)
+	r.On("RunCommand", "pkill", "-f", "ceph-osd .* --id 0$").Return("", nil).Once()
+	r.On("RunCommand", "pgrep", "-f", "ceph-osd .* --id 0$").Return("", fmt.Errorf("no match")).Once()
+	r.On("RunCommand", "ceph-bluestore-tool", "bluefs-bdev-new-db", "--path", osdDataPath, "--dev-target", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "ceph-bluestore-tool", "bluefs-bdev-migrate", "--path", osdDataPath, "--devs-source", filepath.Join(osdDataPath, "block"), "--dev-target", filepath.Join(osdDataPath, "block.db")).Return("", nil).Once()
+	r.On("RunCommand", "snapctl", "restart", "--reload", "microceph.osd").Return("", nil).Once()
+	addOsdtNooutFlagFalseExpectations(r)
+	common.ProcessExec = r
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+	osdmgr.fs = fs
+	osdmgr.storage = st
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+	osdmgr.mountChecker = mockMountChecker
+	osdmgr.pristineChecker = mockPristineChecker
+
+	err := osdmgr.setBluefsDevice(context.Background(), 0, types.DiskRoleDB, types.DiskParameter{Path: "/dev/nvme0n1"})
+	assert.NoError(s.T(), err)
+
+	target, err := os.Readlink(filepath.Join(osdDataPath, "block.db"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "/dev/nvme0n1", target)
+}
+
+// TestSetBluefsDeviceRefused tests the preconditions for attaching WAL/DB devices
+func (s *osdSuite) TestSetBluefsDeviceRefused() {
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("Path", mock.Anything, mock.Anything, int64(0)).Return("/dev/sdb", nil)
+	database.OSDQuery = q
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", "/dev/sdb").Return(true)
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.validator = mockValidator
+
+	err := osdmgr.setBluefsDevice(context.Background(), 0, "journal", types.DiskParameter{Path: "/dev/nvme0n1"})
+	assert.ErrorContains(s.T(), err, "unknown device role")
+
+	err = osdmgr.setBluefsDevice(context.Background(), 0, types.DiskRoleWAL, types.DiskParameter{Path: "/dev/nvme0n1"})
+	assert.ErrorContains(s.T(), err, "not hosted on this node")
+
+	osdDataPath := getOSDDataPath(0)
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, filepath.Join(osdDataPath, "ready"), []byte(""), 0600))
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, filepath.Join(osdDataPath, "block.wal"), []byte(""), 0600))
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, filepath.Join(osdDataPath, "unencrypted.wal"), []byte(""), 0600))
+	err = osdmgr.setBluefsDevice(context.Background(), 0, types.DiskRoleWAL, types.DiskParameter{Path: "/dev/nvme0n1", Encrypt: true})
+	assert.ErrorContains(s.T(), err, "already has an encrypted wal device")
+}
+
+// sharedStorage returns storage information with a 200GiB NVMe device holding the given partitions.
+func sharedStorage(partitions ...uint64) *api.ResourcesStorage {
+	disk := api.ResourcesStorageDisk{ID: "nvme0n1", Device: "259:0", DeviceID: "nvme-1", Size: 200 * 1024 * 1024 * 1024}
+	for _, nr := range partitions {
+		disk.Partitions = append(disk.Partitions, api.ResourcesStorageDiskPartition{
+			ID:        fmt.Sprintf("nvme0n1p%d", nr),
+			Device:    fmt.Sprintf("259:%d", nr),
+			Partition: nr,
+		})
+	}
+	return &api.ResourcesStorage{Disks: []api.ResourcesStorageDisk{disk}}
+}
+
+// TestCarveSlice tests slicing a shared DB device
+func (s *osdSuite) TestCarveSlice() {
+	size := uint64(60 * 1024 * 1024 * 1024)
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("DeviceUsage", mock.Anything, mock.Anything, "/dev/nvme0n1").Return(size, nil).Once()
+	database.OSDQuery = q
+
+	st := mocks.NewStorageInterface(s.T())
+	st.On("GetStorage").Return(sharedStorage(1, 2), nil).Once()
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", mock.Anything).Return(true)
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1").Return(0, 0, uint32(259), uint32(0), uint64(0), 0, nil)
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1p2").Return(0, 0, uint32(259), uint32(2), uint64(0), 0, nil)
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "sgdisk", "--new=2:0:+62914560K", "--change-name=2:microceph-osd.3-db", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "partx", "--add", "--nr", "2", "/dev/nvme0n1").Return("", nil).Once()
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.storage = st
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+
+	disk := types.DiskParameter{Path: "/dev/nvme0n1", Size: size}
+	device, err := osdmgr.carveSlice(context.Background(), &disk, types.DiskRoleDB, 3, sharedStorage(1))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), types.DiskDevice{OSD: 3, Role: types.DiskRoleDB, Path: "/dev/nvme0n1p2", Parent: "/dev/nvme0n1", Partition: 2, Size: size}, device)
+	assert.Equal(s.T(), "/dev/nvme0n1p2", disk.Path)
+}
+
+// TestSharedCapacity tests that shared devices are not over-committed
+func (s *osdSuite) TestSharedCapacity() {
+	size := uint64(60 * 1024 * 1024 * 1024)
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("DeviceUsage", mock.Anything, mock.Anything, "/dev/nvme0n1").Return(size, nil)
+	database.OSDQuery = q
+
+	st := mocks.NewStorageInterface(s.T())
+	st.On("GetStorage").Return(sharedStorage(1), nil)
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", mock.Anything).Return(true)
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1").Return(0, 0, uint32(259), uint32(0), uint64(0), 0, nil)
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.storage = st
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+
+	db := &types.DiskParameter{Path: "/dev/nvme0n1", Size: size}
+	assert.NoError(s.T(), osdmgr.checkSharedCapacity(context.Background(), db, 2))
+	assert.ErrorContains(s.T(), osdmgr.checkSharedCapacity(context.Background(), db, 3), "cannot carve 3 slice(s)")
+
+	// Batch additions are refused up front.
+	disks := []types.DiskParameter{{Path: "/dev/sdb"}, {Path: "/dev/sdc"}, {Path: "/dev/sdd"}}
+	resp := osdmgr.addBulkDisks(context.Background(), disks, nil, db)
+	assert.Contains(s.T(), resp.ValidationError, "has 140.00GiB left")
+	assert.Len(s.T(), resp.Reports, 3)
+
+	// Partitions are used whole by a single OSD.
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1p1").Return(0, 0, uint32(259), uint32(1), uint64(0), 0, nil)
+	part := &types.DiskParameter{Path: "/dev/nvme0n1p1", Size: size}
+	assert.NoError(s.T(), osdmgr.checkSharedCapacity(context.Background(), part, 1))
+	assert.ErrorContains(s.T(), osdmgr.checkSharedCapacity(context.Background(), part, 2), "can only back a single OSD")
+}
+
+// TestSetStablePathVolume tests device mapper volumes are known by their mapper name
+func (s *osdSuite) TestSetStablePathVolume() {
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", "/dev/vg0/lv0").Return(true).Once()
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/vg0/lv0").Return(0, 0, uint32(253), uint32(3), uint64(0), 0, nil).Once()
+
+	osdmgr := NewOSDManager(nil)
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, "/sys/dev/block/253:3/dm/name", []byte("vg0-lv0\n"), 0644))
+
+	param := &types.DiskParameter{Path: "/dev/vg0/lv0"}
+	assert.NoError(s.T(), osdmgr.setStablePath(sharedStorage(), param))
+	assert.Equal(s.T(), "/dev/mapper/vg0-lv0", param.Path)
+}
+
+// TestSetupBluefsVolume tests partitions and logical volumes are used whole as WAL/DB devices
+func (s *osdSuite) TestSetupBluefsVolume() {
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", mock.Anything).Return(true)
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/vg0/lv0").Return(0, 0, uint32(253), uint32(3), uint64(0), 0, nil)
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1p1").Return(0, 0, uint32(259), uint32(1), uint64(0), 0, nil)
+	mockMountChecker := &MockMountChecker{}
+	mockMountChecker.On("IsMounted", mock.Anything).Return(false, nil)
+	mockPristineChecker := &MockPristineChecker{}
+	mockPristineChecker.On("IsPristineDisk", mock.Anything).Return(true, nil)
+
+	// No partitions are created on volumes.
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = mocks.NewRunner(s.T())
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+	osdmgr.mountChecker = mockMountChecker
+	osdmgr.pristineChecker = mockPristineChecker
+	assert.NoError(s.T(), afero.WriteFile(osdmgr.fs, "/sys/dev/block/253:3/dm/name", []byte("vg0-lv0\n"), 0644))
+	mockFileStater.On("GetFileStat", "/dev/mapper/vg0-lv0").Return(0, 0, uint32(253), uint32(3), uint64(0), 0, nil)
+
+	size := uint64(60 * 1024 * 1024 * 1024)
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	lv := &types.DiskParameter{Path: "/dev/vg0/lv0", Size: size}
+	device, err := osdmgr.setupBluefsDevice(context.Background(), reverter, lv, types.DiskRoleDB, getOSDDataPath(3), 3, sharedStorage(1))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), types.DiskDevice{OSD: 3, Role: types.DiskRoleDB, Path: "/dev/mapper/vg0-lv0"}, device)
+
+	part := &types.DiskParameter{Path: "/dev/nvme0n1p1", Size: size}
+	device, err = osdmgr.setupBluefsDevice(context.Background(), reverter, part, types.DiskRoleWAL, getOSDDataPath(3), 3, sharedStorage(1))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), types.DiskDevice{OSD: 3, Role: types.DiskRoleWAL, Path: "/dev/nvme0n1p1"}, device)
+}
+
+// TestReclaimSlices tests returning the slices of a removed OSD
+func (s *osdSuite) TestReclaimSlices() {
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("Devices", mock.Anything, mock.Anything, int64(3)).Return([]types.DiskDevice{
+		{OSD: 3, Role: types.DiskRoleWAL, Path: "/dev/sdx"},
+		{OSD: 3, Role: types.DiskRoleDB, Path: "/dev/nvme0n1p2", Encrypted: true, Parent: "/dev/nvme0n1", Partition: 2, Size: 1024},
+	}, nil).Once()
+	database.OSDQuery = q
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "cryptsetup", "close", "luksosd.db-3").Return("", nil).Once()
+	r.On("RunCommand", "partx", "--delete", "--nr", "2", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "sgdisk", "--delete=2", "/dev/nvme0n1").Return("", nil).Once()
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+
+	assert.NoError(s.T(), osdmgr.reclaimSlices(context.Background(), 3))
+}
+
+// TestSplitDevice tests splitting a device into several OSDs
+func (s *osdSuite) TestSplitDevice() {
+	size := uint64(102399 * 1024 * 1024)
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("DeviceUsage", mock.Anything, mock.Anything, "/dev/nvme0n1").Return(uint64(0), nil).Twice()
+	database.OSDQuery = q
+
+	st := mocks.NewStorageInterface(s.T())
+	st.On("GetStorage").Return(sharedStorage(), nil).Once()
+	st.On("GetStorage").Return(sharedStorage(1), nil).Once()
+	st.On("GetStorage").Return(sharedStorage(1, 2), nil).Once()
+	st.On("GetStorage").Return(sharedStorage(), nil).Once()
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", mock.Anything).Return(true)
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1").Return(0, 0, uint32(259), uint32(0), uint64(0), 0, nil)
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1p1").Return(0, 0, uint32(259), uint32(1), uint64(0), 0, nil)
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1p2").Return(0, 0, uint32(259), uint32(2), uint64(0), 0, nil)
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "sgdisk", "--zap-all", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "partx", "--delete", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "sgdisk", "--new=1:0:+104856576K", "--change-name=1:microceph-data-1", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "partx", "--add", "--nr", "1", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "sgdisk", "--new=2:0:0", "--change-name=2:microceph-data-2", "/dev/nvme0n1").Return("", nil).Once()
+	r.On("RunCommand", "partx", "--add", "--nr", "2", "/dev/nvme0n1").Return("", nil).Once()
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.runner = r
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.storage = st
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+
+	disk := types.DiskParameter{Path: "/dev/nvme0n1", Wipe: true, OSDsPerDevice: 2}
+	disks, err := osdmgr.splitDevice(context.Background(), disk)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []types.DiskParameter{
+		{Path: "/dev/nvme0n1p1", Wipe: true, Size: size, Parent: "/dev/nvme0n1", Partition: 1},
+		{Path: "/dev/nvme0n1p2", Wipe: true, Size: size, Parent: "/dev/nvme0n1", Partition: 2},
+	}, disks)
+
+	// Partitions must hold at least the minimum OSD size.
+	disk.OSDsPerDevice = 200
+	_, err = osdmgr.splitDevice(context.Background(), disk)
+	assert.ErrorContains(s.T(), err, "too small for 200 OSDs")
+}
+
+// TestSplitDeviceRefused tests that split requests are validated as batches
+func (s *osdSuite) TestSplitDeviceRefused() {
+	disks := []types.DiskParameter{{Path: "/dev/nvme0n1", OSDsPerDevice: 2}}
+	assert.NoError(s.T(), validateBulkDiskAdditionArgs(disks, nil, nil))
+	assert.ErrorContains(s.T(), validateBulkDiskAdditionArgs(disks, &types.DiskParameter{Path: "/dev/sdx"}, nil), "not supported in batch")
+	assert.Equal(s.T(), 3, countOSDs([]types.DiskParameter{{Path: "/dev/sdb"}, {Path: "/dev/nvme0n1", OSDsPerDevice: 2}}))
+
+	// A device which already holds OSD partitions can't be split again.
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("DeviceUsage", mock.Anything, mock.Anything, "/dev/nvme0n1").Return(uint64(1024), nil).Once()
+	database.OSDQuery = q
+
+	st := mocks.NewStorageInterface(s.T())
+	st.On("GetStorage").Return(sharedStorage(1, 2), nil).Once()
+
+	mockValidator := &MockPathValidator{}
+	mockValidator.On("IsBlockdevPath", mock.Anything).Return(true)
+	mockFileStater := &MockFileStater{}
+	mockFileStater.On("GetFileStat", "/dev/nvme0n1").Return(0, 0, uint32(259), uint32(0), uint64(0), 0, nil)
+
+	osdmgr := NewOSDManager(&mocks.MockState{ClusterName: "node0"})
+	osdmgr.fs = afero.NewMemMapFs()
+	osdmgr.storage = st
+	osdmgr.validator = mockValidator
+	osdmgr.fileStater = mockFileStater
+
+	resp := osdmgr.addBulkDisks(context.Background(), disks, nil, nil)
+	assert.Contains(s.T(), resp.ValidationError, "already holds OSD partitions")
+	assert.Len(s.T(), resp.Reports, 1)
+}
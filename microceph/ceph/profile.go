@@ -0,0 +1,292 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/canonical/lxd/shared/revert"
+	"github.com/canonical/microcluster/v2/state"
+	"gopkg.in/yaml.v2"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// builtinProfiles tune a cluster for the shapes it is most commonly deployed in.
+var builtinProfiles = types.Profiles{
+	{
+		Name:        "small",
+		Description: "Single node or test clusters, keeping a single copy of the data",
+		Configs: map[string]string{
+			"mon_allow_pool_size_one":        "true",
+			"mon_warn_on_pool_no_redundancy": "false",
+		},
+		PoolDefaults: map[string]string{
+			"size":     "1",
+			"min_size": "1",
+		},
+	},
+	{
+		Name:        "allflash",
+		Description: "Clusters of SSD or NVMe OSDs, favouring client latency",
+		Configs: map[string]string{
+			"osd_mclock_profile":     "high_client_ops",
+			"osd_recovery_sleep_ssd": "0",
+		},
+		ClientConfigs: map[string]string{
+			"rbd_cache": "true",
+		},
+		PoolDefaults: map[string]string{
+			"size":              "3",
+			"min_size":          "2",
+			"pg_autoscale_mode": "on",
+		},
+	},
+	{
+		Name:        "archive",
+		Description: "Clusters of HDD OSDs storing cold data, favouring capacity",
+		Configs: map[string]string{
+			"bluestore_compression_mode":      "aggressive",
+			"bluestore_compression_algorithm": "zstd",
+			"osd_mclock_profile":              "high_recovery_ops",
+		},
+		ClientConfigs: map[string]string{
+			"rbd_cache":      "true",
+			"rbd_cache_size": "67108864",
+		},
+		PoolDefaults: map[string]string{
+			"size":              "3",
+			"min_size":          "2",
+			"pg_autoscale_mode": "on",
+		},
+	},
+}
+
+// profilesDir returns the directory holding the profiles supplied by users, one YAML file each.
+// Patch-able for testing purposes.
+var profilesDir = func() string {
+	return filepath.Join(constants.GetPathConst().ConfPath, "profiles")
+}
+
+// ListProfiles returns the built-in profiles followed by the ones supplied by users.
+func ListProfiles() (types.Profiles, error) {
+	profiles := slices.Clone(builtinProfiles)
+
+	paths, err := filepath.Glob(filepath.Join(profilesDir(), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		profile, err := ReadProfileFile(path)
+		if err != nil {
+			logger.Warnf("skipping profile %s: %v", path, err)
+			continue
+		}
+
+		if slices.ContainsFunc(profiles, func(p types.Profile) bool { return p.Name == profile.Name }) {
+			logger.Warnf("skipping profile %s: profile %s already exists", path, profile.Name)
+			continue
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+// GetProfile returns the profile with the given name.
+func GetProfile(name string) (types.Profile, error) {
+	profiles, err := ListProfiles()
+	if err != nil {
+		return types.Profile{}, err
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+
+	return types.Profile{}, fmt.Errorf("unknown profile %s", name)
+}
+
+// ReadProfileFile reads a profile from a YAML file, named after the file unless it sets a name.
+func ReadProfileFile(path string) (types.Profile, error) {
+	profile := types.Profile{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return profile, err
+	}
+
+	err = yaml.UnmarshalStrict(data, &profile)
+	if err != nil {
+		return profile, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+
+	if len(profile.Name) == 0 {
+		profile.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return profile, nil
+}
+
+// profileConfigs returns the Ceph configs a profile sets, pool defaults included, sorted by key.
+func profileConfigs(profile types.Profile) []types.Config {
+	values := map[string]string{}
+	for key, value := range profile.Configs {
+		values[key] = value
+	}
+
+	for key, value := range profile.PoolDefaults {
+		values["osd_pool_default_"+key] = value
+	}
+
+	configs := []types.Config{}
+	for key, value := range values {
+		configs = append(configs, types.Config{Key: key, Value: value})
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key < configs[j].Key })
+
+	return configs
+}
+
+// planProfile validates the settings of a profile and returns those differing from the current ones.
+func planProfile(ctx context.Context, s state.State, profile types.Profile) ([]types.ProfileChange, error) {
+	dump, err := dumpConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	current := map[configEntry]string{}
+	for _, item := range dump {
+		current[configEntry{Section: item.Section, Key: item.Name}] = item.Value
+	}
+
+	changes := []types.ProfileChange{}
+	for _, c := range profileConfigs(profile) {
+		config, err := checkSetConfig(c)
+		if err != nil {
+			return nil, err
+		}
+
+		old := current[configEntry{Section: config.Who, Key: c.Key}]
+		if old != c.Value {
+			changes = append(changes, types.ProfileChange{Kind: types.ProfileConfig, Key: c.Key, OldValue: old, NewValue: c.Value})
+		}
+	}
+
+	keys := []string{}
+	for key := range profile.ClientConfigs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clientConfigSet := GetClientConfigSet()
+	for _, key := range keys {
+		if _, ok := clientConfigSet[key]; !ok {
+			return nil, fmt.Errorf("%s is not a supported client config", key)
+		}
+
+		items, err := database.ClientConfigQuery.GetAllForKey(ctx, s, key)
+		if err != nil {
+			return nil, err
+		}
+
+		old := ""
+		for _, item := range items {
+			if item.Host == constants.ClientConfigGlobalHostConst {
+				old = item.Value
+			}
+		}
+
+		value := profile.ClientConfigs[key]
+		if old != value {
+			changes = append(changes, types.ProfileChange{Kind: types.ProfileClientConfig, Key: key, OldValue: old, NewValue: value})
+		}
+	}
+
+	return changes, nil
+}
+
+// ApplyProfile sets the settings of a profile which differ from the current ones, recording the
+// configs in the config history. The whole profile is validated before any setting changes, and the
+// settings already changed are set back if one fails. It returns the changes, or only plans them on
+// a dry run, and the daemons to restart for the changes to take effect.
+func ApplyProfile(ctx context.Context, s state.State, profile types.Profile, dryRun bool, by string) ([]types.ProfileChange, []string, error) {
+	changes, err := planProfile(ctx, s, profile)
+	if err != nil || dryRun {
+		return changes, nil, err
+	}
+
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	daemons := []string{}
+	for _, change := range changes {
+		// Registered first, a change may fail after the setting itself changed.
+		reverter.Add(func() {
+			err := revertProfileChange(ctx, s, change, by)
+			if err != nil {
+				logger.Errorf("failed to set %s back to %q: %v", change.Key, change.OldValue, err)
+			}
+		})
+
+		switch change.Kind {
+		case types.ProfileConfig:
+			var config ClusterConfigDefinition
+			config, err = ChangeConfig(ctx, s, types.Config{Key: change.Key, Value: change.NewValue}, by)
+			for _, daemon := range config.Daemons {
+				if !slices.Contains(daemons, daemon) {
+					daemons = append(daemons, daemon)
+				}
+			}
+		case types.ProfileClientConfig:
+			err = database.ClientConfigQuery.AddNew(ctx, s, change.Key, change.NewValue, constants.ClientConfigGlobalHostConst)
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to apply profile %s at %s, the settings already changed were set back: %w", profile.Name, change.Key, err)
+		}
+	}
+
+	reverter.Success()
+
+	logger.Infof("Applied profile %s, %d settings changed", profile.Name, len(changes))
+	return changes, daemons, nil
+}
+
+// revertProfileChange sets a setting changed by a profile back to its previous value, unsetting it
+// if it had none.
+func revertProfileChange(ctx context.Context, s state.State, change types.ProfileChange, by string) error {
+	c := types.Config{Key: change.Key, Value: change.OldValue}
+
+	switch change.Kind {
+	case types.ProfileConfig:
+		var err error
+		if len(change.OldValue) == 0 {
+			_, err = ResetConfig(ctx, s, c, by)
+		} else {
+			_, err = ChangeConfig(ctx, s, c, by)
+		}
+
+		return err
+	case types.ProfileClientConfig:
+		if len(change.OldValue) == 0 {
+			return database.ClientConfigQuery.RemoveOneForKeyAndHost(ctx, s, change.Key, constants.ClientConfigGlobalHostConst)
+		}
+
+		return database.ClientConfigQuery.AddNew(ctx, s, change.Key, change.OldValue, constants.ClientConfigGlobalHostConst)
+	}
+
+	return nil
+}
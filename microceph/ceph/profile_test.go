@@ -0,0 +1,174 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type profileSuite struct {
+	tests.BaseSuite
+}
+
+func TestProfile(t *testing.T) {
+	suite.Run(t, new(profileSuite))
+}
+
+const hddProfile = `description: HDD clusters with a dedicated recovery budget
+configs:
+  osd_max_backfills: "2"
+pool_defaults:
+  size: "2"
+`
+
+func (s *profileSuite) TestListProfiles() {
+	origDir := profilesDir
+	defer func() { profilesDir = origDir }()
+	profilesDir = func() string { return s.Tmp }
+
+	assert.NoError(s.T(), os.WriteFile(filepath.Join(s.Tmp, "hdd.yaml"), []byte(hddProfile), 0644))
+	// Built-in profiles can't be overridden, invalid files are skipped.
+	assert.NoError(s.T(), os.WriteFile(filepath.Join(s.Tmp, "small.yaml"), []byte(hddProfile), 0644))
+	assert.NoError(s.T(), os.WriteFile(filepath.Join(s.Tmp, "broken.yaml"), []byte("unknown: field\n"), 0644))
+
+	profiles, err := ListProfiles()
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), profiles, 4)
+	assert.Equal(s.T(), "hdd", profiles[3].Name)
+	assert.Equal(s.T(), map[string]string{"osd_max_backfills": "2"}, profiles[3].Configs)
+
+	profile, err := GetProfile("small")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "1", profile.PoolDefaults["size"])
+
+	_, err = GetProfile("unknown")
+	assert.ErrorContains(s.T(), err, "unknown profile unknown")
+}
+
+func (s *profileSuite) TestProfileConfigs() {
+	profile := types.Profile{
+		Configs:      map[string]string{"osd_max_backfills": "2"},
+		PoolDefaults: map[string]string{"size": "2", "min_size": "1"},
+	}
+
+	assert.Equal(s.T(), []types.Config{
+		{Key: "osd_max_backfills", Value: "2"},
+		{Key: "osd_pool_default_min_size", Value: "1"},
+		{Key: "osd_pool_default_size", Value: "2"},
+	}, profileConfigs(profile))
+}
+
+func (s *profileSuite) TestPlanProfile() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[{"section":"osd","name":"osd_max_backfills","value":"1"},{"section":"global","name":"osd_pool_default_size","value":"2"}]`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "help", "osd_max_backfills", "-f", "json").Return(`{"name":"osd_max_backfills","type":"uint","services":["osd"],"can_update_at_runtime":true,"flags":[]}`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "help", "osd_pool_default_size", "-f", "json").Return(`{"name":"osd_pool_default_size","type":"uint","services":[],"min":0,"max":10,"can_update_at_runtime":true,"flags":["runtime"]}`, nil).Once()
+	common.ProcessExec = r
+
+	q := mocks.NewClientConfigQueryIntf(s.T())
+	q.On("GetAllForKey", mock.Anything, "rbd_cache").Return(database.ClientConfigItems{{Host: "node1", Key: "rbd_cache", Value: "false"}}, nil).Once()
+	database.ClientConfigQuery = q
+
+	profile := types.Profile{
+		Name:          "hdd",
+		Configs:       map[string]string{"osd_max_backfills": "2"},
+		ClientConfigs: map[string]string{"rbd_cache": "true"},
+		PoolDefaults:  map[string]string{"size": "2"},
+	}
+
+	// The pool size already matches, the host specific client config doesn't count.
+	changes, err := planProfile(context.Background(), &mocks.MockState{ClusterName: "node0"}, profile)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []types.ProfileChange{
+		{Kind: types.ProfileConfig, Key: "osd_max_backfills", OldValue: "1", NewValue: "2"},
+		{Kind: types.ProfileClientConfig, Key: "rbd_cache", NewValue: "true"},
+	}, changes)
+}
+
+func (s *profileSuite) TestPlanProfileInvalid() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[]`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "help", "osd_pool_default_size", "-f", "json").Return(`{"name":"osd_pool_default_size","type":"uint","services":[],"min":0,"max":10,"can_update_at_runtime":true,"flags":[]}`, nil).Once()
+	common.ProcessExec = r
+
+	_, err := planProfile(context.Background(), &mocks.MockState{ClusterName: "node0"}, types.Profile{PoolDefaults: map[string]string{"size": "12"}})
+	assert.ErrorContains(s.T(), err, "12 is above the maximum of 10")
+
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(`[]`, nil).Once()
+	_, err = planProfile(context.Background(), &mocks.MockState{ClusterName: "node0"}, types.Profile{ClientConfigs: map[string]string{"rbd_unknown": "1"}})
+	assert.ErrorContains(s.T(), err, "rbd_unknown is not a supported client config")
+}
+
+func (s *profileSuite) TestApplyProfileRevert() {
+	before := `[{"section":"osd","name":"osd_max_backfills","value":"1"}]`
+	after := `[{"section":"osd","name":"osd_max_backfills","value":"2"}]`
+
+	r := mocks.NewRunner(s.T())
+	// Planning and changing the first setting see the previous value, the dumps after its change the new one.
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(before, nil).Twice()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(after, nil).Times(4)
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(before, nil).Once()
+	r.On("RunCommand", "ceph", "config", "help", "osd_max_backfills", "-f", "json").Return(`{"name":"osd_max_backfills","type":"uint","services":["osd"],"can_update_at_runtime":true,"flags":[]}`, nil)
+	r.On("RunCommand", "ceph", "config", "help", "osd_recovery_max_active", "-f", "json").Return(`{"name":"osd_recovery_max_active","type":"uint","services":["osd"],"can_update_at_runtime":true,"flags":[]}`, nil)
+	r.On("RunCommand", "ceph", "config", "set", "osd", "osd_max_backfills", "2", "-f", "json-pretty").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "osd", "osd_recovery_max_active", "4", "-f", "json-pretty").Return("", errors.New("Error EACCES")).Once()
+	// Both settings are set back once the second fails, the one without a previous value is unset.
+	r.On("RunCommand", "ceph", "config", "rm", "osd", "osd_recovery_max_active").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "osd", "osd_max_backfills", "1", "-f", "json-pretty").Return("", nil).Once()
+	common.ProcessExec = r
+
+	q := mocks.NewConfigHistoryQueryInterface(s.T())
+	q.On("Add", mock.Anything, mock.Anything, mock.MatchedBy(func(change types.ConfigChange) bool {
+		return change.Key == "osd_max_backfills" && change.NewValue == "2"
+	})).Return(int64(1), nil).Once()
+	q.On("Add", mock.Anything, mock.Anything, mock.MatchedBy(func(change types.ConfigChange) bool {
+		return change.Key == "osd_max_backfills" && change.OldValue == "2" && change.NewValue == "1"
+	})).Return(int64(2), nil).Once()
+	database.ConfigHistoryQuery = q
+
+	profile := types.Profile{Name: "hdd", Configs: map[string]string{"osd_max_backfills": "2", "osd_recovery_max_active": "4"}}
+	changes, daemons, err := ApplyProfile(context.Background(), &mocks.MockState{ClusterName: "node0"}, profile, false, "admin")
+	assert.ErrorContains(s.T(), err, "failed to apply profile hdd at osd_recovery_max_active, the settings already changed were set back")
+	assert.Empty(s.T(), changes)
+	assert.Empty(s.T(), daemons)
+}
+
+func (s *profileSuite) TestApplyProfileRevertUnrecorded() {
+	before := `[{"section":"osd","name":"osd_max_backfills","value":"1"}]`
+	after := `[{"section":"osd","name":"osd_max_backfills","value":"2"}]`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(before, nil).Twice()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(after, nil).Twice()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json-pretty").Return(before, nil).Once()
+	r.On("RunCommand", "ceph", "config", "help", "osd_max_backfills", "-f", "json").Return(`{"name":"osd_max_backfills","type":"uint","services":["osd"],"can_update_at_runtime":true,"flags":[]}`, nil)
+	r.On("RunCommand", "ceph", "config", "set", "osd", "osd_max_backfills", "2", "-f", "json-pretty").Return("", nil).Once()
+	// The setting changed in Ceph before recording it failed, it is set back.
+	r.On("RunCommand", "ceph", "config", "set", "osd", "osd_max_backfills", "1", "-f", "json-pretty").Return("", nil).Once()
+	common.ProcessExec = r
+
+	q := mocks.NewConfigHistoryQueryInterface(s.T())
+	q.On("Add", mock.Anything, mock.Anything, mock.MatchedBy(func(change types.ConfigChange) bool {
+		return change.NewValue == "2"
+	})).Return(int64(0), errors.New("database is locked")).Once()
+	q.On("Add", mock.Anything, mock.Anything, mock.MatchedBy(func(change types.ConfigChange) bool {
+		return change.OldValue == "2" && change.NewValue == "1"
+	})).Return(int64(1), nil).Once()
+	database.ConfigHistoryQuery = q
+
+	profile := types.Profile{Name: "hdd", Configs: map[string]string{"osd_max_backfills": "2"}}
+	_, _, err := ApplyProfile(context.Background(), &mocks.MockState{ClusterName: "node0"}, profile, false, "admin")
+	assert.ErrorContains(s.T(), err, "failed to apply profile hdd at osd_max_backfills, the settings already changed were set back")
+}
@@ -193,34 +193,41 @@ func EnablePoolMirroring(pool string, mode types.RbdResourceType, localName stri
 	return BootstrapPeer(pool, localName, remoteName)
 }
 
-// DisablePoolMirroring disables mirroring for an rbd pool.
-func DisablePoolMirroring(pool string, peer RbdReplicationPeer, localName string, remoteName string) error {
-	// remove peer permissions
-	err := RemovePeer(pool, localName, remoteName)
-	if err != nil {
-		logger.Errorf("REPRBD: %s", err.Error())
-		return err
+// DisablePoolMirroring removes the provided remotes as rbd-mirror peers of an rbd pool.
+// Mirroring on the local pool is only disabled if disableLocal is set, i.e. no other peer remains.
+func DisablePoolMirroring(pool string, remotes types.RemoteRecords, disableLocal bool) error {
+	for _, remote := range remotes {
+		// remove peer permissions
+		err := RemovePeer(pool, remote.LocalName, remote.Name)
+		if err != nil {
+			logger.Errorf("REPRBD: %s", err.Error())
+			return err
+		}
 	}
 
-	// Disable pool mirroring on the local cluster.
-	err = configurePoolMirroring(pool, types.RbdResourceDisabled, "", "")
-	if err != nil {
-		logger.Errorf("REPRBD: failed to disable the primary pool mirroring %s", err.Error())
-		return err
+	if disableLocal {
+		// Disable pool mirroring on the local cluster.
+		err := configurePoolMirroring(pool, types.RbdResourceDisabled, "", "")
+		if err != nil {
+			logger.Errorf("REPRBD: failed to disable the primary pool mirroring %s", err.Error())
+			return err
+		}
 	}
 
-	err = retry.Retry(func(i uint) error {
-		// Disable pool mirroring on the remote cluster.
-		err = configurePoolMirroring(pool, types.RbdResourceDisabled, localName, remoteName)
+	for _, remote := range remotes {
+		err := retry.Retry(func(i uint) error {
+			// Disable pool mirroring on the remote cluster.
+			err := configurePoolMirroring(pool, types.RbdResourceDisabled, remote.LocalName, remote.Name)
+			if err != nil {
+				logger.Errorf("REPRBD: attempt %d: %s", i, err.Error())
+				return err
+			}
+			return nil
+		}, strategy.Delay(5), strategy.Limit(10), strategy.Backoff(backoff.Linear(5*time.Second)))
 		if err != nil {
-			logger.Errorf("REPRBD: attempt %d: %s", i, err.Error())
+			logger.Errorf("REPRBD: failed to disable the secondary pool mirroring on %s: %s", remote.Name, err.Error())
 			return err
 		}
-		return nil
-	}, strategy.Delay(5), strategy.Limit(10), strategy.Backoff(backoff.Linear(5*time.Second)))
-	if err != nil {
-		logger.Errorf("REPRBD: failed to disable the secondary pool mirroring: %s", err.Error())
-		return err
 	}
 
 	return nil
@@ -0,0 +1,232 @@
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+type RbdReplicationGroupImage struct {
+	PoolName  string `json:"pool_name"`
+	ImageName string `json:"image_name"`
+}
+
+type RbdReplicationGroupStatus struct {
+	Name        string                     `json:"name"`
+	State       ReplicationState           // whether replication is enabled or disabled
+	IsPrimary   bool                       // not fetched from json field hence no tag for json.
+	ID          string                     `json:"global_id"`
+	Status      string                     `json:"state"`
+	LastUpdate  string                     `json:"last_update"`
+	Images      []RbdReplicationGroupImage `json:"images"`
+	Peers       []RbdReplicationImagePeer  `json:"peer_sites"`
+	Description string                     `json:"description"`
+}
+
+// GetRbdMirrorGroupStatus fetches mirroring status for requested rbd group.
+func GetRbdMirrorGroupStatus(pool string, group string, cluster string, client string) (RbdReplicationGroupStatus, error) {
+	resource := fmt.Sprintf("%s/%s", pool, group)
+	response := RbdReplicationGroupStatus{}
+	args := []string{"mirror", "group", "status", resource, "--format", "json"}
+
+	// add --cluster and --id args
+	args = appendRemoteClusterArgs(args, cluster, client)
+
+	output, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		logger.Warnf("REPRBD: failed group status operation on res(%s): %v", resource, err)
+		return RbdReplicationGroupStatus{State: StateDisabledReplication}, nil
+	}
+
+	err = json.Unmarshal([]byte(output), &response)
+	if err != nil {
+		ne := fmt.Errorf("cannot unmarshal rbd response: %v", err)
+		logger.Errorf("REPRBD: %s", ne.Error())
+		return RbdReplicationGroupStatus{State: StateDisabledReplication}, ne
+	}
+
+	logger.Debugf("REPRBD: Group Status: %v", response)
+
+	// Patch required values
+	response.State = StateEnabledReplication
+	response.IsPrimary = strings.Contains(response.Description, "local group is primary")
+
+	return response, nil
+}
+
+// configureGroupMirroring enables/disables snapshot based mirroring for an rbd group.
+func configureGroupMirroring(req types.RbdReplicationRequest) error {
+	resource := fmt.Sprintf("%s/%s", req.SourcePool, req.SourceGroup)
+	var args []string
+
+	if req.ReplicationType == types.RbdReplicationDisabled {
+		args = []string{"mirror", "group", "disable", resource}
+	} else {
+		args = []string{"mirror", "group", "enable", resource, string(types.RbdReplicationSnapshot)}
+	}
+
+	_, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		logger.Errorf("REPRBD: %s", err.Error())
+		return fmt.Errorf("failed to configure rbd group(%s) mirroring: %v", resource, err)
+	}
+
+	if req.ReplicationType == types.RbdReplicationDisabled {
+		return nil
+	}
+
+	err = createGroupSnapshot(req.SourcePool, req.SourceGroup)
+	if err != nil {
+		logger.Errorf("REPRBD: %s", err.Error())
+		return fmt.Errorf("failed to create group(%s) snapshot : %v", resource, err)
+	}
+
+	err = configureGroupSnapshotSchedule(req.SourcePool, req.SourceGroup, req.Schedule, "")
+	if err != nil {
+		logger.Errorf("REPRBD: %s", err.Error())
+		return fmt.Errorf("failed to create group(%s) snapshot schedule(%s) : %v", resource, req.Schedule, err)
+	}
+
+	return nil
+}
+
+// createGroupSnapshot creates a crash consistent mirror snapshot of all images in the requested group.
+func createGroupSnapshot(pool string, group string) error {
+	args := []string{"mirror", "group", "snapshot", fmt.Sprintf("%s/%s", pool, group)}
+
+	_, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		logger.Errorf("REPRBD: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// getGroupSnapshotSchedule fetches the mirror snapshot schedule of the requested group.
+func getGroupSnapshotSchedule(pool string, group string) (imageSnapshotSchedule, error) {
+	if len(pool) == 0 || len(group) == 0 {
+		return imageSnapshotSchedule{}, fmt.Errorf("GroupName(%s/%s) not complete", pool, group)
+	}
+
+	args := []string{"mirror", "group", "snapshot", "schedule", "list", "--pool", pool, "--group", group}
+	output, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		logger.Errorf("REPRBD: %s", err.Error())
+		return imageSnapshotSchedule{}, err
+	}
+
+	ret := []imageSnapshotSchedule{}
+	err = json.Unmarshal([]byte(output), &ret)
+	if err != nil || len(ret) == 0 {
+		logger.Debugf("REPRBD: no snapshot schedule found for group %s/%s", pool, group)
+		return imageSnapshotSchedule{}, nil
+	}
+
+	return ret[0], nil
+}
+
+// configureGroupSnapshotSchedule adds a mirror snapshot schedule for the requested group.
+func configureGroupSnapshotSchedule(pool string, group string, schedule string, startTime string) error {
+	if len(schedule) == 0 {
+		logger.Debugf("Empty schedule, no-op for group (%s/%s)", pool, group)
+		return nil
+	}
+
+	args := []string{"mirror", "group", "snapshot", "schedule", "add", "--pool", pool, "--group", group, schedule}
+
+	// Also add start-time param if provided.
+	if len(startTime) != 0 {
+		args = append(args, startTime)
+	}
+
+	_, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		logger.Errorf("REPRBD: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// listAllGroupsInPool lists all rbd groups in the requested pool.
+func listAllGroupsInPool(pool string, localName string, remoteName string) []string {
+	args := []string{"group", "list", pool, "--format", "json"}
+
+	// add --cluster and --id args
+	args = appendRemoteClusterArgs(args, remoteName, localName)
+
+	output, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		return []string{}
+	}
+
+	var ret []string
+	err = json.Unmarshal([]byte(output), &ret)
+	if err != nil {
+		logger.Errorf("REPRBD: unexpected error encountered while parsing json output %s", output)
+		return []string{}
+	}
+
+	return ret
+}
+
+// listMirroringGroupsInPool lists rbd groups with mirroring enabled in the requested pool.
+func listMirroringGroupsInPool(pool string) []string {
+	groups := []string{}
+	for _, group := range listAllGroupsInPool(pool, "", "") {
+		status, err := GetRbdMirrorGroupStatus(pool, group, "", "")
+		if err != nil || status.State != StateEnabledReplication {
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// promoteGroup promotes the requested rbd group to primary.
+func promoteGroup(pool string, group string, isForce bool) error {
+	args := []string{"mirror", "group", "promote", fmt.Sprintf("%s/%s", pool, group)}
+
+	if isForce {
+		args = append(args, "--force")
+	}
+
+	output, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		return fmt.Errorf("failed to promote group(%s/%s): %v", pool, group, err)
+	}
+
+	logger.Debugf("REPRBD: Group Promotion Output: %s", output)
+	return nil
+}
+
+// demoteGroup demotes the requested rbd group to non-primary.
+func demoteGroup(pool string, group string) error {
+	args := []string{"mirror", "group", "demote", fmt.Sprintf("%s/%s", pool, group)}
+
+	output, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		return fmt.Errorf("failed to demote group(%s/%s): %v", pool, group, err)
+	}
+
+	logger.Debugf("REPRBD: Group Demotion Output: %s", output)
+	return nil
+}
+
+// flagGroupForResync flags requested mirroring group in the given pool for resync.
+func flagGroupForResync(pool string, group string) error {
+	args := []string{"mirror", "group", "resync", fmt.Sprintf("%s/%s", pool, group)}
+
+	_, err := common.ProcessExec.RunCommand("rbd", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
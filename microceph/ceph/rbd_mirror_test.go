@@ -134,3 +134,152 @@ func (ks *RbdMirrorSuite) TestDemotePoolOnSecondary() {
 	err := handlePoolDemotion("pool")
 	assert.NoError(ks.T(), err)
 }
+
+func (ks *RbdMirrorSuite) TestPeerImageStatesForMultiplePeers() {
+	r := mocks.NewRunner(ks.T())
+
+	output, _ := os.ReadFile("./test_assets/rbd_mirror_verbose_pool_status_multi_peer.json")
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "pool", "status", "pool", "--verbose", "--format", "json"}...).Return(string(output), nil).Once()
+	common.ProcessExec = r
+
+	// Method call
+	states := getPeerImageStates("pool")
+	assert.Equal(ks.T(), map[string]int{"up+replaying": 2}, states["simple"])
+	assert.Equal(ks.T(), map[string]int{"up+replaying": 1, "up+syncing": 1}, states["distant"])
+}
+
+func (ks *RbdMirrorSuite) TestPoolDisablementRemovesRequestedPeerOnly() {
+	r := mocks.NewRunner(ks.T())
+
+	output, _ := os.ReadFile("./test_assets/rbd_mirror_pool_info_multi_peer.json")
+	remoteOutput := `{"mode": "pool", "site_name": "distant", "peers": [{"uuid": "9c1d2b7a-6e1f-4b8e-bf0e-3a7c5d9e2f10", "direction": "rx-tx", "site_name": "magical"}]}`
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "pool", "info", "pool", "--format", "json"}...).Return(string(output), nil).Twice()
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "pool", "info", "pool", "--format", "json", "--cluster", "distant", "--id", "magical"}...).Return(remoteOutput, nil).Once()
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "pool", "peer", "remove", "pool", "5d4a9a3e-2c0b-4f5e-9f5d-8a8a4c1e7b21"}...).Return("ok", nil).Once()
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "pool", "peer", "remove", "pool", "9c1d2b7a-6e1f-4b8e-bf0e-3a7c5d9e2f10", "--cluster", "distant", "--id", "magical"}...).Return("ok", nil).Once()
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "pool", "disable", "pool", "--cluster", "distant", "--id", "magical"}...).Return("ok", nil).Once()
+	common.ProcessExec = r
+
+	poolInfo, err := GetRbdMirrorPoolInfo("pool", "", "")
+	assert.NoError(ks.T(), err)
+
+	rh := RbdReplicationHandler{
+		PoolInfo:   poolInfo,
+		PoolStatus: RbdReplicationPoolStatus{Health: RbdReplicationHealthOK},
+		Request:    types.RbdReplicationRequest{SourcePool: "pool", Remotes: []string{"distant"}},
+	}
+	remotes := types.RemoteRecords{{Name: "distant", LocalName: "magical"}}
+
+	// Method call, local pool mirroring stays enabled for the remaining peer.
+	err = handlePoolDisablement(&rh, remotes)
+	assert.NoError(ks.T(), err)
+}
+
+func (ks *RbdMirrorSuite) TestPoolDisablementUnknownPeer() {
+	r := mocks.NewRunner(ks.T())
+
+	output, _ := os.ReadFile("./test_assets/rbd_mirror_pool_info_multi_peer.json")
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "pool", "info", "pool", "--format", "json"}...).Return(string(output), nil).Once()
+	common.ProcessExec = r
+
+	poolInfo, err := GetRbdMirrorPoolInfo("pool", "", "")
+	assert.NoError(ks.T(), err)
+
+	rh := RbdReplicationHandler{
+		PoolInfo:   poolInfo,
+		PoolStatus: RbdReplicationPoolStatus{Health: RbdReplicationHealthOK},
+		Request:    types.RbdReplicationRequest{SourcePool: "pool", Remotes: []string{"distant", "unknown"}},
+	}
+	remotes := types.RemoteRecords{
+		{Name: "distant", LocalName: "magical"},
+		{Name: "unknown", LocalName: "magical"},
+	}
+
+	// Method call, nothing is disabled as a requested remote is not a peer of the pool.
+	err = handlePoolDisablement(&rh, remotes)
+	assert.ErrorContains(ks.T(), err, "remote(s) unknown are not peers of pool (pool)")
+}
+
+func (ks *RbdMirrorSuite) TestGroupStatus() {
+	r := mocks.NewRunner(ks.T())
+
+	output, _ := os.ReadFile("./test_assets/rbd_mirror_group_status.json")
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "group", "status", "pool/group_one", "--format", "json"}...).Return(string(output), nil).Once()
+	common.ProcessExec = r
+
+	// Method call
+	resp, err := GetRbdMirrorGroupStatus("pool", "group_one", "", "")
+	assert.NoError(ks.T(), err)
+	assert.Equal(ks.T(), resp.Name, "group_one")
+	assert.Equal(ks.T(), resp.State, StateEnabledReplication)
+	assert.Equal(ks.T(), resp.IsPrimary, true)
+	assert.Equal(ks.T(), len(resp.Images), 2)
+	assert.Equal(ks.T(), resp.Peers[0].RemoteName, "simple")
+}
+
+func (ks *RbdMirrorSuite) TestGroupEnablement() {
+	r := mocks.NewRunner(ks.T())
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "group", "enable", "pool/group_one", "snapshot"}...).Return("ok", nil).Once()
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "group", "snapshot", "pool/group_one"}...).Return("ok", nil).Once()
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "group", "snapshot", "schedule", "add", "--pool", "pool", "--group", "group_one", "1h"}...).Return("ok", nil).Once()
+	common.ProcessExec = r
+
+	rh := RbdReplicationHandler{
+		PoolInfo: RbdReplicationPoolInfo{
+			Mode:  types.RbdResourceImage,
+			Peers: []RbdReplicationPeer{{RemoteName: "simple"}},
+		},
+		GroupStatus: RbdReplicationGroupStatus{State: StateDisabledReplication},
+		Request: types.RbdReplicationRequest{
+			SourcePool:      "pool",
+			SourceGroup:     "group_one",
+			Schedule:        "1h",
+			ReplicationType: types.RbdReplicationSnapshot,
+			ResourceType:    types.RbdResourceGroup,
+		},
+	}
+
+	// Method call, remote is already a peer hence no pool mirroring changes.
+	err := handleGroupEnablement(&rh, types.RemoteRecords{{Name: "simple", LocalName: "magical"}})
+	assert.NoError(ks.T(), err)
+
+	// Journaling is not supported for groups.
+	rh.Request.ReplicationType = types.RbdReplicationJournaling
+	err = handleGroupEnablement(&rh, types.RemoteRecords{{Name: "simple", LocalName: "magical"}})
+	assert.ErrorContains(ks.T(), err, "only supported in snapshot mode")
+}
+
+func (ks *RbdMirrorSuite) TestDemoteGroupOnPrimary() {
+	r := mocks.NewRunner(ks.T())
+
+	// mocks and expectations
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "group", "demote", "pool/group_one"}...).Return("ok", nil).Once()
+	r.On("RunCommand", []interface{}{
+		"rbd", "mirror", "group", "resync", "pool/group_one"}...).Return("ok", nil).Once()
+	common.ProcessExec = r
+
+	err := handleGroupDemotion("pool", "group_one")
+	assert.NoError(ks.T(), err)
+}
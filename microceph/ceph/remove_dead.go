@@ -0,0 +1,250 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	microTypes "github.com/canonical/microcluster/v2/rest/types"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// planDead returns the operations removing the traces of a node which is permanently gone.
+// The node is not contacted, everything is cleaned up from the surviving members.
+func (d *Decommission) planDead(req types.DecommissionRequest) ([]Operation, error) {
+	ctx := d.ClusterOps.Context
+	s := d.ClusterOps.State
+
+	if d.Node == s.Name() {
+		return nil, fmt.Errorf("cannot remove the local node '%s', run the removal from another member", d.Node)
+	}
+
+	leader, err := s.Leader()
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := leader.GetClusterMembers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster members: %w", err)
+	}
+
+	idx := slices.IndexFunc(members, func(member microTypes.ClusterMember) bool { return member.Name == d.Node })
+	if idx < 0 {
+		return nil, fmt.Errorf("node '%s' not found", d.Node)
+	}
+
+	if members[idx].Status == microTypes.MemberOnline && !req.Force {
+		return nil, fmt.Errorf("node '%s' is online, decommission it instead or use --force", d.Node)
+	}
+
+	disks, err := ListOSD(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	services, err := ListAllServices(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	operations := []Operation{}
+	for _, disk := range disks {
+		if disk.Location == d.Node {
+			operations = append(operations, &PurgeOsdOps{ClusterOps: d.ClusterOps, OSD: disk.OSD})
+		}
+	}
+
+	nfsClusters := []string{}
+	for _, service := range services {
+		if service.Location != d.Node {
+			continue
+		}
+
+		switch service.Service {
+		case "mon":
+			operations = append(operations, &RemoveMonOps{ClusterOps: d.ClusterOps})
+		case "nfs":
+			nfsClusters = append(nfsClusters, service.GroupID)
+		}
+	}
+
+	operations = append(operations,
+		&RemoveCrushHostOps{ClusterOps: d.ClusterOps},
+		&RemoveAuthKeysOps{ClusterOps: d.ClusterOps, NFSClusters: nfsClusters},
+		&RemoveNodeRecordsOps{ClusterOps: d.ClusterOps},
+		&RemoveMemberOps{ClusterOps: d.ClusterOps, Force: true},
+	)
+
+	return operations, nil
+}
+
+// PurgeOsdOps is an operation to purge an OSD of a dead node.
+type PurgeOsdOps struct {
+	ClusterOps
+	OSD int64
+}
+
+// Run purges the OSD from ceph unless already gone, its record is removed along with the other records of the node.
+func (o *PurgeOsdOps) Run(name string) error {
+	m := NewOSDManager(o.State)
+
+	present, err := m.haveOSDInCeph(o.OSD)
+	if err != nil {
+		return err
+	}
+
+	if present {
+		osd := fmt.Sprintf("osd.%d", o.OSD)
+
+		// Nothing is left to stop the daemon, mark it down so it can be purged.
+		_, err = m.runner.RunCommand("ceph", "osd", "down", osd)
+		if err != nil {
+			logger.Warnf("failed to mark down %s: %v", osd, err)
+		}
+
+		err = m.purgeOSD(o.OSD)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("purged osd.%d of node '%s'", o.OSD, name)
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *PurgeOsdOps) DryRun(name string) string {
+	return fmt.Sprintf("Purge osd.%d of node '%s'.", o.OSD, name)
+}
+
+// GetName returns the name of the action
+func (o *PurgeOsdOps) GetName() string {
+	return "purge-osd-ops"
+}
+
+// RemoveMonOps is an operation to remove the monitor of a dead node from the monmap.
+type RemoveMonOps struct {
+	ClusterOps
+}
+
+// haveMon checks if the monmap holds a monitor of the given name.
+func haveMon(name string) (bool, error) {
+	output, err := cephRun("mon", "dump", "-f", "json")
+	if err != nil {
+		return false, fmt.Errorf("failed to get monmap: %w", err)
+	}
+
+	var monmap struct {
+		Mons []struct {
+			Name string `json:"name"`
+		} `json:"mons"`
+	}
+	err = json.Unmarshal([]byte(output), &monmap)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse monmap: %w", err)
+	}
+
+	for _, mon := range monmap.Mons {
+		if mon.Name == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Run removes the monitor of the node if it is still in the monmap.
+func (o *RemoveMonOps) Run(name string) error {
+	present, err := haveMon(name)
+	if err != nil {
+		return err
+	}
+	if !present {
+		logger.Infof("mon.%s already removed", name)
+		return nil
+	}
+
+	err = removeMon(name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("removed mon.%s from the monmap", name)
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *RemoveMonOps) DryRun(name string) string {
+	return fmt.Sprintf("Remove the monitor of node '%s' from the monmap.", name)
+}
+
+// GetName returns the name of the action
+func (o *RemoveMonOps) GetName() string {
+	return "remove-mon-ops"
+}
+
+// RemoveNodeRecordsOps is an operation to remove the database records of a dead node.
+type RemoveNodeRecordsOps struct {
+	ClusterOps
+}
+
+// Run removes the disk, service and grouped service records of the node along with its config keys.
+func (o *RemoveNodeRecordsOps) Run(name string) error {
+	err := o.State.Database().Transaction(o.Context, func(ctx context.Context, tx *sql.Tx) error {
+		err := database.DeleteDisks(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		err = database.DeleteServices(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		err = database.DeleteMemberGroupedServices(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		config, err := database.GetConfigItems(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		keys := []string{fmt.Sprintf("mon.host.%s", name), autoAddConfigKey(name)}
+		for _, item := range config {
+			if !slices.Contains(keys, item.Key) {
+				continue
+			}
+
+			err = database.DeleteConfigItem(ctx, tx, item.Key)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove records of node '%s': %w", name, err)
+	}
+
+	logger.Infof("removed database records of node '%s'", name)
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *RemoveNodeRecordsOps) DryRun(name string) string {
+	return fmt.Sprintf("Remove the disk, service and config records of node '%s'.", name)
+}
+
+// GetName returns the name of the action
+func (o *RemoveNodeRecordsOps) GetName() string {
+	return "remove-node-records-ops"
+}
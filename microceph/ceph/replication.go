@@ -80,6 +80,8 @@ func GetReplicationStateMachine(initialState ReplicationState) *stateless.StateM
 	newFsm.Configure(StateEnabledReplication).
 		Permit(constants.EventDisableReplication, StateDisabledReplication).
 		OnEntryFrom(constants.EventEnableReplication, enableHandler).
+		// enabling an already enabled resource adds the requested remotes as peers.
+		InternalTransition(constants.EventEnableReplication, enableHandler).
 		InternalTransition(constants.EventConfigureReplication, configureHandler).
 		InternalTransition(constants.EventListReplication, listHandler).
 		InternalTransition(constants.EventStatusReplication, statusHandler).
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/canonical/microceph/microceph/logger"
@@ -11,6 +12,7 @@ import (
 	"github.com/canonical/microceph/microceph/constants"
 	"github.com/canonical/microceph/microceph/database"
 	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microcluster/v2/state"
 )
 
 type RbdReplicationPeer struct {
@@ -78,6 +80,7 @@ type RbdReplicationHandler struct {
 	PoolInfo    RbdReplicationPoolInfo    `json:"pool_info"`
 	PoolStatus  RbdReplicationPoolStatus  `json:"pool_status"`
 	ImageStatus RbdReplicationImageStatus `json:"image_status"`
+	GroupStatus RbdReplicationGroupStatus `json:"group_status"`
 	// Request Info
 	Request types.RbdReplicationRequest
 }
@@ -105,6 +108,12 @@ func (rh *RbdReplicationHandler) PreFill(ctx context.Context, request types.Repl
 		return err
 	}
 
+	if req.ResourceType == types.RbdResourceGroup {
+		// Populate group status
+		rh.GroupStatus, err = GetRbdMirrorGroupStatus(req.SourcePool, req.SourceGroup, "", "")
+		return err
+	}
+
 	return nil
 }
 
@@ -115,6 +124,10 @@ func (rh *RbdReplicationHandler) GetResourceState() ReplicationState {
 		return rh.ImageStatus.State
 	}
 
+	if rh.Request.ResourceType == types.RbdResourceGroup {
+		return rh.GroupStatus.State
+	}
+
 	// Pool request
 	return rh.PoolStatus.State
 }
@@ -123,22 +136,28 @@ func (rh *RbdReplicationHandler) GetResourceState() ReplicationState {
 func (rh *RbdReplicationHandler) EnableHandler(ctx context.Context, args ...any) error {
 	logger.Debugf("REPFSM: Enable handler, Req %v", rh.Request)
 
+	remoteNames := rh.Request.GetRemoteNames()
+	if len(remoteNames) == 0 {
+		return fmt.Errorf("no remote provided for enabling rbd mirroring")
+	}
+
 	st := args[repArgState].(interfaces.CephState).ClusterState()
-	dbRec, err := database.GetRemoteDb(ctx, st, rh.Request.RemoteName)
+	remotes, err := getRemoteRecords(ctx, st, remoteNames)
 	if err != nil {
-		errNew := fmt.Errorf("remote (%s) does not exist: %w", rh.Request.RemoteName, err)
-		return errNew
+		return err
 	}
 
-	logger.Infof("REPRBD: Local(%s) Remote(%s)", dbRec[0].LocalName, dbRec[0].Name)
+	logger.Infof("REPRBD: Local(%s) Remotes(%v)", remotes[0].LocalName, remoteNames)
 	if rh.Request.ResourceType == types.RbdResourcePool {
 		if rh.Request.ReplicationType == types.RbdReplicationSnapshot {
 			return fmt.Errorf("Snapshot-based replication is only supported for individual RBD images, not pools")
 		} else {
-			return handlePoolEnablement(rh, dbRec[0].LocalName, dbRec[0].Name)
+			return handlePoolEnablement(rh, remotes)
 		}
 	} else if rh.Request.ResourceType == types.RbdResourceImage {
-		return handleImageEnablement(rh, dbRec[0].LocalName, dbRec[0].Name)
+		return handleImageEnablement(rh, remotes)
+	} else if rh.Request.ResourceType == types.RbdResourceGroup {
+		return handleGroupEnablement(rh, remotes)
 	}
 
 	return fmt.Errorf("unknown enable request for rbd mirroring %s", rh.Request.ResourceType)
@@ -149,17 +168,26 @@ func (rh *RbdReplicationHandler) DisableHandler(ctx context.Context, args ...any
 	logger.Debugf("REPFSM: Disable handler, Req %v", rh.Request)
 
 	st := args[repArgState].(interfaces.CephState).ClusterState()
-	dbRec, err := database.GetRemoteDb(ctx, st, rh.Request.RemoteName)
+	remoteNames := rh.Request.GetRemoteNames()
+	if len(remoteNames) == 0 {
+		// No remote requested, disable mirroring against all peers of the pool.
+		for _, peer := range rh.PoolInfo.Peers {
+			remoteNames = append(remoteNames, peer.RemoteName)
+		}
+	}
+
+	remotes, err := getRemoteRecords(ctx, st, remoteNames)
 	if err != nil {
-		errNew := fmt.Errorf("remote (%s) does not exist: %w", rh.Request.RemoteName, err)
-		return errNew
+		return err
 	}
 
-	logger.Infof("REPRBD: Entered RBD Disable Handler Local(%s) Remote(%s)", dbRec[0].LocalName, dbRec[0].Name)
+	logger.Infof("REPRBD: Entered RBD Disable Handler Remotes(%v)", remoteNames)
 	if rh.Request.ResourceType == types.RbdResourcePool {
-		return handlePoolDisablement(rh, dbRec[0].LocalName, dbRec[0].Name)
+		return handlePoolDisablement(rh, remotes)
 	} else if rh.Request.ResourceType == types.RbdResourceImage {
 		return handleImageDisablement(rh)
+	} else if rh.Request.ResourceType == types.RbdResourceGroup {
+		return handleGroupDisablement(rh)
 	}
 
 	return fmt.Errorf("unknown disable request for rbd mirroring %s", rh.Request.ResourceType)
@@ -169,6 +197,19 @@ func (rh *RbdReplicationHandler) DisableHandler(ctx context.Context, args ...any
 func (rh *RbdReplicationHandler) ConfigureHandler(ctx context.Context, args ...any) error {
 	logger.Debugf("REPFSM: Configure handler, Req %v", rh.Request)
 
+	if rh.Request.ResourceType == types.RbdResourceGroup {
+		schedule, err := getGroupSnapshotSchedule(rh.Request.SourcePool, rh.Request.SourceGroup)
+		if err != nil {
+			return err
+		}
+
+		if rh.Request.Schedule != schedule.Schedule {
+			return configureGroupSnapshotSchedule(rh.Request.SourcePool, rh.Request.SourceGroup, rh.Request.Schedule, "")
+		}
+
+		return nil
+	}
+
 	schedule, err := getSnapshotSchedule(rh.Request.SourcePool, rh.Request.SourceImage)
 	if err != nil {
 		return err
@@ -242,12 +283,14 @@ func (rh *RbdReplicationHandler) StatusHandler(ctx context.Context, args ...any)
 	// Populate Status resp.
 	if rh.Request.ResourceType == types.RbdResourcePool {
 		// handle pool status
+		imageStates := getPeerImageStates(rh.Request.SourcePool)
 		remotes := make([]types.RbdPoolStatusRemoteBrief, len(rh.PoolInfo.Peers))
 		for id, remote := range rh.PoolInfo.Peers {
 			remotes[id] = types.RbdPoolStatusRemoteBrief{
-				Name:      remote.RemoteName,
-				Direction: string(remote.Direction),
-				UUID:      remote.Id,
+				Name:        remote.RemoteName,
+				Direction:   string(remote.Direction),
+				UUID:        remote.Id,
+				ImageStates: imageStates[remote.RemoteName],
 			}
 		}
 
@@ -267,6 +310,7 @@ func (rh *RbdReplicationHandler) StatusHandler(ctx context.Context, args ...any)
 		for id, remote := range rh.ImageStatus.Peers {
 			remotes[id] = types.RbdImageStatusRemoteBrief{
 				Name:             remote.RemoteName,
+				State:            remote.State,
 				Status:           remote.Status,
 				LastRemoteUpdate: remote.LastUpdate,
 			}
@@ -288,6 +332,32 @@ func (rh *RbdReplicationHandler) StatusHandler(ctx context.Context, args ...any)
 			IsPrimary:       rh.ImageStatus.IsPrimary,
 			Remotes:         remotes,
 		}
+	} else if rh.Request.ResourceType == types.RbdResourceGroup {
+		// handle group status
+		remotes := make([]types.RbdImageStatusRemoteBrief, len(rh.GroupStatus.Peers))
+		for id, remote := range rh.GroupStatus.Peers {
+			remotes[id] = types.RbdImageStatusRemoteBrief{
+				Name:             remote.RemoteName,
+				State:            remote.State,
+				Status:           remote.Status,
+				LastRemoteUpdate: remote.LastUpdate,
+			}
+		}
+
+		images := make([]string, len(rh.GroupStatus.Images))
+		for id, image := range rh.GroupStatus.Images {
+			images[id] = fmt.Sprintf("%s/%s", image.PoolName, image.ImageName)
+		}
+
+		resp = types.RbdGroupStatus{
+			Name:            fmt.Sprintf("%s/%s/%s", rh.Request.SourcePool, types.RbdGroupResourceTag, rh.Request.SourceGroup),
+			ID:              rh.GroupStatus.ID,
+			Status:          rh.GroupStatus.Status,
+			LastLocalUpdate: rh.GroupStatus.LastUpdate,
+			IsPrimary:       rh.GroupStatus.IsPrimary,
+			Images:          images,
+			Remotes:         remotes,
+		}
 	} else {
 		return fmt.Errorf("REPRBD: Unable resource type(%s), cannot find status", rh.Request.ResourceType)
 	}
@@ -307,6 +377,10 @@ func (rh *RbdReplicationHandler) StatusHandler(ctx context.Context, args ...any)
 
 // PromoteHandler promotes sequentially promote all secondary cluster pools to primary.
 func (rh *RbdReplicationHandler) PromoteHandler(ctx context.Context, args ...any) error {
+	if rh.Request.ResourceType == types.RbdResourceGroup {
+		return handleGroupPromotion(rh.Request.SourcePool, rh.Request.SourceGroup, rh.Request.IsForceOp)
+	}
+
 	return handleSiteOp(rh)
 }
 
@@ -315,14 +389,25 @@ func (rh *RbdReplicationHandler) DemoteHandler(ctx context.Context, args ...any)
 		return fmt.Errorf("demotion may cause data loss on this cluster. %s", constants.CliForcePrompt)
 	}
 
+	if rh.Request.ResourceType == types.RbdResourceGroup {
+		return handleGroupDemotion(rh.Request.SourcePool, rh.Request.SourceGroup)
+	}
+
 	return handleSiteOp(rh)
 }
 
 // ################### Helper Functions ###################
 // Enable handler for pool resource.
-func handlePoolEnablement(rh *RbdReplicationHandler, localSite string, remoteSite string) error {
+func handlePoolEnablement(rh *RbdReplicationHandler, remotes types.RemoteRecords) error {
 	if rh.PoolInfo.Mode == types.RbdResourcePool {
-		return nil // already in pool mirroring mode
+		// already in pool mirroring mode, only add the remotes which are not peers yet.
+		for _, remote := range getUnregisteredRemotes(rh.PoolInfo.Peers, remotes) {
+			err := EnablePoolMirroring(rh.Request.SourcePool, types.RbdResourcePool, remote.LocalName, remote.Name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	} else
 
 	// Fail if in Image mirroring mode with Mirroring Images > 0
@@ -333,9 +418,11 @@ func handlePoolEnablement(rh *RbdReplicationHandler, localSite string, remoteSit
 		}
 	}
 
-	err := EnablePoolMirroring(rh.Request.SourcePool, types.RbdResourcePool, localSite, remoteSite)
-	if err != nil {
-		return err
+	for _, remote := range remotes {
+		err := EnablePoolMirroring(rh.Request.SourcePool, types.RbdResourcePool, remote.LocalName, remote.Name)
+		if err != nil {
+			return err
+		}
 	}
 
 	if !rh.Request.SkipAutoEnable {
@@ -353,16 +440,22 @@ func handlePoolEnablement(rh *RbdReplicationHandler, localSite string, remoteSit
 }
 
 // Enable handler for image resource.
-func handleImageEnablement(rh *RbdReplicationHandler, localSite string, remoteSite string) error {
-	if rh.PoolInfo.Mode == types.RbdResourceDisabled {
-		// Enable pool mirroring in Image mirroring mode
-		err := EnablePoolMirroring(rh.Request.SourcePool, types.RbdResourceImage, localSite, remoteSite)
-		if err != nil {
-			logger.Error(err.Error())
-			return err
+func handleImageEnablement(rh *RbdReplicationHandler, remotes types.RemoteRecords) error {
+	if rh.PoolInfo.Mode == types.RbdResourceDisabled || rh.PoolInfo.Mode == types.RbdResourceImage {
+		// Enable pool mirroring in Image mirroring mode for remotes which are not peers yet.
+		for _, remote := range getUnregisteredRemotes(rh.PoolInfo.Peers, remotes) {
+			err := EnablePoolMirroring(rh.Request.SourcePool, types.RbdResourceImage, remote.LocalName, remote.Name)
+			if err != nil {
+				logger.Error(err.Error())
+				return err
+			}
 		}
 		// continue for Image enablement
 	} else if rh.PoolInfo.Mode == types.RbdResourcePool {
+		if len(getUnregisteredRemotes(rh.PoolInfo.Peers, remotes)) != 0 {
+			return fmt.Errorf("parent pool (%s) enabled in pool mode, enable the pool for new remotes instead", rh.Request.SourcePool)
+		}
+
 		if rh.Request.ReplicationType == types.RbdReplicationJournaling {
 			return enableRbdImageFeatures(rh.Request.SourcePool, rh.Request.SourceImage, constants.RbdJournalingEnableFeatureSet[:])
 		} else {
@@ -370,12 +463,17 @@ func handleImageEnablement(rh *RbdReplicationHandler, localSite string, remoteSi
 		}
 	}
 
+	// Image already mirroring, it is mirrored to all pool peers including the newly added ones.
+	if rh.ImageStatus.State == StateEnabledReplication {
+		return nil
+	}
+
 	// pool in Image mirroring mode, Enable Image in requested mode.
 	return configureImageMirroring(rh.Request)
 }
 
 // Disable handler for pool resource.
-func handlePoolDisablement(rh *RbdReplicationHandler, localSite string, remoteSite string) error {
+func handlePoolDisablement(rh *RbdReplicationHandler, remotes types.RemoteRecords) error {
 	// Handle Pool already disabled
 	if rh.PoolInfo.Mode == types.RbdResourceDisabled {
 		return nil
@@ -386,6 +484,23 @@ func handlePoolDisablement(rh *RbdReplicationHandler, localSite string, remoteSi
 		return fmt.Errorf("pool replication status not OK(%s), Can't proceed", rh.PoolStatus.Health)
 	}
 
+	// Fail if a requested remote does not mirror the pool, there's nothing to disable for it.
+	unregistered := getUnregisteredRemotes(rh.PoolInfo.Peers, remotes)
+	if len(unregistered) != 0 {
+		names := make([]string, len(unregistered))
+		for i, remote := range unregistered {
+			names[i] = remote.Name
+		}
+
+		return fmt.Errorf("remote(s) %s are not peers of pool (%s)", strings.Join(names, ", "), rh.Request.SourcePool)
+	}
+
+	// Only remove the requested remotes if other peers keep mirroring the pool.
+	registered := getRegisteredRemotes(rh.PoolInfo.Peers, remotes)
+	if len(registered) < len(rh.PoolInfo.Peers) {
+		return DisablePoolMirroring(rh.Request.SourcePool, registered, false)
+	}
+
 	// Fail if in Image mirroring mode with Mirroring Images > 0
 	if rh.PoolInfo.Mode == types.RbdResourceImage {
 		enabledImageCount := rh.PoolStatus.ImageCount
@@ -402,7 +517,7 @@ func handlePoolDisablement(rh *RbdReplicationHandler, localSite string, remoteSi
 		}
 	}
 
-	return DisablePoolMirroring(rh.Request.SourcePool, rh.PoolInfo.Peers[0], localSite, remoteSite)
+	return DisablePoolMirroring(rh.Request.SourcePool, registered, true)
 }
 
 // Disable handler for image resource.
@@ -426,15 +541,143 @@ func handleImageDisablement(rh *RbdReplicationHandler) error {
 	return configureImageMirroring(rh.Request)
 }
 
-func isPeerRegisteredForMirroring(peers []RbdReplicationPeer, peerName string) bool {
+// Enable handler for group resource.
+func handleGroupEnablement(rh *RbdReplicationHandler, remotes types.RemoteRecords) error {
+	if rh.Request.ReplicationType != types.RbdReplicationSnapshot {
+		return fmt.Errorf("group(%s) mirroring is only supported in snapshot mode", rh.Request.SourceGroup)
+	}
+
+	if rh.PoolInfo.Mode == types.RbdResourcePool {
+		return fmt.Errorf("parent pool (%s) enabled in pool mode, group mirroring requires Image mirroring mode", rh.Request.SourcePool)
+	}
+
+	// Enable pool mirroring in Image mirroring mode for remotes which are not peers yet.
+	for _, remote := range getUnregisteredRemotes(rh.PoolInfo.Peers, remotes) {
+		err := EnablePoolMirroring(rh.Request.SourcePool, types.RbdResourceImage, remote.LocalName, remote.Name)
+		if err != nil {
+			logger.Error(err.Error())
+			return err
+		}
+	}
+
+	// Group already mirroring, it is mirrored to all pool peers including the newly added ones.
+	if rh.GroupStatus.State == StateEnabledReplication {
+		return nil
+	}
+
+	return configureGroupMirroring(rh.Request)
+}
+
+// Disable handler for group resource.
+func handleGroupDisablement(rh *RbdReplicationHandler) error {
+	// Pool or group already disabled
+	if rh.PoolInfo.Mode == types.RbdResourceDisabled || rh.GroupStatus.State == StateDisabledReplication {
+		return nil
+	}
+
+	// patch replication type
+	rh.Request.ReplicationType = types.RbdReplicationDisabled
+	return configureGroupMirroring(rh.Request)
+}
+
+// Promote local group to primary.
+func handleGroupPromotion(poolName string, groupName string, isForce bool) error {
+	err := promoteGroup(poolName, groupName, isForce)
+	if err != nil {
+		logger.Errorf("failed to promote group (%s/%s): %v", poolName, groupName, err)
+
+		if strings.Contains(err.Error(), constants.RbdMirrorNonPrimaryPromoteErr) {
+			return fmt.Errorf(constants.CliForcePrompt)
+		}
+
+		return err
+	}
+	return nil
+}
+
+// Demote local group to secondary.
+func handleGroupDemotion(poolName string, groupName string) error {
+	err := demoteGroup(poolName, groupName)
+	if err != nil {
+		logger.Errorf("failed to demote group (%s/%s): %v", poolName, groupName, err)
+		return err
+	}
+
+	err = flagGroupForResync(poolName, groupName)
+	if err != nil {
+		logger.Warnf("failed to trigger resync for group %s/%s: %v", poolName, groupName, err)
+		return err
+	}
+	return nil
+}
+
+// isPeerRegisteredForMirroring checks if any of the provided peer names is a mirroring peer.
+func isPeerRegisteredForMirroring(peers []RbdReplicationPeer, peerNames ...string) bool {
 	for _, peer := range peers {
-		if peer.RemoteName == peerName {
+		if slices.Contains(peerNames, peer.RemoteName) {
 			return true
 		}
 	}
 	return false
 }
 
+// getRegisteredRemotes filters the remotes which are registered as mirroring peers.
+func getRegisteredRemotes(peers []RbdReplicationPeer, remotes types.RemoteRecords) types.RemoteRecords {
+	registered := types.RemoteRecords{}
+	for _, remote := range remotes {
+		if isPeerRegisteredForMirroring(peers, remote.Name) {
+			registered = append(registered, remote)
+		}
+	}
+	return registered
+}
+
+// getUnregisteredRemotes filters the remotes which are not yet registered as mirroring peers.
+func getUnregisteredRemotes(peers []RbdReplicationPeer, remotes types.RemoteRecords) types.RemoteRecords {
+	unregistered := types.RemoteRecords{}
+	for _, remote := range remotes {
+		if !isPeerRegisteredForMirroring(peers, remote.Name) {
+			unregistered = append(unregistered, remote)
+		}
+	}
+	return unregistered
+}
+
+// getRemoteRecords fetches the db records for each of the requested remotes.
+func getRemoteRecords(ctx context.Context, st state.State, remoteNames []string) (types.RemoteRecords, error) {
+	remotes := types.RemoteRecords{}
+	for _, remoteName := range remoteNames {
+		dbRec, err := database.GetRemoteDb(ctx, st, remoteName)
+		if err != nil || len(dbRec) == 0 {
+			return nil, fmt.Errorf("remote (%s) does not exist: %w", remoteName, err)
+		}
+		remotes = append(remotes, dbRec[0])
+	}
+
+	return remotes, nil
+}
+
+// getPeerImageStates counts the mirroring images of a pool per replication state for each peer.
+func getPeerImageStates(poolName string) map[string]map[string]int {
+	peerStates := map[string]map[string]int{}
+	poolStatus, err := GetRbdMirrorVerbosePoolStatus(poolName, "", "")
+	if err != nil {
+		logger.Warnf("REPRBD: failed to fetch verbose status for %s pool: %v", poolName, err)
+		return peerStates
+	}
+
+	for _, image := range poolStatus.Images {
+		for _, peer := range image.Peers {
+			if _, ok := peerStates[peer.RemoteName]; !ok {
+				peerStates[peer.RemoteName] = map[string]int{}
+			}
+			peerStates[peer.RemoteName][peer.State]++
+		}
+	}
+
+	return peerStates
+}
+
 // getMirrorPoolMetadata fetches pool status and info if mirroring is enabled on pool.
 func getMirrorPoolMetadata(poolName string) (RbdReplicationPoolStatus, RbdReplicationPoolInfo, error) {
 	poolStatus, err := GetRbdMirrorPoolStatus(poolName, "", "")
@@ -504,16 +747,27 @@ func handleSiteOp(rh *RbdReplicationHandler) error {
 			continue
 		}
 
-		if !isPeerRegisteredForMirroring(poolInfo.Peers, rh.Request.RemoteName) {
-			logger.Infof("REPRBD: pool(%s) has no peer(%s), skipping", pool.Name, rh.Request.RemoteName)
+		remoteNames := rh.Request.GetRemoteNames()
+		if !isPeerRegisteredForMirroring(poolInfo.Peers, remoteNames...) {
+			logger.Infof("REPRBD: pool(%s) has no peer in %v, skipping", pool.Name, remoteNames)
 			continue
 		}
 
+		// rbd groups are mirrored independently of their pool.
+		groups := listMirroringGroupsInPool(pool.Name)
+
 		if rh.Request.RequestType == types.PromoteReplicationRequest {
 			err := handlePoolPromotion(pool.Name, rh.Request.IsForceOp)
 			if err != nil {
 				return err
 			}
+
+			for _, group := range groups {
+				err := handleGroupPromotion(pool.Name, group, rh.Request.IsForceOp)
+				if err != nil {
+					return err
+				}
+			}
 			// continue to next pool
 			continue
 		}
@@ -521,7 +775,14 @@ func handleSiteOp(rh *RbdReplicationHandler) error {
 		if rh.Request.RequestType == types.DemoteReplicationRequest {
 			err := handlePoolDemotion(pool.Name)
 			if err != nil {
-				return nil
+				return err
+			}
+
+			for _, group := range groups {
+				err := handleGroupDemotion(pool.Name, group)
+				if err != nil {
+					return err
+				}
 			}
 			// continue to next pool
 			continue
@@ -144,7 +144,7 @@ func rgwCreateServiceDatabase(ctx context.Context, s interfaces.StateInterface)
 
 // startRGW starts the RGW service.
 func startRGW() error {
-	err := snapStart("rgw", true)
+	err := startService("rgw", true)
 	if err != nil {
 		return fmt.Errorf("Failed to start RGW service: %w", err)
 	}
@@ -154,7 +154,7 @@ func startRGW() error {
 
 // stopRGW stops the RGW service.
 func stopRGW() error {
-	err := snapStop("rgw", true)
+	err := stopService("rgw", true)
 	if err != nil {
 		return fmt.Errorf("Failed to stop RGW service: %w", err)
 	}
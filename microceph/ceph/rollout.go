@@ -0,0 +1,261 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Rican7/retry"
+	"github.com/Rican7/retry/strategy"
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// rolloutRestartOrder lists the services restarted on each member once the monitors restarted,
+// the monitors themselves are restarted one at a time beforehand.
+var rolloutRestartOrder = []string{"mgr", "mds", "osd", "rgw"}
+
+// rolloutStep is a step of a change rolled out over the whole cluster.
+type rolloutStep struct {
+	description string
+	run         func(ctx context.Context) error
+}
+
+// rollout applies a change to every member of the cluster, restarting the daemons without losing
+// the monitor quorum or the availability of the data.
+type rollout struct {
+	s state.State
+	// remotes maps each other member to a client for it.
+	remotes  map[string]*microCli.Client
+	services types.Services
+	// noout records whether the rollout set the noout flag.
+	noout bool
+}
+
+// newRollout prepares a rollout over the cluster, every monitor must be in quorum.
+func newRollout(ctx context.Context, s state.State) (*rollout, error) {
+	services, err := ListServices(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restarting a monitor drops it from the quorum for a while, every other one must be up.
+	quorum, err := getActiveMons()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, service := range services {
+		if service.Service == "mon" && !slices.Contains(quorum, service.Location) {
+			return nil, fmt.Errorf("monitor %s is not in quorum, bring it back first", service.Location)
+		}
+	}
+
+	remotes, err := memberClients(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rollout{s: s, remotes: remotes, services: services}, nil
+}
+
+// memberClients returns a client to each other member of the cluster, by name.
+func memberClients(s state.State) (map[string]*microCli.Client, error) {
+	cluster, err := s.Cluster(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a client for every cluster member: %w", err)
+	}
+
+	names := map[string]string{}
+	for name, remote := range s.Remotes().RemotesByName() {
+		names[remote.Address.String()] = name
+	}
+
+	clients := map[string]*microCli.Client{}
+	for i := range cluster {
+		url := cluster[i].URL()
+		clients[names[url.URL.Host]] = &cluster[i]
+	}
+
+	return clients, nil
+}
+
+// run runs the steps in order, or only lists them on a dry run. It returns the steps run.
+func (r *rollout) run(ctx context.Context, name string, steps []rolloutStep, dryRun bool) ([]string, error) {
+	done := []string{}
+	if dryRun {
+		for _, step := range steps {
+			done = append(done, step.description)
+		}
+
+		return done, nil
+	}
+
+	for i, step := range steps {
+		logger.Infof("%s step %d/%d: %s", name, i+1, len(steps), step.description)
+		err := step.run(ctx)
+		if err != nil {
+			if r.noout {
+				nooutErr := setOsdNooutFlag(false)
+				if nooutErr != nil {
+					logger.Errorf("failed to unset noout: %v", nooutErr)
+				}
+			}
+
+			return done, fmt.Errorf("%s stopped at step %d/%d '%s': %w", name, i+1, len(steps), step.description, err)
+		}
+
+		done = append(done, step.description)
+	}
+
+	return done, nil
+}
+
+// members returns the names of the members running any service, sorted.
+func (r *rollout) members() []string {
+	members := []string{}
+	for _, service := range r.services {
+		if !slices.Contains(members, service.Location) {
+			members = append(members, service.Location)
+		}
+	}
+	sort.Strings(members)
+
+	return members
+}
+
+// mons returns the names of the members running a monitor, sorted.
+func (r *rollout) mons() []string {
+	mons := []string{}
+	for _, member := range r.members() {
+		if isServicePlacementOnHost(r.services, "mon", member) {
+			mons = append(mons, member)
+		}
+	}
+
+	return mons
+}
+
+// updateConfigsStep regenerates ceph.conf on every member.
+func (r *rollout) updateConfigsStep() rolloutStep {
+	return rolloutStep{description: "Regenerate ceph.conf on every member", run: r.updateConfigs}
+}
+
+// updateConfigs regenerates ceph.conf on every member.
+func (r *rollout) updateConfigs(ctx context.Context) error {
+	err := UpdateConfig(ctx, interfaces.CephState{State: r.s})
+	if err != nil {
+		return err
+	}
+
+	for member, remote := range r.remotes {
+		err := client.UpdateClientConf(ctx, remote)
+		if err != nil {
+			return fmt.Errorf("failed to update ceph.conf on %s: %w", member, err)
+		}
+	}
+
+	return nil
+}
+
+// restartMonStep restarts the monitor of a member and waits for it to rejoin quorum.
+func (r *rollout) restartMonStep(mon string) rolloutStep {
+	return rolloutStep{
+		description: fmt.Sprintf("Restart monitor %s and wait for it to rejoin quorum", mon),
+		run: func(ctx context.Context) error {
+			err := r.restartServices(ctx, mon, []string{"mon"})
+			if err != nil {
+				return err
+			}
+
+			return waitForQuorum(mon)
+		},
+	}
+}
+
+// restartSteps restarts the daemons other than the monitors one member at a time, with noout set.
+func (r *rollout) restartSteps() []rolloutStep {
+	steps := []rolloutStep{{
+		description: "Run `ceph osd set noout`",
+		run: func(ctx context.Context) error {
+			r.noout = true
+			return setOsdNooutFlag(true)
+		},
+	}}
+
+	for _, member := range r.members() {
+		services := []string{}
+		for _, service := range rolloutRestartOrder {
+			if isServicePlacementOnHost(r.services, service, member) {
+				services = append(services, service)
+			}
+		}
+
+		if len(services) == 0 {
+			continue
+		}
+
+		steps = append(steps, rolloutStep{
+			description: fmt.Sprintf("Restart %s on %s", strings.Join(services, ", "), member),
+			run:         func(ctx context.Context) error { return r.restartServices(ctx, member, services) },
+		})
+	}
+
+	return append(steps, rolloutStep{
+		description: "Run `ceph osd unset noout`",
+		run: func(ctx context.Context) error {
+			err := setOsdNooutFlag(false)
+			if err == nil {
+				r.noout = false
+			}
+
+			return err
+		},
+	})
+}
+
+// restartServices restarts the given services of a member, in order.
+func (r *rollout) restartServices(ctx context.Context, member string, services []string) error {
+	for _, service := range services {
+		var err error
+		if member == r.s.Name() {
+			err = RestartCephService(r.services, service, member)
+		} else {
+			err = client.RestartService(ctx, r.remotes[member], &types.Services{{Service: service}})
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to restart %s on %s: %w", service, member, err)
+		}
+	}
+
+	return nil
+}
+
+// quorumWait is the time given to a monitor to rejoin quorum.
+// Patch-able for testing purposes.
+var quorumWait = 5 * time.Second
+
+// waitForQuorum waits for a monitor to be part of the quorum.
+func waitForQuorum(mon string) error {
+	return retry.Retry(func(i uint) error {
+		quorum, err := getActiveMons()
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(quorum, mon) {
+			return fmt.Errorf("attempt %d: monitor %s not in quorum %v", i, mon, quorum)
+		}
+
+		return nil
+	}, strategy.Limit(24), strategy.Wait(quorumWait))
+}
@@ -0,0 +1,58 @@
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type rolloutSuite struct {
+	tests.BaseSuite
+}
+
+func TestRollout(t *testing.T) {
+	suite.Run(t, new(rolloutSuite))
+}
+
+func (s *rolloutSuite) TestRestartSteps() {
+	r := &rollout{s: &mocks.MockState{ClusterName: "node0"}, services: types.Services{
+		{Service: "mon", Location: "node1"},
+		{Service: "mds", Location: "node1"},
+		{Service: "osd", Location: "node1"},
+		{Service: "mon", Location: "node0"},
+		{Service: "mon", Location: "node2"},
+	}}
+
+	assert.Equal(s.T(), []string{"node0", "node1", "node2"}, r.mons())
+
+	descriptions := []string{}
+	for _, step := range r.restartSteps() {
+		descriptions = append(descriptions, step.description)
+	}
+
+	// Members running only a monitor have nothing left to restart.
+	assert.Equal(s.T(), []string{
+		"Run `ceph osd set noout`",
+		"Restart mds, osd on node1",
+		"Run `ceph osd unset noout`",
+	}, descriptions)
+}
+
+func (s *rolloutSuite) TestWaitForQuorum() {
+	orig := quorumWait
+	defer func() { quorumWait = orig }()
+	quorumWait = 0
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "-s", "-f", "json").Return(`{"quorum_names":["node1"]}`, nil).Once()
+	r.On("RunCommand", "ceph", "-s", "-f", "json").Return(`{"quorum_names":["node0","node1"]}`, nil).Once()
+	common.ProcessExec = r
+
+	assert.NoError(s.T(), waitForQuorum("node0"))
+}
@@ -0,0 +1,218 @@
+package ceph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// ServiceManager manages the lifecycle of the MicroCeph daemons.
+type ServiceManager interface {
+	// Start starts a service, optionally enabling it.
+	Start(service string, enable bool) error
+	// Stop stops a service, optionally disabling it.
+	Stop(service string, disable bool) error
+	// Restart restarts (optionally reloads) a service.
+	Restart(service string, reload bool) error
+	// CheckActive returns an error if the service is not active.
+	CheckActive(service string) error
+	// IsIntfConnected checks if an interface MicroCeph needs is connected.
+	IsIntfConnected(name string) bool
+}
+
+// SnapServiceManager manages the daemons of MicroCeph running from the snap via snapctl.
+type SnapServiceManager struct{}
+
+// Check if a snapd interface is connected to microceph
+func (sm SnapServiceManager) IsIntfConnected(name string) bool {
+	args := []string{
+		"is-connected",
+		name,
+	}
+
+	_, err := common.ProcessExec.RunCommand("snapctl", args...)
+	if err != nil { // Non-zero return code when connection not present.
+		logger.Errorf("Failure: check is-connected %s: %v", name, err)
+		return false
+	}
+
+	// 0 return code when connection is present
+	return true
+}
+
+// Start starts a service via snapctl, optionally enabling it.
+func (sm SnapServiceManager) Start(service string, enable bool) error {
+	args := []string{
+		"start",
+		fmt.Sprintf("microceph.%s", service),
+	}
+
+	if enable {
+		args = append(args, "--enable")
+	}
+
+	_, err := common.ProcessExec.RunCommand("snapctl", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Stop stops a service via snapctl, optionally disabling it.
+func (sm SnapServiceManager) Stop(service string, disable bool) error {
+	args := []string{
+		"stop",
+		fmt.Sprintf("microceph.%s", service),
+	}
+
+	if disable {
+		args = append(args, "--disable")
+	}
+
+	_, err := common.ProcessExec.RunCommand("snapctl", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Restarts (optionally reloads) a service via snapctl.
+func (sm SnapServiceManager) Restart(service string, isReload bool) error {
+	args := []string{
+		"restart",
+	}
+
+	if isReload {
+		args = append(args, "--reload")
+	}
+
+	args = append(args, fmt.Sprintf("microceph.%s", service))
+
+	_, err := common.ProcessExec.RunCommand("snapctl", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Check if a particular snap service is active or inactive
+func (sm SnapServiceManager) CheckActive(service string) error {
+	args := []string{
+		"services",
+		fmt.Sprintf("microceph.%s", service),
+	}
+
+	out, err := common.ProcessExec.RunCommand("snapctl", args...)
+	if err != nil {
+		return err
+	}
+
+	// Check if the particular service is inactive.
+	if strings.Contains(out, "inactive") {
+		return fmt.Errorf("%s service is not active", service)
+	}
+
+	return nil
+}
+
+// SystemdServiceManager manages the daemons of MicroCeph installed from distro packages or a tarball via systemctl.
+type SystemdServiceManager struct {
+	// UnitPrefix is prepended to the service name to get its unit.
+	UnitPrefix string
+}
+
+// unit returns the systemd unit running a service.
+func (sm SystemdServiceManager) unit(service string) string {
+	return fmt.Sprintf("%s%s.service", sm.UnitPrefix, service)
+}
+
+// IsIntfConnected always succeeds, there are no snap interfaces outside of the snap.
+func (sm SystemdServiceManager) IsIntfConnected(name string) bool {
+	return true
+}
+
+// Start starts a service via systemctl, optionally enabling it.
+func (sm SystemdServiceManager) Start(service string, enable bool) error {
+	args := []string{"start", sm.unit(service)}
+	if enable {
+		args = []string{"enable", "--now", sm.unit(service)}
+	}
+
+	_, err := common.ProcessExec.RunCommand("systemctl", args...)
+	return err
+}
+
+// Stop stops a service via systemctl, optionally disabling it.
+func (sm SystemdServiceManager) Stop(service string, disable bool) error {
+	args := []string{"stop", sm.unit(service)}
+	if disable {
+		args = []string{"disable", "--now", sm.unit(service)}
+	}
+
+	_, err := common.ProcessExec.RunCommand("systemctl", args...)
+	return err
+}
+
+// Restart restarts a service via systemctl, reloading it instead if requested and supported.
+func (sm SystemdServiceManager) Restart(service string, isReload bool) error {
+	action := "restart"
+	if isReload {
+		action = "reload-or-restart"
+	}
+
+	_, err := common.ProcessExec.RunCommand("systemctl", action, sm.unit(service))
+	return err
+}
+
+// CheckActive checks if a service is active via systemctl.
+func (sm SystemdServiceManager) CheckActive(service string) error {
+	// systemctl is-active exits non-zero for anything but active units.
+	out, err := common.ProcessExec.RunCommand("systemctl", "is-active", sm.unit(service))
+	if err != nil || strings.TrimSpace(out) != "active" {
+		return fmt.Errorf("%s service is not active", service)
+	}
+
+	return nil
+}
+
+// GetServiceManager returns the service manager of the layout MicroCeph runs in.
+// Patch-able for testing purposes.
+var GetServiceManager = func() ServiceManager {
+	layout, err := constants.GetLayout()
+	if err != nil || layout == nil || layout.ServiceManager == constants.ServiceManagerSnap {
+		return SnapServiceManager{}
+	}
+
+	return SystemdServiceManager{UnitPrefix: layout.UnitPrefix}
+}
+
+// isIntfConnected checks if an interface is connected to microceph.
+func isIntfConnected(name string) bool {
+	return GetServiceManager().IsIntfConnected(name)
+}
+
+// startService starts a service, optionally enabling it.
+func startService(service string, enable bool) error {
+	return GetServiceManager().Start(service, enable)
+}
+
+// stopService stops a service, optionally disabling it.
+func stopService(service string, disable bool) error {
+	return GetServiceManager().Stop(service, disable)
+}
+
+// restartService restarts (optionally reloads) a service.
+func restartService(service string, isReload bool) error {
+	return GetServiceManager().Restart(service, isReload)
+}
+
+// checkServiceActive returns an error if a service is not active.
+func checkServiceActive(service string) error {
+	return GetServiceManager().CheckActive(service)
+}
@@ -0,0 +1,60 @@
+package ceph
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type serviceManagerSuite struct {
+	tests.BaseSuite
+}
+
+func TestServiceManager(t *testing.T) {
+	suite.Run(t, new(serviceManagerSuite))
+}
+
+func (s *serviceManagerSuite) TestSystemdServiceManager() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "systemctl", "enable", "--now", "microceph-mon.service").Return("", nil).Once()
+	r.On("RunCommand", "systemctl", "start", "microceph-mon.service").Return("", nil).Once()
+	r.On("RunCommand", "systemctl", "disable", "--now", "microceph-rgw.service").Return("", nil).Once()
+	r.On("RunCommand", "systemctl", "stop", "microceph-rgw.service").Return("", nil).Once()
+	r.On("RunCommand", "systemctl", "reload-or-restart", "microceph-osd.service").Return("", nil).Once()
+	r.On("RunCommand", "systemctl", "restart", "microceph-osd.service").Return("", nil).Once()
+	common.ProcessExec = r
+
+	sm := SystemdServiceManager{UnitPrefix: "microceph-"}
+	assert.NoError(s.T(), sm.Start("mon", true))
+	assert.NoError(s.T(), sm.Start("mon", false))
+	assert.NoError(s.T(), sm.Stop("rgw", true))
+	assert.NoError(s.T(), sm.Stop("rgw", false))
+	assert.NoError(s.T(), sm.Restart("osd", true))
+	assert.NoError(s.T(), sm.Restart("osd", false))
+	assert.True(s.T(), sm.IsIntfConnected("dm-crypt"))
+}
+
+func (s *serviceManagerSuite) TestSystemdCheckActive() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "systemctl", "is-active", "microceph-mds.service").Return("active\n", nil).Once()
+	r.On("RunCommand", "systemctl", "is-active", "microceph-rgw.service").Return("inactive\n", errors.New("exit status 3")).Once()
+	common.ProcessExec = r
+
+	sm := SystemdServiceManager{UnitPrefix: "microceph-"}
+	assert.NoError(s.T(), sm.CheckActive("mds"))
+	assert.ErrorContains(s.T(), sm.CheckActive("rgw"), "rgw service is not active")
+}
+
+func (s *serviceManagerSuite) TestSnapCheckActive() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "snapctl", "services", "microceph.rgw").Return("microceph.rgw  enabled  inactive  -", nil).Once()
+	common.ProcessExec = r
+
+	assert.ErrorContains(s.T(), SnapServiceManager{}.CheckActive("rgw"), "rgw service is not active")
+}
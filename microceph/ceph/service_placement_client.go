@@ -77,7 +77,7 @@ func clientServiceInit(s interfaces.StateInterface, name string) error {
 		return err
 	}
 
-	err = snapStart(name, true)
+	err = startService(name, true)
 	if err != nil {
 		logger.Error(err.Error())
 		return fmt.Errorf("failed to perform snap start for service %s: %w", name, err)
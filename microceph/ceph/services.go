@@ -27,6 +27,8 @@ import (
 var serviceWorkerTable = map[string](func() (common.Set, error)){
 	"osd": getUpOsds,
 	"mon": getMons,
+	"mgr": getUpMgrs,
+	"mds": getUpMdss,
 	"rgw": getUpRgws,
 }
 
@@ -74,7 +76,7 @@ func RestartCephService(clusterServices types.Services, service string, hostname
 	}
 
 	// Restart the service.
-	snapRestart(service, false)
+	restartService(service, false)
 
 	// Check all the daemons available before Restart are up.
 	err = retry.Retry(func(i uint) error {
@@ -111,7 +113,7 @@ func getUpRgws() (common.Set, error) {
 		}
 	}
 
-	err := snapCheckActive("rgw")
+	err := checkServiceActive("rgw")
 	if err != nil {
 		return common.Set{}, nil // return empty but without errot
 	}
@@ -138,6 +140,36 @@ func getMons() (common.Set, error) {
 	return retval, nil
 }
 
+func getUpMgrs() (common.Set, error) {
+	mgrs, err := getActiveMgrs()
+	if err != nil {
+		return nil, err
+	}
+
+	return nameSet(mgrs), nil
+}
+
+func getUpMdss() (common.Set, error) {
+	mdss, err := getActiveMdss()
+	if err != nil {
+		return nil, err
+	}
+
+	return nameSet(mdss), nil
+}
+
+// nameSet returns the set of the non-empty names.
+func nameSet(names []string) common.Set {
+	retval := common.Set{}
+	for _, name := range names {
+		if len(name) != 0 {
+			retval[name] = struct{}{}
+		}
+	}
+
+	return retval
+}
+
 func getUpOsds() (common.Set, error) {
 	retval := common.Set{}
 	output, err := common.ProcessExec.RunCommand("ceph", "osd", "dump", "-f", "json-pretty")
@@ -194,6 +226,30 @@ func ListServices(ctx context.Context, s state.State) (types.Services, error) {
 	return services, nil
 }
 
+// ListAllServices returns the services of all members, including grouped services.
+func ListAllServices(ctx context.Context, s state.State) (types.Services, error) {
+	services, err := ListServices(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	groupedServices, err := database.GroupedServicesQuery.GetGroupedServices(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, groupedService := range groupedServices {
+		services = append(services, types.Service{
+			Service:  groupedService.Service,
+			Location: groupedService.Member,
+			GroupID:  groupedService.GroupID,
+			Info:     groupedService.Info,
+		})
+	}
+
+	return services, nil
+}
+
 // cleanService removes conf data for a service from the cluster.
 func cleanService(hostname, service string) error {
 	paths := constants.GetPathConst()
@@ -235,7 +291,7 @@ func removeServiceDatabase(ctx context.Context, s interfaces.StateInterface, ser
 
 // DeleteService deletes a service from the node.
 func DeleteService(ctx context.Context, s interfaces.StateInterface, service string) error {
-	err := snapStop(service, true)
+	err := stopService(service, true)
 	if err != nil {
 		logger.Errorf("failed to stop daemon %q: %v", service, err)
 		return fmt.Errorf("failed to stop daemon %q: %w", service, err)
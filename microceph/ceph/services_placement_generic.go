@@ -56,7 +56,7 @@ func (gsp *GenericServicePlacement) DbUpdate(ctx context.Context, s interfaces.S
 // Generic Method Implementations
 func genericHospitalityCheck(service string) error {
 	// Check if service already exists on host.
-	err := snapCheckActive(service)
+	err := checkServiceActive(service)
 	if err == nil {
 		retErr := fmt.Errorf("%s service already active on host", service)
 		logger.Error(retErr.Error())
@@ -96,7 +96,7 @@ func genericServiceInit(s interfaces.StateInterface, name string) error {
 		return fmt.Errorf("failed to add service %s: %w", name, err)
 	}
 
-	err = snapStart(name, true)
+	err = startService(name, true)
 	if err != nil {
 		logger.Error(err.Error())
 		return fmt.Errorf("failed to perform snap start for service %s: %w", name, err)
@@ -110,7 +110,7 @@ func genericPostPlacementCheck(service string) error {
 	attempts := 4
 
 	for attempts > 0 {
-		ret := snapCheckActive(service)
+		ret := checkServiceActive(service)
 		if ret != nil {
 			return ret
 		}
@@ -216,5 +216,20 @@ func Start(ctx context.Context, s interfaces.StateInterface) error {
 		}
 	}()
 
+	// Start background loop to record the health of the OSD devices of this member.
+	go monitorDiskHealth(ctx, s.ClusterState())
+
+	// Start background loop to add new devices of this member as OSDs if requested.
+	go watchDiskHotplug(ctx, s.ClusterState())
+
+	// Start background loop to advance the gradual drains of the OSDs of this member.
+	go monitorDrains(ctx, s.ClusterState())
+
+	// Start background loop to detect changes of the configs set through MicroCeph made in Ceph.
+	go monitorConfigDrift(ctx, s.ClusterState())
+
+	// Start background loop to size the memory target of the OSDs of this member.
+	go monitorOSDMemory(ctx, s.ClusterState())
+
 	return nil
 }
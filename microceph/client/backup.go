@@ -0,0 +1,49 @@
+// Package client provides a full Go API client.
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// Backup sends the request to '/ops/backup' endpoint to create an encrypted backup of a member.
+func Backup(ctx context.Context, c *client.Client, node string, passphrase string) (types.BackupResponse, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	var resp types.BackupResponse
+	data := types.BackupRequest{Passphrase: passphrase}
+
+	if len(node) != 0 {
+		c = c.UseTarget(node)
+	}
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("ops", "backup"), data, &resp)
+	if err != nil {
+		return types.BackupResponse{}, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Restore sends the request to '/ops/restore' endpoint to rebuild the local member from a backup.
+func Restore(ctx context.Context, c *client.Client, archive []byte, passphrase string) (types.RestoreResponse, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	var resp types.RestoreResponse
+	data := types.RestoreRequest{Archive: archive, Passphrase: passphrase}
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("ops", "restore"), data, &resp)
+	if err != nil {
+		return types.RestoreResponse{}, fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	return resp, nil
+}
@@ -47,3 +47,56 @@ func GetConfig(ctx context.Context, c *microCli.Client, data *types.Config) (typ
 
 	return configs, nil
 }
+
+func GetConfigHistory(ctx context.Context, c *microCli.Client, data *types.ConfigHistoryRequest) (types.ConfigChanges, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	changes := types.ConfigChanges{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("configs", "history"), data, &changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config history: %w", err)
+	}
+
+	return changes, nil
+}
+
+func RollbackConfig(ctx context.Context, c *microCli.Client, data *types.ConfigRollback) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*200)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("configs", "rollback"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed rolling back config change %d: %w", data.ID, err)
+	}
+
+	return nil
+}
+
+// GetConfigDrift fetches the configs set through MicroCeph which since changed in Ceph.
+func GetConfigDrift(ctx context.Context, c *microCli.Client) (types.ConfigDriftReport, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	report := types.ConfigDriftReport{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("configs", "drift"), nil, &report)
+	if err != nil {
+		return report, fmt.Errorf("failed to get config drift: %w", err)
+	}
+
+	return report, nil
+}
+
+// SetConfigDriftPolicy sets whether drifted configs are re-asserted.
+func SetConfigDriftPolicy(ctx context.Context, c *microCli.Client, data *types.ConfigDriftPolicy) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("configs", "drift"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set config drift policy: %w", err)
+	}
+
+	return nil
+}
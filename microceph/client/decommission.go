@@ -0,0 +1,27 @@
+// Package client provides a full Go API client.
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/clilogger"
+)
+
+// DecommissionNode sends the request to '/ops/decommission/{node}' endpoint to remove a node along with
+// its OSDs, services and keys from the cluster. Draining the OSDs takes as long as recovery does, each
+// OSD removal is bounded by the timeout of the request instead.
+func DecommissionNode(ctx context.Context, c *client.Client, node string, data *types.DecommissionRequest) (types.MaintenanceResults, error) {
+	var results types.MaintenanceResults
+
+	err := c.Query(ctx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("ops", "decommission", node), data, &results)
+	if err != nil {
+		clilogger.Errorf("error decommissioning node '%s': %v", node, err)
+		return types.MaintenanceResults{}, fmt.Errorf("error decommissioning node '%s': %v", node, err)
+	}
+	return results, nil
+}
@@ -65,19 +65,9 @@ func RemoveDisk(ctx context.Context, c *microCli.Client, data *types.DisksDelete
 	defer cancel()
 
 	// get disks and determine osd location
-	disks, err := GetDisks(ctx, c)
+	location, err := getDiskLocation(ctx, c, data.OSD)
 	if err != nil {
-		return fmt.Errorf("failed to get disks: %w", err)
-	}
-	var location string
-	for _, disk := range disks {
-		if disk.OSD == data.OSD {
-			location = disk.Location
-			break
-		}
-	}
-	if location == "" {
-		return fmt.Errorf("failed to find location for osd.%d", data.OSD)
+		return err
 	}
 	c = c.UseTarget(location)
 
@@ -91,3 +81,288 @@ func RemoveDisk(ctx context.Context, c *microCli.Client, data *types.DisksDelete
 	}
 	return nil
 }
+
+// AdoptDisks requests Ceph adopts the existing OSD devices of a member.
+func AdoptDisks(ctx context.Context, c *microCli.Client, data *types.DisksAdopt) (types.DiskAdoptResponse, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	resp := types.DiskAdoptResponse{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", "adopt"), data, &resp)
+	if err != nil {
+		return resp, fmt.Errorf("failed to request disk adoption: %w", err)
+	}
+
+	return resp, nil
+}
+
+// getDiskLocation returns the name of the member hosting the given OSD.
+func getDiskLocation(ctx context.Context, c *microCli.Client, osd int64) (string, error) {
+	disks, err := GetDisks(ctx, c)
+	if err != nil {
+		return "", fmt.Errorf("failed to get disks: %w", err)
+	}
+
+	for _, disk := range disks {
+		if disk.OSD == osd {
+			return disk.Location, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to find location for osd.%d", osd)
+}
+
+// GetDiskEncryptionProvider returns the key provider settings for encrypted OSDs.
+func GetDiskEncryptionProvider(ctx context.Context, c *microCli.Client) (types.DiskEncryptionProvider, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	conf := types.DiskEncryptionProvider{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", "encryption"), nil, &conf)
+	if err != nil {
+		return conf, fmt.Errorf("failed to get key provider: %w", err)
+	}
+
+	return conf, nil
+}
+
+// SetDiskEncryptionProvider sets the key provider for encrypted OSDs.
+func SetDiskEncryptionProvider(ctx context.Context, c *microCli.Client, data *types.DiskEncryptionProvider) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("disks", "encryption"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set key provider: %w", err)
+	}
+
+	return nil
+}
+
+// GetDiskKey fetches the key of an encrypted OSD data device hosted on the targeted member.
+func GetDiskKey(ctx context.Context, c *microCli.Client, osd int64) (string, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	key := types.DiskEncryptionKey{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "key"), nil, &key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get key for osd.%d: %w", osd, err)
+	}
+
+	return key.Key, nil
+}
+
+// RotateDiskKey requests a rotation of the LUKS keys of an encrypted OSD.
+func RotateDiskKey(ctx context.Context, c *microCli.Client, osd int64) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	location, err := getDiskLocation(ctx, c, osd)
+	if err != nil {
+		return err
+	}
+
+	err = c.UseTarget(location).Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "key"), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to rotate key for osd.%d: %w", osd, err)
+	}
+
+	return nil
+}
+
+// EncryptDisk requests an in place conversion of an OSD to encrypted.
+func EncryptDisk(ctx context.Context, c *microCli.Client, data *types.DisksEncrypt) error {
+	// The conversion waits for recovery, allow for the drain on top of it.
+	timeout := time.Second * time.Duration(2*data.Timeout+5)
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	location, err := getDiskLocation(ctx, c, data.OSD)
+	if err != nil {
+		return err
+	}
+
+	err = c.UseTarget(location).Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(data.OSD, 10), "encrypt"), data, nil)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("failed to encrypt disk, timeout (%ds) reached - abort", data.Timeout)
+		}
+		return fmt.Errorf("failed to encrypt disk: %w", err)
+	}
+
+	return nil
+}
+
+// SetDiskDevice requests attaching or replacing the WAL or DB device of an OSD.
+func SetDiskDevice(ctx context.Context, c *microCli.Client, osd int64, data *types.DisksSetDevice) error {
+	// Migrating BlueFS data can take a while on large OSDs.
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*600)
+	defer cancel()
+
+	location, err := getDiskLocation(ctx, c, osd)
+	if err != nil {
+		return err
+	}
+
+	err = c.UseTarget(location).Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "device"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set %s device of osd.%d: %w", data.Role, osd, err)
+	}
+
+	return nil
+}
+
+// GetDiskHealth fetches the latest SMART readings of an OSD device.
+func GetDiskHealth(ctx context.Context, c *microCli.Client, osd int64) (types.DiskHealth, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	health := types.DiskHealth{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "health"), nil, &health)
+	if err != nil {
+		return health, fmt.Errorf("failed to get health of osd.%d: %w", osd, err)
+	}
+
+	return health, nil
+}
+
+// GetDiskHealthPolicy fetches the cluster wide handling of OSD devices predicted to fail.
+func GetDiskHealthPolicy(ctx context.Context, c *microCli.Client) (types.DiskHealthPolicy, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	policy := types.DiskHealthPolicy{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", "health"), nil, &policy)
+	if err != nil {
+		return policy, fmt.Errorf("failed to get disk health policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// SetDiskHealthPolicy sets the cluster wide handling of OSD devices predicted to fail.
+func SetDiskHealthPolicy(ctx context.Context, c *microCli.Client, data *types.DiskHealthPolicy) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("disks", "health"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set disk health policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetDiskAutoAddPolicy fetches the auto-add policy of the targeted member.
+func GetDiskAutoAddPolicy(ctx context.Context, c *microCli.Client) (types.DiskAutoAddPolicy, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	policy := types.DiskAutoAddPolicy{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", "auto-add"), nil, &policy)
+	if err != nil {
+		return policy, fmt.Errorf("failed to get auto-add policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// SetDiskAutoAddPolicy sets the auto-add policy of the targeted member.
+func SetDiskAutoAddPolicy(ctx context.Context, c *microCli.Client, data *types.DiskAutoAddPolicy) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("disks", "auto-add"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set auto-add policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetDiskDrain fetches the drain progress of an OSD.
+func GetDiskDrain(ctx context.Context, c *microCli.Client, osd int64) (types.DiskDrain, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	drain := types.DiskDrain{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "drain"), nil, &drain)
+	if err != nil {
+		return drain, fmt.Errorf("failed to get drain of osd.%d: %w", osd, err)
+	}
+
+	return drain, nil
+}
+
+// DrainDisk starts, pauses or resumes the drain of an OSD.
+func DrainDisk(ctx context.Context, c *microCli.Client, osd int64, data *types.DisksDrain) (types.DiskDrain, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	drain := types.DiskDrain{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "drain"), data, &drain)
+	if err != nil {
+		return drain, fmt.Errorf("failed to %s drain of osd.%d: %w", data.Action, osd, err)
+	}
+
+	return drain, nil
+}
+
+// drainPollInterval is the interval between drain progress checks when waiting for a drain.
+var drainPollInterval = 10 * time.Second
+
+// WaitForDiskDrain starts the drain of an OSD, or resumes a paused or failed one, and waits for it to complete.
+// A drain not complete within the timeout (seconds) keeps running in the background. progress is called with
+// the drain progress on every step.
+func WaitForDiskDrain(ctx context.Context, c *microCli.Client, osd int64, step float64, timeout int64, progress func(types.DiskDrain)) error {
+	deadline := time.Now().Add(time.Second * time.Duration(timeout))
+
+	drain, err := GetDiskDrain(ctx, c, osd)
+	if err != nil {
+		return err
+	}
+
+	switch drain.Status {
+	case types.DiskDrainDone, types.DiskDrainRunning:
+	case types.DiskDrainPaused, types.DiskDrainFailed:
+		drain, err = DrainDisk(ctx, c, osd, &types.DisksDrain{Action: types.DiskDrainResume})
+	default:
+		drain, err = DrainDisk(ctx, c, osd, &types.DisksDrain{Action: types.DiskDrainStart, Step: step})
+	}
+	if err != nil {
+		return err
+	}
+
+	progress(drain)
+	for drain.Status == types.DiskDrainRunning {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("drain of osd.%d not complete after %ds, it continues in the background", osd, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(drainPollInterval):
+		}
+
+		weight := drain.Weight
+		drain, err = GetDiskDrain(ctx, c, osd)
+		if err != nil {
+			return err
+		}
+
+		if drain.Weight != weight || drain.Status != types.DiskDrainRunning {
+			progress(drain)
+		}
+	}
+
+	switch drain.Status {
+	case types.DiskDrainFailed:
+		return fmt.Errorf("drain of osd.%d failed: %s", osd, drain.Error)
+	case types.DiskDrainPaused:
+		return fmt.Errorf("drain of osd.%d was paused", osd)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+func TestDisks(t *testing.T) {
+	suite.Run(t, new(disksSuite))
+}
+
+// disksSuite is the test suite for the disk client functions.
+type disksSuite struct {
+	tests.BaseSuite
+}
+
+func (s *disksSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	drainPollInterval = time.Millisecond
+}
+
+// fakeDrain serves the drain endpoints of osd.1 starting from the given drain, a started or resumed drain
+// completes on the next check.
+func fakeDrain(drain types.DiskDrain, actions *[]string) func(r *http.Request) (any, error) {
+	return func(r *http.Request) (any, error) {
+		if r.URL.Path != "/1.0/disks/1/drain" {
+			return nil, fmt.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if r.Method == "POST" {
+			req := types.DisksDrain{}
+			err := json.NewDecoder(r.Body).Decode(&req)
+			if err != nil {
+				return nil, err
+			}
+
+			*actions = append(*actions, req.Action)
+			drain = types.DiskDrain{OSD: 1, Status: types.DiskDrainRunning, InitialWeight: 1, Weight: 1, Step: req.Step}
+			return drain, nil
+		}
+
+		current := drain
+		if drain.Status == types.DiskDrainRunning {
+			drain.Status = types.DiskDrainDone
+			drain.Weight = 0
+		}
+
+		return current, nil
+	}
+}
+
+func (s *disksSuite) TestWaitForDiskDrainNoDrain() {
+	// An OSD never drained has an empty drain status, its drain gets started.
+	actions := []string{}
+	cli := s.LocalClient(fakeDrain(types.DiskDrain{OSD: 1}, &actions))
+
+	progress := []types.DiskDrain{}
+	err := WaitForDiskDrain(context.Background(), cli, 1, 0.5, 60, func(drain types.DiskDrain) {
+		progress = append(progress, drain)
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{types.DiskDrainStart}, actions)
+	assert.Len(s.T(), progress, 2)
+	assert.Equal(s.T(), types.DiskDrainDone, progress[1].Status)
+}
+
+func (s *disksSuite) TestWaitForDiskDrainFailed() {
+	// A failed drain gets resumed.
+	actions := []string{}
+	cli := s.LocalClient(fakeDrain(types.DiskDrain{OSD: 1, Status: types.DiskDrainFailed, InitialWeight: 1, Weight: 0.5}, &actions))
+
+	err := WaitForDiskDrain(context.Background(), cli, 1, 0.5, 60, func(types.DiskDrain) {})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{types.DiskDrainResume}, actions)
+}
+
+func (s *disksSuite) TestWaitForDiskDrainDone() {
+	// A complete drain is neither started nor resumed.
+	actions := []string{}
+	cli := s.LocalClient(fakeDrain(types.DiskDrain{OSD: 1, Status: types.DiskDrainDone}, &actions))
+
+	err := WaitForDiskDrain(context.Background(), cli, 1, 0.5, 60, func(types.DiskDrain) {})
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), actions)
+}
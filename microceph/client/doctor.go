@@ -0,0 +1,31 @@
+// Package client provides a full Go API client.
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// GetDoctorReport runs the preflight checks of a member, the local one if target is empty.
+func GetDoctorReport(ctx context.Context, c *client.Client, target string) (types.DoctorReport, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	if len(target) != 0 {
+		c = c.UseTarget(target)
+	}
+
+	report := types.DoctorReport{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("doctor"), nil, &report)
+	if err != nil {
+		return report, fmt.Errorf("failed to run doctor checks: %w", err)
+	}
+
+	return report, nil
+}
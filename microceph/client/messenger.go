@@ -0,0 +1,28 @@
+// Package client provides a full Go API client.
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// SetMessenger converts the cluster to msgr2 only and/or on-wire encryption.
+func SetMessenger(ctx context.Context, c *client.Client, data types.MessengerRequest) (types.MessengerResponse, error) {
+	// Every daemon of the cluster is restarted in turn.
+	queryCtx, cancel := context.WithTimeout(ctx, time.Hour)
+	defer cancel()
+
+	resp := types.MessengerResponse{}
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "messenger"), data, &resp)
+	if err != nil {
+		return resp, fmt.Errorf("failed to set the messenger: %w", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,75 @@
+// Package client provides a full Go API client.
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// CheckNetwork has every member probe the public and cluster network addresses of every other member.
+func CheckNetwork(ctx context.Context, c *client.Client, data types.NetworkCheckRequest) (types.NetworkCheckReport, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	report := types.NetworkCheckReport{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "network", "check"), data, &report)
+	if err != nil {
+		return report, fmt.Errorf("failed to check networks: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetNetworkAddresses fetches the addresses of a member on the given subnets.
+func GetNetworkAddresses(ctx context.Context, c *client.Client, subnets []string) (types.NetworkAddresses, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	url := api.NewURL().Path("cluster", "network", "addresses")
+	for _, subnet := range subnets {
+		url = url.WithQuery("subnet", subnet)
+	}
+
+	addresses := types.NetworkAddresses{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, url, nil, &addresses)
+	if err != nil {
+		return addresses, fmt.Errorf("failed to get network addresses: %w", err)
+	}
+
+	return addresses, nil
+}
+
+// ProbeNetwork has a member probe the given addresses.
+func ProbeNetwork(ctx context.Context, c *client.Client, data types.NetworkProbeRequest) ([]types.NetworkProbeResult, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	results := []types.NetworkProbeResult{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "network", "probe"), data, &results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe network: %w", err)
+	}
+
+	return results, nil
+}
+
+// MigrateNetwork moves the cluster to a new public network.
+func MigrateNetwork(ctx context.Context, c *client.Client, data types.NetworkMigrateRequest) (types.NetworkMigrateResponse, error) {
+	// Every daemon of the cluster is restarted in turn.
+	queryCtx, cancel := context.WithTimeout(ctx, time.Hour)
+	defer cancel()
+
+	resp := types.NetworkMigrateResponse{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "network", "migrate"), data, &resp)
+	if err != nil {
+		return resp, fmt.Errorf("failed to migrate the public network: %w", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// GetProfiles lists the profiles known to the member.
+func GetProfiles(ctx context.Context, c *client.Client) (types.Profiles, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	profiles := types.Profiles{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "profiles"), nil, &profiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// ApplyProfile applies a profile, or lists the changes it makes on a dry run.
+func ApplyProfile(ctx context.Context, c *client.Client, data types.ProfileApplyRequest) (types.ProfileApplyResponse, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*200)
+	defer cancel()
+
+	resp := types.ProfileApplyResponse{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "profiles", "apply"), data, &resp)
+	if err != nil {
+		return resp, fmt.Errorf("failed to apply profile: %w", err)
+	}
+
+	return resp, nil
+}
@@ -34,6 +34,10 @@ func (c *cmdCluster) Command() *cobra.Command {
 	clusterRemoveCmd := cmdClusterRemove{common: c.common, cluster: c}
 	cmd.AddCommand(clusterRemoveCmd.Command())
 
+	// Decommission
+	clusterDecommissionCmd := cmdClusterDecommission{common: c.common, cluster: c}
+	cmd.AddCommand(clusterDecommissionCmd.Command())
+
 	// SQL
 	clusterSQLCmd := cmdClusterSQL{common: c.common, cluster: c}
 	cmd.AddCommand(clusterSQLCmd.Command())
@@ -50,6 +54,26 @@ func (c *cmdCluster) Command() *cobra.Command {
 	clusterMigrateCmd := cmdClusterMigrate{common: c.common, cluster: c}
 	cmd.AddCommand(clusterMigrateCmd.Command())
 
+	// Backup
+	clusterBackupCmd := cmdClusterBackup{common: c.common, cluster: c}
+	cmd.AddCommand(clusterBackupCmd.Command())
+
+	// Restore
+	clusterRestoreCmd := cmdClusterRestore{common: c.common, cluster: c}
+	cmd.AddCommand(clusterRestoreCmd.Command())
+
+	// Network Subcommand
+	clusterNetwork := cmdClusterNetwork{common: c.common}
+	cmd.AddCommand(clusterNetwork.Command())
+
+	// Set Messenger
+	clusterSetMessenger := cmdClusterSetMessenger{common: c.common}
+	cmd.AddCommand(clusterSetMessenger.Command())
+
+	// Profile Subcommand
+	clusterProfile := cmdClusterProfile{common: c.common}
+	cmd.AddCommand(clusterProfile.Command())
+
 	// Maintenance Subcommand
 	clusterMaintenance := cmdClusterMaintenance{common: c.common}
 	cmd.AddCommand(clusterMaintenance.Command())
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterBackup struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagOutput         string
+	flagPassphraseFile string
+}
+
+func (c *cmdClusterBackup) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup [<NODE_NAME>]",
+		Short: "Creates an encrypted backup of a cluster member's state, configuration and OSD keys",
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagOutput, "output", "", "Path of the backup archive to write")
+	cmd.Flags().StringVar(&c.flagPassphraseFile, "passphrase-file", "", "Read the encryption passphrase from a file instead of prompting")
+	cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func (c *cmdClusterBackup) Run(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return cmd.Help()
+	}
+
+	node := ""
+	if len(args) == 1 {
+		node = args[0]
+	}
+
+	passphrase, err := getBackupPassphrase(c.common, c.flagPassphraseFile, true)
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Backup(context.Background(), cli, node, passphrase)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(c.flagOutput, resp.Archive, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write backup archive %s: %w", c.flagOutput, err)
+	}
+
+	fmt.Printf("Backup written to %s\n", c.flagOutput)
+	return nil
+}
+
+// getBackupPassphrase reads the passphrase from the provided file or prompts for it.
+func getBackupPassphrase(common *CmdControl, passphraseFile string, confirm bool) (string, error) {
+	var passphrase string
+	if len(passphraseFile) != 0 {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file %s: %w", passphraseFile, err)
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	} else if confirm {
+		passphrase = common.Asker.AskPassword("Backup passphrase: ")
+	} else {
+		passphrase = common.Asker.AskPasswordOnce("Backup passphrase: ")
+	}
+
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("backup passphrase can not be empty")
+	}
+
+	return passphrase, nil
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"os"
+	"os/user"
+
 	"github.com/spf13/cobra"
 )
 
@@ -31,9 +34,36 @@ func (c *cmdClusterConfig) Command() *cobra.Command {
 	clusterConfigListCmd := cmdClusterConfigList{common: c.common, cluster: c.cluster, clusterConfig: c}
 	cmd.AddCommand(clusterConfigListCmd.Command())
 
+	// History
+	clusterConfigHistoryCmd := cmdClusterConfigHistory{common: c.common, cluster: c.cluster, clusterConfig: c}
+	cmd.AddCommand(clusterConfigHistoryCmd.Command())
+
+	// Rollback
+	clusterConfigRollbackCmd := cmdClusterConfigRollback{common: c.common, cluster: c.cluster, clusterConfig: c}
+	cmd.AddCommand(clusterConfigRollbackCmd.Command())
+
+	// Drift policy
+	clusterConfigDriftPolicyCmd := cmdClusterConfigDriftPolicy{common: c.common, clusterConfig: c}
+	cmd.AddCommand(clusterConfigDriftPolicyCmd.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
 
 	return cmd
 }
+
+// requestingUser returns the user running the command, recorded in the config history.
+func requestingUser() string {
+	sudoUser := os.Getenv("SUDO_USER")
+	if len(sudoUser) != 0 {
+		return sudoUser
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		return ""
+	}
+
+	return current.Username
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterConfigDriftPolicy struct {
+	common        *CmdControl
+	clusterConfig *cmdClusterConfig
+
+	flagReassert bool
+}
+
+func (c *cmdClusterConfigDriftPolicy) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift-policy [--reassert=<true|false>]",
+		Short: "Show or set the handling of configs changed outside of MicroCeph",
+		Long: `Shows or sets the handling of configs set through MicroCeph which were since changed in Ceph,
+for instance with 'ceph config set'. Drifted configs are always reported in the daemon log and by
+'microceph cluster config list --drift'. With --reassert=true they are also set back to the value
+set through MicroCeph.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagReassert, "reassert", false, "Set drifted configs back to the value set through MicroCeph")
+
+	return cmd
+}
+
+func (c *cmdClusterConfigDriftPolicy) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("reassert") {
+		report, err := client.GetConfigDrift(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Re-assert drifted configs: %t\n", report.Reassert)
+		return nil
+	}
+
+	return client.SetConfigDriftPolicy(context.Background(), cli, &types.ConfigDriftPolicy{Reassert: c.flagReassert})
+}
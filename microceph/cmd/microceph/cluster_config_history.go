@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterConfigHistory struct {
+	common        *CmdControl
+	cluster       *cmdCluster
+	clusterConfig *cmdClusterConfig
+}
+
+func (c *cmdClusterConfigHistory) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history [<Key>]",
+		Short: "List the changes of Ceph Cluster configs",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdClusterConfigHistory) Run(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return fmt.Errorf("unable to configure MicroCeph: %w", err)
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.ConfigHistoryRequest{}
+	if len(args) == 1 {
+		req.Key = args[0]
+	}
+
+	changes, err := client.GetConfigHistory(context.Background(), cli, req)
+	if err != nil {
+		return err
+	}
+
+	data := make([][]string, len(changes))
+	for i, change := range changes {
+		data[i] = []string{
+			fmt.Sprintf("%d", change.ID),
+			change.Key,
+			change.Section,
+			unsetIfEmpty(change.OldValue),
+			unsetIfEmpty(change.NewValue),
+			change.ChangedBy,
+			change.ChangedAt.Local().Format(time.DateTime),
+		}
+	}
+
+	header := []string{"ID", "Key", "Section", "Old Value", "New Value", "Changed By", "Changed At"}
+	err = lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, changes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// unsetIfEmpty shows empty values of the config history as unset.
+func unsetIfEmpty(value string) string {
+	if len(value) == 0 {
+		return "(unset)"
+	}
+
+	return value
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	microCli "github.com/canonical/microcluster/v2/client"
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
 
@@ -16,15 +17,21 @@ type cmdClusterConfigList struct {
 	common        *CmdControl
 	cluster       *cmdCluster
 	clusterConfig *cmdClusterConfig
+
+	flagDrift bool
+	flagAll   bool
 }
 
 func (c *cmdClusterConfigList) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
-		Short: "List all set Ceph level configs",
+		Short: "List the Ceph level configs set through MicroCeph",
 		RunE:  c.Run,
 	}
 
+	cmd.Flags().BoolVar(&c.flagDrift, "drift", false, "List the configs set through MicroCeph which since changed in Ceph")
+	cmd.Flags().BoolVar(&c.flagAll, "all", false, "List every config set cluster wide or for a service")
+
 	return cmd
 }
 
@@ -43,9 +50,14 @@ func (c *cmdClusterConfigList) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.flagDrift {
+		return listConfigDrift(cli)
+	}
+
 	// Create an empty Key request.
 	req := &types.Config{
 		Key: "",
+		All: c.flagAll,
 	}
 
 	configs, err := client.GetConfig(context.Background(), cli, req)
@@ -66,3 +78,19 @@ func (c *cmdClusterConfigList) Run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// listConfigDrift lists the configs set through MicroCeph which since changed in Ceph.
+func listConfigDrift(cli *microCli.Client) error {
+	report, err := client.GetConfigDrift(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	data := make([][]string, len(report.Drifts))
+	for i, drift := range report.Drifts {
+		data[i] = []string{drift.Key, drift.Section, unsetIfEmpty(drift.Expected), unsetIfEmpty(drift.Actual)}
+	}
+
+	header := []string{"Key", "Section", "MicroCeph Value", "Ceph Value"}
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, report.Drifts)
+}
@@ -51,6 +51,7 @@ func (c *cmdClusterConfigReset) Run(cmd *cobra.Command, args []string) error {
 		Key:         args[0],
 		Wait:        c.flagWait,
 		SkipRestart: c.flagSkipRestart,
+		User:        requestingUser(),
 	}
 
 	err = client.ClearConfig(context.Background(), cli, req)
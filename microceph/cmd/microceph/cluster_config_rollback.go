@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterConfigRollback struct {
+	common        *CmdControl
+	cluster       *cmdCluster
+	clusterConfig *cmdClusterConfig
+
+	flagWait        bool
+	flagSkipRestart bool
+}
+
+func (c *cmdClusterConfigRollback) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback <ID>",
+		Short: "Restore the value a Ceph Cluster config had before the given change",
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagWait, "wait", false, "Wait for required ceph services to restart post rollback.")
+	cmd.Flags().BoolVar(&c.flagSkipRestart, "skip-restart", false, "Don't perform the daemon restart for current config.")
+	return cmd
+}
+
+func (c *cmdClusterConfigRollback) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid change ID %s: %w", args[0], err)
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return fmt.Errorf("unable to configure MicroCeph: %w", err)
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.ConfigRollback{
+		ID:          id,
+		Wait:        c.flagWait,
+		SkipRestart: c.flagSkipRestart,
+		User:        requestingUser(),
+	}
+
+	return client.RollbackConfig(context.Background(), cli, req)
+}
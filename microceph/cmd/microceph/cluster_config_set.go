@@ -18,6 +18,7 @@ type cmdClusterConfigSet struct {
 
 	flagWait        bool
 	flagSkipRestart bool
+	flagSkipCheck   bool
 }
 
 func (c *cmdClusterConfigSet) Command() *cobra.Command {
@@ -29,6 +30,7 @@ func (c *cmdClusterConfigSet) Command() *cobra.Command {
 
 	cmd.Flags().BoolVar(&c.flagWait, "wait", false, "Wait for required ceph services to restart post config set.")
 	cmd.Flags().BoolVar(&c.flagSkipRestart, "skip-restart", false, "Don't perform the daemon restart for current config.")
+	cmd.Flags().BoolVar(&c.flagSkipCheck, "skip-check", false, "Don't check the members reach each other on a new cluster_network.")
 	return cmd
 }
 
@@ -52,6 +54,8 @@ func (c *cmdClusterConfigSet) Run(cmd *cobra.Command, args []string) error {
 		Value:       args[1],
 		Wait:        c.flagWait,
 		SkipRestart: c.flagSkipRestart,
+		SkipCheck:   c.flagSkipCheck,
+		User:        requestingUser(),
 	}
 
 	err = client.SetConfig(context.Background(), cli, req)
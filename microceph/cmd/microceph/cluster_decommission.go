@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterDecommission struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagDryRun  bool
+	flagForce   bool
+	flagTimeout int64
+}
+
+func (c *cmdClusterDecommission) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decommission <NODE_NAME>",
+		Short: "Removes a server along with its disks, services and keys from the cluster",
+		Long: `Removes a server from the cluster after draining and removing its disks, moving its mon, mgr, mds,
+rgw and nfs services to other members and removing its CRUSH host bucket and cephx keys.
+A decommission which failed part way resumes where it stopped when run again.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, "Only show the planned operations")
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, "Continue past failed operations and forcibly remove the cluster member")
+	cmd.Flags().Int64Var(&c.flagTimeout, "timeout", 3600, "Timeout to drain and remove each disk (seconds)")
+
+	return cmd
+}
+
+func (c *cmdClusterDecommission) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.DecommissionRequest{
+		DryRun:  c.flagDryRun,
+		Force:   c.flagForce,
+		Timeout: c.flagTimeout,
+	}
+
+	results, err := client.DecommissionNode(context.Background(), cli, args[0], req)
+	if err != nil {
+		return fmt.Errorf("failed to decommission node: %v", err)
+	}
+
+	for _, result := range results {
+		if c.flagDryRun {
+			fmt.Println(result.Action)
+		} else if result.Error == "" {
+			fmt.Printf("%s (succeeded)\n", result.Action)
+		} else {
+			fmt.Printf("%s (failed: %s)\n", result.Action, result.Error)
+		}
+	}
+
+	return nil
+}
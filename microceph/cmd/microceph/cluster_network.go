@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdClusterNetwork struct {
+	common *CmdControl
+}
+
+func (c *cmdClusterNetwork) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "Manage the public and cluster networks",
+	}
+
+	// Check
+	clusterNetworkCheck := cmdClusterNetworkCheck{common: c.common}
+	cmd.AddCommand(clusterNetworkCheck.Command())
+
+	// Migrate
+	clusterNetworkMigrate := cmdClusterNetworkMigrate{common: c.common}
+	cmd.AddCommand(clusterNetworkMigrate.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
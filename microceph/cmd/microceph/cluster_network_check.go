@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterNetworkCheck struct {
+	common *CmdControl
+
+	flagClusterNetwork string
+	json               bool
+}
+
+func (c *cmdClusterNetworkCheck) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check every member reaches every other member on the public and cluster networks",
+		Long: "Check every member reaches every other member on the public and cluster networks.\n" +
+			"Each member probes the monitor and OSD ports of the others, measures the latency and\n" +
+			"the largest packet getting through, so mismatched jumbo frame setups show up.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagClusterNetwork, "cluster-network", "", "Check the given subnet instead of the configured cluster network")
+	cmd.Flags().BoolVar(&c.json, "json", false, "output as json string")
+	return cmd
+}
+
+func (c *cmdClusterNetworkCheck) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	report, err := client.CheckNetwork(context.Background(), cli, types.NetworkCheckRequest{ClusterNetwork: c.flagClusterNetwork})
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		opStr, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Printf("%s\n", opStr)
+	} else {
+		printNetworkCheckTable(report)
+	}
+
+	if report.Failed() != 0 {
+		return fmt.Errorf("%d of %d network probes failed", report.Failed(), len(report.Results))
+	}
+
+	return nil
+}
+
+func printNetworkCheckTable(report types.NetworkCheckReport) {
+	fmt.Printf("Public network: %s\n", report.PublicNetwork)
+	if len(report.ClusterNetwork) != 0 {
+		fmt.Printf("Cluster network: %s\n", report.ClusterNetwork)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"From", "To", "Network", "Address", "Status", "Message"})
+	for _, result := range report.Results {
+		t.AppendRow(table.Row{result.From, result.To, result.Network, result.Address, result.Status, result.Message})
+	}
+	if terminal.IsTerminal(0) && terminal.IsTerminal(1) {
+		// Set style if interactive shell.
+		t.SetStyle(table.StyleColoredBright)
+	}
+	t.Render()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterNetworkMigrate struct {
+	common *CmdControl
+
+	flagPublic string
+	flagDryRun bool
+}
+
+func (c *cmdClusterNetworkMigrate) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate --public <cidr>",
+		Short: "Move the cluster to a new public network",
+		Long: "Move the cluster to a new public network, every member needs an address on it.\n" +
+			"The monitors move one at a time, keeping quorum, then public_network and ceph.conf are\n" +
+			"updated on every member and the other daemons restart one member at a time.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagPublic, "public", "", "The new public network, in CIDR notation")
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, "Only print the migration steps")
+	_ = cmd.MarkFlagRequired("public")
+	return cmd
+}
+
+func (c *cmdClusterNetworkMigrate) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.MigrateNetwork(context.Background(), cli, types.NetworkMigrateRequest{Public: c.flagPublic, DryRun: c.flagDryRun})
+	if err != nil {
+		return err
+	}
+
+	for i, step := range resp.Steps {
+		fmt.Printf("%d. %s\n", i+1, step)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdClusterProfile struct {
+	common *CmdControl
+}
+
+func (c *cmdClusterProfile) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage tuning profiles for common cluster shapes",
+	}
+
+	// List
+	clusterProfileList := cmdClusterProfileList{common: c.common}
+	cmd.AddCommand(clusterProfileList.Command())
+
+	// Apply
+	clusterProfileApply := cmdClusterProfileApply{common: c.common}
+	cmd.AddCommand(clusterProfileApply.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterProfileApply struct {
+	common *CmdControl
+
+	flagFile        string
+	flagDryRun      bool
+	flagYes         bool
+	flagWait        bool
+	flagSkipRestart bool
+}
+
+func (c *cmdClusterProfileApply) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply <small|allflash|archive|name> | --file <path>",
+		Short: "Apply a tuning profile to the cluster",
+		Long: "Apply a tuning profile, bundling cluster configs, client configs and pool defaults.\n" +
+			"The settings the profile changes are listed first and applied once confirmed. Profiles\n" +
+			"are either built in, YAML files in the profiles directory of the member, or a YAML file\n" +
+			"passed with --file.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagFile, "file", "", "Apply the profile defined in the given YAML file")
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, "Only list the settings the profile changes")
+	cmd.Flags().BoolVar(&c.flagYes, "yes", false, "Apply the profile without asking for confirmation")
+	cmd.Flags().BoolVar(&c.flagWait, "wait", false, "Wait for required ceph services to restart post profile apply.")
+	cmd.Flags().BoolVar(&c.flagSkipRestart, "skip-restart", false, "Don't perform the daemon restart for the changed configs.")
+	return cmd
+}
+
+func (c *cmdClusterProfileApply) Run(cmd *cobra.Command, args []string) error {
+	req := types.ProfileApplyRequest{
+		DryRun:      true,
+		Wait:        c.flagWait,
+		SkipRestart: c.flagSkipRestart,
+		User:        requestingUser(),
+	}
+
+	if len(c.flagFile) != 0 && len(args) == 0 {
+		profile, err := ceph.ReadProfileFile(c.flagFile)
+		if err != nil {
+			return err
+		}
+
+		req.Name = profile.Name
+		req.Profile = &profile
+	} else if len(c.flagFile) == 0 && len(args) == 1 {
+		req.Name = args[0]
+	} else {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	plan, err := client.ApplyProfile(context.Background(), cli, req)
+	if err != nil {
+		return err
+	}
+
+	if len(plan.Changes) == 0 {
+		fmt.Printf("The cluster already matches profile %s\n", req.Name)
+		return nil
+	}
+
+	err = renderProfileChanges(plan.Changes)
+	if err != nil || c.flagDryRun {
+		return err
+	}
+
+	if !c.flagYes {
+		apply, err := c.common.Asker.AskBool(fmt.Sprintf("Apply profile %s? (yes/no) [default=no]: ", req.Name), "no")
+		if err != nil {
+			return err
+		}
+
+		if !apply {
+			return nil
+		}
+	}
+
+	req.DryRun = false
+	_, err = client.ApplyProfile(context.Background(), cli, req)
+	return err
+}
+
+// renderProfileChanges lists the settings a profile changes.
+func renderProfileChanges(changes []types.ProfileChange) error {
+	data := make([][]string, len(changes))
+	for i, change := range changes {
+		data[i] = []string{change.Kind, change.Key, unsetIfEmpty(change.OldValue), change.NewValue}
+	}
+
+	header := []string{"Kind", "Key", "Current Value", "Profile Value"}
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, changes)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterProfileList struct {
+	common *CmdControl
+}
+
+func (c *cmdClusterProfileList) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the built-in tuning profiles and the ones supplied on this member",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdClusterProfileList) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	profiles, err := client.GetProfiles(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	data := make([][]string, len(profiles))
+	for i, profile := range profiles {
+		settings := len(profile.Configs) + len(profile.ClientConfigs) + len(profile.PoolDefaults)
+		data[i] = []string{profile.Name, profile.Description, fmt.Sprintf("%d", settings)}
+	}
+
+	header := []string{"Name", "Description", "Settings"}
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, profiles)
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 
+	microCli "github.com/canonical/microcluster/v2/client"
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
 )
 
 type cmdClusterRemove struct {
@@ -12,6 +17,7 @@ type cmdClusterRemove struct {
 	cluster *cmdCluster
 
 	flagForce bool
+	flagDead  bool
 }
 
 func (c *cmdClusterRemove) Command() *cobra.Command {
@@ -22,6 +28,7 @@ func (c *cmdClusterRemove) Command() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&c.flagForce, "force", "f", false, "Forcibly remove the cluster member")
+	cmd.Flags().BoolVar(&c.flagDead, "dead", false, "Remove a permanently lost member along with its OSDs, monitor, CRUSH host bucket, keys and records")
 
 	return cmd
 }
@@ -41,5 +48,32 @@ func (c *cmdClusterRemove) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.flagDead {
+		return removeDeadMember(cli, args[0], c.flagForce)
+	}
+
 	return cli.DeleteClusterMember(context.Background(), args[0], c.flagForce)
 }
+
+// removeDeadMember cleans up after a lost member from this one.
+func removeDeadMember(cli *microCli.Client, name string, force bool) error {
+	req := &types.DecommissionRequest{
+		Dead:  true,
+		Force: force,
+	}
+
+	results, err := client.DecommissionNode(context.Background(), cli, name, req)
+	if err != nil {
+		return fmt.Errorf("failed to remove dead node: %v", err)
+	}
+
+	for _, result := range results {
+		if result.Error == "" {
+			fmt.Printf("%s (succeeded)\n", result.Action)
+		} else {
+			fmt.Printf("%s (failed: %s)\n", result.Action, result.Error)
+		}
+	}
+
+	return nil
+}
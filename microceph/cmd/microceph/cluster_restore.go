@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterRestore struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagPassphraseFile string
+}
+
+func (c *cmdClusterRestore) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <BACKUP_FILE>",
+		Short: "Rebuilds a freshly installed cluster member from a backup and re-activates its OSDs",
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagPassphraseFile, "passphrase-file", "", "Read the encryption passphrase from a file instead of prompting")
+	return cmd
+}
+
+func (c *cmdClusterRestore) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	archive, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read backup archive %s: %w", args[0], err)
+	}
+
+	passphrase, err := getBackupPassphrase(c.common, c.flagPassphraseFile, false)
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Restore(context.Background(), cli, archive, passphrase)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored member %s from backup created at %s\n", resp.Member, resp.Created)
+	fmt.Printf("Re-activating OSDs %v, the MicroCeph daemon will restart to rejoin the cluster\n", resp.OSDs)
+	return nil
+}
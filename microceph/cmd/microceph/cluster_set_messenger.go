@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterSetMessenger struct {
+	common *CmdControl
+
+	flagV2Only bool
+	flagSecure bool
+	flagDryRun bool
+}
+
+func (c *cmdClusterSetMessenger) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-messenger --v2-only [--secure]",
+		Short: "Convert the cluster to msgr2 only, optionally with on-wire encryption",
+		Long: "Convert the running cluster to msgr2 only, optionally with on-wire encryption.\n" +
+			"The msgr1 addresses of the monitors are dropped from the monmap one monitor at a time,\n" +
+			"with --secure every msgr2 connection must use the secure mode. ceph.conf is regenerated\n" +
+			"on every member and the daemons restart one member at a time.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagV2Only, "v2-only", false, "Only use the msgr2 protocol")
+	cmd.Flags().BoolVar(&c.flagSecure, "secure", false, "Encrypt the traffic of every msgr2 connection")
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, "Only print the conversion steps")
+	return cmd
+}
+
+func (c *cmdClusterSetMessenger) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 || (!c.flagV2Only && !c.flagSecure) {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := types.MessengerRequest{V2Only: c.flagV2Only, Secure: c.flagSecure, DryRun: c.flagDryRun}
+	resp, err := client.SetMessenger(context.Background(), cli, req)
+	if err != nil {
+		return err
+	}
+
+	for i, step := range resp.Steps {
+		fmt.Printf("%d. %s\n", i+1, step)
+	}
+
+	return nil
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
 )
 
 type cmdDisk struct {
@@ -18,6 +23,10 @@ func (c *cmdDisk) Command() *cobra.Command {
 	diskAddCmd := cmdDiskAdd{common: c.common, disk: c}
 	cmd.AddCommand(diskAddCmd.Command())
 
+	// Adopt
+	diskAdoptCmd := cmdDiskAdopt{common: c.common, disk: c}
+	cmd.AddCommand(diskAdoptCmd.Command())
+
 	// List
 	diskListCmd := cmdDiskList{common: c.common, disk: c}
 	cmd.AddCommand(diskListCmd.Command())
@@ -26,9 +35,53 @@ func (c *cmdDisk) Command() *cobra.Command {
 	diskRemoveCmd := cmdDiskRemove{common: c.common, disk: c}
 	cmd.AddCommand(diskRemoveCmd.Command())
 
+	// Set DB and WAL
+	diskSetDBCmd := cmdDiskSetDevice{common: c.common, disk: c, role: types.DiskRoleDB}
+	cmd.AddCommand(diskSetDBCmd.Command())
+	diskSetWALCmd := cmdDiskSetDevice{common: c.common, disk: c, role: types.DiskRoleWAL}
+	cmd.AddCommand(diskSetWALCmd.Command())
+
+	// Encrypt
+	diskEncryptCmd := cmdDiskEncrypt{common: c.common, disk: c}
+	cmd.AddCommand(diskEncryptCmd.Command())
+
+	// Encryption
+	diskEncryptionCmd := cmdDiskEncryption{common: c.common, disk: c}
+	cmd.AddCommand(diskEncryptionCmd.Command())
+
+	// Drain
+	diskDrainCmd := cmdDiskDrain{common: c.common, disk: c}
+	cmd.AddCommand(diskDrainCmd.Command())
+
+	// Auto-add
+	diskAutoAddCmd := cmdDiskAutoAdd{common: c.common, disk: c}
+	cmd.AddCommand(diskAutoAddCmd.Command())
+
+	// Health
+	diskHealthCmd := cmdDiskHealth{common: c.common, disk: c}
+	cmd.AddCommand(diskHealthCmd.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
 
 	return cmd
 }
+
+// parseOsdArg parses an OSD given either as $id or osd.$id.
+func parseOsdArg(arg string) (int64, error) {
+	// parse as int
+	osd, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		// check arg is of osd.$id form
+		if len(arg) < 4 || arg[:4] != "osd." {
+			return 0, fmt.Errorf("error: osd input must be either in the form $id or osd.$id, got %v", arg)
+		}
+		osd, err = strconv.ParseInt(arg[4:], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error: osd input must be either in the form $id or osd.$id: got %v", arg)
+		}
+	}
+
+	return osd, nil
+}
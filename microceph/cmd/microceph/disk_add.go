@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/units"
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
 
@@ -27,6 +28,9 @@ type cmdDiskAdd struct {
 	dbDevice       string
 	dbEncrypt      bool
 	dbWipe         bool
+	walSize        string
+	dbSize         string
+	osdsPerDevice  int
 	flagAllDevices bool
 }
 
@@ -37,7 +41,11 @@ func (c *cmdDiskAdd) Command() *cobra.Command {
 		Long: `Adds one or more new Ceph disks (OSDs) to the cluster, alongside optional devices for write-ahead logging and database management.
 The command takes arguments which is either one or more paths to block devices such as /dev/sdb, or a specification for loop files.
 
-For block devices, add a space separated list of (absolute) paths, e.g. "/dev/sdb /dev/sdc ...". You may also specify external WAL and DB devices referred to by absolute paths. However when specifying WAL and DB devices you may only add a single OSD block device at a time.
+For block devices, add a space separated list of (absolute) paths, e.g. "/dev/sdb /dev/sdc ...". You may also specify external WAL and DB devices referred to by absolute paths. However when specifying WAL and DB devices you may only add a single OSD block device at a time, unless they are sliced.
+
+With --wal-size or --db-size the WAL or DB device is shared: a partition of the given size (e.g. 60G) is carved off it for each added disk and returned when the disk is removed.
+
+With --osds-per-device each device is split into that many equally sized partitions, each backing an OSD of its own. Partitions and LVM logical volumes, e.g. /dev/vg0/lv0, may also be added directly.
 
 The specification for loop files is of the form loop,<size>,<nr>
 
@@ -58,6 +66,9 @@ Note that loop files can't be used with encryption nor WAL/DB devices.`,
 	cmd.PersistentFlags().StringVar(&c.dbDevice, "db-device", "", "The device used for the DB")
 	cmd.PersistentFlags().BoolVar(&c.dbWipe, "db-wipe", false, "Wipe the DB device prior to use")
 	cmd.PersistentFlags().BoolVar(&c.dbEncrypt, "db-encrypt", false, "Encrypt the DB device prior to use")
+	cmd.PersistentFlags().StringVar(&c.walSize, "wal-size", "", "Size of the slice of a shared WAL device used per disk, e.g. 2G")
+	cmd.PersistentFlags().StringVar(&c.dbSize, "db-size", "", "Size of the slice of a shared DB device used per disk, e.g. 60G")
+	cmd.PersistentFlags().IntVar(&c.osdsPerDevice, "osds-per-device", 1, "Number of OSDs to split each device into")
 
 	return cmd
 }
@@ -104,12 +115,20 @@ func (c *cmdDiskAdd) Run(cmd *cobra.Command, args []string) error {
 				req.WALDev = &c.walDevice
 				req.WALWipe = c.walWipe
 				req.WALEncrypt = c.walEncrypt
+				req.WALSize, err = parseSliceSize(c.walSize)
+				if err != nil {
+					return fmt.Errorf("invalid --wal-size: %w", err)
+				}
 			}
 
 			if c.dbDevice != "" {
 				req.DBDev = &c.dbDevice
 				req.DBWipe = c.dbWipe
 				req.DBEncrypt = c.dbEncrypt
+				req.DBSize, err = parseSliceSize(c.dbSize)
+				if err != nil {
+					return fmt.Errorf("invalid --db-size: %w", err)
+				}
 			}
 		}
 	}
@@ -117,6 +136,7 @@ func (c *cmdDiskAdd) Run(cmd *cobra.Command, args []string) error {
 	// required request params.
 	req.Wipe = c.flagWipe
 	req.Encrypt = c.flagEncrypt
+	req.OSDsPerDevice = c.osdsPerDevice
 	failures, err := client.AddDisk(context.Background(), cli, &req)
 	if err != nil {
 		return err
@@ -176,25 +196,63 @@ func printAddDiskFailures(response types.DiskAddResponse) error {
 
 // validateBatchArgs checks if no loop spec is provided as an argument to batch disk addition.
 func (c *cmdDiskAdd) validateBatchArgs(args []string) error {
-	// no validation if single arg is provided.
-	if len(args) == 1 {
+	if c.walSize != "" && c.walDevice == "" {
+		return fmt.Errorf("--wal-size flag requires --wal-device")
+	}
+
+	if c.dbSize != "" && c.dbDevice == "" {
+		return fmt.Errorf("--db-size flag requires --db-device")
+	}
+
+	if c.osdsPerDevice < 1 {
+		return fmt.Errorf("--osds-per-device must be at least 1, got %d", c.osdsPerDevice)
+	}
+
+	// no validation if single arg is provided, a split device is a batch of its partitions.
+	if len(args) == 1 && c.osdsPerDevice == 1 {
 		return nil
 	}
 
-	// if wal/db devices are provided with batch commands.
-	if c.walDevice != "" {
-		return fmt.Errorf("--wal-device flag is not supported for batch disk addition")
+	// if whole wal/db devices are provided with batch commands.
+	if c.walDevice != "" && c.walSize == "" {
+		return fmt.Errorf("--wal-device flag is not supported for batch disk addition without --wal-size")
 	}
 
-	if c.dbDevice != "" {
-		return fmt.Errorf("--db-device flag is not supported for batch disk addition")
+	if c.dbDevice != "" && c.dbSize == "" {
+		return fmt.Errorf("--db-device flag is not supported for batch disk addition without --db-size")
 	}
 
 	for _, diskPath := range args {
 		if strings.HasPrefix(diskPath, constants.LoopSpecId) {
+			if c.osdsPerDevice > 1 {
+				return fmt.Errorf("loop spec %s cannot be split with --osds-per-device", diskPath)
+			}
 			return fmt.Errorf("loop spec %s is not supported as an argument to batch disk addition, use separately", diskPath)
 		}
 	}
 
 	return nil
 }
+
+// parseSliceSize parses a WAL/DB slice size such as 60G or 60GiB into bytes.
+func parseSliceSize(size string) (uint64, error) {
+	if size == "" {
+		return 0, nil
+	}
+
+	// Accept the single letter suffixes used by loop specs as binary units.
+	if strings.ContainsAny(size[len(size)-1:], "KMGT") {
+		size = size + "iB"
+	}
+
+	bytes, err := units.ParseByteSizeString(size)
+	if err != nil {
+		return 0, err
+	}
+
+	if bytes <= 0 {
+		return 0, fmt.Errorf("size must be positive, got %s", size)
+	}
+
+	return uint64(bytes), nil
+}
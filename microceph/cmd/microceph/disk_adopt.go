@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskAdopt struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagDryRun bool
+}
+
+func (c *cmdDiskAdopt) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "adopt [--dry-run]",
+		Short: "Adopt existing Ceph disks (OSDs) recorded for this node",
+		Long: `Scans the block devices of this node for BlueStore labels and MicroCeph encrypted devices which belong to
+OSDs recorded against this node, e.g. after a reinstall with the data disks left intact.
+Matching OSDs have their data directories and keyrings recreated and are started without data movement.`,
+		RunE: c.Run,
+	}
+
+	cmd.PersistentFlags().BoolVar(&c.flagDryRun, "dry-run", false, "Only report the OSDs which can be adopted")
+
+	return cmd
+}
+
+func (c *cmdDiskAdopt) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.AdoptDisks(context.Background(), cli, &types.DisksAdopt{DryRun: c.flagDryRun})
+	if err != nil {
+		return err
+	}
+
+	return printAdoptDiskReports(resp)
+}
+
+func printAdoptDiskReports(response types.DiskAdoptResponse) error {
+	if len(response.Reports) == 0 {
+		fmt.Println("No OSDs to adopt.")
+		return nil
+	}
+
+	data := [][]string{}
+	failureCount := 0
+	for _, report := range response.Reports {
+		if report.Report == "Failure" {
+			failureCount += 1
+			fmt.Printf("osd.%d: %s\n", report.OSD, report.Error)
+		}
+		data = append(data, []string{fmt.Sprintf("%d", report.OSD), report.Path, report.Report})
+	}
+
+	header := []string{"OSD", "Path", "Status"}
+	sort.Sort(lxdCmd.SortColumnsNaturally(data))
+	err := lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, data)
+	if err != nil {
+		return err
+	}
+
+	if failureCount > 0 {
+		return fmt.Errorf("failed adopting %d disk(s), please check logs for details", failureCount)
+	}
+
+	return nil
+}
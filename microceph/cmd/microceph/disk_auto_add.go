@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/lxd/shared/units"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskAutoAdd struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagTypes   []string
+	flagModel   string
+	flagMinSize string
+	flagMaxSize string
+	flagEncrypt bool
+}
+
+func (c *cmdDiskAutoAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auto-add [enable|disable] [--type <type>] [--model <pattern>] [--min-size <size>] [--max-size <size>] [--encrypt]",
+		Short: "Show or set the policy for adding new disks of this node as OSDs",
+		Long: `Shows or sets the policy for adding new disks of this node as Ceph disks (OSDs) as they appear.
+When enabled, whole and pristine disks matching all of the given filters are added automatically,
+both when they are plugged in and when the daemon starts. Every decision is logged.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringSliceVar(&c.flagTypes, "type", nil, "Only add disks of this bus type (e.g. nvme, sata), may be repeated")
+	cmd.Flags().StringVar(&c.flagModel, "model", "", "Only add disks whose model matches this glob pattern")
+	cmd.Flags().StringVar(&c.flagMinSize, "min-size", "", "Only add disks of at least this size, e.g. 1T")
+	cmd.Flags().StringVar(&c.flagMaxSize, "max-size", "", "Only add disks of at most this size, e.g. 8T")
+	cmd.Flags().BoolVar(&c.flagEncrypt, "encrypt", false, "Encrypt the added disks")
+
+	return cmd
+}
+
+func (c *cmdDiskAutoAdd) Run(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		policy, err := client.GetDiskAutoAddPolicy(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+
+		printAutoAddPolicy(policy)
+		return nil
+	}
+
+	var policy types.DiskAutoAddPolicy
+	switch args[0] {
+	case "enable":
+		policy, err = c.policy()
+		if err != nil {
+			return err
+		}
+	case "disable":
+		// Keep the filters for later.
+		policy, err = client.GetDiskAutoAddPolicy(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+		policy.Enabled = false
+	default:
+		return fmt.Errorf("unknown action %q, expected enable or disable", args[0])
+	}
+
+	return client.SetDiskAutoAddPolicy(context.Background(), cli, &policy)
+}
+
+// policy builds an enabled auto-add policy from the filter flags.
+func (c *cmdDiskAutoAdd) policy() (types.DiskAutoAddPolicy, error) {
+	var err error
+	policy := types.DiskAutoAddPolicy{
+		Enabled: true,
+		Types:   c.flagTypes,
+		Model:   c.flagModel,
+		Encrypt: c.flagEncrypt,
+	}
+
+	policy.MinSize, err = parseSliceSize(c.flagMinSize)
+	if err != nil {
+		return policy, fmt.Errorf("invalid --min-size: %w", err)
+	}
+
+	policy.MaxSize, err = parseSliceSize(c.flagMaxSize)
+	if err != nil {
+		return policy, fmt.Errorf("invalid --max-size: %w", err)
+	}
+
+	return policy, nil
+}
+
+func printAutoAddPolicy(policy types.DiskAutoAddPolicy) {
+	fmt.Printf("Enabled: %t\n", policy.Enabled)
+	if len(policy.Types) != 0 {
+		fmt.Printf("Types: %s\n", strings.Join(policy.Types, ", "))
+	}
+	if len(policy.Model) != 0 {
+		fmt.Printf("Model: %s\n", policy.Model)
+	}
+	if policy.MinSize != 0 {
+		fmt.Printf("Minimum size: %s\n", units.GetByteSizeStringIEC(int64(policy.MinSize), 2))
+	}
+	if policy.MaxSize != 0 {
+		fmt.Printf("Maximum size: %s\n", units.GetByteSizeStringIEC(int64(policy.MaxSize), 2))
+	}
+	fmt.Printf("Encrypt: %t\n", policy.Encrypt)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+// drainPollInterval is the interval between progress reports when waiting for a drain.
+var drainPollInterval = 10 * time.Second
+
+type cmdDiskDrain struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagStep   float64
+	flagPause  bool
+	flagResume bool
+	flagStatus bool
+	flagWait   bool
+}
+
+func (c *cmdDiskDrain) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drain <osd-id> [--step <weight>] [--pause | --resume | --status] [--wait]",
+		Short: "Gradually move the data off a Ceph disk (OSD)",
+		Long: `Gradually moves the data off a Ceph disk (OSD) by stepping its CRUSH weight down to 0.
+After each step the drain waits for all placement groups to be active+clean before taking the next one.
+The drain continues in the background and can be paused and resumed.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().Float64Var(&c.flagStep, "step", 0, "CRUSH weight to remove per step, defaults to a tenth of the OSD weight")
+	cmd.Flags().BoolVar(&c.flagPause, "pause", false, "Pause the drain after its current step")
+	cmd.Flags().BoolVar(&c.flagResume, "resume", false, "Resume a paused or failed drain")
+	cmd.Flags().BoolVar(&c.flagStatus, "status", false, "Show the progress of the drain")
+	cmd.Flags().BoolVar(&c.flagWait, "wait", false, "Report progress until the drain completes")
+	cmd.MarkFlagsMutuallyExclusive("pause", "resume", "status")
+	cmd.MarkFlagsMutuallyExclusive("pause", "wait")
+
+	return cmd
+}
+
+func (c *cmdDiskDrain) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOsdArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	if c.flagStep < 0 {
+		return fmt.Errorf("--step must not be negative")
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	var drain types.DiskDrain
+	switch {
+	case c.flagStatus:
+		drain, err = client.GetDiskDrain(context.Background(), cli, osd)
+	case c.flagPause:
+		drain, err = client.DrainDisk(context.Background(), cli, osd, &types.DisksDrain{Action: types.DiskDrainPause})
+	case c.flagResume:
+		drain, err = client.DrainDisk(context.Background(), cli, osd, &types.DisksDrain{Action: types.DiskDrainResume})
+	default:
+		drain, err = client.DrainDisk(context.Background(), cli, osd, &types.DisksDrain{Action: types.DiskDrainStart, Step: c.flagStep})
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(drain.Status) == 0 {
+		fmt.Printf("osd.%d is not being drained\n", osd)
+		return nil
+	}
+
+	printDrain(drain)
+	if !c.flagWait {
+		return nil
+	}
+
+	for drain.Status == types.DiskDrainRunning {
+		time.Sleep(drainPollInterval)
+
+		weight := drain.Weight
+		drain, err = client.GetDiskDrain(context.Background(), cli, osd)
+		if err != nil {
+			return err
+		}
+
+		if drain.Weight != weight || drain.Status != types.DiskDrainRunning {
+			printDrain(drain)
+		}
+	}
+
+	if drain.Status == types.DiskDrainFailed {
+		return fmt.Errorf("drain of osd.%d failed: %s", osd, drain.Error)
+	}
+
+	return nil
+}
+
+// drainProgress returns the share of the CRUSH weight of an OSD drained so far, in percent.
+func drainProgress(drain types.DiskDrain) float64 {
+	if drain.InitialWeight <= 0 {
+		return 100
+	}
+
+	return (drain.InitialWeight - drain.Weight) / drain.InitialWeight * 100
+}
+
+func printDrain(drain types.DiskDrain) {
+	fmt.Printf("osd.%d: %s, CRUSH weight %.4f of %.4f (%.0f%% drained)\n",
+		drain.OSD, drain.Status, drain.Weight, drain.InitialWeight, drainProgress(drain))
+	if len(drain.Error) != 0 {
+		fmt.Printf("Error: %s\n", drain.Error)
+	}
+}
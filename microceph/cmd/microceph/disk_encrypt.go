@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskEncrypt struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagNoout   bool
+	flagTimeout int64
+}
+
+func (c *cmdDiskEncrypt) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encrypt <osd-id> [--noout] [--timeout=1800]",
+		Short: "Convert an unencrypted Ceph disk (OSD) to encrypted in place",
+		Long: `Converts an unencrypted Ceph disk (OSD) to an encrypted one, keeping its OSD id.
+By default the OSD is drained before its device is wiped and re-created on top of LUKS.
+With --noout the OSD is only stopped for the conversion and recovers from its peers afterwards.
+The command returns once all placement groups are active+clean again.`,
+		RunE: c.Run,
+	}
+
+	cmd.PersistentFlags().BoolVar(&c.flagNoout, "noout", false, "Skip draining the OSD, set noout while it is re-created")
+	cmd.PersistentFlags().Int64Var(&c.flagTimeout, "timeout", 1800, "Timeout to wait for recovery (seconds)")
+
+	return cmd
+}
+
+func (c *cmdDiskEncrypt) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOsdArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.DisksEncrypt{
+		OSD:     osd,
+		Noout:   c.flagNoout,
+		Timeout: c.flagTimeout,
+	}
+
+	fmt.Printf("Encrypting osd.%d, timeout %ds\n", osd, req.Timeout)
+	return client.EncryptDisk(context.Background(), cli, req)
+}
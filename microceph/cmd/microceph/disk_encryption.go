@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdDiskEncryption struct {
+	common *CmdControl
+	disk   *cmdDisk
+}
+
+func (c *cmdDiskEncryption) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Manage the keys of encrypted Ceph disks (OSDs)",
+	}
+
+	// Provider
+	diskEncryptionProviderCmd := cmdDiskEncryptionProvider{common: c.common, encryption: c}
+	cmd.AddCommand(diskEncryptionProviderCmd.Command())
+
+	// Rotate
+	diskEncryptionRotateCmd := cmdDiskEncryptionRotate{common: c.common, encryption: c}
+	cmd.AddCommand(diskEncryptionRotateCmd.Command())
+
+	// Get Key
+	diskEncryptionGetKeyCmd := cmdDiskEncryptionGetKey{common: c.common, encryption: c}
+	cmd.AddCommand(diskEncryptionGetKeyCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskEncryptionGetKey struct {
+	common     *CmdControl
+	encryption *cmdDiskEncryption
+}
+
+func (c *cmdDiskEncryptionGetKey) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "get-key <osd-id>",
+		Short:  "Print the key of a local encrypted Ceph disk (OSD), used to unlock it at start",
+		Hidden: true,
+		RunE:   c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdDiskEncryptionGetKey) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOsdArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	key, err := client.GetDiskKey(context.Background(), cli, osd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(key)
+	return nil
+}
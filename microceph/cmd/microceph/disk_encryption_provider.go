@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskEncryptionProvider struct {
+	common     *CmdControl
+	encryption *cmdDiskEncryption
+
+	flagURL       string
+	flagTokenFile string
+}
+
+func (c *cmdDiskEncryptionProvider) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "provider [config-key|file|http] [--url <URL>] [--token-file <FILE>]",
+		Short: "Show or set the key provider for encrypted disks",
+		Long: `Shows or sets where the keys of encrypted disks (OSDs) are kept:
+  config-key  the Ceph monitor config-key store (default)
+  file        files on the node hosting the disk
+  http        a key server (e.g. a KMIP gateway) serving keys at <URL>/keys/<name>
+
+The provider applies to newly encrypted disks and to rotated keys.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagURL, "url", "", "URL of the key server (http provider)")
+	cmd.Flags().StringVar(&c.flagTokenFile, "token-file", "", "File holding the bearer token for the key server (http provider)")
+
+	return cmd
+}
+
+func (c *cmdDiskEncryptionProvider) Run(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		conf, err := client.GetDiskEncryptionProvider(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Provider: %s\n", conf.Provider)
+		if len(conf.URL) != 0 {
+			fmt.Printf("URL: %s\n", conf.URL)
+		}
+		return nil
+	}
+
+	req := &types.DiskEncryptionProvider{Provider: args[0], URL: c.flagURL}
+	if len(c.flagTokenFile) != 0 {
+		token, err := os.ReadFile(c.flagTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		req.Token = strings.TrimSpace(string(token))
+	}
+
+	return client.SetDiskEncryptionProvider(context.Background(), cli, req)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskEncryptionRotate struct {
+	common     *CmdControl
+	encryption *cmdDiskEncryption
+}
+
+func (c *cmdDiskEncryptionRotate) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate <osd-id>",
+		Short: "Rotate the keys of an encrypted Ceph disk (OSD)",
+		Long: `Adds a LUKS keyslot with a new key to each encrypted device of the OSD, stores the new key with the
+configured key provider and retires the keyslot of the old key.`,
+		RunE: c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdDiskEncryptionRotate) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOsdArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.RotateDiskKey(context.Background(), cli, osd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rotated keys of osd.%d\n", osd)
+	return nil
+}
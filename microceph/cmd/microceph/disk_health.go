@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdDiskHealth struct {
+	common *CmdControl
+	disk   *cmdDisk
+}
+
+func (c *cmdDiskHealth) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "Show the SMART health of Ceph disks (OSDs) and the handling of failing disks",
+	}
+
+	// Show
+	diskHealthShowCmd := cmdDiskHealthShow{common: c.common, health: c}
+	cmd.AddCommand(diskHealthShowCmd.Command())
+
+	// Policy
+	diskHealthPolicyCmd := cmdDiskHealthPolicy{common: c.common, health: c}
+	cmd.AddCommand(diskHealthPolicyCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
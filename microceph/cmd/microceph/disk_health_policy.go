@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskHealthPolicy struct {
+	common *CmdControl
+	health *cmdDiskHealth
+
+	flagMarkOut bool
+}
+
+func (c *cmdDiskHealthPolicy) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy [--mark-out=<true|false>]",
+		Short: "Show or set the handling of disks predicted to fail",
+		Long: `Shows or sets the handling of Ceph disks (OSDs) whose device is predicted to fail.
+Failing devices always raise a warning in the cluster log. With --mark-out=true their OSDs are
+also marked out, so that data is moved off them before they fail.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagMarkOut, "mark-out", false, "Mark out OSDs whose device is predicted to fail")
+
+	return cmd
+}
+
+func (c *cmdDiskHealthPolicy) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("mark-out") {
+		policy, err := client.GetDiskHealthPolicy(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Mark out failing disks: %t\n", policy.MarkOut)
+		return nil
+	}
+
+	return client.SetDiskHealthPolicy(context.Background(), cli, &types.DiskHealthPolicy{MarkOut: c.flagMarkOut})
+}
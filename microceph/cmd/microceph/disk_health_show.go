@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskHealthShow struct {
+	common *CmdControl
+	health *cmdDiskHealth
+}
+
+func (c *cmdDiskHealthShow) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <osd-id>",
+		Short: "Show the latest SMART readings of the device backing a Ceph disk (OSD)",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdDiskHealthShow) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOsdArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	health, err := client.GetDiskHealth(context.Background(), cli, osd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("OSD: osd.%d\n", health.OSD)
+	fmt.Printf("Status: %s\n", health.Status)
+	for _, reason := range health.Reasons {
+		fmt.Printf("  - %s\n", reason)
+	}
+
+	if health.UpdatedAt.IsZero() {
+		return nil
+	}
+
+	fmt.Printf("Temperature: %d°C\n", health.Temperature)
+	fmt.Printf("Power on hours: %d\n", health.PowerOnHours)
+	fmt.Printf("Reallocated sectors: %d\n", health.ReallocatedSectors)
+	fmt.Printf("Pending sectors: %d\n", health.PendingSectors)
+	fmt.Printf("Media errors: %d\n", health.MediaErrors)
+	fmt.Printf("Endurance used: %d%%\n", health.PercentageUsed)
+	fmt.Printf("Marked out: %t\n", health.MarkedOut)
+	fmt.Printf("Updated: %s\n", health.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
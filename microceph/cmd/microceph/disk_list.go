@@ -109,12 +109,32 @@ func outputFormattedTable(configuredDisks types.Disks, availableDisks []Disk) er
 
 	if len(configuredDisks) > 0 {
 		// Print configured disks.
+		// Only show the device and health columns when some OSDs share a split device or have readings.
+		split := false
+		health := false
+		for _, cDisk := range configuredDisks {
+			split = split || len(cDisk.Parent) != 0
+			health = health || len(cDisk.Health) != 0
+		}
+
 		cData := make([][]string, len(configuredDisks))
 		for i, cDisk := range configuredDisks {
 			cData[i] = []string{fmt.Sprintf("%d", cDisk.OSD), cDisk.Location, cDisk.Path}
+			if split {
+				cData[i] = append(cData[i], cDisk.Parent)
+			}
+			if health {
+				cData[i] = append(cData[i], cDisk.Health)
+			}
 		}
 
 		header := []string{"OSD", "LOCATION", "PATH"}
+		if split {
+			header = append(header, "DEVICE")
+		}
+		if health {
+			header = append(header, "HEALTH")
+		}
 		sort.Sort(lxdCmd.SortColumnsNaturally(cData))
 
 		fmt.Println("Disks configured in MicroCeph:")
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
@@ -20,11 +19,13 @@ type cmdDiskRemove struct {
 	flagConfirmDowngrade       bool
 	flagProhibitCrushScaledown bool
 	flagTimeout                int64
+	flagDrain                  bool
+	flagDrainStep              float64
 }
 
 func (c *cmdDiskRemove) Command() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "remove <osd-id> [--timeout=300] [--bypass-safety-checks=false] [--confirm-failure-domain-downgrade=false]",
+		Use:   "remove <osd-id> [--timeout=300] [--bypass-safety-checks=false] [--confirm-failure-domain-downgrade=false] [--drain]",
 		Short: "Remove a Ceph disk (OSD) given an osd.$id.",
 		RunE:  c.Run,
 	}
@@ -33,6 +34,8 @@ func (c *cmdDiskRemove) Command() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(&c.flagBypassSafety, "bypass-safety-checks", false, "Bypass safety checks")
 	cmd.PersistentFlags().BoolVar(&c.flagConfirmDowngrade, "confirm-failure-domain-downgrade", false, "Confirm failure domain downgrade if required")
 	cmd.PersistentFlags().BoolVar(&c.flagProhibitCrushScaledown, "prohibit-crush-scaledown", false, "Remove OSD without scaling down the crush failure domain")
+	cmd.PersistentFlags().BoolVar(&c.flagDrain, "drain", false, "Step the CRUSH weight down gradually instead of taking the OSD out at once")
+	cmd.PersistentFlags().Float64Var(&c.flagDrainStep, "drain-step", 0, "CRUSH weight to remove per drain step, defaults to a tenth of the OSD weight")
 
 	return cmd
 }
@@ -52,17 +55,9 @@ func (c *cmdDiskRemove) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// parse as int
-	osd, err := strconv.ParseInt(args[0], 10, 64)
+	osd, err := parseOsdArg(args[0])
 	if err != nil {
-		// check arg is of osd.$id form
-		if len(args[0]) < 4 || args[0][:4] != "osd." {
-			return fmt.Errorf("error: osd input must be either in the form $id or osd.$id, got %v", args[0])
-		}
-		osd, err = strconv.ParseInt(args[0][4:], 10, 64)
-		if err != nil {
-			return fmt.Errorf("error: osd input must be either in the form $id or osd.$id: got %v", args[0])
-		}
+		return err
 	}
 
 	if c.flagConfirmDowngrade && c.flagProhibitCrushScaledown {
@@ -75,6 +70,16 @@ func (c *cmdDiskRemove) Run(cmd *cobra.Command, args []string) error {
 		ConfirmDowngrade:       c.flagConfirmDowngrade,
 		ProhibitCrushScaledown: c.flagProhibitCrushScaledown,
 		Timeout:                c.flagTimeout,
+		Drain:                  c.flagDrain,
+	}
+
+	// The drain runs in the background on the member hosting the OSD, an interrupted one is resumed.
+	if c.flagDrain {
+		fmt.Printf("Draining osd.%d, timeout %ds\n", osd, req.Timeout)
+		err = client.WaitForDiskDrain(context.Background(), cli, osd, c.flagDrainStep, c.flagTimeout, printDrain)
+		if err != nil {
+			return err
+		}
 	}
 
 	fmt.Printf("Removing osd.%d, timeout %ds\n", osd, req.Timeout)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskSetDevice struct {
+	common *CmdControl
+	disk   *cmdDisk
+	role   string
+
+	flagWipe    bool
+	flagEncrypt bool
+}
+
+func (c *cmdDiskSetDevice) Command() *cobra.Command {
+	name := strings.ToUpper(c.role)
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("set-%s <osd-id> <device> [--wipe] [--encrypt]", c.role),
+		Short: fmt.Sprintf("Attach or replace the %s device of a Ceph disk (OSD)", name),
+		Long: fmt.Sprintf(`Attaches a %[1]s device to an existing Ceph disk (OSD), or moves its %[1]s onto a new device.
+The OSD is stopped with noout set while BlueFS data is migrated and started again afterwards.`, name),
+		RunE: c.Run,
+	}
+
+	cmd.PersistentFlags().BoolVar(&c.flagWipe, "wipe", false, fmt.Sprintf("Wipe the %s device prior to use", name))
+	cmd.PersistentFlags().BoolVar(&c.flagEncrypt, "encrypt", false, fmt.Sprintf("Encrypt the %s device prior to use", name))
+
+	return cmd
+}
+
+func (c *cmdDiskSetDevice) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOsdArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.DisksSetDevice{
+		Role:    c.role,
+		Path:    args[1],
+		Wipe:    c.flagWipe,
+		Encrypt: c.flagEncrypt,
+	}
+
+	return client.SetDiskDevice(context.Background(), cli, osd, req)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDoctor struct {
+	common     *CmdControl
+	flagTarget string
+	json       bool
+}
+
+func (c *cmdDoctor) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor [--target <server>]",
+		Short: "Check the hosts of the cluster members for common problems",
+		Long: "Check the hosts of the cluster members for common problems, e.g. missing snap interface\n" +
+			"connections, no disk encryption support, low disk space, unsynchronised clocks or no address\n" +
+			"on the cluster networks. Before bootstrap or join, only this host is checked.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagTarget, "target", "", "Only check the given server")
+	cmd.Flags().BoolVar(&c.json, "json", false, "output as json string")
+	return cmd
+}
+
+func (c *cmdDoctor) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	reports := []types.DoctorReport{}
+	for _, target := range doctorTargets(cli, c.flagTarget) {
+		report, err := client.GetDoctorReport(context.Background(), cli, target)
+		if err != nil {
+			report = types.DoctorReport{Member: target, Results: []types.DoctorResult{{
+				Check:   "reachable",
+				Status:  types.DoctorFail,
+				Message: err.Error(),
+				Hint:    "check the MicroCeph daemon of the member is running",
+			}}}
+		}
+
+		reports = append(reports, report)
+	}
+
+	if c.json {
+		opStr, err := json.Marshal(reports)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Printf("%s\n", opStr)
+	} else {
+		printDoctorReports(reports)
+	}
+
+	failed := 0
+	for _, report := range reports {
+		for _, result := range report.Results {
+			if result.Status == types.DoctorFail {
+				failed++
+			}
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+
+	return nil
+}
+
+// doctorTargets returns the members to check, an empty name stands for the local member.
+func doctorTargets(cli *microCli.Client, target string) []string {
+	if len(target) != 0 {
+		return []string{target}
+	}
+
+	// Only the local member can be checked until it is part of a cluster.
+	members, err := cli.GetClusterMembers(context.Background())
+	if err != nil || len(members) == 0 {
+		return []string{""}
+	}
+
+	targets := []string{}
+	for _, member := range members {
+		targets = append(targets, member.Name)
+	}
+
+	return targets
+}
+
+// printDoctorReports prints the results of each member along with the hints to fix them.
+func printDoctorReports(reports []types.DoctorReport) {
+	for _, report := range reports {
+		member := report.Member
+		if len(member) == 0 {
+			member = "this host"
+		}
+
+		fmt.Printf("%s:\n", member)
+		for _, result := range report.Results {
+			fmt.Printf("  [%s] %s: %s\n", strings.ToUpper(result.Status), result.Check, result.Message)
+			if len(result.Hint) != 0 {
+				fmt.Printf("         hint: %s\n", result.Hint)
+			}
+		}
+	}
+}
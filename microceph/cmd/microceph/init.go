@@ -3,10 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"time"
 
+	"github.com/canonical/lxd/lxd/resources"
 	"github.com/canonical/lxd/lxd/util"
 	"github.com/canonical/lxd/shared/api"
 	microCli "github.com/canonical/microcluster/v2/client"
@@ -14,24 +15,38 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/canonical/microceph/microceph/api/types"
-	"github.com/canonical/microceph/microceph/client"
 )
 
 type cmdInit struct {
 	common *CmdControl
+
+	flagPreseed bool
+	flagDump    bool
 }
 
 func (c *cmdInit) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Interactive configuration of MicroCeph",
-		RunE:  c.Run,
+		Long: `Interactive configuration of MicroCeph.
+
+With --preseed the answers are read as YAML from standard input instead, covering bootstrap or join,
+networks, disks, services and cluster configs. With --dump the answers are printed as such a preseed
+instead of being applied.`,
+		RunE: c.Run,
 	}
 
+	cmd.Flags().BoolVar(&c.flagPreseed, "preseed", false, "Read the answers as YAML from standard input")
+	cmd.Flags().BoolVar(&c.flagDump, "dump", false, "Print the answers as preseed instead of applying them")
+
 	return cmd
 }
 
 func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
+	if c.flagPreseed && c.flagDump {
+		return fmt.Errorf("--preseed and --dump can't be combined")
+	}
+
 	// Connect to the daemon.
 	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
 	if err != nil {
@@ -50,6 +65,35 @@ func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var p *preseed
+	if c.flagPreseed {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read preseed from standard input: %w", err)
+		}
+
+		p, err = parsePreseed(data)
+		if err != nil {
+			return err
+		}
+	} else {
+		p, err = c.ask(lc, isUninitialized)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.flagDump {
+		return dumpPreseed(p)
+	}
+
+	return applyPreseed(m, lc, p, isUninitialized)
+}
+
+// ask collects the answers of the interactive configuration.
+func (c *cmdInit) ask(lc *microCli.Client, isUninitialized bool) (*preseed, error) {
+	p := &preseed{}
+
 	// User interaction.
 	mode := "existing"
 
@@ -57,54 +101,41 @@ func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
 		// Get system name.
 		hostName, err := os.Hostname()
 		if err != nil {
-			return fmt.Errorf("failed to retrieve system hostname: %w", err)
+			return nil, fmt.Errorf("failed to retrieve system hostname: %w", err)
 		}
 
 		// Get system address.
 		address := util.NetworkInterfaceAddress()
 		address, err = c.common.Asker.AskString(fmt.Sprintf("Please choose the address MicroCeph will be listening on [default=%s]: ", address), address, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		address = util.CanonicalNetworkAddress(address, 7443)
+		p.Address = address
 
 		wantsBootstrap, err := c.common.Asker.AskBool("Would you like to create a new MicroCeph cluster? (yes/no) [default=no]: ", "no")
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if wantsBootstrap {
 			mode = "bootstrap"
+			p.Bootstrap = true
+
 			// Offer overriding the name.
 			hostName, err = c.common.Asker.AskString(fmt.Sprintf("Please choose a name for this system [default=%s]: ", hostName), hostName, nil)
 			if err != nil {
-				return err
-			}
-
-			// Bootstrap the cluster.
-			ctx, cancel := context.WithTimeout(context.Background(), time.Minute*2)
-			defer cancel()
-
-			err = m.NewCluster(ctx, hostName, address, nil)
-			if err != nil {
-				return err
+				return nil, err
 			}
 		} else {
 			mode = "join"
 
-			token, err := c.common.Asker.AskString("Please enter your join token: ", "", nil)
-			if err != nil {
-				return err
-			}
-
-			ctx, cancel := context.WithTimeout(context.Background(), time.Minute*2)
-			defer cancel()
-
-			err = m.JoinCluster(ctx, hostName, address, token, nil)
+			p.Token, err = c.common.Asker.AskString("Please enter your join token: ", "", nil)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
+
+		p.Name = hostName
 	} else {
 		fmt.Printf("MicroCeph has already been initialized.\n\n")
 	}
@@ -113,27 +144,21 @@ func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
 	if mode != "join" {
 		wantsMachines, err := c.common.Asker.AskBool("Would you like to add additional servers to the cluster? (yes/no) [default=no]: ", "no")
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if wantsMachines {
 			for {
 				tokenName, err := c.common.Asker.AskString("What's the name of the new MicroCeph server? (empty to exit): ", "", func(input string) error { return nil })
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				if tokenName == "" {
 					break
 				}
 
-				// Issue the token.
-				token, err := m.NewJoinToken(context.Background(), tokenName, 3*time.Hour)
-				if err != nil {
-					return err
-				}
-
-				fmt.Println(token)
+				p.AddServers = append(p.AddServers, tokenName)
 			}
 		}
 	}
@@ -141,19 +166,19 @@ func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
 	// Add some disks.
 	wantsDisks, err := c.common.Asker.AskBool("Would you like to add additional local disks to MicroCeph? (yes/no) [default=yes]: ", "yes")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if wantsDisks {
-		err = printLocalDisks(lc)
+		err = printLocalDisks(lc, isUninitialized)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		for {
 			diskPath, err := c.common.Asker.AskString("What's the disk path? (empty to exit): ", "", func(input string) error { return nil })
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			if diskPath == "" {
@@ -162,34 +187,44 @@ func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
 
 			diskWipe, err := c.common.Asker.AskBool("Would you like the disk to be wiped? [default=no]: ", "no")
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			diskEncrypt, err := c.common.Asker.AskBool("Would you like the disk to be encrypted? [default=no]: ", "no")
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			// Add the disk.
-			req := &types.DisksPost{
-				Path:    []string{diskPath},
+			p.Disks = append(p.Disks, preseedDisk{
+				Path:    diskPath,
 				Wipe:    diskWipe,
 				Encrypt: diskEncrypt,
-			}
-
-			failures, err := client.AddDisk(context.Background(), lc, req)
-			if err != nil {
-				return fmt.Errorf("failed to add the following disks:\n%v \nerr: %w", failures, err)
-			}
+			})
 		}
 	}
 
-	return nil
+	return p, nil
 }
 
-func printLocalDisks(cli *microCli.Client) error {
-	// List unpartitioned disks.
-	availableDisks, err := getUnpartitionedDisks(cli)
+func printLocalDisks(cli *microCli.Client, isUninitialized bool) error {
+	var availableDisks []Disk
+	var err error
+
+	if isUninitialized {
+		// The database isn't up before bootstrap or join, so look at the local disks directly,
+		// none of them can be configured yet.
+		var storage *api.ResourcesStorage
+		storage, err = resources.GetStorage()
+		if err != nil {
+			return fmt.Errorf("internal error: unable to fetch available disks: %w", err)
+		}
+
+		availableDisks, err = filterLocalDisks(storage, types.Disks{})
+	} else {
+		// List unpartitioned disks.
+		availableDisks, err = getUnpartitionedDisks(cli)
+	}
+
 	if err != nil {
 		return fmt.Errorf("internal error: unable to fetch unpartitioned disks: %w", err)
 	}
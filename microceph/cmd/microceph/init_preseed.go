@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/lxd/util"
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"gopkg.in/yaml.v2"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+)
+
+// preseed holds the answers to microceph init, so that nodes can be set up non-interactively.
+type preseed struct {
+	// Bootstrap creates a new cluster, otherwise the node joins the cluster the token was issued by.
+	Bootstrap bool   `yaml:"bootstrap"`
+	Name      string `yaml:"name,omitempty"`
+	Address   string `yaml:"address,omitempty"`
+	Token     string `yaml:"token,omitempty"`
+
+	// Cluster wide network settings, only used when bootstrapping.
+	MonIP          string `yaml:"mon_ip,omitempty"`
+	PublicNetwork  string `yaml:"public_network,omitempty"`
+	ClusterNetwork string `yaml:"cluster_network,omitempty"`
+	V2Only         bool   `yaml:"v2_only,omitempty"`
+
+	// AddServers are the names of the servers to issue join tokens for.
+	AddServers []string          `yaml:"add_servers,omitempty"`
+	Disks      []preseedDisk     `yaml:"disks,omitempty"`
+	Services   preseedServices   `yaml:"services,omitempty"`
+	Config     map[string]string `yaml:"config,omitempty"`
+}
+
+// preseedDisk describes a disk to add as OSD, the path may also be a loop file spec.
+type preseedDisk struct {
+	Path          string `yaml:"path"`
+	Wipe          bool   `yaml:"wipe,omitempty"`
+	Encrypt       bool   `yaml:"encrypt,omitempty"`
+	WALDevice     string `yaml:"wal_device,omitempty"`
+	WALSize       string `yaml:"wal_size,omitempty"`
+	WALWipe       bool   `yaml:"wal_wipe,omitempty"`
+	WALEncrypt    bool   `yaml:"wal_encrypt,omitempty"`
+	DBDevice      string `yaml:"db_device,omitempty"`
+	DBSize        string `yaml:"db_size,omitempty"`
+	DBWipe        bool   `yaml:"db_wipe,omitempty"`
+	DBEncrypt     bool   `yaml:"db_encrypt,omitempty"`
+	OSDsPerDevice int    `yaml:"osds_per_device,omitempty"`
+}
+
+// preseedServices lists the services to enable on the node.
+type preseedServices struct {
+	MON          bool         `yaml:"mon,omitempty"`
+	MGR          bool         `yaml:"mgr,omitempty"`
+	MDS          bool         `yaml:"mds,omitempty"`
+	RBDMirror    bool         `yaml:"rbd_mirror,omitempty"`
+	CephFSMirror bool         `yaml:"cephfs_mirror,omitempty"`
+	RGW          *preseedRGW  `yaml:"rgw,omitempty"`
+	NFS          []preseedNFS `yaml:"nfs,omitempty"`
+}
+
+// preseedRGW holds the RGW service settings, certificate and key are base64 encoded.
+type preseedRGW struct {
+	Port           int    `yaml:"port,omitempty"`
+	SSLPort        int    `yaml:"ssl_port,omitempty"`
+	SSLCertificate string `yaml:"ssl_certificate,omitempty"`
+	SSLPrivateKey  string `yaml:"ssl_private_key,omitempty"`
+}
+
+// preseedNFS holds the settings of an NFS Ganesha service.
+type preseedNFS struct {
+	ClusterID    string `yaml:"cluster_id"`
+	BindAddress  string `yaml:"bind_address,omitempty"`
+	BindPort     uint   `yaml:"bind_port,omitempty"`
+	V4MinVersion *uint  `yaml:"v4_min_version,omitempty"`
+}
+
+// parsePreseed parses and validates a preseed, filling in the defaults.
+func parsePreseed(data []byte) (*preseed, error) {
+	p := &preseed{}
+	err := yaml.UnmarshalStrict(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse preseed: %w", err)
+	}
+
+	err = p.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid preseed: %w", err)
+	}
+
+	return p, nil
+}
+
+// validate checks the preseed for conflicting or invalid settings and fills in the defaults.
+func (p *preseed) validate() error {
+	if p.Bootstrap && len(p.Token) != 0 {
+		return fmt.Errorf("a token is only used to join a cluster, not to bootstrap one")
+	}
+
+	if !p.Bootstrap {
+		if len(p.MonIP) != 0 || len(p.PublicNetwork) != 0 || len(p.ClusterNetwork) != 0 || p.V2Only {
+			return fmt.Errorf("networks can only be set when bootstrapping a cluster")
+		}
+
+		if len(p.AddServers) != 0 {
+			return fmt.Errorf("join tokens can only be issued when bootstrapping a cluster")
+		}
+	}
+
+	for _, network := range []string{p.PublicNetwork, p.ClusterNetwork} {
+		if len(network) == 0 {
+			continue
+		}
+
+		_, _, err := net.ParseCIDR(network)
+		if err != nil {
+			return fmt.Errorf("invalid network %q: %w", network, err)
+		}
+	}
+
+	for _, disk := range p.Disks {
+		if len(disk.Path) == 0 {
+			return fmt.Errorf("disk path must not be empty")
+		}
+
+		_, err := disk.request()
+		if err != nil {
+			return err
+		}
+	}
+
+	rgw := p.Services.RGW
+	if rgw != nil {
+		if (len(rgw.SSLCertificate) == 0) != (len(rgw.SSLPrivateKey) == 0) {
+			return fmt.Errorf("rgw needs both an SSL certificate and private key")
+		}
+
+		if rgw.SSLPort == 0 {
+			rgw.SSLPort = 443
+		}
+	}
+
+	clusterIDs := map[string]bool{}
+	for i := range p.Services.NFS {
+		nfs := &p.Services.NFS[i]
+		if !types.NFSClusterIDRegex.MatchString(nfs.ClusterID) {
+			return fmt.Errorf("invalid nfs cluster ID %q (regex: '%s')", nfs.ClusterID, types.NFSClusterIDRegex.String())
+		}
+
+		if clusterIDs[nfs.ClusterID] {
+			return fmt.Errorf("nfs cluster ID %q given more than once", nfs.ClusterID)
+		}
+		clusterIDs[nfs.ClusterID] = true
+
+		if len(nfs.BindAddress) == 0 {
+			nfs.BindAddress = "0.0.0.0"
+		}
+
+		if net.ParseIP(nfs.BindAddress) == nil {
+			return fmt.Errorf("invalid nfs bind address %q", nfs.BindAddress)
+		}
+
+		if nfs.BindPort == 0 {
+			nfs.BindPort = 2049
+		}
+
+		// 49152 - 65535 - dynamic and / or private ports.
+		if nfs.BindPort > 49151 {
+			return fmt.Errorf("invalid nfs bind port %d, must be in [1, 49151]", nfs.BindPort)
+		}
+
+		if nfs.V4MinVersion == nil {
+			version := uint(1)
+			nfs.V4MinVersion = &version
+		}
+
+		if *nfs.V4MinVersion > 2 {
+			return fmt.Errorf("invalid nfs v4 minimum version %d, must be 0, 1 or 2", *nfs.V4MinVersion)
+		}
+	}
+
+	return nil
+}
+
+// request returns the disk addition request of a preseeded disk.
+func (d preseedDisk) request() (*types.DisksPost, error) {
+	var err error
+
+	req := &types.DisksPost{
+		Path:          []string{d.Path},
+		Wipe:          d.Wipe,
+		Encrypt:       d.Encrypt,
+		OSDsPerDevice: d.OSDsPerDevice,
+	}
+
+	if strings.HasPrefix(d.Path, constants.LoopSpecId) {
+		if len(d.WALDevice) != 0 || len(d.DBDevice) != 0 || d.Encrypt {
+			return nil, fmt.Errorf("loop files can't be used with encryption nor WAL/DB devices: %s", d.Path)
+		}
+
+		return req, nil
+	}
+
+	if len(d.WALDevice) != 0 {
+		req.WALDev = &d.WALDevice
+		req.WALWipe = d.WALWipe
+		req.WALEncrypt = d.WALEncrypt
+		req.WALSize, err = parseSliceSize(d.WALSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wal_size of %s: %w", d.Path, err)
+		}
+	}
+
+	if len(d.DBDevice) != 0 {
+		req.DBDev = &d.DBDevice
+		req.DBWipe = d.DBWipe
+		req.DBEncrypt = d.DBEncrypt
+		req.DBSize, err = parseSliceSize(d.DBSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid db_size of %s: %w", d.Path, err)
+		}
+	}
+
+	return req, nil
+}
+
+// serviceRequests returns the service enablement requests of the preseeded services.
+func (s preseedServices) serviceRequests() ([]*types.EnableService, error) {
+	reqs := []*types.EnableService{}
+
+	for _, svc := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"mon", s.MON},
+		{"mgr", s.MGR},
+		{"mds", s.MDS},
+		{"rbd-mirror", s.RBDMirror},
+		{"cephfs-mirror", s.CephFSMirror},
+	} {
+		if svc.enabled {
+			reqs = append(reqs, &types.EnableService{Name: svc.name, Wait: true})
+		}
+	}
+
+	if s.RGW != nil {
+		jsp, err := json.Marshal(ceph.RgwServicePlacement{
+			Port:           s.RGW.Port,
+			SSLPort:        s.RGW.SSLPort,
+			SSLCertificate: s.RGW.SSLCertificate,
+			SSLPrivateKey:  s.RGW.SSLPrivateKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		reqs = append(reqs, &types.EnableService{Name: "rgw", Wait: true, Payload: string(jsp)})
+	}
+
+	for _, nfs := range s.NFS {
+		jsp, err := json.Marshal(ceph.NFSServicePlacement{
+			ClusterID:    nfs.ClusterID,
+			V4MinVersion: *nfs.V4MinVersion,
+			BindAddress:  nfs.BindAddress,
+			BindPort:     nfs.BindPort,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		reqs = append(reqs, &types.EnableService{Name: "nfs", Wait: true, Payload: string(jsp)})
+	}
+
+	return reqs, nil
+}
+
+// applyPreseed sets up the node as described by the preseed, a node which is already initialized only gets
+// the disks, services and configs missing.
+func applyPreseed(m *microcluster.MicroCluster, lc *microCli.Client, p *preseed, isUninitialized bool) error {
+	if isUninitialized {
+		err := initCluster(m, p)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("MicroCeph has already been initialized.\n\n")
+	}
+
+	for _, name := range p.AddServers {
+		token, err := m.NewJoinToken(context.Background(), name, 3*time.Hour)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(token)
+	}
+
+	err := addPreseedDisks(m, lc, p.Disks)
+	if err != nil {
+		return err
+	}
+
+	err = enablePreseedServices(m, lc, p.Services)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(p.Config))
+	for key := range p.Config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err = client.SetConfig(context.Background(), lc, &types.Config{Key: key, Value: p.Config[key], Wait: true})
+		if err != nil {
+			return fmt.Errorf("failed to set config %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// initCluster bootstraps a new cluster or joins an existing one.
+func initCluster(m *microcluster.MicroCluster, p *preseed) error {
+	name := p.Name
+	if len(name) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve system hostname: %w", err)
+		}
+		name = hostname
+	}
+
+	address := p.Address
+	if len(address) == 0 {
+		address = util.NetworkInterfaceAddress()
+	}
+	address = util.CanonicalNetworkAddress(address, constants.BootstrapPortConst)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*2)
+	defer cancel()
+
+	err := m.Ready(ctx)
+	if err != nil {
+		return fmt.Errorf("fault while waiting for App readiness: %w", err)
+	}
+
+	if !p.Bootstrap {
+		if len(p.Token) == 0 {
+			return fmt.Errorf("a join token is needed to join a cluster")
+		}
+
+		return m.JoinCluster(ctx, name, address, p.Token, nil)
+	}
+
+	data := common.BootstrapConfig{
+		MonIp:      p.MonIP,
+		PublicNet:  p.PublicNetwork,
+		ClusterNet: p.ClusterNetwork,
+		V2Only:     p.V2Only,
+	}
+
+	err = preCheckBootstrapConfig(data)
+	if err != nil {
+		return err
+	}
+
+	return m.NewCluster(ctx, name, address, common.EncodeBootstrapConfig(data))
+}
+
+// addPreseedDisks adds the preseeded disks which are not OSDs of this node yet.
+func addPreseedDisks(m *microcluster.MicroCluster, lc *microCli.Client, disks []preseedDisk) error {
+	if len(disks) == 0 {
+		return nil
+	}
+
+	status, err := m.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	configured, err := client.GetDisks(context.Background(), lc)
+	if err != nil {
+		return fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	for _, disk := range disks {
+		if isDiskConfigured(configured, status.Name, disk.Path) {
+			fmt.Printf("Disk %s is already added, skipping\n", disk.Path)
+			continue
+		}
+
+		req, err := disk.request()
+		if err != nil {
+			return err
+		}
+
+		failures, err := client.AddDisk(context.Background(), lc, req)
+		if err != nil {
+			return fmt.Errorf("failed to add the following disks:\n%v \nerr: %w", failures, err)
+		}
+	}
+
+	return nil
+}
+
+// isDiskConfigured checks whether one of the configured OSDs of the member is backed by the device at path.
+// OSD paths are stored as stable /dev/disk/by-id/ links, so both sides are resolved before comparing.
+func isDiskConfigured(configured types.Disks, location string, path string) bool {
+	resolve := func(path string) string {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return path
+		}
+
+		return resolved
+	}
+
+	target := resolve(path)
+	for _, osd := range configured {
+		// Other members may have OSDs on devices of the same name.
+		if osd.Location != location {
+			continue
+		}
+
+		if osd.Path == path || resolve(osd.Path) == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enablePreseedServices enables the preseeded services which don't run on this node yet.
+func enablePreseedServices(m *microcluster.MicroCluster, lc *microCli.Client, services preseedServices) error {
+	reqs, err := services.serviceRequests()
+	if err != nil {
+		return err
+	}
+
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	status, err := m.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	present, err := client.GetServices(context.Background(), lc)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	for _, req := range reqs {
+		groupID := ""
+		if req.Name == "nfs" {
+			var nfs ceph.NFSServicePlacement
+			_ = json.Unmarshal([]byte(req.Payload), &nfs)
+			groupID = nfs.ClusterID
+		}
+
+		running := false
+		for _, service := range present {
+			if service.Location == status.Name && service.Service == req.Name && service.GroupID == groupID {
+				running = true
+				break
+			}
+		}
+
+		if running {
+			fmt.Printf("Service %s is already enabled, skipping\n", req.Name)
+			continue
+		}
+
+		err = client.SendServicePlacementReq(context.Background(), lc, req, "")
+		if err != nil {
+			return fmt.Errorf("failed to enable %s: %w", req.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// dumpPreseed prints the preseed as yaml.
+func dumpPreseed(p *preseed) error {
+	out, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal preseed: %w", err)
+	}
+
+	fmt.Print(string(out))
+	return nil
+}
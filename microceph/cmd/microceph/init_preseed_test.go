@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/yaml.v2"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type initPreseedSuite struct {
+	tests.BaseSuite
+}
+
+func TestInitPreseed(t *testing.T) {
+	suite.Run(t, new(initPreseedSuite))
+}
+
+func (s *initPreseedSuite) TestParseBootstrap() {
+	data := `
+bootstrap: true
+name: node0
+public_network: 10.0.0.0/24
+cluster_network: 10.1.0.0/24
+v2_only: true
+disks:
+  - path: /dev/sdb
+    wipe: true
+    encrypt: true
+    wal_device: /dev/nvme0n1
+    wal_size: 2G
+  - path: loop,4G,3
+services:
+  rgw:
+    port: 8080
+  nfs:
+    - cluster_id: foo
+config:
+  osd_pool_default_size: "3"
+`
+	p, err := parsePreseed([]byte(data))
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), p.Bootstrap)
+	assert.Equal(s.T(), "node0", p.Name)
+	assert.Equal(s.T(), "3", p.Config["osd_pool_default_size"])
+
+	req, err := p.Disks[0].request()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"/dev/sdb"}, req.Path)
+	assert.True(s.T(), req.Encrypt)
+	assert.Equal(s.T(), "/dev/nvme0n1", *req.WALDev)
+	assert.Equal(s.T(), uint64(2*1024*1024*1024), req.WALSize)
+	assert.Nil(s.T(), req.DBDev)
+
+	// Defaults are filled in.
+	assert.Equal(s.T(), 443, p.Services.RGW.SSLPort)
+	assert.Equal(s.T(), "0.0.0.0", p.Services.NFS[0].BindAddress)
+	assert.Equal(s.T(), uint(2049), p.Services.NFS[0].BindPort)
+	assert.Equal(s.T(), uint(1), *p.Services.NFS[0].V4MinVersion)
+
+	reqs, err := p.Services.serviceRequests()
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), reqs, 2)
+	assert.Equal(s.T(), "rgw", reqs[0].Name)
+	assert.Equal(s.T(), "nfs", reqs[1].Name)
+	assert.Contains(s.T(), reqs[1].Payload, `"cluster_id":"foo"`)
+}
+
+func (s *initPreseedSuite) TestParseJoin() {
+	p, err := parsePreseed([]byte("token: abc\nservices:\n  mds: true\n"))
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), p.Bootstrap)
+
+	reqs, err := p.Services.serviceRequests()
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), reqs, 1)
+	assert.Equal(s.T(), "mds", reqs[0].Name)
+}
+
+func (s *initPreseedSuite) TestParseInvalid() {
+	for data, msg := range map[string]string{
+		"bootstrap: true\ntoken: abc\n":                                                            "a token is only used to join a cluster",
+		"token: abc\npublic_network: 10.0.0.0/24\n":                                                "networks can only be set when bootstrapping",
+		"token: abc\nadd_servers: [node1]\n":                                                       "join tokens can only be issued when bootstrapping",
+		"bootstrap: true\npublic_network: 10.0.0.0\n":                                              "invalid network",
+		"bootstrap: true\ndisks:\n  - path: loop,4G,1\n    encrypt: true\n":                        "loop files can't be used with encryption",
+		"bootstrap: true\ndisks:\n  - path: /dev/sdb\n    db_device: /dev/sdc\n    db_size: big\n": "invalid db_size",
+		"bootstrap: true\nservices:\n  nfs:\n    - cluster_id: .foo\n":                             "invalid nfs cluster ID",
+		"bootstrap: true\nservices:\n  nfs:\n    - cluster_id: foo\n      bind_port: 50000\n":      "invalid nfs bind port",
+		"bootstrap: true\nservices:\n  rgw:\n    ssl_certificate: abc\n":                           "rgw needs both an SSL certificate and private key",
+		"bootstrap: true\nunknown: 1\n":                                                            "failed to parse preseed",
+	} {
+		_, err := parsePreseed([]byte(data))
+		assert.ErrorContains(s.T(), err, msg)
+	}
+}
+
+func (s *initPreseedSuite) TestDumpRoundTrip() {
+	p := &preseed{
+		Bootstrap: true,
+		Name:      "node0",
+		Disks:     []preseedDisk{{Path: "/dev/sdb", Wipe: true}},
+	}
+
+	out, err := yaml.Marshal(p)
+	assert.NoError(s.T(), err)
+
+	parsed, err := parsePreseed(out)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), p, parsed)
+}
+
+func (s *initPreseedSuite) TestIsDiskConfigured() {
+	// Configured OSDs are recorded by their by-id link, preseeds usually list the kernel name.
+	dev := filepath.Join(s.Tmp, "sdb")
+	byID := filepath.Join(s.Tmp, "scsi-0QEMU_disk1")
+	assert.NoError(s.T(), os.WriteFile(dev, nil, 0600))
+	assert.NoError(s.T(), os.Symlink(dev, byID))
+
+	configured := types.Disks{{OSD: 1, Path: byID, Location: "node0"}, {OSD: 2, Path: "/dev/vdb", Location: "node1"}}
+	assert.True(s.T(), isDiskConfigured(configured, "node0", dev))
+	assert.True(s.T(), isDiskConfigured(configured, "node0", byID))
+	assert.False(s.T(), isDiskConfigured(configured, "node0", filepath.Join(s.Tmp, "sdc")))
+
+	// OSDs of other members don't count, even on a device of the same name.
+	assert.False(s.T(), isDiskConfigured(configured, "node0", "/dev/vdb"))
+	assert.True(s.T(), isDiskConfigured(configured, "node1", "/dev/vdb"))
+	assert.False(s.T(), isDiskConfigured(configured, "node1", dev))
+}
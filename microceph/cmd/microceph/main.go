@@ -7,6 +7,7 @@ import (
 
 	cli "github.com/canonical/lxd/shared/cmd"
 	"github.com/canonical/microceph/microceph/clilogger"
+	"github.com/canonical/microceph/microceph/constants"
 	"github.com/canonical/microceph/microceph/version"
 	"github.com/spf13/cobra"
 )
@@ -46,8 +47,20 @@ func main() {
 	app.Version = version.Version()
 
 	// Initialize CLI logger based on flags
-	app.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	app.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		clilogger.InitLogger(commonCmd.FlagLogDebug, commonCmd.FlagLogVerbose)
+
+		// Outside of the snap the state directory comes from the layout config.
+		layout, err := constants.GetLayout()
+		if err != nil {
+			return err
+		}
+
+		if layout != nil && commonCmd.FlagStateDir == "" {
+			commonCmd.FlagStateDir = layout.Paths.State
+		}
+
+		return nil
 	}
 
 	// Top-level.
@@ -63,6 +76,9 @@ func main() {
 	var cmdStatus = cmdStatus{common: &commonCmd}
 	app.AddCommand(cmdStatus.Command())
 
+	var cmdDoctor = cmdDoctor{common: &commonCmd}
+	app.AddCommand(cmdDoctor.Command())
+
 	// Nested.
 	var cmdCluster = cmdCluster{common: &commonCmd}
 	app.AddCommand(cmdCluster.Command())
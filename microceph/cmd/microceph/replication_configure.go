@@ -33,7 +33,7 @@ type cmdReplicationConfigureRbd struct {
 func (c *cmdReplicationConfigureRbd) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rbd <resource>",
-		Short: "Configure replication parameters for RBD resource (Pool or Image)",
+		Short: "Configure replication parameters for RBD resource (Pool, Image or Group)",
 		RunE:  c.Run,
 	}
 
@@ -70,7 +70,7 @@ func (c *cmdReplicationConfigureRbd) Run(cmd *cobra.Command, args []string) erro
 }
 
 func (c *cmdReplicationConfigureRbd) prepareRbdPayload(requestType types.ReplicationRequestType, args []string) (types.RbdReplicationRequest, error) {
-	pool, image, err := types.GetPoolAndImageFromResource(args[0])
+	pool, image, group, err := types.ParseRbdResource(args[0])
 	if err != nil {
 		return types.RbdReplicationRequest{}, err
 	}
@@ -78,9 +78,10 @@ func (c *cmdReplicationConfigureRbd) prepareRbdPayload(requestType types.Replica
 	retReq := types.RbdReplicationRequest{
 		SourcePool:   pool,
 		SourceImage:  image,
+		SourceGroup:  group,
 		Schedule:     c.schedule,
 		RequestType:  requestType,
-		ResourceType: types.GetRbdResourceType(pool, image),
+		ResourceType: types.GetRbdResourceType(pool, image, group),
 	}
 
 	return retReq, nil
@@ -26,17 +26,19 @@ func (c *cmdReplicationDisable) Command() *cobra.Command {
 }
 
 type cmdReplicationDisableRbd struct {
-	common  *CmdControl
-	isForce bool
+	common      *CmdControl
+	remoteNames []string
+	isForce     bool
 }
 
 func (c *cmdReplicationDisableRbd) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rbd <resource>",
-		Short: "Disable replication for RBD resource (Pool or Image)",
+		Short: "Disable replication for RBD resource (Pool, Image or Group)",
 		RunE:  c.Run,
 	}
 
+	cmd.Flags().StringSliceVar(&c.remoteNames, "remote", []string{}, "remote MicroCeph cluster name(s) to stop mirroring to, defaults to all remotes")
 	cmd.Flags().BoolVar(&c.isForce, "force", false, "forcefully disable replication for rbd resource")
 	return cmd
 }
@@ -66,17 +68,19 @@ func (c *cmdReplicationDisableRbd) Run(cmd *cobra.Command, args []string) error
 }
 
 func (c *cmdReplicationDisableRbd) prepareRbdPayload(requestType types.ReplicationRequestType, args []string) (types.RbdReplicationRequest, error) {
-	pool, image, err := types.GetPoolAndImageFromResource(args[0])
+	pool, image, group, err := types.ParseRbdResource(args[0])
 	if err != nil {
 		return types.RbdReplicationRequest{}, err
 	}
 
 	retReq := types.RbdReplicationRequest{
+		Remotes:      c.remoteNames,
 		SourcePool:   pool,
 		SourceImage:  image,
+		SourceGroup:  group,
 		RequestType:  requestType,
 		IsForceOp:    c.isForce,
-		ResourceType: types.GetRbdResourceType(pool, image),
+		ResourceType: types.GetRbdResourceType(pool, image, group),
 	}
 
 	return retReq, nil
@@ -26,7 +26,7 @@ func (c *cmdReplicationEnable) Command() *cobra.Command {
 
 type cmdReplicationEnableRbd struct {
 	common         *CmdControl
-	remoteName     string
+	remoteNames    []string
 	repType        string
 	schedule       string
 	skipAutoEnable bool
@@ -35,14 +35,14 @@ type cmdReplicationEnableRbd struct {
 func (c *cmdReplicationEnableRbd) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rbd <resource>",
-		Short: "Enable replication for RBD resource (Pool or Image)",
+		Short: "Enable replication for RBD resource (Pool, Image or Group)",
 		RunE:  c.Run,
 	}
 
-	cmd.Flags().StringVar(&c.remoteName, "remote", "", "remote MicroCeph cluster name")
+	cmd.Flags().StringSliceVar(&c.remoteNames, "remote", []string{}, "remote MicroCeph cluster name(s), repeat or comma separate for multiple remotes")
 	cmd.MarkFlagRequired("remote")
 	cmd.Flags().BoolVar(&c.skipAutoEnable, "skip-auto-enable", false, "do not auto enable rbd mirroring for all images in the pool.")
-	cmd.Flags().StringVar(&c.repType, "type", "journal", "'journal' or 'snapshot', defaults to journal")
+	cmd.Flags().StringVar(&c.repType, "type", "journal", "'journal' or 'snapshot', defaults to journal (groups always use snapshot)")
 	cmd.Flags().StringVar(&c.schedule, "schedule", "", "snapshot schedule in days, hours, or minutes using d, h, m suffix respectively")
 	return cmd
 }
@@ -76,19 +76,27 @@ func (c *cmdReplicationEnableRbd) Run(cmd *cobra.Command, args []string) error {
 }
 
 func (c *cmdReplicationEnableRbd) prepareRbdPayload(requestType types.ReplicationRequestType, args []string) (types.RbdReplicationRequest, error) {
-	pool, image, err := types.GetPoolAndImageFromResource(args[0])
+	pool, image, group, err := types.ParseRbdResource(args[0])
 	if err != nil {
 		return types.RbdReplicationRequest{}, err
 	}
 
+	repType := types.RbdReplicationType(c.repType)
+	resourceType := types.GetRbdResourceType(pool, image, group)
+	if resourceType == types.RbdResourceGroup {
+		// rbd groups are only mirrored using group snapshots.
+		repType = types.RbdReplicationSnapshot
+	}
+
 	retReq := types.RbdReplicationRequest{
-		RemoteName:      c.remoteName,
+		Remotes:         c.remoteNames,
 		SourcePool:      pool,
 		SourceImage:     image,
+		SourceGroup:     group,
 		Schedule:        c.schedule,
-		ReplicationType: types.RbdReplicationType(c.repType),
+		ReplicationType: repType,
 		RequestType:     requestType,
-		ResourceType:    types.GetRbdResourceType(pool, image),
+		ResourceType:    resourceType,
 		SkipAutoEnable:  c.skipAutoEnable,
 	}
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/client"
@@ -10,26 +11,26 @@ import (
 )
 
 type cmdReplicationPromote struct {
-	common     *CmdControl
-	remoteName string
-	isForce    bool
+	common      *CmdControl
+	remoteNames []string
+	isForce     bool
 }
 
 func (c *cmdReplicationPromote) Command() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "promote",
+		Use:   "promote [<pool>/group/<group>]",
 		Short: "Promote a non-primary cluster to primary status",
 		RunE:  c.Run,
 	}
 
-	cmd.Flags().StringVar(&c.remoteName, "remote", "", "remote MicroCeph cluster name")
+	cmd.Flags().StringSliceVar(&c.remoteNames, "remote", []string{}, "remote MicroCeph cluster name(s), repeat or comma separate for multiple remotes")
 	cmd.Flags().BoolVar(&c.isForce, "yes-i-really-mean-it", false, "forcefully promote site to primary")
 	cmd.MarkFlagRequired("remote")
 	return cmd
 }
 
 func (c *cmdReplicationPromote) Run(cmd *cobra.Command, args []string) error {
-	if len(args) != 0 {
+	if len(args) > 1 {
 		return cmd.Help()
 	}
 
@@ -43,7 +44,7 @@ func (c *cmdReplicationPromote) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	payload, err := c.preparePayload(types.PromoteReplicationRequest)
+	payload, err := c.preparePayload(types.PromoteReplicationRequest, args)
 	if err != nil {
 		return err
 	}
@@ -56,14 +57,30 @@ func (c *cmdReplicationPromote) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (c *cmdReplicationPromote) preparePayload(requestType types.ReplicationRequestType) (types.RbdReplicationRequest, error) {
+func (c *cmdReplicationPromote) preparePayload(requestType types.ReplicationRequestType, args []string) (types.RbdReplicationRequest, error) {
 	retReq := types.RbdReplicationRequest{
-		RemoteName:   c.remoteName,
+		Remotes:      c.remoteNames,
 		RequestType:  requestType,
 		IsForceOp:    c.isForce,
 		ResourceType: types.RbdResourcePool,
 		SourcePool:   "",
 	}
 
+	// Only the requested rbd group is promoted if provided.
+	if len(args) == 1 {
+		pool, _, group, err := types.ParseRbdResource(args[0])
+		if err != nil {
+			return types.RbdReplicationRequest{}, err
+		}
+
+		if len(group) == 0 {
+			return types.RbdReplicationRequest{}, fmt.Errorf("check resource name %s, should be in $pool/group/$group format", args[0])
+		}
+
+		retReq.SourcePool = pool
+		retReq.SourceGroup = group
+		retReq.ResourceType = types.RbdResourceGroup
+	}
+
 	return retReq, nil
 }
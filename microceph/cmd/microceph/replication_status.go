@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/canonical/microceph/microceph/api/types"
@@ -41,7 +42,7 @@ type cmdReplicationStatusRbd struct {
 func (c *cmdReplicationStatusRbd) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rbd <resource>",
-		Short: "Show RBD resource (Pool or Image) replication status",
+		Short: "Show RBD resource (Pool, Image or Group) replication status",
 		RunE:  c.Run,
 	}
 
@@ -83,7 +84,7 @@ func (c *cmdReplicationStatusRbd) Run(cmd *cobra.Command, args []string) error {
 }
 
 func (c *cmdReplicationStatusRbd) prepareRbdPayload(requestType types.ReplicationRequestType, args []string) (types.RbdReplicationRequest, error) {
-	pool, image, err := types.GetPoolAndImageFromResource(args[0])
+	pool, image, group, err := types.ParseRbdResource(args[0])
 	if err != nil {
 		return types.RbdReplicationRequest{}, err
 	}
@@ -91,8 +92,9 @@ func (c *cmdReplicationStatusRbd) prepareRbdPayload(requestType types.Replicatio
 	retReq := types.RbdReplicationRequest{
 		SourcePool:   pool,
 		SourceImage:  image,
+		SourceGroup:  group,
 		RequestType:  requestType,
-		ResourceType: types.GetRbdResourceType(pool, image),
+		ResourceType: types.GetRbdResourceType(pool, image, group),
 	}
 
 	return retReq, nil
@@ -139,9 +141,9 @@ func printReplicationStatusTable(ResourceType types.RbdResourceType, response st
 		// Remotes Section
 		t_remotes := table.NewWriter()
 		t_remotes.SetOutputMirror(os.Stdout)
-		t_remotes.AppendHeader(table.Row{"Remote Name", "Direction", "UUID"})
+		t_remotes.AppendHeader(table.Row{"Remote Name", "Direction", "UUID", "Image States"})
 		for _, remote := range resp.Remotes {
-			t_remotes.AppendRow(table.Row{remote.Name, remote.Direction, remote.UUID})
+			t_remotes.AppendRow(table.Row{remote.Name, remote.Direction, remote.UUID, formatImageStates(remote.ImageStates)})
 		}
 		if terminal.IsTerminal(0) && terminal.IsTerminal(1) {
 			// Set style if interactive shell.
@@ -174,7 +176,7 @@ func printReplicationStatusTable(ResourceType types.RbdResourceType, response st
 		// Images Section.
 		t_images := table.NewWriter()
 		t_images.SetOutputMirror(os.Stdout)
-		t_images.AppendHeader(table.Row{"Remote Name", "Status", "Last Remote Update"}, rowConfigAutoMerge)
+		t_images.AppendHeader(table.Row{"Remote Name", "State", "Status", "Last Remote Update"}, rowConfigAutoMerge)
 		for _, remote := range resp.Remotes {
 			var status string
 			statusList := strings.Split(remote.Status, ",")
@@ -183,7 +185,7 @@ func printReplicationStatusTable(ResourceType types.RbdResourceType, response st
 			} else {
 				status = statusList[0]
 			}
-			t_images.AppendRow(table.Row{remote.Name, status, remote.LastRemoteUpdate})
+			t_images.AppendRow(table.Row{remote.Name, remote.State, status, remote.LastRemoteUpdate})
 		}
 		if terminal.IsTerminal(0) && terminal.IsTerminal(1) {
 			// Set style if interactive shell.
@@ -191,6 +193,54 @@ func printReplicationStatusTable(ResourceType types.RbdResourceType, response st
 		}
 		t_images.Render()
 		fmt.Println()
+	} else if ResourceType == types.RbdResourceGroup {
+		var resp types.RbdGroupStatus
+		err = json.Unmarshal([]byte(response), &resp)
+		if err != nil {
+			return err
+		}
+
+		// Summary Section.
+		t_summary := table.NewWriter()
+		t_summary.SetOutputMirror(os.Stdout)
+		t_summary.AppendHeader(table.Row{"Summary", "Summary"}, rowConfigAutoMerge)
+		t_summary.AppendRow(table.Row{"Name", resp.Name}, rowConfigAutoMerge)
+		t_summary.AppendRow(table.Row{"ID", resp.ID}, rowConfigAutoMerge)
+		t_summary.AppendRow(table.Row{"Images", strings.Join(resp.Images, ", ")}, rowConfigAutoMerge)
+		t_summary.AppendRow(table.Row{"Is Primary", resp.IsPrimary}, rowConfigAutoMerge)
+		t_summary.AppendRow(table.Row{"Status", resp.Status}, rowConfigAutoMerge)
+		t_summary.AppendRow(table.Row{"Last Local Update", resp.LastLocalUpdate}, rowConfigAutoMerge)
+		if terminal.IsTerminal(0) && terminal.IsTerminal(1) {
+			// Set style if interactive shell.
+			t_summary.SetStyle(table.StyleColoredBright)
+		}
+		t_summary.Render()
+		fmt.Println()
+
+		// Remotes Section.
+		t_remotes := table.NewWriter()
+		t_remotes.SetOutputMirror(os.Stdout)
+		t_remotes.AppendHeader(table.Row{"Remote Name", "State", "Status", "Last Remote Update"}, rowConfigAutoMerge)
+		for _, remote := range resp.Remotes {
+			t_remotes.AppendRow(table.Row{remote.Name, remote.State, strings.Split(remote.Status, ",")[0], remote.LastRemoteUpdate})
+		}
+		if terminal.IsTerminal(0) && terminal.IsTerminal(1) {
+			// Set style if interactive shell.
+			t_remotes.SetStyle(table.StyleColoredBright)
+		}
+		t_remotes.Render()
+		fmt.Println()
 	}
 	return nil
 }
+
+// formatImageStates renders per state image counts as a sorted, comma separated list.
+func formatImageStates(imageStates map[string]int) string {
+	states := make([]string, 0, len(imageStates))
+	for state, count := range imageStates {
+		states = append(states, fmt.Sprintf("%s: %d", state, count))
+	}
+	sort.Strings(states)
+
+	return strings.Join(states, ", ")
+}
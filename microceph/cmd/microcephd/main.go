@@ -20,6 +20,7 @@ import (
 	"github.com/canonical/microceph/microceph/database"
 	"github.com/canonical/microceph/microceph/interfaces"
 	"github.com/canonical/microceph/microceph/logger"
+	"github.com/canonical/microceph/microceph/simulate"
 	"github.com/canonical/microceph/microceph/version"
 )
 
@@ -47,6 +48,10 @@ type cmdDaemon struct {
 	global *cmdGlobal
 
 	flagStateDir string
+
+	flagSimulate        bool
+	flagSimulateDir     string
+	flagSimulateCluster string
 }
 
 func (c *cmdDaemon) Command() *cobra.Command {
@@ -62,9 +67,18 @@ func (c *cmdDaemon) Command() *cobra.Command {
 }
 
 func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
+	// Outside of the snap the paths and service manager come from the layout config.
+	layout, err := constants.GetLayout()
+	if err != nil {
+		return err
+	}
+
+	if layout != nil && c.flagStateDir == "" {
+		c.flagStateDir = layout.Paths.State
+	}
+
 	// Initialize the node logger with config file
 	pathConst := constants.GetPathConst()
-	var err error
 	logger.DaemonLogger, err = logger.NewLogger(filepath.Join(pathConst.DataPath, "log-config.json"))
 	if err != nil {
 		return fmt.Errorf("failed to initialize logging: %w", err)
@@ -76,21 +90,37 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// setHost tells the simulated cluster which member the local daemons run on.
+	setHost := func(s state.State) {}
+	if c.flagSimulate {
+		runner, err := simulate.NewRunner(c.flagSimulateDir, c.flagSimulateCluster)
+		if err != nil {
+			return err
+		}
+
+		logger.Warnf("Simulating cluster %q in %s, no Ceph daemons will run", runner.Cluster, runner.Dir)
+		common.ProcessExec = runner
+		setHost = func(s state.State) { runner.SetHost(s.Name()) }
+	}
+
 	h := &state.Hooks{}
 	h.PostBootstrap = func(ctx context.Context, s state.State, initConfig map[string]string) error {
 		data := common.BootstrapConfig{}
 		interf := interfaces.CephState{State: s}
 		common.DecodeBootstrapConfig(initConfig, &data)
+		setHost(s)
 		return ceph.Bootstrap(ctx, interf, data)
 	}
 
 	h.PostJoin = func(ctx context.Context, s state.State, initConfig map[string]string) error {
 		interf := interfaces.CephState{State: s}
+		setHost(s)
 		return ceph.Join(ctx, interf)
 	}
 
 	h.OnStart = func(ctx context.Context, s state.State) error {
 		interf := interfaces.CephState{State: s}
+		setHost(s)
 		return ceph.Start(ctx, interf)
 	}
 
@@ -126,6 +156,9 @@ func main() {
 	app.PersistentFlags().BoolVarP(&daemonCmd.global.flagLogVerbose, "verbose", "v", false, "Show all information messages")
 
 	app.PersistentFlags().StringVar(&daemonCmd.flagStateDir, "state-dir", "", "Path to store state information"+"``")
+	app.PersistentFlags().BoolVar(&daemonCmd.flagSimulate, "simulate", false, "Simulate the Ceph cluster instead of running Ceph daemons")
+	app.PersistentFlags().StringVar(&daemonCmd.flagSimulateDir, "simulate-dir", filepath.Join(os.TempDir(), "microceph-simulate"), "Path to store the simulated cluster state, shared by the simulated members"+"``")
+	app.PersistentFlags().StringVar(&daemonCmd.flagSimulateCluster, "simulate-cluster", simulate.DefaultCluster, "Name of the simulated cluster"+"``")
 
 	app.SetVersionTemplate("{{.Version}}\n")
 
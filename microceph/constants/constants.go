@@ -3,7 +3,6 @@ package constants
 
 import (
 	"os"
-	"path/filepath"
 )
 
 // Constants for Size Constraints
@@ -41,21 +40,19 @@ type PathConst struct {
 	ProcPath     string
 	SSLFilesPath string
 	SnapPath     string
+	StatePath    string
 }
 
 type PathFileMode map[string]os.FileMode
 
+// GetPathConst returns the paths of the layout MicroCeph runs in, the snap layout unless a layout config file exists.
 var GetPathConst = func() PathConst {
-	return PathConst{
-		ConfPath:     filepath.Join(os.Getenv("SNAP_DATA"), "conf"),
-		RunPath:      filepath.Join(os.Getenv("SNAP_DATA"), "run"),
-		DataPath:     filepath.Join(os.Getenv("SNAP_COMMON"), "data"),
-		LogPath:      filepath.Join(os.Getenv("SNAP_COMMON"), "logs"),
-		RootFs:       filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/"),
-		ProcPath:     filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/proc"),
-		SSLFilesPath: filepath.Join(os.Getenv("SNAP_COMMON"), "/"),
-		SnapPath:     filepath.Join(os.Getenv("SNAP"), "/"),
+	layout, err := GetLayout()
+	if err == nil && layout != nil {
+		return layout.PathConst()
 	}
+
+	return snapPathConst()
 }
 
 // File Modes
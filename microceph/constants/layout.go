@@ -0,0 +1,142 @@
+package constants
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Service managers the MicroCeph daemons can be run by.
+const (
+	ServiceManagerSnap    = "snap"
+	ServiceManagerSystemd = "systemd"
+)
+
+// LayoutConfigEnv names the environment variable overriding the layout config file.
+const LayoutConfigEnv = "MICROCEPH_CONFIG"
+
+// DefaultLayoutConfig is the layout config file read when MicroCeph does not run from the snap.
+const DefaultLayoutConfig = "/etc/microceph/microceph.yaml"
+
+// DefaultUnitPrefix is the prefix of the systemd units running the MicroCeph daemons.
+const DefaultUnitPrefix = "microceph-"
+
+// Layout describes where MicroCeph keeps its files and how its daemons are managed when it
+// runs from distro packages or a tarball instead of the snap.
+type Layout struct {
+	ServiceManager string `yaml:"service_manager"`
+	// UnitPrefix is prepended to the service name to get the systemd unit, e.g. microceph-mon.service.
+	UnitPrefix string      `yaml:"unit_prefix"`
+	Paths      LayoutPaths `yaml:"paths"`
+}
+
+// LayoutPaths holds the directories of a layout.
+type LayoutPaths struct {
+	Conf  string `yaml:"conf"`
+	Run   string `yaml:"run"`
+	Data  string `yaml:"data"`
+	Logs  string `yaml:"logs"`
+	SSL   string `yaml:"ssl"`
+	Share string `yaml:"share"`
+	State string `yaml:"state"`
+}
+
+// defaultLayout is the layout of a MicroCeph installed from distro packages.
+func defaultLayout() Layout {
+	return Layout{
+		ServiceManager: ServiceManagerSystemd,
+		UnitPrefix:     DefaultUnitPrefix,
+		Paths: LayoutPaths{
+			Conf:  "/etc/microceph",
+			Run:   "/run/microceph",
+			Data:  "/var/lib/microceph/data",
+			Logs:  "/var/log/microceph",
+			SSL:   "/var/lib/microceph",
+			Share: "/usr",
+			State: "/var/lib/microceph/state",
+		},
+	}
+}
+
+// readLayoutConfig reads the layout config file, returns nil if MicroCeph runs from the snap or there is no config file.
+func readLayoutConfig() (*Layout, error) {
+	if len(os.Getenv("SNAP")) != 0 {
+		return nil, nil
+	}
+
+	path := os.Getenv(LayoutConfigEnv)
+	if len(path) == 0 {
+		path = DefaultLayoutConfig
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read layout config %s: %w", path, err)
+	}
+
+	layout := defaultLayout()
+	err = yaml.UnmarshalStrict(data, &layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse layout config %s: %w", path, err)
+	}
+
+	switch layout.ServiceManager {
+	case ServiceManagerSnap, ServiceManagerSystemd:
+	default:
+		return nil, fmt.Errorf("unknown service manager %q in %s", layout.ServiceManager, path)
+	}
+
+	for _, dir := range []string{layout.Paths.Conf, layout.Paths.Run, layout.Paths.Data, layout.Paths.Logs, layout.Paths.SSL, layout.Paths.Share, layout.Paths.State} {
+		if !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("path %q in %s is not absolute", dir, path)
+		}
+	}
+
+	return &layout, nil
+}
+
+// GetLayout returns the layout read from the layout config file, nil if MicroCeph uses the snap layout.
+// The config file is only read once.
+var GetLayout = sync.OnceValues(readLayoutConfig)
+
+// IsSnapLayout checks if MicroCeph keeps its files in the snap directories.
+func IsSnapLayout() bool {
+	layout, _ := GetLayout()
+	return layout == nil
+}
+
+// snapPathConst returns the paths of MicroCeph running from the snap.
+func snapPathConst() PathConst {
+	return PathConst{
+		ConfPath:     filepath.Join(os.Getenv("SNAP_DATA"), "conf"),
+		RunPath:      filepath.Join(os.Getenv("SNAP_DATA"), "run"),
+		DataPath:     filepath.Join(os.Getenv("SNAP_COMMON"), "data"),
+		LogPath:      filepath.Join(os.Getenv("SNAP_COMMON"), "logs"),
+		RootFs:       filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/"),
+		ProcPath:     filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/proc"),
+		SSLFilesPath: filepath.Join(os.Getenv("SNAP_COMMON"), "/"),
+		SnapPath:     filepath.Join(os.Getenv("SNAP"), "/"),
+		StatePath:    filepath.Join(os.Getenv("SNAP_COMMON"), "state"),
+	}
+}
+
+// PathConst returns the paths of the layout.
+func (l Layout) PathConst() PathConst {
+	return PathConst{
+		ConfPath:     l.Paths.Conf,
+		RunPath:      l.Paths.Run,
+		DataPath:     l.Paths.Data,
+		LogPath:      l.Paths.Logs,
+		RootFs:       filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/"),
+		ProcPath:     filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/proc"),
+		SSLFilesPath: l.Paths.SSL,
+		SnapPath:     l.Paths.Share,
+		StatePath:    l.Paths.State,
+	}
+}
@@ -0,0 +1,64 @@
+package constants
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeLayoutConfig(t *testing.T, data string) {
+	path := filepath.Join(t.TempDir(), "microceph.yaml")
+	err := os.WriteFile(path, []byte(data), 0644)
+	assert.NoError(t, err)
+
+	t.Setenv("SNAP", "")
+	t.Setenv(LayoutConfigEnv, path)
+}
+
+func TestReadLayoutConfig(t *testing.T) {
+	writeLayoutConfig(t, "paths:\n  conf: /etc/ceph\n")
+
+	layout, err := readLayoutConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ServiceManagerSystemd, layout.ServiceManager)
+	assert.Equal(t, DefaultUnitPrefix, layout.UnitPrefix)
+
+	paths := layout.PathConst()
+	assert.Equal(t, "/etc/ceph", paths.ConfPath)
+	assert.Equal(t, "/var/lib/microceph/data", paths.DataPath)
+	assert.Equal(t, "/var/lib/microceph/state", paths.StatePath)
+}
+
+func TestReadLayoutConfigMissing(t *testing.T) {
+	t.Setenv("SNAP", "")
+	t.Setenv(LayoutConfigEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+
+	// Falls back to the snap layout.
+	layout, err := readLayoutConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, layout)
+}
+
+func TestReadLayoutConfigInSnap(t *testing.T) {
+	writeLayoutConfig(t, "service_manager: systemd\n")
+	t.Setenv("SNAP", "/snap/microceph/current")
+
+	layout, err := readLayoutConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, layout)
+}
+
+func TestReadLayoutConfigInvalid(t *testing.T) {
+	for data, msg := range map[string]string{
+		"service_manager: openrc\n":  "unknown service manager",
+		"paths:\n  data: relative\n": "is not absolute",
+		"unknown: true\n":            "failed to parse layout config",
+	} {
+		writeLayoutConfig(t, data)
+
+		_, err := readLayoutConfig()
+		assert.ErrorContains(t, err, msg)
+	}
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/cluster"
+)
+
+var configItemCreateOrUpdate = cluster.RegisterStmt(`
+INSERT OR REPLACE INTO config (key, value)
+  VALUES (?, ?)
+`)
+
+// SetConfigItem creates or updates the config item with the given key.
+func SetConfigItem(ctx context.Context, tx *sql.Tx, key string, value string) error {
+	stmt, err := cluster.Stmt(tx, configItemCreateOrUpdate)
+	if err != nil {
+		return fmt.Errorf("failed to get \"configItemCreateOrUpdate\" prepared statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to set config item %q: %w", key, err)
+	}
+
+	return nil
+}
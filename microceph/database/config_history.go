@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/db/query"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/cluster"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+var addConfigChange = cluster.RegisterStmt(`
+INSERT INTO config_history (key, section, old_value, new_value, changed_by, changed_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`)
+
+var configChange = cluster.RegisterStmt(`
+SELECT config_history.id, config_history.key, config_history.section, config_history.old_value, config_history.new_value, config_history.changed_by, config_history.changed_at
+FROM config_history
+WHERE config_history.id = ?
+`)
+
+var configChanges = cluster.RegisterStmt(`
+SELECT config_history.id, config_history.key, config_history.section, config_history.old_value, config_history.new_value, config_history.changed_by, config_history.changed_at
+FROM config_history
+ORDER BY config_history.id
+`)
+
+var configChangesByKey = cluster.RegisterStmt(`
+SELECT config_history.id, config_history.key, config_history.section, config_history.old_value, config_history.new_value, config_history.changed_by, config_history.changed_at
+FROM config_history
+WHERE config_history.key = ?
+ORDER BY config_history.id
+`)
+
+// ConfigHistoryQueryInterface is for querying the config history. Introduced for mocking.
+//
+//go:generate mockery --name ConfigHistoryQueryInterface
+type ConfigHistoryQueryInterface interface {
+	Add(ctx context.Context, s state.State, change types.ConfigChange) (int64, error)
+	Get(ctx context.Context, s state.State, id int64) (types.ConfigChange, error)
+	List(ctx context.Context, s state.State, key string) (types.ConfigChanges, error)
+}
+
+type ConfigHistoryQueryImpl struct{}
+
+// Add records a config change, returning its id
+func (c ConfigHistoryQueryImpl) Add(ctx context.Context, s state.State, change types.ConfigChange) (int64, error) {
+	var id int64
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, addConfigChange)
+		if err != nil {
+			return fmt.Errorf("failed to get \"addConfigChange\" prepared statement: %w", err)
+		}
+
+		result, err := sqlStmt.Exec(change.Key, change.Section, change.OldValue, change.NewValue, change.ChangedBy, change.ChangedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record change of %s: %w", change.Key, err)
+		}
+
+		id, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Get returns the config change with the given id
+func (c ConfigHistoryQueryImpl) Get(ctx context.Context, s state.State, id int64) (types.ConfigChange, error) {
+	var change types.ConfigChange
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, configChange)
+		if err != nil {
+			return fmt.Errorf("failed to get \"configChange\" prepared statement: %w", err)
+		}
+
+		err = sqlStmt.QueryRow(id).Scan(&change.ID, &change.Key, &change.Section, &change.OldValue, &change.NewValue, &change.ChangedBy, &change.ChangedAt)
+		if err == sql.ErrNoRows {
+			return api.StatusErrorf(http.StatusNotFound, "config change %d not found", id)
+		} else if err != nil {
+			return fmt.Errorf("failed to get \"configChange\" objects: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return change, err
+	}
+	return change, nil
+}
+
+// List returns the changes of the given config key in order, or of every key if empty
+func (c ConfigHistoryQueryImpl) List(ctx context.Context, s state.State, key string) (types.ConfigChanges, error) {
+	changes := types.ConfigChanges{}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var sqlStmt *sql.Stmt
+		var err error
+		args := []any{}
+		if len(key) == 0 {
+			sqlStmt, err = cluster.Stmt(tx, configChanges)
+		} else {
+			sqlStmt, err = cluster.Stmt(tx, configChangesByKey)
+			args = append(args, key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get \"configChanges\" prepared statement: %w", err)
+		}
+
+		err = query.SelectObjects(ctx, sqlStmt, func(scan func(dest ...any) error) error {
+			var change types.ConfigChange
+			err := scan(&change.ID, &change.Key, &change.Section, &change.OldValue, &change.NewValue, &change.ChangedBy, &change.ChangedAt)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change)
+			return nil
+		}, args...)
+		if err != nil {
+			return fmt.Errorf("failed to get \"configChanges\" objects: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Singleton for the ConfigHistoryQueryImpl, to be mocked in unit testing
+var ConfigHistoryQuery ConfigHistoryQueryInterface = ConfigHistoryQueryImpl{}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/canonical/microceph/microceph/api/types"
 
@@ -132,6 +133,14 @@ type OSDQueryInterface interface {
 	Delete(ctx context.Context, s state.State, osd int64) error
 	List(ctx context.Context, s state.State) (types.Disks, error)
 	UpdatePath(ctx context.Context, s state.State, osd int64, path string) error
+	SetDevice(ctx context.Context, s state.State, device types.DiskDevice) error
+	Devices(ctx context.Context, s state.State, osd int64) ([]types.DiskDevice, error)
+	DeviceUsage(ctx context.Context, s state.State, parent string) (uint64, error)
+	SetHealth(ctx context.Context, s state.State, health types.DiskHealth) error
+	Health(ctx context.Context, s state.State, osd int64) (types.DiskHealth, error)
+	SetDrain(ctx context.Context, s state.State, drain types.DiskDrain) error
+	Drain(ctx context.Context, s state.State, osd int64) (types.DiskDrain, error)
+	Drains(ctx context.Context, s state.State) ([]types.DiskDrain, error)
 }
 
 type OSDQueryImpl struct{}
@@ -220,11 +229,55 @@ func (o OSDQueryImpl) List(ctx context.Context, s state.State) (types.Disks, err
 			return fmt.Errorf("Failed to fetch disks: %w", err)
 		}
 
+		// OSDs on a partition of a split device.
+		parents := map[int64]string{}
+		sqlStmt, err := cluster.Stmt(tx, dataDeviceParents)
+		if err != nil {
+			return fmt.Errorf("Failed to get \"dataDeviceParents\" prepared statement: %w", err)
+		}
+
+		err = query.SelectObjects(ctx, sqlStmt, func(scan func(dest ...any) error) error {
+			var osd int64
+			var parent string
+			err := scan(&osd, &parent)
+			if err != nil {
+				return err
+			}
+			parents[osd] = parent
+			return nil
+		}, types.DiskRoleData)
+		if err != nil {
+			return fmt.Errorf("Failed to get \"dataDeviceParents\" objects: %w", err)
+		}
+
+		// Latest health status of OSD devices.
+		health := map[int64]string{}
+		sqlStmt, err = cluster.Stmt(tx, diskHealthStatus)
+		if err != nil {
+			return fmt.Errorf("Failed to get \"diskHealthStatus\" prepared statement: %w", err)
+		}
+
+		err = query.SelectObjects(ctx, sqlStmt, func(scan func(dest ...any) error) error {
+			var osd int64
+			var status string
+			err := scan(&osd, &status)
+			if err != nil {
+				return err
+			}
+			health[osd] = status
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to get \"diskHealthStatus\" objects: %w", err)
+		}
+
 		for _, disk := range records {
 			disks = append(disks, types.Disk{
 				OSD:      int64(disk.ID),
 				Location: disk.Member,
 				Path:     disk.Path,
+				Parent:   parents[int64(disk.ID)],
+				Health:   health[int64(disk.ID)],
 			})
 		}
 
@@ -256,5 +309,258 @@ func (o OSDQueryImpl) UpdatePath(ctx context.Context, s state.State, osd int64,
 	return nil
 }
 
+var dataDeviceParents = cluster.RegisterStmt(`
+SELECT disk_devices.disk_id, disk_devices.parent
+FROM disk_devices
+WHERE disk_devices.role = ? AND disk_devices.parent != ''
+`)
+
+var setDevice = cluster.RegisterStmt(`
+INSERT OR REPLACE INTO disk_devices (disk_id, role, path, encrypted, parent, partition, size)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`)
+
+var osdDevices = cluster.RegisterStmt(`
+SELECT disk_devices.role, disk_devices.path, disk_devices.encrypted, disk_devices.parent, disk_devices.partition, disk_devices.size
+FROM disk_devices
+WHERE disk_devices.disk_id = ?
+ORDER BY disk_devices.role
+`)
+
+var deviceUsage = cluster.RegisterStmt(`
+SELECT coalesce(sum(disk_devices.size), 0)
+FROM disk_devices
+JOIN disks ON disk_devices.disk_id = disks.id
+JOIN core_cluster_members ON disks.member_id = core_cluster_members.id
+WHERE core_cluster_members.name = ? AND disk_devices.parent = ?
+`)
+
+// SetDevice records the data partition, WAL or DB device of the given OSD, replacing an earlier record for the same role
+func (o OSDQueryImpl) SetDevice(ctx context.Context, s state.State, device types.DiskDevice) error {
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, setDevice)
+		if err != nil {
+			return fmt.Errorf("failed to get \"setDevice\" prepared statement: %w", err)
+		}
+
+		_, err = sqlStmt.Exec(device.OSD, device.Role, device.Path, device.Encrypted, device.Parent, device.Partition, device.Size)
+		if err != nil {
+			return fmt.Errorf("failed to record %s device of osd.%d: %w", device.Role, device.OSD, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Devices returns the data partition, WAL and DB devices recorded for the given OSD
+func (o OSDQueryImpl) Devices(ctx context.Context, s state.State, osd int64) ([]types.DiskDevice, error) {
+	devices := []types.DiskDevice{}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, osdDevices)
+		if err != nil {
+			return fmt.Errorf("failed to get \"osdDevices\" prepared statement: %w", err)
+		}
+
+		return query.SelectObjects(ctx, sqlStmt, func(scan func(dest ...any) error) error {
+			device := types.DiskDevice{OSD: osd}
+			err := scan(&device.Role, &device.Path, &device.Encrypted, &device.Parent, &device.Partition, &device.Size)
+			if err != nil {
+				return err
+			}
+			devices = append(devices, device)
+			return nil
+		}, osd)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// DeviceUsage returns the number of bytes sliced off the given shared device of this member
+func (o OSDQueryImpl) DeviceUsage(ctx context.Context, s state.State, parent string) (uint64, error) {
+	var used uint64
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, deviceUsage)
+		if err != nil {
+			return fmt.Errorf("failed to get \"deviceUsage\" prepared statement: %w", err)
+		}
+
+		err = sqlStmt.QueryRow(s.Name(), parent).Scan(&used)
+		if err != nil {
+			return fmt.Errorf("failed to get \"deviceUsage\" objects: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return used, nil
+}
+
+var diskHealthStatus = cluster.RegisterStmt(`
+SELECT disk_health.disk_id, disk_health.status
+FROM disk_health
+`)
+
+var setHealth = cluster.RegisterStmt(`
+INSERT OR REPLACE INTO disk_health (disk_id, status, reasons, temperature, power_on_hours, reallocated_sectors, pending_sectors, media_errors, percentage_used, marked_out, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`)
+
+var diskHealth = cluster.RegisterStmt(`
+SELECT disk_health.status, disk_health.reasons, disk_health.temperature, disk_health.power_on_hours, disk_health.reallocated_sectors,
+  disk_health.pending_sectors, disk_health.media_errors, disk_health.percentage_used, disk_health.marked_out, disk_health.updated_at
+FROM disk_health
+WHERE disk_health.disk_id = ?
+`)
+
+// SetHealth records the latest health readings of the given OSD
+func (o OSDQueryImpl) SetHealth(ctx context.Context, s state.State, health types.DiskHealth) error {
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, setHealth)
+		if err != nil {
+			return fmt.Errorf("failed to get \"setHealth\" prepared statement: %w", err)
+		}
+
+		_, err = sqlStmt.Exec(health.OSD, health.Status, strings.Join(health.Reasons, "\n"), health.Temperature, health.PowerOnHours,
+			health.ReallocatedSectors, health.PendingSectors, health.MediaErrors, health.PercentageUsed, health.MarkedOut, health.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record health of osd.%d: %w", health.OSD, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Health returns the latest health readings of the given OSD, the status is unknown until readings are recorded
+func (o OSDQueryImpl) Health(ctx context.Context, s state.State, osd int64) (types.DiskHealth, error) {
+	health := types.DiskHealth{OSD: osd, Status: types.DiskHealthUnknown}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, diskHealth)
+		if err != nil {
+			return fmt.Errorf("failed to get \"diskHealth\" prepared statement: %w", err)
+		}
+
+		var reasons string
+		err = sqlStmt.QueryRow(osd).Scan(&health.Status, &reasons, &health.Temperature, &health.PowerOnHours, &health.ReallocatedSectors,
+			&health.PendingSectors, &health.MediaErrors, &health.PercentageUsed, &health.MarkedOut, &health.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to get \"diskHealth\" objects: %w", err)
+		}
+
+		if len(reasons) != 0 {
+			health.Reasons = strings.Split(reasons, "\n")
+		}
+		return nil
+	})
+	if err != nil {
+		return health, err
+	}
+	return health, nil
+}
+
 // Singleton for the OSDQueryImpl, to be mocked in unit testing
 var OSDQuery OSDQueryInterface = OSDQueryImpl{}
+
+var setDrain = cluster.RegisterStmt(`
+INSERT OR REPLACE INTO disk_drain (disk_id, status, initial_weight, weight, step, error, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`)
+
+var diskDrain = cluster.RegisterStmt(`
+SELECT disk_drain.status, disk_drain.initial_weight, disk_drain.weight, disk_drain.step, disk_drain.error, disk_drain.updated_at
+FROM disk_drain
+WHERE disk_drain.disk_id = ?
+`)
+
+var diskDrains = cluster.RegisterStmt(`
+SELECT disk_drain.disk_id, disk_drain.status, disk_drain.initial_weight, disk_drain.weight, disk_drain.step, disk_drain.error, disk_drain.updated_at
+FROM disk_drain
+ORDER BY disk_drain.disk_id
+`)
+
+// SetDrain records the drain progress of the given OSD
+func (o OSDQueryImpl) SetDrain(ctx context.Context, s state.State, drain types.DiskDrain) error {
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, setDrain)
+		if err != nil {
+			return fmt.Errorf("failed to get \"setDrain\" prepared statement: %w", err)
+		}
+
+		_, err = sqlStmt.Exec(drain.OSD, drain.Status, drain.InitialWeight, drain.Weight, drain.Step, drain.Error, drain.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record drain of osd.%d: %w", drain.OSD, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Drain returns the drain progress of the given OSD, the status is empty if the OSD was never drained
+func (o OSDQueryImpl) Drain(ctx context.Context, s state.State, osd int64) (types.DiskDrain, error) {
+	drain := types.DiskDrain{OSD: osd}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, diskDrain)
+		if err != nil {
+			return fmt.Errorf("failed to get \"diskDrain\" prepared statement: %w", err)
+		}
+
+		err = sqlStmt.QueryRow(osd).Scan(&drain.Status, &drain.InitialWeight, &drain.Weight, &drain.Step, &drain.Error, &drain.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to get \"diskDrain\" objects: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return drain, err
+	}
+	return drain, nil
+}
+
+// Drains returns the drain progress of all OSDs being or having been drained
+func (o OSDQueryImpl) Drains(ctx context.Context, s state.State) ([]types.DiskDrain, error) {
+	drains := []types.DiskDrain{}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		sqlStmt, err := cluster.Stmt(tx, diskDrains)
+		if err != nil {
+			return fmt.Errorf("failed to get \"diskDrains\" prepared statement: %w", err)
+		}
+
+		err = query.SelectObjects(ctx, sqlStmt, func(scan func(dest ...any) error) error {
+			var drain types.DiskDrain
+			err := scan(&drain.OSD, &drain.Status, &drain.InitialWeight, &drain.Weight, &drain.Step, &drain.Error, &drain.UpdatedAt)
+			if err != nil {
+				return err
+			}
+			drains = append(drains, drain)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get \"diskDrains\" objects: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drains, nil
+}
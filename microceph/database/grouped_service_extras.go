@@ -159,37 +159,61 @@ func (g GroupedServiceQueryImpl) RemoveForHost(ctx context.Context, s interfaces
 	}
 
 	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
-		// Delete the GroupedService record.
-		err := DeleteGroupedService(ctx, tx, s.ClusterState().Name(), service, groupID)
-		if err != nil {
-			return fmt.Errorf("failed to delete grouped service record: %w", err)
-		}
+		return deleteGroupedService(ctx, tx, s.ClusterState().Name(), service, groupID)
+	})
 
-		// Check if there is any GroupedService referencing this ServiceGroup.
-		filter := GroupedServiceFilter{
-			Service: &service,
-			GroupID: &groupID,
-		}
-		groupedServices, err := GetGroupedServices(ctx, tx, filter)
-		if err != nil {
-			return fmt.Errorf("failed to get grouped services records: %w", err)
-		}
+	return err
+}
 
-		if len(groupedServices) > 0 {
-			// There's still at least one GroupedService referencing this ServiceGroup.
-			return nil
-		}
+// deleteGroupedService deletes the grouped service record of a member, and the service group record
+// if there is no grouped service referencing it anymore.
+func deleteGroupedService(ctx context.Context, tx *sql.Tx, member, service, groupID string) error {
+	// Delete the GroupedService record.
+	err := DeleteGroupedService(ctx, tx, member, service, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to delete grouped service record: %w", err)
+	}
 
-		// Delete the ServiceGroup record.
-		err = DeleteServiceGroup(ctx, tx, service, groupID)
-		if err != nil {
-			return fmt.Errorf("failed to delete service group record: %w", err)
-		}
+	// Check if there is any GroupedService referencing this ServiceGroup.
+	filter := GroupedServiceFilter{
+		Service: &service,
+		GroupID: &groupID,
+	}
+	groupedServices, err := GetGroupedServices(ctx, tx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to get grouped services records: %w", err)
+	}
 
+	if len(groupedServices) > 0 {
+		// There's still at least one GroupedService referencing this ServiceGroup.
 		return nil
-	})
+	}
 
-	return err
+	// Delete the ServiceGroup record.
+	err = DeleteServiceGroup(ctx, tx, service, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to delete service group record: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMemberGroupedServices deletes all grouped service records of a member, along with the
+// service groups no other member references.
+func DeleteMemberGroupedServices(ctx context.Context, tx *sql.Tx, member string) error {
+	groupedServices, err := GetGroupedServices(ctx, tx, GroupedServiceFilter{Member: &member})
+	if err != nil {
+		return fmt.Errorf("failed to get grouped services records: %w", err)
+	}
+
+	for _, groupedService := range groupedServices {
+		err = deleteGroupedService(ctx, tx, member, groupedService.Service, groupedService.GroupID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 var GroupedServicesQuery GroupedServiceQueryIntf = GroupedServiceQueryImpl{}
@@ -19,6 +19,11 @@ var SchemaExtensions = []schema.Update{
 	schemaUpdate4,
 	schemaUpdate5,
 	schemaUpdate6,
+	schemaUpdate7,
+	schemaUpdate8,
+	schemaUpdate9,
+	schemaUpdate10,
+	schemaUpdate11,
 }
 
 // getClusterTableName returns the name of the table that holds the record of cluster members from sqlite_master.
@@ -212,3 +217,93 @@ CREATE TABLE grouped_services (
 
 	return err
 }
+
+// schemaUpdate7 adds the disk_devices table holding the WAL and DB devices of OSDs
+func schemaUpdate7(ctx context.Context, tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE disk_devices (
+  id                            INTEGER  PRIMARY KEY AUTOINCREMENT NOT NULL,
+  disk_id                       INTEGER  NOT  NULL,
+  role                          TEXT     NOT  NULL,
+  path                          TEXT     NOT  NULL,
+  encrypted                     BOOLEAN  NOT  NULL DEFAULT 0,
+  FOREIGN KEY (disk_id) REFERENCES "disks" (id) ON DELETE CASCADE,
+  UNIQUE (disk_id, role)
+);
+  `
+	_, err := tx.ExecContext(ctx, stmt)
+
+	return err
+}
+
+// schemaUpdate8 adds the columns tracking slices of shared WAL and DB devices
+func schemaUpdate8(ctx context.Context, tx *sql.Tx) error {
+	stmt := `
+ALTER TABLE disk_devices ADD COLUMN parent TEXT NOT NULL DEFAULT '';
+ALTER TABLE disk_devices ADD COLUMN partition INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE disk_devices ADD COLUMN size INTEGER NOT NULL DEFAULT 0;
+  `
+	_, err := tx.ExecContext(ctx, stmt)
+
+	return err
+}
+
+// schemaUpdate9 adds the disk_health table holding the latest SMART readings of OSD devices
+func schemaUpdate9(ctx context.Context, tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE disk_health (
+  disk_id                       INTEGER  PRIMARY KEY NOT NULL,
+  status                        TEXT     NOT  NULL,
+  reasons                       TEXT     NOT  NULL DEFAULT '',
+  temperature                   INTEGER  NOT  NULL DEFAULT 0,
+  power_on_hours                INTEGER  NOT  NULL DEFAULT 0,
+  reallocated_sectors           INTEGER  NOT  NULL DEFAULT 0,
+  pending_sectors               INTEGER  NOT  NULL DEFAULT 0,
+  media_errors                  INTEGER  NOT  NULL DEFAULT 0,
+  percentage_used               INTEGER  NOT  NULL DEFAULT 0,
+  marked_out                    BOOLEAN  NOT  NULL DEFAULT 0,
+  updated_at                    DATETIME NOT  NULL,
+  FOREIGN KEY (disk_id) REFERENCES "disks" (id) ON DELETE CASCADE
+);
+  `
+	_, err := tx.ExecContext(ctx, stmt)
+
+	return err
+}
+
+// schemaUpdate10 adds the disk_drain table tracking gradual drains of OSDs
+func schemaUpdate10(ctx context.Context, tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE disk_drain (
+  disk_id                       INTEGER  PRIMARY KEY NOT NULL,
+  status                        TEXT     NOT  NULL,
+  initial_weight                REAL     NOT  NULL,
+  weight                        REAL     NOT  NULL,
+  step                          REAL     NOT  NULL,
+  error                         TEXT     NOT  NULL DEFAULT '',
+  updated_at                    DATETIME NOT  NULL,
+  FOREIGN KEY (disk_id) REFERENCES "disks" (id) ON DELETE CASCADE
+);
+  `
+	_, err := tx.ExecContext(ctx, stmt)
+
+	return err
+}
+
+// schemaUpdate11 adds the config_history table recording every change of the Ceph config
+func schemaUpdate11(ctx context.Context, tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE config_history (
+  id                            INTEGER  PRIMARY KEY AUTOINCREMENT NOT NULL,
+  key                           TEXT     NOT  NULL,
+  section                       TEXT     NOT  NULL,
+  old_value                     TEXT     NOT  NULL DEFAULT '',
+  new_value                     TEXT     NOT  NULL DEFAULT '',
+  changed_by                    TEXT     NOT  NULL,
+  changed_at                    DATETIME NOT  NULL
+);
+  `
+	_, err := tx.ExecContext(ctx, stmt)
+
+	return err
+}
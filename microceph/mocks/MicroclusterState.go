@@ -3,6 +3,7 @@ package mocks
 import (
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/client"
 	state "github.com/canonical/microcluster/v2/state"
 )
 
@@ -12,6 +13,7 @@ type MockState struct {
 
 	URL         *api.URL
 	ClusterName string
+	LeaderCli   *client.Client
 }
 
 // Name returns the name supplied to MockState.
@@ -28,3 +30,8 @@ func (m *MockState) Address() *api.URL {
 func (m *MockState) ServerCert() *shared.CertInfo {
 	return nil
 }
+
+// Leader returns the client supplied to MockState.
+func (m *MockState) Leader() (*client.Client, error) {
+	return m.LeaderCli, nil
+}
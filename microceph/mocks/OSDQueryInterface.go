@@ -35,6 +35,122 @@ func (_m *OSDQueryInterface) Delete(ctx context.Context, s state.State, osd int6
 	return r0
 }
 
+// DeviceUsage provides a mock function with given fields: ctx, s, parent
+func (_m *OSDQueryInterface) DeviceUsage(ctx context.Context, s state.State, parent string) (uint64, error) {
+	ret := _m.Called(ctx, s, parent)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeviceUsage")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, string) (uint64, error)); ok {
+		return rf(ctx, s, parent)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, string) uint64); ok {
+		r0 = rf(ctx, s, parent)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, state.State, string) error); ok {
+		r1 = rf(ctx, s, parent)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Devices provides a mock function with given fields: ctx, s, osd
+func (_m *OSDQueryInterface) Devices(ctx context.Context, s state.State, osd int64) ([]types.DiskDevice, error) {
+	ret := _m.Called(ctx, s, osd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Devices")
+	}
+
+	var r0 []types.DiskDevice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, int64) ([]types.DiskDevice, error)); ok {
+		return rf(ctx, s, osd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, int64) []types.DiskDevice); ok {
+		r0 = rf(ctx, s, osd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.DiskDevice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, state.State, int64) error); ok {
+		r1 = rf(ctx, s, osd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Drain provides a mock function with given fields: ctx, s, osd
+func (_m *OSDQueryInterface) Drain(ctx context.Context, s state.State, osd int64) (types.DiskDrain, error) {
+	ret := _m.Called(ctx, s, osd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Drain")
+	}
+
+	var r0 types.DiskDrain
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, int64) (types.DiskDrain, error)); ok {
+		return rf(ctx, s, osd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, int64) types.DiskDrain); ok {
+		r0 = rf(ctx, s, osd)
+	} else {
+		r0 = ret.Get(0).(types.DiskDrain)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, state.State, int64) error); ok {
+		r1 = rf(ctx, s, osd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Drains provides a mock function with given fields: ctx, s
+func (_m *OSDQueryInterface) Drains(ctx context.Context, s state.State) ([]types.DiskDrain, error) {
+	ret := _m.Called(ctx, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Drains")
+	}
+
+	var r0 []types.DiskDrain
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State) ([]types.DiskDrain, error)); ok {
+		return rf(ctx, s)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, state.State) []types.DiskDrain); ok {
+		r0 = rf(ctx, s)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.DiskDrain)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, state.State) error); ok {
+		r1 = rf(ctx, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // HaveOSD provides a mock function with given fields: ctx, s, osd
 func (_m *OSDQueryInterface) HaveOSD(ctx context.Context, s state.State, osd int64) (bool, error) {
 	ret := _m.Called(ctx, s, osd)
@@ -63,6 +179,34 @@ func (_m *OSDQueryInterface) HaveOSD(ctx context.Context, s state.State, osd int
 	return r0, r1
 }
 
+// Health provides a mock function with given fields: ctx, s, osd
+func (_m *OSDQueryInterface) Health(ctx context.Context, s state.State, osd int64) (types.DiskHealth, error) {
+	ret := _m.Called(ctx, s, osd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Health")
+	}
+
+	var r0 types.DiskHealth
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, int64) (types.DiskHealth, error)); ok {
+		return rf(ctx, s, osd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, int64) types.DiskHealth); ok {
+		r0 = rf(ctx, s, osd)
+	} else {
+		r0 = ret.Get(0).(types.DiskHealth)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, state.State, int64) error); ok {
+		r1 = rf(ctx, s, osd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // List provides a mock function with given fields: ctx, s
 func (_m *OSDQueryInterface) List(ctx context.Context, s state.State) (types.Disks, error) {
 	ret := _m.Called(ctx, s)
@@ -121,6 +265,60 @@ func (_m *OSDQueryInterface) Path(ctx context.Context, s state.State, osd int64)
 	return r0, r1
 }
 
+// SetDevice provides a mock function with given fields: ctx, s, device
+func (_m *OSDQueryInterface) SetDevice(ctx context.Context, s state.State, device types.DiskDevice) error {
+	ret := _m.Called(ctx, s, device)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDevice")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, types.DiskDevice) error); ok {
+		r0 = rf(ctx, s, device)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetDrain provides a mock function with given fields: ctx, s, drain
+func (_m *OSDQueryInterface) SetDrain(ctx context.Context, s state.State, drain types.DiskDrain) error {
+	ret := _m.Called(ctx, s, drain)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDrain")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, types.DiskDrain) error); ok {
+		r0 = rf(ctx, s, drain)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetHealth provides a mock function with given fields: ctx, s, health
+func (_m *OSDQueryInterface) SetHealth(ctx context.Context, s state.State, health types.DiskHealth) error {
+	ret := _m.Called(ctx, s, health)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetHealth")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, state.State, types.DiskHealth) error); ok {
+		r0 = rf(ctx, s, health)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdatePath provides a mock function with given fields: ctx, s, osd, path
 func (_m *OSDQueryInterface) UpdatePath(ctx context.Context, s state.State, osd int64, path string) error {
 	ret := _m.Called(ctx, s, osd, path)
@@ -0,0 +1,870 @@
+package simulate
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pborman/uuid"
+)
+
+// errUnsupported returns the error of a command the simulation does not know.
+func errUnsupported(cmd command) error {
+	return fmt.Errorf("Error EINVAL: %s %s is not supported by the simulation", cmd.name, strings.Join(cmd.args, " "))
+}
+
+// errNoEntity returns the error ceph reports for a missing entity.
+func errNoEntity(kind string, name string) error {
+	return fmt.Errorf("Error ENOENT: %s '%s' does not exist", kind, name)
+}
+
+// parseOSD parses an OSD id given as osd.N or N.
+func parseOSD(arg string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "osd."), 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("Error EINVAL: invalid osd id '%s'", arg)
+	}
+
+	return id, nil
+}
+
+// runCeph runs a ceph command.
+func runCeph(c *Cluster, cmd command) (string, error) {
+	if cmd.flag("-v") || cmd.flag("--version") || cmd.arg(0) == "version" {
+		return fmt.Sprintf("ceph version %s (simulated) %s (stable)\n", cephVersion, cephRelease), nil
+	}
+
+	if cmd.flag("-s") {
+		return cephStatus(c)
+	}
+
+	switch cmd.arg(0) {
+	case "status":
+		return cephStatus(c)
+	case "health":
+		if c.degraded() {
+			return "HEALTH_WARN", nil
+		}
+		return "HEALTH_OK", nil
+	case "fsid":
+		return c.FSID + "\n", nil
+	case "versions":
+		return cephVersions(c)
+	case "log":
+		return "", nil
+	case "mon":
+		return runMon(c, cmd)
+	case "mgr":
+		return runMgr(c, cmd)
+	case "fs":
+		return runFs(c, cmd)
+	case "osd":
+		return runOSD(c, cmd)
+	case "pg":
+		return runPG(c, cmd)
+	case "auth":
+		return runAuth(c, cmd)
+	case "config":
+		return runConfig(c, cmd)
+	case "config-key":
+		return runConfigKey(c, cmd)
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// cephStatus returns the output of ceph status.
+func cephStatus(c *Cluster) (string, error) {
+	up, in := 0, 0
+	for _, osd := range c.OSDs {
+		if osd.Up {
+			up++
+		}
+		if osd.In {
+			in++
+		}
+	}
+
+	health := "HEALTH_OK"
+	if c.degraded() {
+		health = "HEALTH_WARN"
+	}
+
+	return toJSON(map[string]any{
+		"fsid":         c.FSID,
+		"health":       map[string]any{"status": health},
+		"quorum_names": c.quorum(),
+		"osdmap": map[string]any{
+			"epoch":       c.Epoch,
+			"num_osds":    len(c.OSDs),
+			"num_up_osds": up,
+			"num_in_osds": in,
+		},
+		"pgmap": map[string]any{
+			"num_pools": len(c.Pools),
+			"num_pgs":   c.numPGs(),
+		},
+	})
+}
+
+// quorum returns the monitors in quorum, those whose host runs the mon service.
+func (c *Cluster) quorum() []string {
+	quorum := []string{}
+	for _, mon := range c.Mons {
+		if c.running(mon.Name, "mon") {
+			quorum = append(quorum, mon.Name)
+		}
+	}
+
+	return quorum
+}
+
+// cephVersions returns the output of ceph versions.
+func cephVersions(c *Cluster) (string, error) {
+	version := fmt.Sprintf("ceph version %s (simulated) %s (stable)", cephVersion, cephRelease)
+	versions := map[string]map[string]int{"overall": {}}
+	for _, service := range []string{"mon", "mgr", "osd", "mds"} {
+		count := len(c.runningHosts(service))
+		if service == "osd" {
+			count = 0
+			for _, osd := range c.OSDs {
+				if osd.Up {
+					count++
+				}
+			}
+		}
+
+		versions[service] = map[string]int{}
+		if count != 0 {
+			versions[service][version] = count
+			versions["overall"][version] += count
+		}
+	}
+
+	return toJSON(versions)
+}
+
+// runMon runs a ceph mon command.
+func runMon(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(1) {
+	case "stat":
+		if len(c.quorum()) == 0 {
+			return "", fmt.Errorf("error connecting to the cluster: no monitor in quorum")
+		}
+		return fmt.Sprintf("e%d: %d mons, quorum %s\n", c.Epoch, len(c.Mons), strings.Join(c.quorum(), ",")), nil
+	case "dump":
+		mons := []map[string]any{}
+		for rank, mon := range c.Mons {
+			mons = append(mons, map[string]any{"rank": rank, "name": mon.Name, "public_addr": mon.Addr, "addr": mon.Addr})
+		}
+		return toJSON(map[string]any{"epoch": c.Epoch, "fsid": c.FSID, "mons": mons})
+	case "getmap":
+		out, err := toJSON(monmap{FSID: c.FSID, Mons: c.Mons})
+		if err != nil {
+			return "", err
+		}
+		return writeOutput(cmd, out)
+	case "rm":
+		name := cmd.arg(2)
+		if !c.hasMon(name) {
+			return "", errNoEntity("mon", name)
+		}
+		c.Mons = slices.DeleteFunc(c.Mons, func(mon Mon) bool { return mon.Name == name })
+		c.bump()
+		return "", nil
+	case "set-addrs":
+		i := slices.IndexFunc(c.Mons, func(mon Mon) bool { return mon.Name == cmd.arg(2) })
+		if i < 0 {
+			return "", errNoEntity("mon", cmd.arg(2))
+		}
+		c.Mons[i].Addr = cmd.arg(3)
+		c.bump()
+		return "", nil
+	case "enable-msgr2":
+		return "", nil
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// runMgr runs a ceph mgr command.
+func runMgr(c *Cluster, cmd command) (string, error) {
+	if cmd.arg(1) != "dump" {
+		return "", errUnsupported(cmd)
+	}
+
+	mgrs := c.runningHosts("mgr")
+	active := ""
+	standbys := []map[string]any{}
+	for i, mgr := range mgrs {
+		if i == 0 {
+			active = mgr
+			continue
+		}
+		standbys = append(standbys, map[string]any{"name": mgr})
+	}
+
+	return toJSON(map[string]any{"active_name": active, "available": len(active) != 0, "standbys": standbys})
+}
+
+// runFs runs a ceph fs command.
+func runFs(c *Cluster, cmd command) (string, error) {
+	if cmd.arg(1) != "status" {
+		return "", errUnsupported(cmd)
+	}
+
+	mdss := []map[string]any{}
+	for _, mds := range c.runningHosts("mds") {
+		mdss = append(mdss, map[string]any{"name": mds, "state": "standby"})
+	}
+
+	return toJSON(map[string]any{"mdsmap": mdss, "pools": []any{}, "clients": []any{}})
+}
+
+// runPG runs a ceph pg command.
+func runPG(c *Cluster, cmd command) (string, error) {
+	if cmd.arg(1) != "stat" {
+		return "", errUnsupported(cmd)
+	}
+
+	// Placement groups only recover while every pool has enough OSDs.
+	state := "active+clean"
+	if c.degraded() {
+		state = "active+undersized+degraded"
+	}
+
+	byState := []map[string]any{}
+	if c.numPGs() != 0 {
+		byState = append(byState, map[string]any{"name": state, "num": c.numPGs()})
+	}
+
+	return toJSON(map[string]any{"pg_summary": map[string]any{"num_pg_by_state": byState, "num_pgs": c.numPGs()}})
+}
+
+// runOSD runs a ceph osd command.
+func runOSD(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(1) {
+	case "tree":
+		return osdTree(c)
+	case "dump":
+		return osdDump(c, cmd)
+	case "set", "unset":
+		flag := cmd.arg(2)
+		c.Flags = slices.DeleteFunc(c.Flags, func(f string) bool { return f == flag })
+		if cmd.arg(1) == "set" {
+			c.Flags = append(c.Flags, flag)
+			sort.Strings(c.Flags)
+		}
+		c.bump()
+		return "", nil
+	case "in", "out", "down", "destroy", "purge", "safe-to-destroy":
+		return osdState(c, cmd)
+	case "ok-to-stop":
+		return osdOkToStop(c, cmd)
+	case "new":
+		return osdNew(c, cmd)
+	case "require-osd-release":
+		c.RequireOSDRelease = cmd.arg(2)
+		return "", nil
+	case "crush":
+		return runCrush(c, cmd)
+	case "pool":
+		return runPool(c, cmd)
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// osdTree returns the output of ceph osd tree.
+func osdTree(c *Cluster) (string, error) {
+	nodes := []map[string]any{}
+	root := map[string]any{"id": -1, "name": "default", "type": "root", "type_id": 11, "children": []int64{}}
+	nodes = append(nodes, root)
+
+	rootChildren := []int64{}
+	for i, host := range c.CrushHosts {
+		id := int64(-2 - i)
+		rootChildren = append(rootChildren, id)
+
+		children := []int64{}
+		osds := []map[string]any{}
+		for _, osd := range c.OSDs {
+			if osd.Host != host {
+				continue
+			}
+
+			children = append(children, osd.ID)
+			status := "down"
+			switch {
+			case osd.Destroyed:
+				status = "destroyed"
+			case osd.Up:
+				status = "up"
+			}
+
+			reweight := 0.0
+			if osd.In {
+				reweight = 1.0
+			}
+
+			osds = append(osds, map[string]any{
+				"id": osd.ID, "name": fmt.Sprintf("osd.%d", osd.ID), "type": "osd", "type_id": 0,
+				"crush_weight": osd.CrushWeight, "status": status, "reweight": reweight,
+			})
+		}
+
+		nodes = append(nodes, map[string]any{"id": id, "name": host, "type": "host", "type_id": 1, "children": children})
+		nodes = append(nodes, osds...)
+	}
+	root["children"] = rootChildren
+
+	return toJSON(map[string]any{"nodes": nodes, "stray": []any{}})
+}
+
+// osdDump returns the output of ceph osd dump.
+func osdDump(c *Cluster, cmd command) (string, error) {
+	flags := strings.Join(append([]string{"sortbitwise"}, c.Flags...), ",")
+
+	if len(cmd.opt("-f", "--format")) == 0 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "epoch %d\nfsid %s\nflags %s\nrequire_osd_release %s\n", c.Epoch, c.FSID, flags, c.RequireOSDRelease)
+		for _, pool := range c.Pools {
+			fmt.Fprintf(&sb, "pool %d '%s' replicated size %d min_size %d crush_rule %d\n", pool.ID, pool.Name, pool.Size, pool.MinSize, pool.CrushRule)
+		}
+		for _, osd := range c.OSDs {
+			fmt.Fprintf(&sb, "osd.%d %s %s %s\n", osd.ID, upDown(osd.Up), inOut(osd.In), osd.UUID)
+		}
+		return sb.String(), nil
+	}
+
+	osds := []map[string]any{}
+	for _, osd := range c.OSDs {
+		osds = append(osds, map[string]any{
+			"osd": osd.ID, "uuid": osd.UUID, "up": boolInt(osd.Up), "in": boolInt(osd.In), "weight": float64(boolInt(osd.In)),
+		})
+	}
+
+	return toJSON(map[string]any{
+		"epoch": c.Epoch, "fsid": c.FSID, "flags": flags, "require_osd_release": c.RequireOSDRelease,
+		"pools": poolDetails(c), "osds": osds,
+	})
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func upDown(up bool) string {
+	if up {
+		return "up"
+	}
+
+	return "down"
+}
+
+func inOut(in bool) string {
+	if in {
+		return "in"
+	}
+
+	return "out"
+}
+
+// osdState changes the state of an OSD.
+func osdState(c *Cluster, cmd command) (string, error) {
+	id, err := parseOSD(cmd.arg(2))
+	if err != nil {
+		return "", err
+	}
+
+	osd := c.osd(id)
+	if osd == nil {
+		return "", errNoEntity("osd", fmt.Sprintf("osd.%d", id))
+	}
+
+	switch cmd.arg(1) {
+	case "in":
+		osd.In = true
+	case "out":
+		osd.In = false
+	case "down":
+		osd.Up = false
+	case "safe-to-destroy":
+		// Data is only gone from an OSD once it is out and has no data left to serve.
+		if osd.In && osd.Up {
+			return "", fmt.Errorf("Error EBUSY: osd.%d is still in and up", id)
+		}
+		return fmt.Sprintf("OSD(s) %d are safe to destroy without reducing data durability.\n", id), nil
+	case "destroy":
+		if osd.Up {
+			return "", fmt.Errorf("Error EBUSY: osd.%d is still up", id)
+		}
+		osd.Destroyed = true
+		osd.In = false
+		delete(c.Auth, fmt.Sprintf("osd.%d", id))
+	case "purge":
+		if osd.Up {
+			return "", fmt.Errorf("Error EBUSY: osd.%d is still up", id)
+		}
+		c.OSDs = slices.DeleteFunc(c.OSDs, func(o *OSD) bool { return o.ID == id })
+		delete(c.Auth, fmt.Sprintf("osd.%d", id))
+	}
+
+	c.bump()
+	return "", nil
+}
+
+// osdOkToStop checks if the given OSDs can stop without making a pool unavailable.
+func osdOkToStop(c *Cluster, cmd command) (string, error) {
+	stopping := []int64{}
+	for _, arg := range cmd.args[2:] {
+		id, err := parseOSD(arg)
+		if err != nil {
+			return "", err
+		}
+		stopping = append(stopping, id)
+	}
+
+	remaining := int64(0)
+	for _, osd := range c.OSDs {
+		if osd.Up && osd.In && !slices.Contains(stopping, osd.ID) {
+			remaining++
+		}
+	}
+
+	for _, pool := range c.Pools {
+		if remaining < pool.MinSize {
+			return "", fmt.Errorf("Error EBUSY: stopping %v would leave pool %s with %d of min_size %d OSDs", stopping, pool.Name, remaining, pool.MinSize)
+		}
+	}
+
+	return "", nil
+}
+
+// osdNew brings a destroyed OSD id back with a new uuid.
+func osdNew(c *Cluster, cmd command) (string, error) {
+	id, err := parseOSD(cmd.arg(3))
+	if err != nil {
+		return "", err
+	}
+
+	osd := c.osd(id)
+	if osd == nil {
+		osd = &OSD{ID: id, Host: cmd.host}
+		c.OSDs = append(c.OSDs, osd)
+	}
+	osd.UUID = cmd.arg(2)
+	osd.Destroyed = false
+	osd.In = true
+	c.bump()
+
+	return fmt.Sprintf("%d\n", id), nil
+}
+
+// runCrush runs a ceph osd crush command.
+func runCrush(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(2) {
+	case "remove", "rm":
+		name := cmd.arg(3)
+		if strings.HasPrefix(name, "osd.") {
+			id, err := parseOSD(name)
+			if err != nil {
+				return "", err
+			}
+			osd := c.osd(id)
+			if osd != nil {
+				osd.CrushWeight = 0
+			}
+			return "", nil
+		}
+
+		for _, osd := range c.OSDs {
+			if osd.Host == name {
+				return "", fmt.Errorf("Error ENOTEMPTY: (39) Directory not empty")
+			}
+		}
+		c.CrushHosts = slices.DeleteFunc(c.CrushHosts, func(host string) bool { return host == name })
+		c.bump()
+		return "", nil
+	case "reweight":
+		id, err := parseOSD(cmd.arg(3))
+		if err != nil {
+			return "", err
+		}
+		osd := c.osd(id)
+		if osd == nil {
+			return "", errNoEntity("osd", cmd.arg(3))
+		}
+		osd.CrushWeight, err = strconv.ParseFloat(cmd.arg(4), 64)
+		if err != nil {
+			return "", fmt.Errorf("Error EINVAL: invalid weight '%s'", cmd.arg(4))
+		}
+		c.bump()
+		return "", nil
+	case "rule":
+		return runCrushRule(c, cmd)
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// runCrushRule runs a ceph osd crush rule command.
+func runCrushRule(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(3) {
+	case "ls":
+		names := []string{}
+		for _, rule := range c.Rules {
+			names = append(names, rule.Name)
+		}
+		return strings.Join(names, "\n") + "\n", nil
+	case "dump":
+		rule := c.rule(cmd.arg(4))
+		if rule == nil {
+			return "", errNoEntity("rule", cmd.arg(4))
+		}
+		return toJSON(map[string]any{
+			"rule_id": rule.ID, "rule_name": rule.Name, "type": 1,
+			"steps": []map[string]any{
+				{"op": "take", "item_name": rule.Root},
+				{"op": "chooseleaf_firstn", "num": 0, "type": rule.FailureDomain},
+				{"op": "emit"},
+			},
+		})
+	case "create-replicated":
+		if c.rule(cmd.arg(4)) != nil {
+			return "", nil
+		}
+		id := 0
+		for _, rule := range c.Rules {
+			id = max(id, rule.ID+1)
+		}
+		c.Rules = append(c.Rules, Rule{ID: id, Name: cmd.arg(4), Root: cmd.arg(5), FailureDomain: cmd.arg(6)})
+		c.bump()
+		return "", nil
+	case "rm":
+		name := cmd.arg(4)
+		rule := c.rule(name)
+		if rule == nil {
+			return "", nil
+		}
+		for _, pool := range c.Pools {
+			if pool.CrushRule == rule.ID {
+				return "", fmt.Errorf("Error EBUSY: crush rule %s in use by pool %s", name, pool.Name)
+			}
+		}
+		c.Rules = slices.DeleteFunc(c.Rules, func(r Rule) bool { return r.Name == name })
+		c.bump()
+		return "", nil
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// poolDetails returns the pools as listed by ceph osd pool ls detail.
+func poolDetails(c *Cluster) []map[string]any {
+	pools := []map[string]any{}
+	for _, pool := range c.Pools {
+		apps := map[string]any{}
+		for app, meta := range pool.Applications {
+			apps[app] = meta
+		}
+
+		pools = append(pools, map[string]any{
+			"pool_id": pool.ID, "pool_name": pool.Name, "size": pool.Size, "min_size": pool.MinSize,
+			"crush_rule": pool.CrushRule, "pg_num": pgsPerPool, "application_metadata": apps,
+		})
+	}
+
+	return pools
+}
+
+// createPool creates a pool with the default size and CRUSH rule of the cluster.
+func (c *Cluster) createPool(name string) *Pool {
+	size := int64(3)
+	value, ok := c.Config["global"]["osd_pool_default_size"]
+	if ok {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			size = parsed
+		}
+	}
+
+	rule := 0
+	value, ok = c.Config["global"]["osd_pool_default_crush_rule"]
+	if ok {
+		parsed, err := strconv.Atoi(value)
+		if err == nil {
+			rule = parsed
+		}
+	}
+
+	pool := &Pool{
+		ID:           c.NextPoolID,
+		Name:         name,
+		Size:         size,
+		MinSize:      size - size/2,
+		CrushRule:    rule,
+		Applications: map[string]map[string]string{},
+		MirrorMode:   "disabled",
+	}
+	c.NextPoolID++
+	c.Pools = append(c.Pools, pool)
+	c.bump()
+
+	return pool
+}
+
+// runPool runs a ceph osd pool command.
+func runPool(c *Cluster, cmd command) (string, error) {
+	name := cmd.arg(3)
+
+	switch cmd.arg(2) {
+	case "ls":
+		if cmd.arg(3) == "detail" {
+			return toJSON(poolDetails(c))
+		}
+		names := []string{}
+		for _, pool := range c.Pools {
+			names = append(names, pool.Name)
+		}
+		return toJSON(names)
+	case "create":
+		if c.pool(name) == nil {
+			c.createPool(name)
+		}
+		return fmt.Sprintf("pool '%s' created\n", name), nil
+	case "application":
+		if cmd.arg(3) != "enable" {
+			return "", errUnsupported(cmd)
+		}
+		name, app := cmd.arg(4), cmd.arg(5)
+		pool := c.pool(name)
+		if pool == nil {
+			return "", errNoEntity("pool", name)
+		}
+		if pool.Applications[app] == nil {
+			pool.Applications[app] = map[string]string{}
+		}
+		return fmt.Sprintf("enabled application '%s' on pool '%s'\n", app, name), nil
+	case "rm", "delete":
+		if c.pool(name) == nil {
+			return "", errNoEntity("pool", name)
+		}
+		c.Pools = slices.DeleteFunc(c.Pools, func(p *Pool) bool { return p.Name == name })
+		c.bump()
+		return "", nil
+	}
+
+	pool := c.pool(name)
+	if pool == nil {
+		return "", errNoEntity("pool", name)
+	}
+
+	switch cmd.arg(2) {
+	case "get":
+		return toJSON(map[string]any{
+			"pool": pool.Name, "pool_id": pool.ID, "size": pool.Size, "min_size": pool.MinSize,
+			"crush_rule": c.ruleName(pool.CrushRule), "pg_num": pgsPerPool,
+		})
+	case "set":
+		return poolSet(c, pool, cmd.arg(4), cmd.arg(5))
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// poolSet sets a pool property.
+func poolSet(c *Cluster, pool *Pool, key string, value string) (string, error) {
+	switch key {
+	case "size", "min_size":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("Error EINVAL: invalid %s '%s'", key, value)
+		}
+		if key == "size" {
+			if n == 1 && c.Config["global"]["mon_allow_pool_size_one"] != "true" {
+				return "", fmt.Errorf("Error EPERM: configuring pool size as 1 is disabled by default")
+			}
+			pool.Size = n
+			pool.MinSize = min(pool.MinSize, n)
+		} else {
+			pool.MinSize = n
+		}
+	case "crush_rule":
+		rule := c.rule(value)
+		if rule == nil {
+			return "", errNoEntity("crush rule", value)
+		}
+		pool.CrushRule = rule.ID
+	}
+
+	c.bump()
+	return fmt.Sprintf("set pool %d %s to %s\n", pool.ID, key, value), nil
+}
+
+// runAuth runs a ceph auth command.
+func runAuth(c *Cluster, cmd command) (string, error) {
+	name := cmd.arg(2)
+
+	switch cmd.arg(1) {
+	case "get-or-create", "get-or-create-key":
+		entity, ok := c.Auth[name]
+		if !ok {
+			entity = &Auth{Key: genKey(), Caps: map[string]string{}}
+			for i := 3; i+1 < len(cmd.args); i += 2 {
+				entity.Caps[cmd.args[i]] = cmd.args[i+1]
+			}
+			c.Auth[name] = entity
+		}
+		return writeOutput(cmd, formatKeyring(name, entity))
+	case "get":
+		entity, ok := c.Auth[name]
+		if !ok {
+			return "", errNoEntity("entity", name)
+		}
+		return writeOutput(cmd, formatKeyring(name, entity))
+	case "print-key", "print_key":
+		entity, ok := c.Auth[name]
+		if !ok {
+			return "", errNoEntity("entity", name)
+		}
+		return entity.Key + "\n", nil
+	case "import":
+		keyring, err := os.ReadFile(cmd.opt("-i"))
+		if err != nil {
+			return "", err
+		}
+		for name, entity := range parseKeyring(string(keyring)) {
+			c.Auth[name] = entity
+		}
+		return "", nil
+	case "del", "rm":
+		delete(c.Auth, name)
+		return "", nil
+	case "ls", "list":
+		names := []string{}
+		for name := range c.Auth {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		dump := []map[string]any{}
+		for _, name := range names {
+			dump = append(dump, map[string]any{"entity": name, "key": c.Auth[name].Key, "caps": c.Auth[name].Caps})
+		}
+		return toJSON(map[string]any{"auth_dump": dump})
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// runConfig runs a ceph config command.
+func runConfig(c *Cluster, cmd command) (string, error) {
+	who, key := cmd.arg(2), cmd.arg(3)
+
+	switch cmd.arg(1) {
+	case "set":
+		if c.Config[who] == nil {
+			c.Config[who] = map[string]string{}
+		}
+		c.Config[who][key] = cmd.arg(4)
+		return "", nil
+	case "get":
+		// Fall back to the global section, unset options read as empty.
+		value, ok := c.Config[who][key]
+		if !ok {
+			value = c.Config["global"][key]
+		}
+		return value + "\n", nil
+	case "rm":
+		delete(c.Config[who], key)
+		return "", nil
+	case "help":
+		// There is no option schema to simulate, every option reads as a string updated at runtime.
+		return toJSON(map[string]any{"name": who, "type": "str", "services": []string{}, "can_update_at_runtime": true, "flags": []string{}})
+	case "dump":
+		sections := []string{}
+		for section := range c.Config {
+			sections = append(sections, section)
+		}
+		sort.Strings(sections)
+
+		dump := []map[string]string{}
+		for _, section := range sections {
+			keys := []string{}
+			for key := range c.Config[section] {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				dump = append(dump, map[string]string{"section": section, "name": key, "value": c.Config[section][key]})
+			}
+		}
+		return toJSON(dump)
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// runConfigKey runs a ceph config-key command.
+func runConfigKey(c *Cluster, cmd command) (string, error) {
+	key := cmd.arg(2)
+
+	switch cmd.arg(1) {
+	case "set", "put":
+		c.ConfigKeys[key] = cmd.arg(3)
+		return "", nil
+	case "get":
+		value, ok := c.ConfigKeys[key]
+		if !ok {
+			return "", fmt.Errorf("Error ENOENT: error obtaining '%s': (2) No such file or directory", key)
+		}
+		return writeOutput(cmd, value)
+	case "exists":
+		_, ok := c.ConfigKeys[key]
+		if !ok {
+			return "", fmt.Errorf("Error ENOENT: key '%s' doesn't exist", key)
+		}
+		return fmt.Sprintf("key '%s' exists\n", key), nil
+	case "rm", "del":
+		delete(c.ConfigKeys, key)
+		return "", nil
+	case "ls", "list":
+		keys := []string{}
+		for key := range c.ConfigKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return toJSON(keys)
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// newOSD adds an OSD of the given host to the OSD map.
+func (c *Cluster) newOSD(id int64, host string) *OSD {
+	osd := c.osd(id)
+	if osd == nil {
+		osd = &OSD{ID: id, UUID: uuid.NewRandom().String()}
+		c.OSDs = append(c.OSDs, osd)
+		sort.Slice(c.OSDs, func(i, j int) bool { return c.OSDs[i].ID < c.OSDs[j].ID })
+	}
+
+	osd.Host = host
+	osd.In = true
+	osd.Destroyed = false
+	osd.CrushWeight = 1
+	osd.Up = c.running(host, "osd")
+	c.addCrushHost(host)
+	c.bump()
+
+	return osd
+}
@@ -0,0 +1,513 @@
+package simulate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+// peerToken is the content of a simulated rbd-mirror peer bootstrap token.
+type peerToken struct {
+	Cluster    string `json:"cluster"`
+	FSID       string `json:"fsid"`
+	SiteName   string `json:"site_name"`
+	MirrorUUID string `json:"mirror_uuid"`
+}
+
+// errNoPool returns the error rbd reports for a missing pool.
+func errNoPool(name string) error {
+	return fmt.Errorf("rbd: error opening pool '%s': (2) No such file or directory", name)
+}
+
+// splitSpec splits an image spec of the form pool/image, the pool may also be given with --pool.
+func splitSpec(cmd command, spec string) (string, string) {
+	pool, image, found := strings.Cut(spec, "/")
+	if !found {
+		return cmd.opt("--pool", "-p"), spec
+	}
+
+	return pool, image
+}
+
+// image returns the image of the given name, nil if there is none.
+func (p *Pool) image(name string) *Image {
+	for _, image := range p.Images {
+		if image.Name == name {
+			return image
+		}
+	}
+
+	return nil
+}
+
+// mirrored checks if the image is mirrored in a pool of the given mirroring mode.
+func (i *Image) mirrored(mode string) bool {
+	switch mode {
+	case "pool":
+		return slices.Contains(i.Features, "journaling")
+	case "image":
+		return len(i.Mirror) != 0
+	}
+
+	return false
+}
+
+// runRbd runs an rbd command.
+func runRbd(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(0) {
+	case "ls", "list":
+		pool := c.pool(cmd.arg(1))
+		if pool == nil {
+			return "", errNoPool(cmd.arg(1))
+		}
+		names := []string{}
+		for _, image := range pool.Images {
+			names = append(names, image.Name)
+		}
+		return toJSON(names)
+	case "create", "rm", "remove", "info", "feature":
+		return rbdImage(c, cmd)
+	case "mirror":
+		return rbdMirror(c, cmd)
+	case "group":
+		return rbdGroup(c, cmd)
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// rbdImage runs an rbd command on an image.
+func rbdImage(c *Cluster, cmd command) (string, error) {
+	spec := cmd.arg(1)
+	if cmd.arg(0) == "feature" {
+		spec = cmd.arg(2)
+	}
+
+	poolName, name := splitSpec(cmd, spec)
+	pool := c.pool(poolName)
+	if pool == nil {
+		return "", errNoPool(poolName)
+	}
+
+	image := pool.image(name)
+	if cmd.arg(0) == "create" {
+		if image != nil {
+			return "", fmt.Errorf("rbd: create error: (17) File exists")
+		}
+		pool.Images = append(pool.Images, &Image{
+			Name:     name,
+			GlobalID: uuid.NewRandom().String(),
+			Features: []string{"layering", "exclusive-lock", "object-map", "fast-diff", "deep-flatten"},
+			Primary:  true,
+		})
+		return "", nil
+	}
+
+	if image == nil {
+		return "", fmt.Errorf("rbd: error opening image %s: (2) No such file or directory", name)
+	}
+
+	switch cmd.arg(0) {
+	case "rm", "remove":
+		pool.Images = slices.DeleteFunc(pool.Images, func(i *Image) bool { return i.Name == name })
+		return "", nil
+	case "info":
+		return toJSON(map[string]any{"name": image.Name, "features": image.Features, "mirroring": map[string]any{"mode": image.Mirror, "global_id": image.GlobalID, "primary": image.Primary}})
+	}
+
+	feature := cmd.arg(3)
+	enabled := slices.Contains(image.Features, feature)
+	switch cmd.arg(1) {
+	case "enable":
+		if enabled {
+			return "", fmt.Errorf("rbd: failed to update image features: (22) Invalid argument: one or more requested features are already enabled")
+		}
+		image.Features = append(image.Features, feature)
+		if feature == "journaling" && pool.MirrorMode == "pool" {
+			mirrorImage(cmd, pool, image)
+		}
+	case "disable":
+		if !enabled {
+			return "", fmt.Errorf("rbd: failed to update image features: (22) Invalid argument: one or more requested features are already disabled")
+		}
+		image.Features = slices.DeleteFunc(image.Features, func(f string) bool { return f == feature })
+	default:
+		return "", errUnsupported(cmd)
+	}
+
+	return "", nil
+}
+
+// mirrorImage creates the non-primary copies of a mirrored image on the peers of its pool, as rbd-mirror would.
+func mirrorImage(cmd command, pool *Pool, image *Image) {
+	replica := *image
+	replica.Primary = false
+	replica.Features = slices.Clone(image.Features)
+
+	for _, peer := range pool.Peers {
+		cmd.deferUpdate(peer.Cluster, func(c *Cluster) error {
+			remote := c.pool(pool.Name)
+			if remote == nil || remote.image(replica.Name) != nil {
+				return nil
+			}
+
+			remote.Images = append(remote.Images, &replica)
+			return nil
+		})
+	}
+}
+
+// rbdMirror runs an rbd mirror command.
+func rbdMirror(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(1) {
+	case "pool":
+		return rbdMirrorPool(c, cmd)
+	case "image":
+		return rbdMirrorImage(c, cmd)
+	case "snapshot":
+		return rbdMirrorSchedule(c, cmd)
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// rbdMirrorPool runs an rbd mirror pool command.
+func rbdMirrorPool(c *Cluster, cmd command) (string, error) {
+	if cmd.arg(2) == "peer" {
+		return rbdMirrorPeer(c, cmd)
+	}
+
+	pool := c.pool(cmd.arg(3))
+	if pool == nil {
+		return "", errNoPool(cmd.arg(3))
+	}
+
+	switch cmd.arg(2) {
+	case "enable":
+		pool.MirrorMode = cmd.arg(4)
+		return "", nil
+	case "disable":
+		pool.MirrorMode = "disabled"
+		pool.Peers = nil
+		return "", nil
+	case "info":
+		peers := []map[string]any{}
+		for _, peer := range pool.Peers {
+			peers = append(peers, map[string]any{
+				"uuid": peer.UUID, "mirror_uuid": peer.MirrorUUID, "site_name": peer.SiteName,
+				"direction": peer.Direction, "client_name": "client.rbd-mirror-peer",
+			})
+		}
+		return toJSON(map[string]any{"mode": pool.MirrorMode, "site_name": c.SiteName, "peers": peers})
+	case "status":
+		return rbdMirrorPoolStatus(c, cmd, pool)
+	case "promote", "demote":
+		for _, image := range pool.Images {
+			if image.mirrored(pool.MirrorMode) {
+				image.Primary = cmd.arg(2) == "promote"
+			}
+		}
+		return "", nil
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// imageStatus returns the mirroring status of an image.
+func imageStatus(pool *Pool, image *Image) map[string]any {
+	now := time.Now().Format(time.DateTime)
+	state, description := "up+replaying", "replaying"
+	if image.Primary {
+		state, description = "up+stopped", "local image is primary"
+	}
+
+	peers := []map[string]any{}
+	for _, peer := range pool.Peers {
+		peerState, peerDescription := "up+stopped", "local image is primary"
+		if image.Primary {
+			peerState, peerDescription = "up+replaying", "replaying"
+		}
+		peers = append(peers, map[string]any{
+			"site_name": peer.SiteName, "mirror_uuids": peer.MirrorUUID, "state": peerState,
+			"description": peerDescription, "last_update": now,
+		})
+	}
+
+	return map[string]any{
+		"name": image.Name, "global_id": image.GlobalID, "state": state,
+		"description": description, "last_update": now, "peer_sites": peers,
+	}
+}
+
+// rbdMirrorPoolStatus returns the mirroring status of a pool.
+func rbdMirrorPoolStatus(c *Cluster, cmd command, pool *Pool) (string, error) {
+	if pool.MirrorMode == "disabled" {
+		return "", fmt.Errorf("rbd: mirroring not enabled on the pool")
+	}
+
+	// Nothing replicates without an rbd-mirror daemon.
+	daemonHealth := "OK"
+	if len(c.runningHosts("rbd-mirror")) == 0 {
+		daemonHealth = "WARNING"
+	}
+
+	states := map[string]int{}
+	images := []map[string]any{}
+	for _, image := range pool.Images {
+		if !image.mirrored(pool.MirrorMode) {
+			continue
+		}
+
+		status := imageStatus(pool, image)
+		states[strings.TrimPrefix(status["state"].(string), "up+")]++
+		images = append(images, status)
+	}
+
+	out := map[string]any{
+		"summary": map[string]any{"health": daemonHealth, "daemon_health": daemonHealth, "image_health": "OK", "states": states},
+	}
+	if cmd.flag("--verbose") {
+		out["images"] = images
+	}
+
+	return toJSON(out)
+}
+
+// rbdMirrorPeer runs an rbd mirror pool peer command.
+func rbdMirrorPeer(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(3) {
+	case "bootstrap":
+		return rbdMirrorBootstrap(c, cmd)
+	case "remove":
+		pool := c.pool(cmd.arg(4))
+		if pool == nil {
+			return "", errNoPool(cmd.arg(4))
+		}
+		pool.Peers = slices.DeleteFunc(pool.Peers, func(p Peer) bool { return p.UUID == cmd.arg(5) })
+		return "", nil
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// rbdMirrorBootstrap creates or imports an rbd-mirror peer bootstrap token.
+// Importing a token sets up the peers on both clusters.
+func rbdMirrorBootstrap(c *Cluster, cmd command) (string, error) {
+	poolName := cmd.arg(5)
+	pool := c.pool(poolName)
+	if pool == nil {
+		return "", errNoPool(poolName)
+	}
+
+	site := cmd.opt("--site-name")
+	if len(site) != 0 {
+		c.SiteName = site
+	}
+
+	switch cmd.arg(4) {
+	case "create":
+		data, err := json.Marshal(peerToken{Cluster: cmd.cluster, FSID: c.FSID, SiteName: c.SiteName, MirrorUUID: c.MirrorUUID})
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(data), nil
+	case "import":
+		encoded, err := os.ReadFile(cmd.arg(6))
+		if err != nil {
+			return "", err
+		}
+
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil {
+			return "", fmt.Errorf("rbd: failed to decode peer bootstrap token: %w", err)
+		}
+
+		token := peerToken{}
+		err = json.Unmarshal(data, &token)
+		if err != nil {
+			return "", fmt.Errorf("rbd: failed to decode peer bootstrap token: %w", err)
+		}
+
+		direction := cmd.opt("--direction")
+		if len(direction) == 0 {
+			direction = "rx-tx"
+		}
+
+		addPeer(pool, Peer{MirrorUUID: token.MirrorUUID, SiteName: token.SiteName, Direction: direction, Cluster: token.Cluster})
+		if direction == "rx-tx" {
+			local := Peer{MirrorUUID: c.MirrorUUID, SiteName: c.SiteName, Direction: direction, Cluster: cmd.cluster}
+			cmd.deferUpdate(token.Cluster, func(remote *Cluster) error {
+				remotePool := remote.pool(poolName)
+				if remotePool != nil {
+					addPeer(remotePool, local)
+				}
+				return nil
+			})
+		}
+		return "", nil
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// addPeer adds a peer to a pool unless a peer of the same site exists.
+func addPeer(pool *Pool, peer Peer) {
+	if slices.ContainsFunc(pool.Peers, func(p Peer) bool { return p.SiteName == peer.SiteName }) {
+		return
+	}
+
+	peer.UUID = uuid.NewRandom().String()
+	pool.Peers = append(pool.Peers, peer)
+}
+
+// rbdMirrorImage runs an rbd mirror image command.
+func rbdMirrorImage(c *Cluster, cmd command) (string, error) {
+	poolName, name := splitSpec(cmd, cmd.arg(3))
+	pool := c.pool(poolName)
+	if pool == nil {
+		return "", errNoPool(poolName)
+	}
+
+	image := pool.image(name)
+	if image == nil {
+		return "", fmt.Errorf("rbd: error opening image %s: (2) No such file or directory", name)
+	}
+
+	switch cmd.arg(2) {
+	case "enable":
+		mode := cmd.arg(4)
+		if len(mode) == 0 {
+			mode = "journal"
+		}
+		if mode == "journal" && !slices.Contains(image.Features, "journaling") {
+			image.Features = append(image.Features, "journaling")
+		}
+		image.Mirror = mode
+		image.Primary = true
+		mirrorImage(cmd, pool, image)
+	case "disable":
+		image.Mirror = ""
+	case "status":
+		if !image.mirrored(pool.MirrorMode) {
+			return "", fmt.Errorf("rbd: mirroring not enabled on the image")
+		}
+		return toJSON(imageStatus(pool, image))
+	case "snapshot":
+		image.Snapshots++
+		return "Snapshot ID: " + fmt.Sprint(image.Snapshots) + "\n", nil
+	case "promote", "demote":
+		image.Primary = cmd.arg(2) == "promote"
+	case "resync":
+	default:
+		return "", errUnsupported(cmd)
+	}
+
+	return "", nil
+}
+
+// rbdMirrorSchedule runs an rbd mirror snapshot schedule command.
+func rbdMirrorSchedule(c *Cluster, cmd command) (string, error) {
+	if cmd.arg(2) != "schedule" {
+		return "", errUnsupported(cmd)
+	}
+
+	poolName := cmd.opt("--pool", "-p")
+	pool := c.pool(poolName)
+	if pool == nil {
+		return "", errNoPool(poolName)
+	}
+
+	schedules := &pool.Schedules
+	if name := cmd.opt("--image"); len(name) != 0 {
+		image := pool.image(name)
+		if image == nil {
+			return "", fmt.Errorf("rbd: error opening image %s: (2) No such file or directory", name)
+		}
+		schedules = &image.Schedules
+	}
+
+	switch cmd.arg(3) {
+	case "add":
+		*schedules = append(*schedules, Schedule{Interval: cmd.arg(4), StartTime: cmd.arg(5)})
+	case "rm", "remove":
+		*schedules = slices.DeleteFunc(*schedules, func(s Schedule) bool { return len(cmd.arg(4)) == 0 || s.Interval == cmd.arg(4) })
+	case "ls", "list":
+		// rbd prints nothing when there is no schedule.
+		if len(*schedules) == 0 {
+			return "", nil
+		}
+		return toJSON(*schedules)
+	default:
+		return "", errUnsupported(cmd)
+	}
+
+	return "", nil
+}
+
+// rbdGroup runs an rbd group command, groups are not mirrored by the simulation.
+func rbdGroup(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(1) {
+	case "list", "ls":
+		pool := c.pool(cmd.arg(2))
+		if pool == nil {
+			return "", errNoPool(cmd.arg(2))
+		}
+		return toJSON(append([]string{}, pool.Groups...))
+	case "create":
+		poolName, name := splitSpec(cmd, cmd.arg(2))
+		pool := c.pool(poolName)
+		if pool == nil {
+			return "", errNoPool(poolName)
+		}
+		if !slices.Contains(pool.Groups, name) {
+			pool.Groups = append(pool.Groups, name)
+		}
+		return "", nil
+	}
+
+	return "", errUnsupported(cmd)
+}
+
+// runRados runs a rados command.
+func runRados(c *Cluster, cmd command) (string, error) {
+	poolName := cmd.opt("--pool", "-p")
+	pool := c.pool(poolName)
+	if pool == nil {
+		if !cmd.flag("--create") {
+			return "", fmt.Errorf("error opening pool %s: (2) No such file or directory", poolName)
+		}
+		pool = c.createPool(poolName)
+	}
+
+	switch cmd.arg(0) {
+	case "ls":
+		var sb strings.Builder
+		for _, object := range pool.Objects {
+			ns, name, _ := strings.Cut(object, "/")
+			if cmd.flag("--all") || ns == cmd.opt("-N", "--namespace") {
+				fmt.Fprintln(&sb, name)
+			}
+		}
+		return sb.String(), nil
+	case "create", "rm":
+		object := fmt.Sprintf("%s/%s", cmd.opt("-N", "--namespace"), cmd.arg(1))
+		exists := slices.Contains(pool.Objects, object)
+		if cmd.arg(0) == "rm" {
+			pool.Objects = slices.DeleteFunc(pool.Objects, func(o string) bool { return o == object })
+			return "", nil
+		}
+		if exists {
+			return "", fmt.Errorf("error creating %s/%s: (17) File exists", poolName, cmd.arg(1))
+		}
+		pool.Objects = append(pool.Objects, object)
+		return "", nil
+	}
+
+	return "", errUnsupported(cmd)
+}
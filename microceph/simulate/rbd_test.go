@@ -0,0 +1,65 @@
+package simulate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestMirrorBootstrap(t *testing.T) {
+	dir := t.TempDir()
+	siteA := newTestRunner(t, dir, "site-a", "node0")
+	siteB := newTestRunner(t, dir, "site-b", "node1")
+
+	for _, r := range []*Runner{siteA, siteB} {
+		run(t, r, "ceph", "osd", "pool", "create", "rbd")
+		run(t, r, "rbd", "mirror", "pool", "enable", "rbd", "image")
+	}
+
+	// Importing the token of site-a on site-b peers the pools both ways.
+	token := run(t, siteA, "rbd", "mirror", "pool", "peer", "bootstrap", "create", "--site-name", "site-a", "rbd")
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(token), 0600))
+	run(t, siteB, "rbd", "mirror", "pool", "peer", "bootstrap", "import", "--site-name", "site-b", "--direction", "rx-tx", "rbd", tokenPath)
+
+	info := run(t, siteB, "rbd", "mirror", "pool", "info", "rbd", "--format", "json")
+	assert.Equal(t, `["site-a"]`, gjson.Get(info, "peers.#.site_name").Raw)
+	info = run(t, siteA, "rbd", "mirror", "pool", "info", "rbd", "--format", "json")
+	assert.Equal(t, `["site-b"]`, gjson.Get(info, "peers.#.site_name").Raw)
+
+	// Enabling mirroring on an image replicates it to the peer as non primary.
+	run(t, siteA, "rbd", "create", "--size", "1G", "rbd/disk")
+	run(t, siteA, "rbd", "mirror", "image", "enable", "rbd/disk", "snapshot")
+	assert.True(t, gjson.Get(run(t, siteB, "rbd", "info", "rbd/disk", "--format", "json"), "mirroring.primary").Exists())
+	assert.False(t, gjson.Get(run(t, siteB, "rbd", "info", "rbd/disk", "--format", "json"), "mirroring.primary").Bool())
+
+	// Images only replay while an rbd-mirror daemon runs.
+	status := run(t, siteB, "rbd", "mirror", "pool", "status", "rbd", "--format", "json")
+	assert.Equal(t, "WARNING", gjson.Get(status, "summary.daemon_health").String())
+	run(t, siteB, "snapctl", "start", "microceph.rbd-mirror")
+	status = run(t, siteB, "rbd", "mirror", "pool", "status", "rbd", "--verbose", "--format", "json")
+	assert.Equal(t, "OK", gjson.Get(status, "summary.daemon_health").String())
+	assert.Equal(t, "up+replaying", gjson.Get(status, "images.0.state").String())
+
+	// Schedules list nothing until one is added.
+	assert.Empty(t, run(t, siteA, "rbd", "mirror", "snapshot", "schedule", "ls", "--pool", "rbd", "--image", "disk", "--format", "json"))
+	run(t, siteA, "rbd", "mirror", "snapshot", "schedule", "add", "--pool", "rbd", "--image", "disk", "1h")
+	assert.Equal(t, "1h", gjson.Get(run(t, siteA, "rbd", "mirror", "snapshot", "schedule", "ls", "--pool", "rbd", "--image", "disk", "--format", "json"), "0.interval").String())
+}
+
+func TestRadosObjects(t *testing.T) {
+	r := newTestRunner(t, t.TempDir(), "", "node0")
+	run(t, r, "ceph", "osd", "pool", "create", ".nfs")
+
+	run(t, r, "rados", "create", "-p", ".nfs", "-N", "ganesha", "conf-node0")
+	_, err := r.RunCommand("rados", "create", "-p", ".nfs", "-N", "ganesha", "conf-node0")
+	assert.ErrorContains(t, err, "File exists")
+	assert.Equal(t, "conf-node0\n", run(t, r, "rados", "ls", "-p", ".nfs", "-N", "ganesha"))
+
+	run(t, r, "rados", "rm", "-p", ".nfs", "-N", "ganesha", "conf-node0")
+	assert.Empty(t, run(t, r, "rados", "ls", "-p", ".nfs", "-N", "ganesha"))
+}
@@ -0,0 +1,329 @@
+// Package simulate provides a stateful stand-in for the Ceph and system commands MicroCeph runs,
+// so clusters can be bootstrapped and exercised without Ceph daemons or disks.
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/canonical/lxd/shared"
+
+	"github.com/canonical/microceph/microceph/logger"
+)
+
+// DefaultCluster is the name of the local simulated cluster.
+const DefaultCluster = "ceph"
+
+// Runner runs commands against a simulated cluster instead of launching processes.
+// The cluster state lives in a file so the daemons of several members can share it.
+type Runner struct {
+	// Dir holds the state files, one per simulated cluster.
+	Dir string
+	// Cluster names the local cluster, remote clusters are addressed with --cluster.
+	Cluster string
+
+	mu   sync.Mutex
+	host string
+}
+
+// NewRunner returns a runner keeping the state of its clusters in dir.
+func NewRunner(dir string, cluster string) (*Runner, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create simulation directory %s: %w", dir, err)
+	}
+
+	if len(cluster) == 0 {
+		cluster = DefaultCluster
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{Dir: dir, Cluster: cluster, host: host}, nil
+}
+
+// SetHost sets the member the local daemons run on.
+func (r *Runner) SetHost(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.host = host
+}
+
+// Host returns the member the local daemons run on.
+func (r *Runner) Host() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.host
+}
+
+// RunCommand runs a simulated command.
+func (r *Runner) RunCommand(name string, arg ...string) (string, error) {
+	return r.RunCommandContext(context.Background(), name, arg...)
+}
+
+// RunCommandContext runs a simulated command, the context is only checked before the command runs.
+func (r *Runner) RunCommandContext(ctx context.Context, name string, arg ...string) (string, error) {
+	err := ctx.Err()
+	if err != nil {
+		return "", shared.NewRunError(name, arg, err, &bytes.Buffer{}, &bytes.Buffer{})
+	}
+
+	cmd := parseArgs(filepath.Base(name), arg)
+	cmd.host = r.Host()
+	cmd.cluster = cmd.opt("--cluster")
+	if len(cmd.cluster) == 0 {
+		cmd.cluster = r.Cluster
+	}
+
+	// Changes to other clusters are applied once the lock on this one is released.
+	deferred := []deferredUpdate{}
+	cmd.deferred = &deferred
+
+	out, err := r.update(cmd.cluster, func(c *Cluster) (string, error) { return dispatch(c, cmd) })
+	for _, d := range deferred {
+		if err != nil {
+			break
+		}
+
+		if len(d.cluster) == 0 {
+			continue
+		}
+
+		_, err = r.update(d.cluster, func(c *Cluster) (string, error) { return "", d.fn(c) })
+	}
+	if err != nil {
+		logger.Debugf("SIMULATE: %s %s: %v", name, strings.Join(arg, " "), err)
+		return "", shared.NewRunError(name, arg, err, &bytes.Buffer{}, bytes.NewBufferString(err.Error()))
+	}
+
+	logger.Debugf("SIMULATE: %s %s", name, strings.Join(arg, " "))
+	return out, nil
+}
+
+// statePath returns the state file of a cluster.
+func (r *Runner) statePath(cluster string) string {
+	return filepath.Join(r.Dir, fmt.Sprintf("%s.json", cluster))
+}
+
+// update runs fn on the state of a cluster under an exclusive lock, saving the state if fn succeeds.
+func (r *Runner) update(cluster string, fn func(c *Cluster) (string, error)) (string, error) {
+	lock, err := os.OpenFile(filepath.Join(r.Dir, fmt.Sprintf("%s.lock", cluster)), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to open lock of cluster %s: %w", cluster, err)
+	}
+	defer lock.Close()
+
+	err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		return "", fmt.Errorf("failed to lock cluster %s: %w", cluster, err)
+	}
+	defer func() { _ = syscall.Flock(int(lock.Fd()), syscall.LOCK_UN) }()
+
+	c, err := r.load(cluster)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := fn(c)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := r.statePath(cluster)
+	err = os.WriteFile(path+".tmp", data, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return out, os.Rename(path+".tmp", path)
+}
+
+// load reads the state of a cluster, a cluster without state starts out empty.
+func (r *Runner) load(cluster string) (*Cluster, error) {
+	data, err := os.ReadFile(r.statePath(cluster))
+	if errors.Is(err, os.ErrNotExist) {
+		return newCluster(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read state of cluster %s: %w", cluster, err)
+	}
+
+	c := newCluster()
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state of cluster %s: %w", cluster, err)
+	}
+
+	return c, nil
+}
+
+// valueOpts lists the options taking a value, all other options are flags.
+var valueOpts = []string{
+	"-f", "--format", "-o", "-i", "-n", "-N", "--cluster", "--id", "--pool", "--image", "--group",
+	"--site-name", "--direction", "--cephconf", "--ns", "--userid", "--mon-data", "--monmap",
+	"--keyring", "--fsid", "--bluestore-block-wal-path", "--bluestore-block-db-path", "--dev",
+	"--path", "--devs-source", "--dev-target", "--source", "--size", "-s", "-p", "--namespace",
+}
+
+// deferredUpdate is a change to another cluster made by a command.
+type deferredUpdate struct {
+	cluster string
+	fn      func(c *Cluster) error
+}
+
+// command is a parsed command line.
+type command struct {
+	name string
+	args []string
+	opts map[string][]string
+	// host is the member running the command.
+	host string
+	// cluster is the cluster the command runs against.
+	cluster  string
+	deferred *[]deferredUpdate
+}
+
+// deferUpdate queues a change to another cluster.
+func (c command) deferUpdate(cluster string, fn func(c *Cluster) error) {
+	*c.deferred = append(*c.deferred, deferredUpdate{cluster: cluster, fn: fn})
+}
+
+// parseArgs splits a command line into its positional arguments and options.
+func parseArgs(name string, arg []string) command {
+	cmd := command{name: name, opts: map[string][]string{}}
+	for i := 0; i < len(arg); i++ {
+		a := arg[i]
+		if len(a) < 2 || a[0] != '-' || isNumber(a) {
+			cmd.args = append(cmd.args, a)
+			continue
+		}
+
+		key, value, found := strings.Cut(a, "=")
+		if found {
+			cmd.opts[key] = append(cmd.opts[key], value)
+			continue
+		}
+
+		// Options given without a value are flags, e.g. ceph -s.
+		if shared.ValueInSlice(a, valueOpts) && i+1 < len(arg) && (!strings.HasPrefix(arg[i+1], "-") || isNumber(arg[i+1])) {
+			cmd.opts[a] = append(cmd.opts[a], arg[i+1])
+			i++
+			continue
+		}
+
+		cmd.opts[a] = append(cmd.opts[a], "")
+	}
+
+	return cmd
+}
+
+// isNumber checks if an argument is a number rather than an option, e.g. a negative config value.
+func isNumber(a string) bool {
+	_, err := strconv.ParseFloat(a, 64)
+	return err == nil
+}
+
+// opt returns the last value of an option, any of the given aliases.
+func (c command) opt(names ...string) string {
+	for _, name := range names {
+		values := c.opts[name]
+		if len(values) != 0 {
+			return values[len(values)-1]
+		}
+	}
+
+	return ""
+}
+
+// flag checks if a flag is set.
+func (c command) flag(name string) bool {
+	_, ok := c.opts[name]
+	return ok
+}
+
+// arg returns the positional argument at the given index, empty if missing.
+func (c command) arg(i int) string {
+	if i < len(c.args) {
+		return c.args[i]
+	}
+
+	return ""
+}
+
+// dispatch runs a command against the cluster state.
+func dispatch(c *Cluster, cmd command) (string, error) {
+	switch cmd.name {
+	case "ceph":
+		return runCeph(c, cmd)
+	case "rbd":
+		return runRbd(c, cmd)
+	case "rados":
+		return runRados(c, cmd)
+	case "ceph-authtool":
+		return runAuthtool(c, cmd)
+	case "monmaptool":
+		return runMonmaptool(cmd)
+	case "ceph-mon":
+		return runCephMon(c, cmd)
+	case "ceph-osd":
+		return runCephOSD(c, cmd)
+	case "snapctl":
+		return runSnapctl(c, cmd)
+	case "systemctl":
+		return runSystemctl(c, cmd)
+	case "truncate":
+		return runTruncate(cmd)
+	case "pgrep":
+		return runPgrep(c, cmd)
+	case "pkill":
+		return runPkill(c, cmd)
+	case "cryptsetup":
+		return runCryptsetup(cmd)
+	}
+
+	// Device and daemon helpers have no effect on the simulated cluster.
+	return "", nil
+}
+
+// toJSON marshals a command output.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// writeOutput writes the output of a command to the file given with -o, if any.
+func writeOutput(cmd command, out string) (string, error) {
+	path := cmd.opt("-o")
+	if len(path) == 0 {
+		return out, nil
+	}
+
+	err := os.WriteFile(path, []byte(out), 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
@@ -0,0 +1,202 @@
+package simulate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+// newTestRunner returns a runner for the given host keeping its state in dir.
+func newTestRunner(t *testing.T, dir string, cluster string, host string) *Runner {
+	r, err := NewRunner(dir, cluster)
+	require.NoError(t, err)
+	r.SetHost(host)
+
+	return r
+}
+
+// run runs a command that must succeed.
+func run(t *testing.T, r *Runner, name string, arg ...string) string {
+	out, err := r.RunCommand(name, arg...)
+	require.NoError(t, err, "%s %v", name, arg)
+
+	return out
+}
+
+// bootstrap runs the commands bootstrapping a cluster with its first monitor.
+func bootstrap(t *testing.T, r *Runner) {
+	tmp := t.TempDir()
+	monKeyring := filepath.Join(tmp, "mon.keyring")
+	adminKeyring := filepath.Join(tmp, "ceph.client.admin.keyring")
+	monmap := filepath.Join(tmp, "mon.map")
+
+	run(t, r, "ceph-authtool", "--create-keyring", monKeyring, "--gen-key", "-n", "mon.", "--cap", "mon", "allow *")
+	run(t, r, "ceph-authtool", "--create-keyring", adminKeyring, "--gen-key", "-n", "client.admin", "--cap", "mon", "allow *", "--cap", "osd", "allow *")
+	run(t, r, "ceph-authtool", monKeyring, "--import-keyring", adminKeyring)
+	run(t, r, "monmaptool", "--create", "--fsid", "8c6dc1e4-2f0a-4a3b-9d1f-2a1b2c3d4e5f", monmap)
+	run(t, r, "monmaptool", "--add", r.Host(), "10.0.0.1", monmap)
+	run(t, r, "ceph-mon", "--mkfs", "-i", r.Host(), "--mon-data", tmp, "--monmap", monmap, "--keyring", monKeyring)
+	run(t, r, "snapctl", "start", "microceph.mon", "--enable")
+	run(t, r, "snapctl", "start", "microceph.mgr", "--enable")
+}
+
+func TestParseArgs(t *testing.T) {
+	cmd := parseArgs("ceph", []string{"-s", "-f", "json"})
+	assert.True(t, cmd.flag("-s"))
+	assert.Equal(t, "json", cmd.opt("-f", "--format"))
+	assert.Empty(t, cmd.args)
+
+	cmd = parseArgs("ceph", []string{"config", "set", "global", "osd_max_backfills", "-1", "--format=json"})
+	assert.Equal(t, []string{"config", "set", "global", "osd_max_backfills", "-1"}, cmd.args)
+	assert.Equal(t, "json", cmd.opt("-f", "--format"))
+
+	cmd = parseArgs("rbd", []string{"mirror", "pool", "info", "rbd", "--cluster", "site-b", "--id", "site-a"})
+	assert.Equal(t, "site-b", cmd.opt("--cluster"))
+	assert.Equal(t, "rbd", cmd.arg(3))
+}
+
+func TestBootstrap(t *testing.T) {
+	r := newTestRunner(t, t.TempDir(), "", "node0")
+	bootstrap(t, r)
+
+	assert.Equal(t, "8c6dc1e4-2f0a-4a3b-9d1f-2a1b2c3d4e5f\n", run(t, r, "ceph", "fsid"))
+	run(t, r, "ceph", "mon", "stat")
+
+	status := run(t, r, "ceph", "-s", "-f", "json")
+	assert.Equal(t, `["node0"]`, gjson.Get(status, "quorum_names").Raw)
+	assert.Equal(t, "node0", gjson.Get(run(t, r, "ceph", "mgr", "dump", "-f", "json"), "active_name").String())
+
+	// The keyrings of the bootstrap made it into the auth database.
+	auth := run(t, r, "ceph", "auth", "ls", "--format", "json")
+	assert.ElementsMatch(t, []string{"client.admin", "mon."}, toStrings(gjson.Get(auth, "auth_dump.#.entity").Array()))
+
+	// Bootstrapping another cluster into the same state is refused.
+	tmp := t.TempDir()
+	monmap := filepath.Join(tmp, "mon.map")
+	run(t, r, "monmaptool", "--create", "--fsid", "00000000-0000-0000-0000-000000000000", monmap)
+	_, err := r.RunCommand("ceph-mon", "--mkfs", "-i", "node0", "--mon-data", tmp, "--monmap", monmap, "--keyring", filepath.Join(tmp, "missing"))
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestJoinSharedState(t *testing.T) {
+	dir := t.TempDir()
+	r0 := newTestRunner(t, dir, "", "node0")
+	r1 := newTestRunner(t, dir, "", "node1")
+	bootstrap(t, r0)
+
+	// A joining member fetches the monmap and mon keyring and creates its monitor.
+	tmp := t.TempDir()
+	run(t, r1, "ceph", "mon", "getmap", "-o", filepath.Join(tmp, "mon.map"))
+	run(t, r1, "ceph", "auth", "get", "mon.", "-o", filepath.Join(tmp, "mon.keyring"))
+	keyring, err := os.ReadFile(filepath.Join(tmp, "mon.keyring"))
+	require.NoError(t, err)
+	assert.Contains(t, string(keyring), "[mon.]")
+
+	run(t, r1, "ceph-mon", "--mkfs", "-i", "node1", "--mon-data", tmp, "--monmap", filepath.Join(tmp, "mon.map"), "--keyring", filepath.Join(tmp, "mon.keyring"))
+	run(t, r1, "systemctl", "enable", "--now", "microceph-mon.service")
+
+	status := run(t, r0, "ceph", "-s", "-f", "json")
+	assert.Equal(t, `["node0","node1"]`, gjson.Get(status, "quorum_names").Raw)
+
+	run(t, r0, "ceph", "mon", "set-addrs", "node1", "[v2:10.2.0.2:3300,v1:10.2.0.2:6789]")
+	assert.Equal(t, "[v2:10.2.0.2:3300,v1:10.2.0.2:6789]", gjson.Get(run(t, r1, "ceph", "mon", "dump", "-f", "json"), `mons.#(name=="node1").addr`).String())
+
+	run(t, r0, "ceph", "mon", "rm", "node1")
+	assert.Equal(t, `["node0"]`, gjson.Get(run(t, r1, "ceph", "mon", "dump", "-f", "json"), "mons.#.name").Raw)
+}
+
+func TestOSDLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRunner(t, dir, "", "node0")
+	bootstrap(t, r)
+
+	for _, id := range []string{"1", "2", "3"} {
+		run(t, r, "ceph", "auth", "get-or-create", "osd."+id, "mon", "allow profile osd", "-o", filepath.Join(t.TempDir(), "keyring"))
+		run(t, r, "ceph-osd", "--mkfs", "--no-mon-config", "-i", id)
+	}
+
+	// OSDs come up with the osd service.
+	assert.Equal(t, int64(0), gjson.Get(run(t, r, "ceph", "-s", "-f", "json"), "osdmap.num_up_osds").Int())
+	run(t, r, "snapctl", "restart", "microceph.osd")
+	assert.Equal(t, int64(3), gjson.Get(run(t, r, "ceph", "-s", "-f", "json"), "osdmap.num_up_osds").Int())
+
+	tree := run(t, r, "ceph", "osd", "tree", "-f", "json")
+	assert.Equal(t, `[1,2,3]`, gjson.Get(tree, `nodes.#(type=="host").children`).Raw)
+
+	// Pools pick up the defaults and stay clean while enough OSDs are up.
+	run(t, r, "ceph", "config", "set", "global", "osd_pool_default_size", "2")
+	assert.Equal(t, "osd_pool_default_size", gjson.Get(run(t, r, "ceph", "config", "help", "osd_pool_default_size", "-f", "json"), "name").String())
+	run(t, r, "ceph", "osd", "pool", "create", "rbd")
+	assert.Equal(t, int64(2), gjson.Get(run(t, r, "ceph", "osd", "pool", "get", "rbd", "all", "--format", "json"), "size").Int())
+	assert.Equal(t, "active+clean", gjson.Get(run(t, r, "ceph", "pg", "stat", "--format", "json"), "pg_summary.num_pg_by_state.0.name").String())
+
+	// Maintenance: noout and ok-to-stop.
+	run(t, r, "ceph", "osd", "set", "noout")
+	assert.Contains(t, run(t, r, "ceph", "osd", "dump"), "noout")
+	run(t, r, "ceph", "osd", "ok-to-stop", "osd.1")
+	_, err := r.RunCommand("ceph", "osd", "ok-to-stop", "osd.1", "osd.2", "osd.3")
+	assert.Error(t, err)
+
+	// Removal: out, down, safe-to-destroy and purge.
+	_, err = r.RunCommand("ceph", "osd", "safe-to-destroy", "osd.1")
+	assert.Error(t, err)
+	run(t, r, "ceph", "osd", "out", "osd.1")
+	run(t, r, "pkill", "-f", "ceph-osd .* --id 1$")
+	_, err = r.RunCommand("pgrep", "-f", "ceph-osd .* --id 1$")
+	assert.Error(t, err)
+	run(t, r, "ceph", "osd", "safe-to-destroy", "osd.1")
+	run(t, r, "ceph", "osd", "purge", "osd.1", "--yes-i-really-mean-it")
+
+	tree = run(t, r, "ceph", "osd", "tree", "-f", "json")
+	assert.False(t, gjson.Get(tree, `nodes.#(id==1)`).Exists())
+
+	// A host bucket can only be removed once empty.
+	_, err = r.RunCommand("ceph", "osd", "crush", "remove", "node0")
+	assert.ErrorContains(t, err, "ENOTEMPTY")
+}
+
+func TestCrushRules(t *testing.T) {
+	r := newTestRunner(t, t.TempDir(), "", "node0")
+
+	run(t, r, "ceph", "osd", "crush", "rule", "create-replicated", "microceph_auto_osd", "default", "osd")
+	assert.Equal(t, "replicated_rule\nmicroceph_auto_osd\n", run(t, r, "ceph", "osd", "crush", "rule", "ls"))
+	assert.Equal(t, int64(1), gjson.Get(run(t, r, "ceph", "osd", "crush", "rule", "dump", "microceph_auto_osd"), "rule_id").Int())
+
+	run(t, r, "ceph", "config", "set", "global", "osd_pool_default_crush_rule", "1")
+	run(t, r, "ceph", "osd", "pool", "create", "data")
+	assert.Equal(t, "microceph_auto_osd", gjson.Get(run(t, r, "ceph", "osd", "pool", "get", "data", "all", "--format", "json"), "crush_rule").String())
+	assert.Equal(t, `["data"]`, gjson.Get(run(t, r, "ceph", "osd", "pool", "ls", "detail", "--format=json"), "#(crush_rule==1)#.pool_name").Raw)
+}
+
+func TestConfigKey(t *testing.T) {
+	r := newTestRunner(t, t.TempDir(), "", "node0")
+
+	_, err := r.RunCommand("ceph", "config-key", "exists", "key")
+	assert.Error(t, err)
+	run(t, r, "ceph", "config-key", "set", "key", "value")
+	run(t, r, "ceph", "config-key", "exists", "key")
+	assert.Equal(t, "value", run(t, r, "ceph", "config-key", "get", "key"))
+}
+
+func TestUnsupported(t *testing.T) {
+	r := newTestRunner(t, t.TempDir(), "", "node0")
+
+	_, err := r.RunCommand("ceph", "orch", "ls")
+	assert.ErrorContains(t, err, "not supported by the simulation")
+
+	// Device helpers succeed without effect.
+	run(t, r, "sgdisk", "--zap-all", "/dev/sdb")
+}
+
+func toStrings(results []gjson.Result) []string {
+	ret := []string{}
+	for _, result := range results {
+		ret = append(ret, result.String())
+	}
+
+	return ret
+}
@@ -0,0 +1,262 @@
+package simulate
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"sort"
+
+	"github.com/pborman/uuid"
+)
+
+// cephVersion is the version reported by the simulated daemons.
+const cephVersion = "19.2.0"
+
+// cephRelease is the release name reported by the simulated daemons.
+const cephRelease = "squid"
+
+// pgsPerPool is the number of placement groups of a simulated pool.
+const pgsPerPool = 32
+
+// Mon is a monitor of the simulated monmap.
+type Mon struct {
+	Name string `json:"name"`
+	Addr string `json:"addr"`
+}
+
+// OSD is an OSD of the simulated OSD map.
+type OSD struct {
+	ID          int64   `json:"id"`
+	UUID        string  `json:"uuid"`
+	Host        string  `json:"host"`
+	Up          bool    `json:"up"`
+	In          bool    `json:"in"`
+	Destroyed   bool    `json:"destroyed"`
+	CrushWeight float64 `json:"crush_weight"`
+}
+
+// Rule is a replicated CRUSH rule.
+type Rule struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Root          string `json:"root"`
+	FailureDomain string `json:"failure_domain"`
+}
+
+// Peer is an rbd-mirror peer of a pool.
+type Peer struct {
+	UUID       string `json:"uuid"`
+	MirrorUUID string `json:"mirror_uuid"`
+	SiteName   string `json:"site_name"`
+	Direction  string `json:"direction"`
+	// Cluster names the simulated cluster of the peer.
+	Cluster string `json:"cluster"`
+}
+
+// Schedule is an rbd mirror snapshot schedule.
+type Schedule struct {
+	Interval  string `json:"interval"`
+	StartTime string `json:"start_time"`
+}
+
+// Image is an rbd image.
+type Image struct {
+	Name      string     `json:"name"`
+	GlobalID  string     `json:"global_id"`
+	Features  []string   `json:"features"`
+	Mirror    string     `json:"mirror"`
+	Primary   bool       `json:"primary"`
+	Snapshots int        `json:"snapshots"`
+	Schedules []Schedule `json:"schedules"`
+}
+
+// Pool is a pool of the simulated OSD map.
+type Pool struct {
+	ID           int64                        `json:"id"`
+	Name         string                       `json:"name"`
+	Size         int64                        `json:"size"`
+	MinSize      int64                        `json:"min_size"`
+	CrushRule    int                          `json:"crush_rule"`
+	Applications map[string]map[string]string `json:"applications"`
+	MirrorMode   string                       `json:"mirror_mode"`
+	Peers        []Peer                       `json:"peers"`
+	Schedules    []Schedule                   `json:"schedules"`
+	Images       []*Image                     `json:"images"`
+	Groups       []string                     `json:"groups"`
+	Objects      []string                     `json:"objects"`
+}
+
+// Auth is an entity of the simulated auth database.
+type Auth struct {
+	Key  string            `json:"key"`
+	Caps map[string]string `json:"caps"`
+}
+
+// Cluster is the state of a simulated Ceph cluster.
+type Cluster struct {
+	FSID              string                       `json:"fsid"`
+	Epoch             int64                        `json:"epoch"`
+	RequireOSDRelease string                       `json:"require_osd_release"`
+	Flags             []string                     `json:"flags"`
+	Mons              []Mon                        `json:"mons"`
+	OSDs              []*OSD                       `json:"osds"`
+	CrushHosts        []string                     `json:"crush_hosts"`
+	Rules             []Rule                       `json:"rules"`
+	Pools             []*Pool                      `json:"pools"`
+	NextPoolID        int64                        `json:"next_pool_id"`
+	Auth              map[string]*Auth             `json:"auth"`
+	Config            map[string]map[string]string `json:"config"`
+	ConfigKeys        map[string]string            `json:"config_keys"`
+	// Services lists the running services of each host.
+	Services map[string][]string `json:"services"`
+	// MirrorUUID identifies the cluster as an rbd-mirror peer.
+	MirrorUUID string `json:"mirror_uuid"`
+	// SiteName is the rbd-mirror site name of the cluster.
+	SiteName string `json:"site_name"`
+}
+
+// newCluster returns an empty simulated cluster.
+func newCluster() *Cluster {
+	return &Cluster{
+		RequireOSDRelease: cephRelease,
+		Rules:             []Rule{{ID: 0, Name: "replicated_rule", Root: "default", FailureDomain: "host"}},
+		NextPoolID:        1,
+		Auth:              map[string]*Auth{},
+		Config:            map[string]map[string]string{},
+		ConfigKeys:        map[string]string{},
+		Services:          map[string][]string{},
+		MirrorUUID:        uuid.NewRandom().String(),
+	}
+}
+
+// genKey returns a random cephx key.
+func genKey() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// bump advances the map epoch.
+func (c *Cluster) bump() {
+	c.Epoch++
+}
+
+// hasMon checks if the monmap holds a monitor of the given name.
+func (c *Cluster) hasMon(name string) bool {
+	return slices.ContainsFunc(c.Mons, func(mon Mon) bool { return mon.Name == name })
+}
+
+// osd returns the OSD of the given id, nil if there is none.
+func (c *Cluster) osd(id int64) *OSD {
+	for _, osd := range c.OSDs {
+		if osd.ID == id {
+			return osd
+		}
+	}
+
+	return nil
+}
+
+// pool returns the pool of the given name, nil if there is none.
+func (c *Cluster) pool(name string) *Pool {
+	for _, pool := range c.Pools {
+		if pool.Name == name {
+			return pool
+		}
+	}
+
+	return nil
+}
+
+// rule returns the CRUSH rule of the given name, nil if there is none.
+func (c *Cluster) rule(name string) *Rule {
+	for i := range c.Rules {
+		if c.Rules[i].Name == name {
+			return &c.Rules[i]
+		}
+	}
+
+	return nil
+}
+
+// ruleName returns the name of the CRUSH rule of the given id.
+func (c *Cluster) ruleName(id int) string {
+	for _, rule := range c.Rules {
+		if rule.ID == id {
+			return rule.Name
+		}
+	}
+
+	return ""
+}
+
+// running checks if a service runs on the given host.
+func (c *Cluster) running(host string, service string) bool {
+	return slices.Contains(c.Services[host], service)
+}
+
+// runningHosts returns the sorted hosts running the given service.
+func (c *Cluster) runningHosts(service string) []string {
+	hosts := []string{}
+	for host := range c.Services {
+		if c.running(host, service) {
+			hosts = append(hosts, host)
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts
+}
+
+// setRunning records a service of a host as running or stopped.
+// The OSDs of the host follow the state of its osd service.
+func (c *Cluster) setRunning(host string, service string, running bool) {
+	services := slices.DeleteFunc(c.Services[host], func(s string) bool { return s == service })
+	if running {
+		services = append(services, service)
+		sort.Strings(services)
+	}
+	c.Services[host] = services
+
+	if service != "osd" {
+		return
+	}
+
+	for _, osd := range c.OSDs {
+		if osd.Host == host && !osd.Destroyed {
+			osd.Up = running
+		}
+	}
+	c.bump()
+}
+
+// addCrushHost adds a host bucket to the CRUSH map.
+func (c *Cluster) addCrushHost(host string) {
+	if !slices.Contains(c.CrushHosts, host) {
+		c.CrushHosts = append(c.CrushHosts, host)
+		sort.Strings(c.CrushHosts)
+	}
+}
+
+// numPGs returns the number of placement groups of the cluster.
+func (c *Cluster) numPGs() int {
+	return pgsPerPool * len(c.Pools)
+}
+
+// degraded checks if any pool has fewer up and in OSDs than replicas.
+func (c *Cluster) degraded() bool {
+	active := 0
+	for _, osd := range c.OSDs {
+		if osd.Up && osd.In {
+			active++
+		}
+	}
+
+	for _, pool := range c.Pools {
+		if int64(active) < pool.Size {
+			return true
+		}
+	}
+
+	return false
+}
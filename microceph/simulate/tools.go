@@ -0,0 +1,358 @@
+package simulate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/microceph/microceph/constants"
+)
+
+// services lists the MicroCeph services, longer names first so unit names match the most specific one.
+var services = []string{"cephfs-mirror", "rbd-mirror", "mon", "mgr", "mds", "osd", "rgw", "nfs"}
+
+// monmap is the content of a simulated monmap file.
+type monmap struct {
+	FSID string `json:"fsid"`
+	Mons []Mon  `json:"mons"`
+}
+
+// readMonmap reads a monmap file.
+func readMonmap(path string) (monmap, error) {
+	m := monmap{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, fmt.Errorf("monmaptool: error reading %s: %w", path, err)
+	}
+
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return m, fmt.Errorf("monmaptool: error parsing %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// formatKeyring returns the keyring of an entity.
+func formatKeyring(name string, entity *Auth) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s]\n\tkey = %s\n", name, entity.Key)
+
+	kinds := []string{}
+	for kind := range entity.Caps {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		fmt.Fprintf(&sb, "\tcaps %s = \"%s\"\n", kind, entity.Caps[kind])
+	}
+
+	return sb.String()
+}
+
+// parseKeyring parses the entities of a keyring.
+func parseKeyring(keyring string) map[string]*Auth {
+	entities := map[string]*Auth{}
+	var entity *Auth
+
+	scan := bufio.NewScanner(strings.NewReader(keyring))
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			entity = &Auth{Caps: map[string]string{}}
+			entities[strings.Trim(line, "[]")] = entity
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if entity == nil || !found {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), "\"")
+		if key == "key" {
+			entity.Key = value
+		} else if kind, ok := strings.CutPrefix(key, "caps "); ok {
+			entity.Caps[kind] = value
+		}
+	}
+
+	return entities
+}
+
+// readKeyring reads the entities of a keyring file, a missing file holds none.
+func readKeyring(path string) (map[string]*Auth, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]*Auth{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return parseKeyring(string(data)), nil
+}
+
+// writeKeyring writes the entities of a keyring file.
+func writeKeyring(path string, entities map[string]*Auth) error {
+	names := []string{}
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(formatKeyring(name, entities[name]))
+	}
+
+	return os.WriteFile(path, []byte(sb.String()), 0600)
+}
+
+// runAuthtool runs ceph-authtool, which only works on keyring files.
+func runAuthtool(c *Cluster, cmd command) (string, error) {
+	path := cmd.arg(0)
+
+	entities := map[string]*Auth{}
+	if !cmd.flag("--create-keyring") {
+		var err error
+		entities, err = readKeyring(path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if cmd.flag("--import-keyring") {
+		imported, err := readKeyring(cmd.arg(1))
+		if err != nil {
+			return "", err
+		}
+
+		for name, entity := range imported {
+			entities[name] = entity
+		}
+	}
+
+	if cmd.flag("--gen-key") {
+		entity := &Auth{Key: genKey(), Caps: map[string]string{}}
+		for i := 1; i+1 < len(cmd.args); i += 2 {
+			entity.Caps[cmd.args[i]] = cmd.args[i+1]
+		}
+		entities[cmd.opt("-n")] = entity
+	}
+
+	return "", writeKeyring(path, entities)
+}
+
+// runMonmaptool runs monmaptool, which only works on monmap files.
+func runMonmaptool(cmd command) (string, error) {
+	path := cmd.args[len(cmd.args)-1]
+
+	m := monmap{Mons: []Mon{}}
+	if cmd.flag("--create") {
+		m.FSID = cmd.opt("--fsid")
+	} else {
+		var err error
+		m, err = readMonmap(path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if cmd.flag("--add") {
+		m.Mons = append(m.Mons, Mon{Name: cmd.arg(0), Addr: cmd.arg(1)})
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	return "", os.WriteFile(path, data, 0600)
+}
+
+// runCephMon runs ceph-mon --mkfs. The first monitor creates the cluster from its monmap and keyring,
+// later ones join the existing monmap.
+func runCephMon(c *Cluster, cmd command) (string, error) {
+	if !cmd.flag("--mkfs") {
+		return "", nil
+	}
+
+	m, err := readMonmap(cmd.opt("--monmap"))
+	if err != nil {
+		return "", err
+	}
+
+	if len(c.FSID) != 0 && c.FSID != m.FSID {
+		return "", fmt.Errorf("ceph-mon: simulated cluster %s already exists, use a fresh simulation directory", c.FSID)
+	}
+
+	if len(c.FSID) == 0 {
+		c.FSID = m.FSID
+		c.Mons = m.Mons
+
+		entities, err := readKeyring(cmd.opt("--keyring"))
+		if err != nil {
+			return "", err
+		}
+
+		for name, entity := range entities {
+			c.Auth[name] = entity
+		}
+	}
+
+	name := cmd.opt("-i")
+	if !c.hasMon(name) {
+		c.Mons = append(c.Mons, Mon{Name: name, Addr: name})
+	}
+	c.bump()
+
+	return "", nil
+}
+
+// runCephOSD runs ceph-osd --mkfs, creating the OSD in the OSD map.
+func runCephOSD(c *Cluster, cmd command) (string, error) {
+	if !cmd.flag("--mkfs") {
+		return "", nil
+	}
+
+	id, err := strconv.ParseInt(cmd.opt("-i"), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("ceph-osd: invalid id '%s'", cmd.opt("-i"))
+	}
+
+	osd := c.newOSD(id, cmd.host)
+
+	// Use the uuid generated for the OSD data directory.
+	fsid, err := os.ReadFile(filepath.Join(constants.GetPathConst().DataPath, "osd", fmt.Sprintf("ceph-%d", id), "fsid"))
+	if err == nil {
+		osd.UUID = strings.TrimSpace(string(fsid))
+	}
+
+	return "", nil
+}
+
+// serviceOf returns the service run by a snap app or systemd unit.
+func serviceOf(name string) string {
+	name = strings.TrimSuffix(name, ".service")
+	_, app, found := strings.Cut(name, ".")
+	if found {
+		return app
+	}
+
+	for _, service := range services {
+		if strings.HasSuffix(name, "-"+service) || name == service {
+			return service
+		}
+	}
+
+	return name
+}
+
+// runSnapctl runs snapctl, recording the state of the services of the host.
+func runSnapctl(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(0) {
+	case "start", "restart":
+		c.setRunning(cmd.host, serviceOf(cmd.arg(1)), true)
+	case "stop":
+		c.setRunning(cmd.host, serviceOf(cmd.arg(1)), false)
+	case "services":
+		service := serviceOf(cmd.arg(1))
+		state := "inactive"
+		if c.running(cmd.host, service) {
+			state = "active"
+		}
+		return fmt.Sprintf("Service  Startup  Current  Notes\n%s  enabled  %s  -\n", cmd.arg(1), state), nil
+	}
+
+	return "", nil
+}
+
+// runSystemctl runs systemctl, recording the state of the services of the host.
+func runSystemctl(c *Cluster, cmd command) (string, error) {
+	switch cmd.arg(0) {
+	case "start", "restart", "reload-or-restart":
+		c.setRunning(cmd.host, serviceOf(cmd.arg(1)), true)
+	case "enable":
+		if cmd.flag("--now") {
+			c.setRunning(cmd.host, serviceOf(cmd.arg(1)), true)
+		}
+	case "stop":
+		c.setRunning(cmd.host, serviceOf(cmd.arg(1)), false)
+	case "disable":
+		if cmd.flag("--now") {
+			c.setRunning(cmd.host, serviceOf(cmd.arg(1)), false)
+		}
+	case "is-active":
+		if !c.running(cmd.host, serviceOf(cmd.arg(1))) {
+			return "inactive\n", fmt.Errorf("inactive")
+		}
+		return "active\n", nil
+	}
+
+	return "", nil
+}
+
+// runTruncate creates the sparse backing file of a loop OSD.
+func runTruncate(cmd command) (string, error) {
+	path := cmd.args[len(cmd.args)-1]
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return "", f.Close()
+}
+
+// osdCmdline matches the command line patterns used to find OSD processes.
+var osdCmdline = regexp.MustCompile(`--id (\d+)`)
+
+// runPgrep finds the simulated process of an OSD, which runs while the OSD is up.
+func runPgrep(c *Cluster, cmd command) (string, error) {
+	match := osdCmdline.FindStringSubmatch(cmd.opt("-f"))
+	if match == nil {
+		return "", fmt.Errorf("no process found")
+	}
+
+	id, _ := strconv.ParseInt(match[1], 10, 64)
+	osd := c.osd(id)
+	if osd == nil || !osd.Up {
+		return "", fmt.Errorf("no process found")
+	}
+
+	return fmt.Sprintf("%d\n", 10000+id), nil
+}
+
+// runPkill stops the simulated process of an OSD.
+func runPkill(c *Cluster, cmd command) (string, error) {
+	match := osdCmdline.FindStringSubmatch(cmd.opt("-f"))
+	if match == nil {
+		return "", nil
+	}
+
+	id, _ := strconv.ParseInt(match[1], 10, 64)
+	osd := c.osd(id)
+	if osd == nil || !osd.Up {
+		return "", fmt.Errorf("no process found")
+	}
+
+	osd.Up = false
+	c.bump()
+	return "", nil
+}
+
+// runCryptsetup runs cryptsetup, simulated devices carry no LUKS headers.
+func runCryptsetup(cmd command) (string, error) {
+	if cmd.arg(0) == "isLuks" {
+		return "", fmt.Errorf("Device %s is not a valid LUKS device", cmd.arg(1))
+	}
+
+	return "", nil
+}
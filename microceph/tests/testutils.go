@@ -1,11 +1,17 @@
 package tests
 
 import (
+	"encoding/json"
+	"net"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
 
+	"github.com/canonical/lxd/shared/api"
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -92,3 +98,40 @@ func CopyTestConf(dir string, conf string) error {
 	}
 	return nil
 }
+
+// LocalClient serves a fake API on a control socket under Tmp and returns a client for it. The handler returns
+// the metadata of a sync response, or an error which is sent as an error response.
+func (s *BaseSuite) LocalClient(handler func(r *http.Request) (any, error)) *microCli.Client {
+	stateDir := filepath.Join(s.Tmp, "state")
+	m, err := microcluster.App(microcluster.Args{StateDir: stateDir})
+	if err != nil {
+		s.T().Fatal("error creating app:", err)
+	}
+
+	listener, err := net.Listen("unix", filepath.Join(stateDir, "control.socket"))
+	if err != nil {
+		s.T().Fatal("error listening on control socket:", err)
+	}
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := api.ResponseRaw{Type: api.SyncResponse, Status: api.Success.String(), StatusCode: int(api.Success)}
+		metadata, err := handler(r)
+		if err != nil {
+			resp = api.ResponseRaw{Type: api.ErrorResponse, Code: http.StatusInternalServerError, Error: err.Error()}
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			resp.Metadata = metadata
+		}
+
+		_ = json.NewEncoder(w).Encode(resp)
+	})}
+	go func() { _ = server.Serve(listener) }()
+	s.T().Cleanup(func() { _ = server.Close() })
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		s.T().Fatal("error creating client:", err)
+	}
+
+	return cli
+}